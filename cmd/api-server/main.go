@@ -1,32 +1,431 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"userapi/app/internal/adapters/blob"
 	"userapi/app/internal/adapters/db"
+	"userapi/app/internal/adapters/events"
 	"userapi/app/internal/adapters/http"
+	"userapi/app/internal/adapters/imaging"
+	"userapi/app/internal/adapters/jsonschema"
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/adapters/metrics"
+	"userapi/app/internal/adapters/notify"
+	"userapi/app/internal/adapters/password"
 	"userapi/app/internal/adapters/service"
+	"userapi/app/internal/adapters/token"
+	"userapi/app/internal/adapters/totp"
+	"userapi/app/internal/adapters/tracing"
+	"userapi/app/internal/adapters/webauthn"
+	"userapi/app/internal/config"
 	"userapi/app/internal/core/ports"
+	"userapi/app/internal/lifecycle"
 
 	"github.com/go-playground/validator/v10"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
+// schedulerLockKey is the Postgres advisory lock key held by the replica that
+// runs the scheduled actions.
+const schedulerLockKey int64 = 0x7573657273636864
+
+// outboxLockKey is the Postgres advisory lock key held by the replica that
+// publishes the user events.
+const outboxLockKey int64 = 0x757365726f757462
+
+// webhookLockKey is the Postgres advisory lock key held by the replica that
+// posts the webhook deliveries.
+const webhookLockKey int64 = 0x7573657277686f6b
+
 // @title User Management API
 // @version 1.0
 // @description This api allow to create, modify,delete, and retrieve user records.
 
 func main() {
-	var userRepository ports.UserRepository = db.NewPostgresRepository()
-	defer userRepository.Close()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := migrate(os.Args[2:])
+		switch {
+		case errors.Is(err, errMigrateUsage):
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		case err != nil && !errors.Is(err, flag.ErrHelp):
+			slog.Error("Migration failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	cfg, options, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		slog.Error("Could not load the configuration", "error", err)
+		os.Exit(2)
+	}
+	if options.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			slog.Error("Could not print the configuration", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	levels, err := logging.Setup(logging.Config{
+		Format:       cfg.Log.Format,
+		Levels:       cfg.Log.Level,
+		RedactFields: append(logging.DefaultRedactedFields, cfg.Log.RedactFields...),
+		RedactUUIDs:  cfg.Log.RedactUUIDs,
+		Output:       os.Stdout,
+	})
+	if err != nil {
+		slog.Error("Could not set up logging", "error", err)
+		os.Exit(1)
+	}
+	tracer, err := newTracer(cfg.Tracing)
+	if err != nil {
+		slog.Error("Could not set up tracing", "error", err)
+		os.Exit(1)
+	}
+	tracing.SetDefault(tracer)
+	postgresRepository, err := db.NewPostgresRepository(postgresConfig(cfg.Database))
+	if err != nil {
+		slog.Error("Could not set up the database", "error", err)
+		os.Exit(2)
+	}
+	var userRepository ports.UserRepository = postgresRepository
+	var tenantRepository ports.TenantRepository = postgresRepository
+	var privacyRepository ports.PrivacyRepository = postgresRepository
 	requestValidator := validator.New()
 	var validator ports.Validator = requestValidator
-	var userService ports.UserService = service.NewUserService(userRepository, validator)
+	var sessionRepository ports.SessionRepository = postgresRepository
+	if cfg.Sessions.Store == "memory" {
+		sessionRepository = db.NewMemorySessionRepository()
+	}
+	userServiceImpl := service.NewUserService(userRepository, validator)
+	userServiceImpl.SessionRepository = sessionRepository
+	schemaValidator := jsonschema.NewValidator()
+	userServiceImpl.AttributeSchemas = postgresRepository
+	userServiceImpl.SchemaValidator = schemaValidator
+	metricsRegistry := prometheus.NewRegistry()
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.NewPoolCollector(postgresRepository.PoolStat),
+	)
+	var userService ports.UserService = metrics.InstrumentUserService(tracing.TraceUserService(userServiceImpl), metricsRegistry)
+	tenantServiceImpl := service.NewTenantService(tenantRepository, validator)
+	tenantServiceImpl.AttributeSchemas = postgresRepository
+	tenantServiceImpl.SchemaValidator = schemaValidator
+	var tenantService ports.TenantService = tenantServiceImpl
+	privacyServiceImpl := service.NewPrivacyService(userService, privacyRepository, validator)
+	var privacyService ports.PrivacyService = privacyServiceImpl
 	server := http.NewServer(userService, validator)
-	err := server.Start()
-	defer server.Stop()
+	server.TenantService = tenantService
+	server.Metrics = metricsRegistry
+	server.PrivacyService = privacyService
+	server.DefaultTenant = cfg.Server.DefaultTenant
+	server.AdminToken = cfg.Server.AdminToken
+	server.StepUpMaxAge = cfg.Auth.StepUpMaxAge
+	server.Addr = cfg.Server.Addr
+	server.ReadTimeout = cfg.Server.ReadTimeout
+	server.WriteTimeout = cfg.Server.WriteTimeout
+	server.IdleTimeout = cfg.Server.IdleTimeout
+	server.DrainDelay = cfg.Server.DrainDelay
+	server.RateLimiter = http.NewRateLimiter(cfg.Server.RateLimit.RequestsPerSecond, cfg.Server.RateLimit.Burst)
+	server.HealthCheckers = append(server.HealthCheckers, postgresRepository)
+	migrator, err := postgresRepository.NewMigrator()
 	if err != nil {
-		slog.Error("Could not start the server", "error", err)
-		return
+		slog.Error("Could not set up the migrations", "error", err)
+		os.Exit(1)
+	}
+	server.HealthCheckers = append(server.HealthCheckers, migrator)
+	if cfg.Auth.TokenSecret != "" {
+		server.TokenSigner = token.NewHMACSigner([]byte(cfg.Auth.TokenSecret))
+	}
+	emailNotifier, err := newEmailNotifier(cfg.Notify)
+	if err != nil {
+		slog.Error("Could not set up notifications", "error", err)
+		os.Exit(1)
+	}
+	verificationService := service.NewVerificationService(userService, userRepository, postgresRepository)
+	verificationService.TokenSigner = server.TokenSigner
+	verificationService.EmailNotifier = emailNotifier
+	if cfg.Notify.SMSOutboxFile != "" {
+		verificationService.SMSNotifier = notify.NewFileNotifier(cfg.Notify.SMSOutboxFile)
+	}
+	verificationService.EmailVerificationURL = cfg.Verification.EmailURL
+	verificationService.EmailVerificationTTL = cfg.Verification.EmailTTL
+	server.VerificationService = verificationService
+	privacyServiceImpl.VerificationRepository = postgresRepository
+	privacyServiceImpl.CredentialRepository = postgresRepository
+	mfaService := service.NewMFAService(userService, postgresRepository, totp.NewAuthenticator(cfg.MFA.TOTPIssuer))
+	if rpID := cfg.MFA.WebAuthnRPID; rpID != "" {
+		rpName := cmp.Or(cfg.MFA.WebAuthnRPName, cfg.MFA.TOTPIssuer)
+		origins := cfg.MFA.WebAuthnOrigins
+		if len(origins) == 0 {
+			origins = []string{"https://" + rpID}
+		}
+		mfaService.WebAuthn = webauthn.NewRelyingParty(rpID, rpName, origins)
+	}
+	server.MFAService = mfaService
+	privacyServiceImpl.MFAService = mfaService
+	if server.TokenSigner != nil {
+		credentialService := service.NewCredentialService(userService, postgresRepository, password.NewArgon2Hasher(), server.TokenSigner)
+		credentialService.Notifier = emailNotifier
+		credentialService.PasswordResetURL = cfg.Auth.PasswordResetURL
+		credentialService.Policy.MinLength = cfg.Auth.PasswordMinLength
+		if path := cfg.Auth.BreachListFile; path != "" {
+			breachList, err := password.LoadBreachList(path)
+			if err != nil {
+				slog.Error("Could not load the breach list", "error", err)
+				os.Exit(1)
+			}
+			slog.Info("Loaded the breach list", "passwords", breachList.Len())
+			credentialService.Policy.Breached = breachList
+		}
+		credentialService.SessionTTL = cfg.Sessions.TTL
+		credentialService.MFAService = mfaService
+		sessionService := service.NewSessionService(userService, sessionRepository, server.TokenSigner)
+		sessionService.AccessTTL = credentialService.SessionTTL
+		sessionService.RefreshTTL = cfg.Sessions.RefreshTTL
+		credentialService.SessionService = sessionService
+		server.SessionService = sessionService
+		privacyServiceImpl.SessionService = sessionService
+		server.CredentialService = credentialService
+	}
+	scheduleService := service.NewScheduleService(userService, postgresRepository, tenantRepository)
+	scheduleService.LeaderLock = postgresRepository.NewAdvisoryLock(schedulerLockKey)
+	scheduleService.Interval = cfg.Scheduler.Interval
+	server.ScheduleService = scheduleService
+	privacyServiceImpl.ScheduleService = scheduleService
+	eventSinks, err := newEventSinks(cfg.Events)
+	if err != nil {
+		slog.Error("Could not set up the event sinks", "error", err)
+		os.Exit(1)
+	}
+	webhookService := service.NewWebhookService(postgresRepository, tenantRepository, events.NewWebhookSender())
+	webhookService.LeaderLock = postgresRepository.NewAdvisoryLock(webhookLockKey)
+	webhookService.Interval = cfg.Webhooks.Interval
+	webhookService.MaxFailures = cfg.Webhooks.MaxFailures
+	webhookService.Retention = cfg.Webhooks.Retention
+	server.WebhookService = webhookService
+	privacyServiceImpl.WebhookRepository = postgresRepository
+	eventSinks = append(eventSinks, webhookService)
+	eventDispatcher := service.NewEventDispatcher(postgresRepository, eventSinks...)
+	eventDispatcher.LeaderLock = postgresRepository.NewAdvisoryLock(outboxLockKey)
+	eventDispatcher.Interval = cfg.Events.Interval
+	eventDispatcher.BatchSize = cfg.Events.BatchSize
+	eventDispatcher.Retention = cfg.Events.Retention
+	eventDispatcher.MaxAttempts = cfg.Events.MaxAttempts
+	groupService := service.NewGroupService(userService, postgresRepository)
+	server.GroupService = groupService
+	privacyServiceImpl.GroupService = groupService
+	server.ContactService = service.NewContactService(userService, postgresRepository, validator)
+	privacyServiceImpl.ContactRepository = postgresRepository
+	blobStore, err := newBlobStore(cfg.Avatars)
+	if err != nil {
+		slog.Error("Could not set up the avatar store", "error", err)
+		os.Exit(1)
+	}
+	if blobStore != nil {
+		avatarService := service.NewAvatarService(userService, blobStore, imaging.NewProcessor())
+		userServiceImpl.AvatarService = avatarService
+		privacyServiceImpl.AvatarService = avatarService
+		server.AvatarService = avatarService
+		if checker, ok := blobStore.(ports.HealthChecker); ok {
+			server.HealthCheckers = append(server.HealthCheckers, checker)
+		}
+		server.AvatarMaxBytes = cfg.Avatars.MaxBytes
+	}
+	go reloadOnHangup(os.Args[1:], cfg, levels, server.RateLimiter)
+
+	// Hooks stop in the reverse order: the server drains first and the pool
+	// closes last, once nothing uses it.
+	manager := lifecycle.NewManager(cfg.Server.ShutdownTimeout)
+	manager.Append(lifecycle.Hook{Name: "postgres", OnStop: func(context.Context) error { return userRepository.Close() }})
+	if cfg.Database.MigrateOnStart {
+		manager.Append(lifecycle.Hook{Name: "migrations", OnStart: func(ctx context.Context) error {
+			migrationRepository, err := db.NewPostgresRepository(migrationConfig(cfg.Database))
+			if err != nil {
+				return err
+			}
+			defer migrationRepository.Close()
+			migrator, err := migrationRepository.NewMigrator()
+			if err != nil {
+				return err
+			}
+			return migrator.Up(ctx)
+		}})
+	}
+	manager.Append(lifecycle.Hook{Name: "row security", OnStart: func(ctx context.Context) error {
+		if err := postgresRepository.CheckRowSecurity(ctx); err != nil {
+			slog.Warn("Tenants are not isolated by the database", "error", err)
+		}
+		return nil
+	}})
+	manager.Append(lifecycle.Hook{Name: "tracer", OnStop: tracer.Shutdown})
+	manager.Append(lifecycle.Background("scheduler", func(ctx context.Context) {
+		scheduleService.Run(logging.ContextWithLogger(ctx, logging.ForPackage("scheduler")))
+	}))
+	manager.Append(lifecycle.Background("events", func(ctx context.Context) {
+		eventDispatcher.Run(logging.ContextWithLogger(ctx, logging.ForPackage("events")))
+	}))
+	manager.Append(lifecycle.Background("webhooks", func(ctx context.Context) {
+		webhookService.Run(logging.ContextWithLogger(ctx, logging.ForPackage("webhooks")))
+	}))
+	manager.Append(lifecycle.Hook{
+		Name:    "http",
+		OnStart: func(context.Context) error { return server.Start(manager.Fail) },
+		OnStop:  server.Stop,
+	})
+	if err := manager.Run(context.Background()); err != nil {
+		slog.Error("The server stopped with errors", "error", err)
+		os.Exit(1)
+	}
+}
+
+// newEventSinks returns the sinks the user events are published to, on top of
+// the webhook subscriptions.
+func newEventSinks(cfg config.EventsConfig) ([]ports.EventSink, error) {
+	var sinks []ports.EventSink
+	if cfg.WebhookURL != "" {
+		sinks = append(sinks, events.NewWebhookSink(cfg.WebhookURL))
+	}
+	if cfg.NATSURL != "" {
+		sink, err := events.NewNATSSink(cfg.NATSURL, cfg.NATSSubject)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if cfg.File != "" {
+		sinks = append(sinks, events.NewFileSink(cfg.File))
+	}
+	return sinks, nil
+}
+
+// newEmailNotifier sends through SMTP when notify.smtpAddr is set, otherwise to
+// the notify.outboxFile stand-in. It returns nil when neither is configured.
+func newEmailNotifier(cfg config.NotifyConfig) (ports.Notifier, error) {
+	if cfg.SMTPAddr != "" {
+		return notify.NewSMTPNotifier(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUsername, cfg.SMTPPassword)
+	}
+	if cfg.OutboxFile != "" {
+		return notify.NewFileNotifier(cfg.OutboxFile), nil
+	}
+	return nil, nil
+}
+
+// newTracer exports spans as tracing.exporter says: "otlp" to the collector
+// at tracing.endpoint, "console" to stdout and "file" to tracing.file.
+// Without an exporter, spans are only propagated.
+func newTracer(cfg config.TracingConfig) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "otlp":
+		headers := map[string]string{}
+		for _, pair := range strings.Split(cfg.Headers, ",") {
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				continue
+			}
+			if unescaped, err := url.QueryUnescape(value); err == nil {
+				value = unescaped
+			}
+			headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+		exporter, err = tracing.NewOTLPExporter(context.Background(), cfg.Endpoint, headers)
+	case "console":
+		exporter, err = tracing.NewWriterExporter(os.Stdout)
+	case "file":
+		file, openErr := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+		if openErr != nil {
+			return nil, fmt.Errorf("could not open the traces file: %w", openErr)
+		}
+		exporter, err = tracing.NewWriterExporter(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tracing.NewTracerProvider(cfg.ServiceName, exporter, cfg.SampleRatio), nil
+}
+
+// newBlobStore keeps avatars in the S3 bucket avatars.s3.bucket when set,
+// otherwise under the avatars.dir directory. It returns nil when neither is
+// configured.
+func newBlobStore(cfg config.AvatarsConfig) (ports.BlobStore, error) {
+	if cfg.S3.Bucket != "" {
+		endpoint := cmp.Or(cfg.S3.Endpoint, "https://s3."+cfg.S3.Region+".amazonaws.com")
+		return blob.NewS3Store(endpoint, cfg.S3.Region, cfg.S3.Bucket, cfg.S3.AccessKeyID, cfg.S3.SecretAccessKey)
+	}
+	if cfg.Dir != "" {
+		return blob.NewFileStore(cfg.Dir)
+	}
+	return nil, nil
+}
+
+// reloadOnHangup loads the configuration again on SIGHUP and applies the log
+// levels and rate limits. The other settings need a restart, which is
+// logged when they change.
+func reloadOnHangup(args []string, current *config.Config, levels *logging.Levels, rateLimiter *http.RateLimiter) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	for range hangup {
+		next, _, err := config.Load(args)
+		if err != nil {
+			slog.Error("Could not reload the configuration", "error", err)
+			continue
+		}
+		reloaded, restart := config.Changes(current, next)
+		if err := levels.Set(next.Log.Level); err != nil {
+			slog.Error("Could not reload the log levels", "error", err)
+			continue
+		}
+		rateLimiter.SetLimit(next.Server.RateLimit.RequestsPerSecond, next.Server.RateLimit.Burst)
+		if len(restart) > 0 {
+			slog.Warn("Some settings only apply after a restart", "settings", restart)
+		}
+		slog.Info("Reloaded the configuration", "changed", reloaded)
+		current.Log.Level = next.Log.Level
+		current.Server.RateLimit = next.Server.RateLimit
+	}
+}
+
+// postgresConfig returns the settings of the Postgres repository.
+func postgresConfig(c config.DatabaseConfig) db.Config {
+	return db.Config{
+		Host:            c.Host,
+		Port:            c.Port,
+		User:            c.User,
+		Password:        c.Password,
+		Database:        c.Name,
+		SSLMode:         c.SSLMode,
+		KeyringFile:     c.KeyringFile,
+		EncryptedFields: c.EncryptedFields,
+	}
+}
+
+// migrationConfig returns the settings of the migrations, which run as the
+// database.migrationUser owning the schema when it is set.
+func migrationConfig(c config.DatabaseConfig) db.Config {
+	migration := postgresConfig(c)
+	if c.MigrationUser != "" {
+		migration.User, migration.Password = c.MigrationUser, c.MigrationPassword
 	}
+	migration.KeyringFile = ""
+	return migration
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"userapi/app/internal/adapters/db"
+	"userapi/app/internal/config"
+)
+
+var errMigrateUsage = errors.New(`usage: api-server migrate up|down|status [flags]
+       api-server migrate goto <version> [flags]`)
+
+// migrate runs the migrate subcommand: up applies the pending migrations, down
+// reverts the last applied one, goto applies or reverts migrations up to a
+// version and status lists them. The flags are the server ones.
+func migrate(args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+	action, args := args[0], args[1:]
+	if !slices.Contains([]string{"up", "down", "goto", "status"}, action) {
+		return errMigrateUsage
+	}
+	var version int
+	if action == "goto" {
+		if len(args) == 0 {
+			return errMigrateUsage
+		}
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("%q is not a migration version", args[0])
+		}
+		version, args = parsed, args[1:]
+	}
+	cfg, _, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	repository, err := db.NewPostgresRepository(migrationConfig(cfg.Database))
+	if err != nil {
+		return err
+	}
+	defer repository.Close()
+	migrator, err := repository.NewMigrator()
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx)
+	case "goto":
+		return migrator.Goto(ctx, version)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if !status.AppliedAt.IsZero() {
+				applied = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return writer.Flush()
+	}
+	return errMigrateUsage
+}
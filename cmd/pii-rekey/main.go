@@ -0,0 +1,104 @@
+// Command pii-rekey manages the keyring used to encrypt user PII and re-encrypts
+// stored users after a key rotation.
+//
+//	pii-rekey add-key -keyring keys.json -id 2026-10
+//	PII_KEYRING_FILE=keys.json pii-rekey reencrypt
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"userapi/app/internal/adapters/db"
+	"userapi/app/internal/adapters/encryption"
+	"userapi/app/internal/config"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "add-key":
+		err = addKey(os.Args[2:])
+	case "reencrypt":
+		err = reencrypt(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		slog.Error("pii-rekey failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: pii-rekey add-key -keyring <file> -id <key id>")
+	fmt.Fprintln(os.Stderr, "       PII_KEYRING_FILE=<file> pii-rekey reencrypt [--config <file>]")
+}
+
+// addKey adds a new active key to the keyring, creating the keyring if needed.
+func addKey(args []string) error {
+	flags := flag.NewFlagSet("add-key", flag.ExitOnError)
+	keyringPath := flags.String("keyring", os.Getenv("PII_KEYRING_FILE"), "path of the keyring file")
+	keyID := flags.String("id", "", "id of the new key")
+	_ = flags.Parse(args)
+	if *keyringPath == "" || *keyID == "" {
+		return errors.New("-keyring and -id are required")
+	}
+	keyring, err := encryption.LoadKeyring(*keyringPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		keyring, err = encryption.NewKeyring()
+	}
+	if err != nil {
+		return err
+	}
+	if err := keyring.AddKey(*keyID); err != nil {
+		return err
+	}
+	if err := keyring.Save(*keyringPath); err != nil {
+		return err
+	}
+	slog.Info("added key to the keyring", "keyId", *keyID, "keyring", *keyringPath)
+	return nil
+}
+
+// reencrypt rewrites every user that is not encrypted under the active key.
+// It takes the database settings of the server, from its flags, environment
+// or --config file.
+func reencrypt(args []string) error {
+	cfg, _, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+	repository, err := db.NewPostgresRepository(postgresConfig(cfg.Database))
+	if err != nil {
+		return err
+	}
+	defer repository.Close()
+	count, err := repository.ReencryptUsers(context.Background())
+	slog.Info("re-encrypted users", "count", count)
+	return err
+}
+
+// postgresConfig returns the settings of the Postgres repository.
+func postgresConfig(c config.DatabaseConfig) db.Config {
+	return db.Config{
+		Host:            c.Host,
+		Port:            c.Port,
+		User:            c.User,
+		Password:        c.Password,
+		Database:        c.Name,
+		SSLMode:         c.SSLMode,
+		KeyringFile:     c.KeyringFile,
+		EncryptedFields: c.EncryptedFields,
+	}
+}
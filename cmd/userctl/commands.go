@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+// attributesFlag collects repeated -attribute name=value flags. A value that
+// parses as JSON is taken as such, anything else is a string, as in the
+// attributes.<name> filters of the API.
+type attributesFlag map[string]any
+
+func (a attributesFlag) String() string { return "" }
+
+func (a attributesFlag) Set(text string) error {
+	name, raw, ok := strings.Cut(text, "=")
+	if !ok || name == "" {
+		return errors.New("expected name=value")
+	}
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		value = raw
+	}
+	a[name] = value
+	return nil
+}
+
+// userFlags are the flags setting the fields of a user.
+type userFlags struct {
+	user       domain.User
+	status     string
+	attributes attributesFlag
+}
+
+func addUserFlags(flags *flag.FlagSet) *userFlags {
+	fields := &userFlags{attributes: attributesFlag{}}
+	flags.StringVar(&fields.user.FirstName, "first-name", "", "first name")
+	flags.StringVar(&fields.user.LastName, "last-name", "", "last name")
+	flags.StringVar(&fields.user.Email, "email", "", "email")
+	flags.StringVar(&fields.user.Phone, "phone", "", "phone number in E.164 format")
+	flags.IntVar(&fields.user.Age, "age", 0, "age")
+	flags.StringVar(&fields.status, "status", "", "`status`, see the transition table")
+	flags.Var(fields.attributes, "attribute", "custom attribute as `name=value`, repeat for several")
+	return fields
+}
+
+// parse returns the user the flags describe.
+func (fields *userFlags) parse() (domain.User, error) {
+	user := fields.user
+	if fields.status != "" {
+		user.Status = domain.ParseUserStatus(fields.status)
+		if user.Status == 0 {
+			return user, fmt.Errorf("unknown status %q", fields.status)
+		}
+	}
+	if len(fields.attributes) > 0 {
+		user.Attributes = fields.attributes
+	}
+	return user, nil
+}
+
+// userID returns the single argument naming a user.
+func userID(flags *flag.FlagSet) (string, error) {
+	if flags.NArg() != 1 {
+		return "", fmt.Errorf("expected a user id, got %d arguments", flags.NArg())
+	}
+	return flags.Arg(0), nil
+}
+
+func create(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("create")
+	fields := addUserFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	user, err := fields.parse()
+	if err != nil {
+		return err
+	}
+	users, ctx, release, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
+	created, err := users.AddUser(ctx, user)
+	if err != nil {
+		return err
+	}
+	return printUsers(os.Stdout, opts.output, []domain.User{created})
+}
+
+func get(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("get")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	ref, err := userID(flags)
+	if err != nil {
+		return err
+	}
+	users, ctx, release, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
+	var user domain.User
+	if strings.Contains(ref, "@") {
+		user, err = users.GetUserByEmail(ctx, ref)
+	} else {
+		user, err = users.GetUserById(ctx, ref)
+	}
+	if err != nil {
+		return err
+	}
+	return printUsers(os.Stdout, opts.output, []domain.User{user})
+}
+
+func list(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("list")
+	email := flags.String("email", "", "only the user with this `email`")
+	status := flags.String("status", "", "only the users in this `status`")
+	attributes := attributesFlag{}
+	flags.Var(attributes, "attribute", "only the users with this attribute, as `name=value`, repeat for several")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var wanted domain.UserStatus
+	if *status != "" {
+		if wanted = domain.ParseUserStatus(*status); wanted == 0 {
+			return fmt.Errorf("unknown status %q", *status)
+		}
+	}
+	users, ctx, release, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
+	var found []domain.User
+	switch {
+	case *email != "":
+		user, err := users.GetUserByEmail(ctx, *email)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		if err == nil {
+			found = append(found, user)
+		}
+	case len(attributes) > 0:
+		found, err = users.GetUsersByAttributes(ctx, attributes)
+	default:
+		found, err = users.GetAllUsers(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	filtered := found[:0]
+	for _, user := range found {
+		if (wanted == 0 || user.Status == wanted) && user.HasAttributes(attributes) {
+			filtered = append(filtered, user)
+		}
+	}
+	return printUsers(os.Stdout, opts.output, filtered)
+}
+
+func update(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("update")
+	fields := addUserFlags(flags)
+	flags.StringVar(&fields.user.StatusReason, "reason", "", "reason of the status change")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	id, err := userID(flags)
+	if err != nil {
+		return err
+	}
+	changes, err := fields.parse()
+	if err != nil {
+		return err
+	}
+	users, ctx, release, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
+	updated, err := users.UpdateUserByID(ctx, id, changes)
+	if err != nil {
+		return err
+	}
+	return printUsers(os.Stdout, opts.output, []domain.User{updated})
+}
+
+func deactivate(ctx context.Context, args []string) error {
+	return changeStatus(ctx, "deactivate", args, func(ctx context.Context, users ports.UserService, id string, reason string) (domain.User, error) {
+		return users.UpdateUserByID(ctx, id, domain.User{Status: domain.DEACTIVATED, StatusReason: reason})
+	})
+}
+
+func reactivate(ctx context.Context, args []string) error {
+	return changeStatus(ctx, "reactivate", args, func(ctx context.Context, users ports.UserService, id string, reason string) (domain.User, error) {
+		return users.ReactivateUser(ctx, id, reason)
+	})
+}
+
+func changeStatus(ctx context.Context, name string, args []string, change func(context.Context, ports.UserService, string, string) (domain.User, error)) error {
+	flags, opts := newFlagSet(name)
+	reason := flags.String("reason", "", "`reason` recorded with the status change")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	id, err := userID(flags)
+	if err != nil {
+		return err
+	}
+	users, ctx, release, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
+	user, err := change(ctx, users, id, *reason)
+	if err != nil {
+		return err
+	}
+	return printUsers(os.Stdout, opts.output, []domain.User{user})
+}
+
+func remove(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("delete")
+	confirmed := flags.Bool("yes", false, "confirm the deletion, which cannot be undone")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	id, err := userID(flags)
+	if err != nil {
+		return err
+	}
+	if !*confirmed {
+		return errors.New("deleting a user cannot be undone, pass -yes to confirm, or deactivate the user instead")
+	}
+	users, ctx, release, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := users.DeleteUserByID(ctx, id); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "deleted", id)
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"userapi/app/internal/core/domain"
+)
+
+// printUsers writes the users as a table, or as a JSON array.
+func printUsers(w io.Writer, output string, users []domain.User) error {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(users)
+	}
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "USER ID\tNAME\tEMAIL\tPHONE\tSTATUS\tREASON")
+	for index := range users {
+		user := &users[index]
+		fmt.Fprintf(writer, "%s\t%s %s\t%s\t%s\t%s\t%s\n",
+			user.UserID, user.FirstName, user.LastName, user.Email, user.Phone, user.Status.String(), user.StatusReason)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+	"userapi/app/pkg/client"
+)
+
+// remoteUsers is the user service of a server, called through the Go client.
+type remoteUsers struct {
+	client *client.Client
+}
+
+// remoteErrors are the domain errors the client errors stand for.
+var remoteErrors = map[error]error{
+	client.ErrNotFound:          domain.ErrNotFound,
+	client.ErrInvalidTransition: domain.ErrInvalidTransition,
+	client.ErrAlreadyErased:     domain.ErrAlreadyErased,
+	client.ErrReasonRequired:    domain.ErrReasonRequired,
+	client.ErrInvalidAttributes: domain.ErrInvalidAttributes,
+	client.ErrTenantRequired:    domain.ErrTenantRequired,
+	client.ErrTenantDisabled:    domain.ErrTenantDisabled,
+}
+
+// remoteError makes err match the domain error of the response, keeping the
+// response in the message.
+func remoteError(err error) error {
+	for clientErr, domainErr := range remoteErrors {
+		if errors.Is(err, clientErr) {
+			return fmt.Errorf("%w: %w", domainErr, err)
+		}
+	}
+	return err
+}
+
+func toClientUser(user domain.User) client.User {
+	return client.User{
+		UserID:          user.UserID,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Email:           user.Email,
+		Phone:           user.Phone,
+		Age:             user.Age,
+		Status:          client.UserStatus(user.Status.String()),
+		StatusReason:    user.StatusReason,
+		SuspendedUntil:  user.SuspendedUntil,
+		EmailVerified:   user.EmailVerified,
+		PhoneVerifiedAt: user.PhoneVerifiedAt,
+		Attributes:      user.Attributes,
+	}
+}
+
+func toDomainUser(user client.User) domain.User {
+	return domain.User{
+		UserID:          user.UserID,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Email:           user.Email,
+		Phone:           user.Phone,
+		Age:             user.Age,
+		Status:          domain.ParseUserStatus(string(user.Status)),
+		StatusReason:    user.StatusReason,
+		SuspendedUntil:  user.SuspendedUntil,
+		EmailVerified:   user.EmailVerified,
+		PhoneVerifiedAt: user.PhoneVerifiedAt,
+		Attributes:      user.Attributes,
+	}
+}
+
+func toDomainUsers(users []client.User, err error) ([]domain.User, error) {
+	if err != nil {
+		return nil, remoteError(err)
+	}
+	converted := make([]domain.User, 0, len(users))
+	for _, user := range users {
+		converted = append(converted, toDomainUser(user))
+	}
+	return converted, nil
+}
+
+func toDomainResult(user client.User, err error) (domain.User, error) {
+	if err != nil {
+		return domain.User{}, remoteError(err)
+	}
+	return toDomainUser(user), nil
+}
+
+func (r remoteUsers) AddUser(ctx context.Context, user domain.User) (domain.User, error) {
+	return toDomainResult(r.client.AddUser(ctx, toClientUser(user)))
+}
+
+func (r remoteUsers) GetUserById(ctx context.Context, userID string) (domain.User, error) {
+	return toDomainResult(r.client.GetUserById(ctx, userID))
+}
+
+func (r remoteUsers) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	return toDomainResult(r.client.GetUserByEmail(ctx, email))
+}
+
+func (r remoteUsers) GetAllUsers(ctx context.Context) ([]domain.User, error) {
+	return toDomainUsers(r.client.GetAllUsers(ctx))
+}
+
+func (r remoteUsers) GetUsersByAttributes(ctx context.Context, attributes map[string]any) ([]domain.User, error) {
+	return toDomainUsers(r.client.GetUsersByAttributes(ctx, attributes))
+}
+
+func (r remoteUsers) GetUsersPage(ctx context.Context, attributes map[string]any, after string, limit int) ([]domain.User, error) {
+	return toDomainUsers(r.client.GetUsersPage(ctx, attributes, after, limit))
+}
+
+func (r remoteUsers) UpdateUserByID(ctx context.Context, userID string, user domain.User) (domain.User, error) {
+	return toDomainResult(r.client.UpdateUserByID(ctx, userID, toClientUser(user)))
+}
+
+func (r remoteUsers) DeleteUserByID(ctx context.Context, userID string) error {
+	if err := r.client.DeleteUserByID(ctx, userID); err != nil {
+		return remoteError(err)
+	}
+	return nil
+}
+
+func (r remoteUsers) ActivateUser(ctx context.Context, userID string, reason string) (domain.User, error) {
+	return toDomainResult(r.client.ActivateUser(ctx, userID, reason))
+}
+
+func (r remoteUsers) SuspendUser(ctx context.Context, userID string, reason string, until time.Time) (domain.User, error) {
+	return toDomainResult(r.client.SuspendUser(ctx, userID, reason, until))
+}
+
+func (r remoteUsers) ReactivateUser(ctx context.Context, userID string, reason string) (domain.User, error) {
+	return toDomainResult(r.client.ReactivateUser(ctx, userID, reason))
+}
+
+func (r remoteUsers) GetStatusTransitions(ctx context.Context, userID string) ([]domain.StatusTransition, error) {
+	transitions, err := r.client.GetStatusTransitions(ctx, userID)
+	if err != nil {
+		return nil, remoteError(err)
+	}
+	converted := make([]domain.StatusTransition, 0, len(transitions))
+	for _, transition := range transitions {
+		converted = append(converted, domain.StatusTransition{
+			TransitionID:   transition.TransitionID,
+			UserID:         transition.UserID,
+			From:           domain.ParseUserStatus(string(transition.From)),
+			To:             domain.ParseUserStatus(string(transition.To)),
+			Reason:         transition.Reason,
+			SuspendedUntil: transition.SuspendedUntil,
+			CreatedAt:      transition.CreatedAt,
+		})
+	}
+	return converted, nil
+}
+
+var _ ports.UserService = remoteUsers{}
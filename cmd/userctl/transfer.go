@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"userapi/app/internal/core/domain"
+)
+
+// csvColumns are the columns of the CSV files, in the order of the exports.
+var csvColumns = []string{"userId", "firstName", "lastName", "email", "phone", "age", "status", "statusReason", "attributes"}
+
+func exportUsers(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("export")
+	format := flags.String("format", "jsonl", "file `format`, jsonl (a JSON user per line) or csv")
+	path := flags.String("file", "", "`file` to write, standard output when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "jsonl" && *format != "csv" {
+		return fmt.Errorf("unknown format %q, use jsonl or csv", *format)
+	}
+	users, ctx, release, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
+	all, err := users.GetAllUsers(ctx)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = os.Stdout
+	if *path != "" {
+		file, err := os.Create(*path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	buffered := bufio.NewWriter(w)
+	if *format == "csv" {
+		err = writeCSV(buffered, all)
+	} else {
+		err = writeJSONLines(buffered, all)
+	}
+	if err != nil {
+		return fmt.Errorf("could not write the users: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("could not write the users: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d users\n", len(all))
+	return nil
+}
+
+func writeJSONLines(w io.Writer, users []domain.User) error {
+	encoder := json.NewEncoder(w)
+	for index := range users {
+		if err := encoder.Encode(&users[index]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, users []domain.User) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+	for index := range users {
+		user := &users[index]
+		attributes := ""
+		if len(user.Attributes) > 0 {
+			blob, err := json.Marshal(user.Attributes)
+			if err != nil {
+				return err
+			}
+			attributes = string(blob)
+		}
+		age := ""
+		if user.Age != 0 {
+			age = strconv.Itoa(user.Age)
+		}
+		record := []string{user.UserID, user.FirstName, user.LastName, user.Email, user.Phone, age, user.Status.String(), user.StatusReason, attributes}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// importUsers creates the users of the file, each through the user service,
+// and reports the ones that are refused. The user ids of the file are not
+// kept. Users that are neither active nor pending are created active and then
+// moved to their status, which is recorded like any other transition.
+func importUsers(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("import")
+	format := flags.String("format", "jsonl", "file `format`, jsonl (a JSON user per line) or csv")
+	path := flags.String("file", "", "`file` to read, standard input when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var r io.Reader = os.Stdin
+	if *path != "" {
+		file, err := os.Open(*path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	var records []importRecord
+	var err error
+	switch *format {
+	case "jsonl":
+		records, err = readJSONLines(r)
+	case "csv":
+		records, err = readCSV(r)
+	default:
+		return fmt.Errorf("unknown format %q, use jsonl or csv", *format)
+	}
+	if err != nil {
+		return err
+	}
+	users, ctx, release, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
+	var imported, failed int
+	for _, record := range records {
+		user := record.user
+		user.UserID = ""
+		status, reason := user.Status, user.StatusReason
+		if status != domain.ACTIVE && status != domain.PENDING {
+			user.Status, user.StatusReason = 0, ""
+		}
+		created, err := users.AddUser(ctx, user)
+		if err == nil && created.Status != status && status != 0 {
+			_, err = users.UpdateUserByID(ctx, created.UserID, domain.User{Status: status, StatusReason: reason})
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "line %d (%s): %v\n", record.line, user.Email, err)
+			continue
+		}
+		imported++
+	}
+	fmt.Fprintf(os.Stderr, "imported %d users, %d failed\n", imported, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d users could not be imported", failed)
+	}
+	return nil
+}
+
+// importRecord is a user read from a file, with its line for the reports.
+type importRecord struct {
+	line int
+	user domain.User
+}
+
+func readJSONLines(r io.Reader) ([]importRecord, error) {
+	var records []importRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var user domain.User
+		if err := json.Unmarshal(scanner.Bytes(), &user); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, importRecord{line: line, user: user})
+	}
+	return records, scanner.Err()
+}
+
+func readCSV(r io.Reader) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the header: %w", err)
+	}
+	columns := map[string]int{}
+	for index, name := range header {
+		columns[name] = index
+	}
+	for _, required := range []string{"firstName", "lastName", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("the header has no %s column", required)
+		}
+	}
+	field := func(row []string, name string) string {
+		if index, ok := columns[name]; ok && index < len(row) {
+			return row[index]
+		}
+		return ""
+	}
+	var records []importRecord
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		user := domain.User{
+			FirstName:    field(row, "firstName"),
+			LastName:     field(row, "lastName"),
+			Email:        field(row, "email"),
+			Phone:        field(row, "phone"),
+			StatusReason: field(row, "statusReason"),
+		}
+		if age := field(row, "age"); age != "" {
+			if user.Age, err = strconv.Atoi(age); err != nil {
+				return nil, fmt.Errorf("line %d: age %q is not a number", line, age)
+			}
+		}
+		if status := field(row, "status"); status != "" {
+			if user.Status = domain.ParseUserStatus(status); user.Status == 0 {
+				return nil, fmt.Errorf("line %d: unknown status %q", line, status)
+			}
+		}
+		if attributes := field(row, "attributes"); attributes != "" {
+			if err := json.Unmarshal([]byte(attributes), &user.Attributes); err != nil {
+				return nil, fmt.Errorf("line %d: attributes are not a JSON object: %w", line, err)
+			}
+		}
+		records = append(records, importRecord{line: line, user: user})
+	}
+}
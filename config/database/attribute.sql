@@ -0,0 +1,14 @@
+-- name: RetrieveAttributeSchema :one
+SELECT * FROM attribute_schemas WHERE tenant_id = $1;
+
+-- name: UpsertAttributeSchema :one
+INSERT INTO attribute_schemas (
+    tenant_id, schema
+) VALUES (
+             $1, $2
+         )
+ON CONFLICT (tenant_id) DO UPDATE SET schema = EXCLUDED.schema, updated_at = now()
+RETURNING *;
+
+-- name: DeleteAttributeSchema :execrows
+DELETE FROM attribute_schemas WHERE tenant_id = $1;
@@ -0,0 +1,109 @@
+-- name: CreateContactMethod :one
+INSERT INTO contact_methods (
+    tenant_id, user_id, kind, label, value, is_primary, pii_key_id, pii_data_key
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8
+         )
+RETURNING *;
+
+-- name: RetrieveContactMethodsByUser :many
+SELECT * FROM contact_methods
+WHERE tenant_id = $1 AND user_id = $2 AND kind = $3
+ORDER BY is_primary DESC, created_at;
+
+-- name: RetrieveContactMethodById :one
+SELECT * FROM contact_methods
+WHERE tenant_id = $1 AND user_id = $2 AND kind = $3 AND contact_id = $4;
+
+-- name: UpdateContactMethod :one
+UPDATE contact_methods
+SET label = $5, value = $6, pii_key_id = $7, pii_data_key = $8
+WHERE tenant_id = $1 AND user_id = $2 AND kind = $3 AND contact_id = $4
+RETURNING *;
+
+-- name: ClearPrimaryContactMethod :exec
+UPDATE contact_methods
+SET is_primary = FALSE
+WHERE tenant_id = $1 AND user_id = $2 AND kind = $3 AND is_primary;
+
+-- name: SetPrimaryContactMethod :one
+UPDATE contact_methods
+SET is_primary = TRUE
+WHERE tenant_id = $1 AND user_id = $2 AND kind = $3 AND contact_id = $4
+RETURNING *;
+
+-- name: DeleteContactMethod :execrows
+DELETE FROM contact_methods
+WHERE tenant_id = $1 AND user_id = $2 AND kind = $3 AND contact_id = $4;
+
+-- name: DeleteContactMethodsByUser :exec
+DELETE FROM contact_methods WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: RetrieveContactMethodsNotOnKey :many
+SELECT * FROM contact_methods
+WHERE tenant_id = $1 AND pii_key_id IS DISTINCT FROM sqlc.arg('active_key_id')::text;
+
+-- name: UpdateContactMethodPii :exec
+UPDATE contact_methods
+SET
+    value        = $3,
+    pii_key_id   = $4,
+    pii_data_key = $5
+WHERE tenant_id = $1 AND contact_id = $2;
+
+-- name: CreatePostalAddress :one
+INSERT INTO postal_addresses (
+    tenant_id, user_id, label, line1, line2, city, region, postal_code, country, is_primary, pii_key_id, pii_data_key
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+         )
+RETURNING *;
+
+-- name: RetrievePostalAddressesByUser :many
+SELECT * FROM postal_addresses
+WHERE tenant_id = $1 AND user_id = $2
+ORDER BY is_primary DESC, created_at;
+
+-- name: RetrievePostalAddressById :one
+SELECT * FROM postal_addresses
+WHERE tenant_id = $1 AND user_id = $2 AND address_id = $3;
+
+-- name: UpdatePostalAddress :one
+UPDATE postal_addresses
+SET label = $4, line1 = $5, line2 = $6, city = $7, region = $8, postal_code = $9, country = $10,
+    pii_key_id = $11, pii_data_key = $12
+WHERE tenant_id = $1 AND user_id = $2 AND address_id = $3
+RETURNING *;
+
+-- name: ClearPrimaryPostalAddress :exec
+UPDATE postal_addresses
+SET is_primary = FALSE
+WHERE tenant_id = $1 AND user_id = $2 AND is_primary;
+
+-- name: SetPrimaryPostalAddress :one
+UPDATE postal_addresses
+SET is_primary = TRUE
+WHERE tenant_id = $1 AND user_id = $2 AND address_id = $3
+RETURNING *;
+
+-- name: DeletePostalAddress :execrows
+DELETE FROM postal_addresses
+WHERE tenant_id = $1 AND user_id = $2 AND address_id = $3;
+
+-- name: DeletePostalAddressesByUser :exec
+DELETE FROM postal_addresses WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: RetrievePostalAddressesNotOnKey :many
+SELECT * FROM postal_addresses
+WHERE tenant_id = $1 AND pii_key_id IS DISTINCT FROM sqlc.arg('active_key_id')::text;
+
+-- name: UpdatePostalAddressPii :exec
+UPDATE postal_addresses
+SET
+    line1        = $3,
+    line2        = $4,
+    city         = $5,
+    postal_code  = $6,
+    pii_key_id   = $7,
+    pii_data_key = $8
+WHERE tenant_id = $1 AND address_id = $2;
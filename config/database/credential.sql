@@ -0,0 +1,39 @@
+-- name: UpsertCredential :one
+INSERT INTO credentials (
+    tenant_id, user_id, password_hash
+) VALUES (
+             $1, $2, $3
+         )
+ON CONFLICT (user_id) DO UPDATE
+SET
+    password_hash       = EXCLUDED.password_hash,
+    failed_attempts     = 0,
+    locked_until        = NULL,
+    password_changed_at = now()
+RETURNING *;
+
+-- name: RetrieveCredential :one
+SELECT * FROM credentials WHERE tenant_id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: CountLoginFailure :one
+UPDATE credentials
+SET failed_attempts = failed_attempts + 1
+WHERE tenant_id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: LockCredential :exec
+UPDATE credentials
+SET
+    locked_until    = $3,
+    failed_attempts = 0
+WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: ResetLoginFailures :exec
+UPDATE credentials
+SET
+    failed_attempts = 0,
+    locked_until    = NULL
+WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: DeleteCredential :exec
+DELETE FROM credentials WHERE tenant_id = $1 AND user_id = $2;
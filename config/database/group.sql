@@ -0,0 +1,85 @@
+-- name: CreateGroup :one
+INSERT INTO groups (
+    tenant_id, name, description
+) VALUES (
+             $1, $2, $3
+         )
+RETURNING *;
+
+-- name: RetrieveGroupById :one
+SELECT * FROM groups WHERE tenant_id = $1 AND group_id = $2;
+
+-- name: RetrieveAllGroups :many
+SELECT * FROM groups WHERE tenant_id = $1 ORDER BY name;
+
+-- name: UpdateGroupById :one
+UPDATE groups
+SET name = COALESCE(sqlc.narg('name'), name),
+    description = COALESCE(sqlc.narg('description'), description),
+    updated_at = now()
+WHERE tenant_id = sqlc.arg('tenant_id') AND group_id = sqlc.arg('group_id')
+RETURNING *;
+
+-- name: DeleteGroupById :execrows
+DELETE FROM groups WHERE tenant_id = $1 AND group_id = $2;
+
+-- name: RetrieveGroupMembers :many
+SELECT * FROM group_members WHERE tenant_id = $1 AND group_id = $2 ORDER BY created_at;
+
+-- name: AddGroupUser :exec
+INSERT INTO group_members (tenant_id, group_id, user_id)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveGroupUser :execrows
+DELETE FROM group_members WHERE tenant_id = $1 AND group_id = $2 AND user_id = $3;
+
+-- name: AddGroupSubgroup :exec
+INSERT INTO group_members (tenant_id, group_id, member_group_id)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveGroupSubgroup :execrows
+DELETE FROM group_members WHERE tenant_id = $1 AND group_id = $2 AND member_group_id = $3;
+
+-- name: LockGroupHierarchy :exec
+SELECT pg_advisory_xact_lock(hashtextextended('groups:' || sqlc.arg('tenant_id')::text, 0));
+
+-- name: IsGroupDescendant :one
+WITH RECURSIVE descendants (group_id) AS (
+    SELECT member_group_id FROM group_members
+    WHERE tenant_id = sqlc.arg('tenant_id') AND group_members.group_id = sqlc.arg('ancestor_id') AND member_group_id IS NOT NULL
+    UNION
+    SELECT m.member_group_id FROM group_members m JOIN descendants d ON m.group_id = d.group_id
+    WHERE m.tenant_id = sqlc.arg('tenant_id') AND m.member_group_id IS NOT NULL
+)
+SELECT EXISTS (SELECT 1 FROM descendants WHERE group_id = sqlc.arg('group_id'));
+
+-- name: RetrieveEffectiveGroupsByUser :many
+WITH RECURSIVE memberships (group_id, direct) AS (
+    SELECT group_members.group_id, TRUE FROM group_members WHERE tenant_id = $1 AND user_id = $2
+    UNION
+    SELECT m.group_id, FALSE FROM group_members m JOIN memberships s ON m.member_group_id = s.group_id
+    WHERE m.tenant_id = $1
+)
+SELECT g.group_id, g.name, bool_or(s.direct)::boolean AS direct
+FROM groups g JOIN memberships s ON s.group_id = g.group_id
+WHERE g.tenant_id = $1
+GROUP BY g.group_id
+ORDER BY g.name;
+
+-- name: RetrieveEffectiveGroupUsers :many
+WITH RECURSIVE subgroups (group_id) AS (
+    SELECT sqlc.arg('group_id')::uuid
+    UNION
+    SELECT m.member_group_id FROM group_members m JOIN subgroups s ON m.group_id = s.group_id
+    WHERE m.tenant_id = sqlc.arg('tenant_id') AND m.member_group_id IS NOT NULL
+)
+SELECT * FROM users
+WHERE tenant_id = sqlc.arg('tenant_id') AND user_id IN (
+    SELECT m.user_id FROM group_members m JOIN subgroups s ON m.group_id = s.group_id
+    WHERE m.tenant_id = sqlc.arg('tenant_id') AND m.user_id IS NOT NULL
+);
+
+-- name: DeleteGroupMembershipsByUser :exec
+DELETE FROM group_members WHERE tenant_id = $1 AND user_id = $2;
@@ -0,0 +1,22 @@
+-- name: TransitionUserStatus :one
+UPDATE users
+SET
+    status          = sqlc.arg('to_status'),
+    status_reason   = sqlc.narg('reason'),
+    suspended_until = sqlc.narg('suspended_until')
+WHERE tenant_id = sqlc.arg('tenant_id') AND user_id = sqlc.arg('user_id') AND status = sqlc.arg('from_status')
+RETURNING *;
+
+-- name: CreateStatusTransition :one
+INSERT INTO user_status_transitions (
+    tenant_id, user_id, from_status, to_status, reason, suspended_until
+) VALUES (
+             $1, $2, $3, $4, $5, $6
+         )
+RETURNING *;
+
+-- name: RetrieveStatusTransitionsByUser :many
+SELECT * FROM user_status_transitions WHERE tenant_id = $1 AND user_id = $2 ORDER BY created_at DESC;
+
+-- name: ClearStatusTransitionReasonsByUser :exec
+UPDATE user_status_transitions SET reason = NULL WHERE tenant_id = $1 AND user_id = $2;
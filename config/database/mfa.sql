@@ -0,0 +1,72 @@
+-- name: CreateMFAFactor :one
+INSERT INTO mfa_factors (
+    tenant_id, user_id, kind, name, status, totp_secret, pii_key_id, pii_data_key, credential_id, public_key, sign_count
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+         )
+RETURNING *;
+
+-- name: RetrieveMFAFactorById :one
+SELECT * FROM mfa_factors WHERE tenant_id = $1 AND user_id = $2 AND factor_id = $3 LIMIT 1;
+
+-- name: RetrieveMFAFactorByCredentialId :one
+SELECT * FROM mfa_factors WHERE tenant_id = $1 AND user_id = $2 AND credential_id = $3 LIMIT 1;
+
+-- name: RetrieveMFAFactorsByUser :many
+SELECT * FROM mfa_factors WHERE tenant_id = $1 AND user_id = $2 ORDER BY created_at;
+
+-- name: UseTOTPFactor :one
+UPDATE mfa_factors
+SET
+    status         = 'ACTIVE',
+    last_used_step = $4,
+    last_used_at   = now()
+WHERE tenant_id = $1 AND user_id = $2 AND factor_id = $3 AND kind = 'TOTP'
+  AND (last_used_step IS NULL OR last_used_step < $4)
+RETURNING *;
+
+-- name: UseWebAuthnFactor :one
+UPDATE mfa_factors
+SET
+    sign_count   = $4,
+    last_used_at = now()
+WHERE tenant_id = $1 AND user_id = $2 AND factor_id = $3 AND kind = 'WEBAUTHN' AND status = 'ACTIVE'
+  AND (sign_count < $4 OR (sign_count = 0 AND $4 = 0))
+RETURNING *;
+
+-- name: DeleteMFAFactor :execrows
+DELETE FROM mfa_factors WHERE tenant_id = $1 AND user_id = $2 AND factor_id = $3;
+
+-- name: DeleteMFAFactorsByUser :exec
+DELETE FROM mfa_factors WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO mfa_recovery_codes (
+    tenant_id, user_id, code_hash
+) VALUES (
+             $1, $2, $3
+         );
+
+-- name: UseRecoveryCode :execrows
+UPDATE mfa_recovery_codes
+SET used_at = now()
+WHERE tenant_id = $1 AND user_id = $2 AND code_hash = $3 AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM mfa_recovery_codes WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges (
+    tenant_id, user_id, purpose, challenge, expires_at
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+RETURNING *;
+
+-- name: ConsumeMFAChallenge :one
+DELETE FROM mfa_challenges
+WHERE tenant_id = $1 AND user_id = $2 AND challenge_id = $3 AND purpose = $4
+RETURNING *;
+
+-- name: DeleteMFAChallenges :exec
+DELETE FROM mfa_challenges WHERE tenant_id = $1 AND user_id = $2;
@@ -0,0 +1,43 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox (
+    tenant_id, user_id, event_type, payload
+) VALUES (
+             $1, $2, $3, $4
+         );
+
+-- name: RetrieveDueOutboxEvents :many
+SELECT * FROM outbox
+WHERE published_at IS NULL AND dead_at IS NULL AND next_attempt_at <= sqlc.arg('now')
+  AND NOT EXISTS (
+      SELECT 1 FROM outbox AS earlier
+      WHERE earlier.user_id = outbox.user_id AND earlier.sequence < outbox.sequence
+        AND earlier.published_at IS NULL AND earlier.dead_at IS NULL
+        AND earlier.next_attempt_at > sqlc.arg('now')
+  )
+ORDER BY sequence
+LIMIT sqlc.arg('limit');
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox SET published_at = now(), last_error = NULL WHERE sequence = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox
+SET
+    attempts        = attempts + 1,
+    last_error      = sqlc.arg('last_error'),
+    next_attempt_at = sqlc.arg('next_attempt_at')
+WHERE sequence = sqlc.arg('sequence');
+
+-- name: MarkOutboxEventDead :exec
+UPDATE outbox
+SET
+    attempts   = attempts + 1,
+    last_error = sqlc.arg('last_error'),
+    dead_at    = now()
+WHERE sequence = sqlc.arg('sequence');
+
+-- name: DeleteFinishedOutboxEvents :execrows
+DELETE FROM outbox WHERE published_at < $1 OR dead_at < $1;
+
+-- name: DeleteOutboxEventsByUser :exec
+DELETE FROM outbox WHERE tenant_id = $1 AND user_id = $2;
@@ -0,0 +1,55 @@
+-- name: CreatePrivacyRequest :one
+INSERT INTO privacy_requests (
+    tenant_id, user_id, kind, status, due_at
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+RETURNING *;
+
+-- name: CompletePrivacyRequest :one
+UPDATE privacy_requests
+SET
+    status       = sqlc.arg('status'),
+    error        = sqlc.narg('error'),
+    completed_at = now()
+WHERE tenant_id = sqlc.arg('tenant_id') AND request_id = sqlc.arg('request_id')
+RETURNING *;
+
+-- name: RetrievePrivacyRequestById :one
+SELECT * FROM privacy_requests WHERE tenant_id = $1 AND request_id = $2 LIMIT 1;
+
+-- name: RetrievePrivacyRequests :many
+SELECT * FROM privacy_requests WHERE tenant_id = $1 ORDER BY requested_at DESC;
+
+-- name: RetrievePrivacyRequestsByUser :many
+SELECT * FROM privacy_requests WHERE tenant_id = $1 AND user_id = $2 ORDER BY requested_at;
+
+-- name: AnonymizeUserById :one
+UPDATE users
+SET
+    first_name   = 'Erased',
+    last_name    = 'User',
+    email        = 'erased-' || user_id || '@erased.invalid',
+    phone        = NULL,
+    age          = NULL,
+    status_reason = NULL,
+    attributes   = '{}',
+    email_verified = FALSE,
+    phone_verified_at = NULL,
+    email_index  = NULL,
+    pii_key_id   = NULL,
+    pii_data_key = NULL,
+    erased_at    = now()
+WHERE tenant_id = $1 AND user_id = $2 AND erased_at IS NULL
+RETURNING *;
+
+-- name: CreateErasureCertificate :one
+INSERT INTO erasure_certificates (
+    tenant_id, request_id, user_id, erased_fields
+) VALUES (
+             $1, $2, $3, $4
+         )
+RETURNING *;
+
+-- name: RetrieveErasureCertificatesByUser :many
+SELECT * FROM erasure_certificates WHERE tenant_id = $1 AND user_id = $2 ORDER BY erased_at;
@@ -1,27 +1,45 @@
+-- name: SetTenant :exec
+SELECT set_config('app.tenant_id', sqlc.arg('tenant_id')::text, TRUE);
+
 -- name: RetrieveUserById :one
-SELECT * FROM users WHERE user_id = $1 LIMIT 1;
+SELECT * FROM users WHERE tenant_id = $1 AND user_id = $2 LIMIT 1;
 
 -- name: RetrieveAllUsers :many
-SELECT * FROM users;
+SELECT * FROM users WHERE tenant_id = $1;
+
+-- name: RetrieveUsersByAttributes :many
+SELECT * FROM users WHERE tenant_id = $1 AND attributes @> $2;
+
+-- name: RetrieveUsersPage :many
+SELECT * FROM users
+WHERE tenant_id = $1 AND attributes @> $2 AND user_id > $3
+ORDER BY user_id
+LIMIT $4;
 
 -- name: CreateUserDefault :one
 INSERT INTO users (
-    first_name, last_name, email, phone, age
+    tenant_id, first_name, last_name, email, phone, age
 ) VALUES (
-             $1, $2, $3,$4, $5
+             $1, $2, $3, $4, $5, $6
           )
 RETURNING *;
 
 -- name: CreateUser :one
 INSERT INTO users (
-    first_name, last_name, email, phone, age, status
+    tenant_id, first_name, last_name, email, phone, age, status, email_index, pii_key_id, pii_data_key, attributes
 ) VALUES (
-             $1, $2, $3,$4, $5, $6
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
          )
 RETURNING *;
 
--- name: DeleteUserById :exec
-DELETE FROM users WHERE user_id = $1;
+-- name: RetrieveUserByEmail :one
+SELECT * FROM users
+WHERE tenant_id = sqlc.arg('tenant_id')
+  AND (email = sqlc.narg('email') OR email_index = sqlc.narg('email_index'))
+LIMIT 1;
+
+-- name: DeleteUserById :execrows
+DELETE FROM users WHERE tenant_id = $1 AND user_id = $2;
 
 -- name: UpdateUserById :one
 UPDATE users
@@ -31,6 +49,40 @@ SET
     email      = COALESCE(sqlc.narg('email'), email),
     age        = COALESCE(sqlc.narg('age'), age),
     phone      = COALESCE(sqlc.narg('phone'), phone),
-    status     = COALESCE(sqlc.narg('status'), status)
-WHERE user_id = sqlc.arg('user_id')
-RETURNING user_id, first_name, last_name, email, age, phone, status;
+    status     = COALESCE(sqlc.narg('status'), status),
+    email_index = COALESCE(sqlc.narg('email_index'), email_index),
+    attributes = COALESCE(sqlc.narg('attributes'), attributes),
+    -- a new address must be verified again. Sealed emails are compared by their blind index.
+    email_verified = CASE
+        WHEN sqlc.narg('email')::text IS NULL THEN email_verified
+        WHEN sqlc.narg('email_index')::bytea IS NOT NULL THEN email_verified AND email_index IS NOT DISTINCT FROM sqlc.narg('email_index')
+        ELSE email_verified AND email = sqlc.narg('email')
+    END,
+    phone_verified_at = CASE
+        WHEN sqlc.narg('phone')::text IS NULL OR sqlc.narg('phone') = phone THEN phone_verified_at
+    END
+WHERE tenant_id = sqlc.arg('tenant_id') AND user_id = sqlc.arg('user_id')
+RETURNING *;
+
+-- name: SetEmailVerified :one
+UPDATE users
+SET email_verified = $3
+WHERE tenant_id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: RetrieveUsersNotOnKey :many
+SELECT * FROM users
+WHERE tenant_id = $1 AND pii_key_id IS DISTINCT FROM sqlc.arg('active_key_id')::text;
+
+-- name: UpdateUserPii :exec
+UPDATE users
+SET
+    email        = $3,
+    phone        = $4,
+    email_index  = $5,
+    pii_key_id   = $6,
+    pii_data_key = $7
+WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: LockUserById :one
+SELECT * FROM users WHERE tenant_id = $1 AND user_id = $2 FOR UPDATE;
@@ -0,0 +1,32 @@
+-- name: CreateScheduledAction :one
+INSERT INTO scheduled_actions (
+    tenant_id, user_id, kind, run_at, reason
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+RETURNING *;
+
+-- name: RetrieveScheduledActionsByUser :many
+SELECT * FROM scheduled_actions WHERE tenant_id = $1 AND user_id = $2 ORDER BY run_at;
+
+-- name: CancelScheduledAction :execrows
+UPDATE scheduled_actions
+SET status = 'CANCELLED', executed_at = now()
+WHERE tenant_id = $1 AND user_id = $2 AND action_id = $3 AND status = 'PENDING';
+
+-- name: RetrieveDueScheduledActions :many
+SELECT * FROM scheduled_actions
+WHERE tenant_id = $1 AND status = 'PENDING' AND run_at <= $2
+ORDER BY run_at
+LIMIT $3;
+
+-- name: CompleteScheduledAction :execrows
+UPDATE scheduled_actions
+SET status = $3, error = $4, executed_at = now()
+WHERE tenant_id = $1 AND action_id = $2 AND status = 'PENDING';
+
+-- name: DeleteScheduledActionsByUser :exec
+DELETE FROM scheduled_actions WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: RetrieveExpiredSuspensions :many
+SELECT user_id FROM users WHERE tenant_id = $1 AND status = 'SUSPENDED' AND suspended_until <= $2;
@@ -0,0 +1,45 @@
+-- name: CreateSession :one
+INSERT INTO sessions (
+    tenant_id, user_id, refresh_token_hash, user_agent, ip_address, expires_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6
+         )
+RETURNING *;
+
+-- name: RetrieveSessionById :one
+SELECT * FROM sessions WHERE tenant_id = $1 AND session_id = $2 LIMIT 1;
+
+-- name: RetrieveActiveSessionsByUser :many
+SELECT * FROM sessions
+WHERE tenant_id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > now()
+ORDER BY last_seen_at DESC;
+
+-- name: TouchSession :exec
+UPDATE sessions
+SET
+    last_seen_at = now(),
+    ip_address   = COALESCE(sqlc.narg('ip_address'), ip_address)
+WHERE tenant_id = sqlc.arg('tenant_id') AND session_id = sqlc.arg('session_id');
+
+-- name: RotateSessionRefreshToken :one
+UPDATE sessions
+SET
+    refresh_token_hash = sqlc.arg('new_hash'),
+    expires_at         = sqlc.arg('expires_at'),
+    last_seen_at       = now()
+WHERE tenant_id = sqlc.arg('tenant_id') AND session_id = sqlc.arg('session_id')
+  AND refresh_token_hash = sqlc.arg('old_hash') AND revoked_at IS NULL AND expires_at > now()
+RETURNING *;
+
+-- name: RevokeSession :execrows
+UPDATE sessions
+SET revoked_at = now()
+WHERE tenant_id = $1 AND user_id = $2 AND session_id = $3 AND revoked_at IS NULL;
+
+-- name: RevokeSessionsByUser :execrows
+UPDATE sessions
+SET revoked_at = now()
+WHERE tenant_id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: DeleteSessionsByUser :exec
+DELETE FROM sessions WHERE tenant_id = $1 AND user_id = $2;
@@ -0,0 +1,22 @@
+-- name: CreateTenant :one
+INSERT INTO tenants (
+    name, slug
+) VALUES (
+             $1, $2
+         )
+RETURNING *;
+
+-- name: RetrieveTenantById :one
+SELECT * FROM tenants WHERE tenant_id = $1 LIMIT 1;
+
+-- name: RetrieveTenantBySlug :one
+SELECT * FROM tenants WHERE slug = $1 LIMIT 1;
+
+-- name: RetrieveAllTenants :many
+SELECT * FROM tenants ORDER BY created_at;
+
+-- name: DisableTenantById :one
+UPDATE tenants
+SET disabled = TRUE
+WHERE tenant_id = $1
+RETURNING *;
@@ -0,0 +1,37 @@
+-- name: UpsertPhoneVerification :one
+INSERT INTO phone_verifications (
+    tenant_id, user_id, code_hash, expires_at
+) VALUES (
+             $1, $2, $3, $4
+         )
+ON CONFLICT (user_id) DO UPDATE
+SET
+    code_hash    = EXCLUDED.code_hash,
+    expires_at   = EXCLUDED.expires_at,
+    attempts     = 0,
+    locked_until = NULL,
+    created_at   = now()
+RETURNING *;
+
+-- name: RetrievePhoneVerification :one
+SELECT * FROM phone_verifications WHERE tenant_id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: CountPhoneVerificationAttempt :one
+UPDATE phone_verifications
+SET attempts = attempts + 1
+WHERE tenant_id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: LockPhoneVerification :exec
+UPDATE phone_verifications
+SET locked_until = $3
+WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: DeletePhoneVerification :exec
+DELETE FROM phone_verifications WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: SetPhoneVerified :one
+UPDATE users
+SET phone_verified_at = now()
+WHERE tenant_id = $1 AND user_id = $2
+RETURNING *;
@@ -0,0 +1,64 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+    tenant_id, url, event_types, secret
+) VALUES (
+             $1, $2, $3, $4
+         )
+RETURNING *;
+
+-- name: RetrieveWebhookById :one
+SELECT * FROM webhooks WHERE tenant_id = $1 AND webhook_id = $2;
+
+-- name: RetrieveWebhooks :many
+SELECT * FROM webhooks WHERE tenant_id = $1 ORDER BY created_at;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks WHERE tenant_id = $1 AND webhook_id = $2;
+
+-- name: CreateWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (
+    tenant_id, webhook_id, event_id, event_type, payload, user_id
+)
+SELECT tenant_id, webhook_id, sqlc.arg('event_id')::uuid, sqlc.arg('event_type')::text, sqlc.arg('payload')::jsonb, sqlc.arg('user_id')::uuid
+FROM webhooks
+WHERE tenant_id = sqlc.arg('tenant_id') AND sqlc.arg('event_type')::text = ANY (event_types)
+ON CONFLICT (webhook_id, event_id) DO NOTHING;
+
+-- name: RetrieveWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE tenant_id = $1 AND webhook_id = $2
+ORDER BY created_at DESC
+LIMIT $3;
+
+-- name: RetrieveWebhookDeliveriesByUser :many
+SELECT * FROM webhook_deliveries
+WHERE tenant_id = $1 AND user_id = $2
+ORDER BY created_at DESC;
+
+-- name: RetrieveDueWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE tenant_id = $1 AND status = 'PENDING' AND next_attempt_at <= $2
+ORDER BY created_at
+LIMIT $3;
+
+-- name: RecordWebhookAttempt :execrows
+UPDATE webhook_deliveries
+SET
+    status          = sqlc.arg('status'),
+    failures        = CASE WHEN sqlc.arg('status') = 'SUCCEEDED' THEN 0 ELSE failures + 1 END,
+    attempts        = attempts || jsonb_build_array(sqlc.arg('attempt')::jsonb),
+    next_attempt_at = sqlc.arg('next_attempt_at'),
+    delivered_at    = CASE WHEN sqlc.arg('status') = 'SUCCEEDED' THEN now() ELSE delivered_at END
+WHERE tenant_id = sqlc.arg('tenant_id') AND delivery_id = sqlc.arg('delivery_id') AND status = 'PENDING';
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'PENDING', failures = 0, next_attempt_at = now()
+WHERE tenant_id = $1 AND webhook_id = $2 AND delivery_id = $3
+RETURNING *;
+
+-- name: DeleteWebhookDeliveriesByUser :exec
+DELETE FROM webhook_deliveries WHERE tenant_id = $1 AND user_id = $2;
+
+-- name: DeleteFinishedWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries WHERE tenant_id = $1 AND status <> 'PENDING' AND created_at < $2;
@@ -0,0 +1,15 @@
+#!/bin/sh
+# Writes random secrets to the files the docker compose setups read them from,
+# leaving the existing ones alone. The files are ignored by git, keep them out
+# of commits.
+set -e
+cd "$(dirname "$0")"
+for file in postgres/db/password.txt postgres/db/app-password.txt \
+    deploy/compose/db/password.txt deploy/compose/db/app-password.txt \
+    deploy/compose/admin-token.txt; do
+    if [ ! -s "$file" ]; then
+        mkdir -p "$(dirname "$file")"
+        od -An -N32 -tx1 /dev/urandom | tr -d ' \n' > "$file"
+        echo "generated $file"
+    fi
+done
@@ -0,0 +1,13 @@
+#!/bin/sh
+# Creates the userapi login role the server connects as, with the password in
+# APP_PASSWORD or the APP_PASSWORD_FILE. It is neither a superuser nor
+# BYPASSRLS, so the row level security policies isolating the tenants apply to
+# it, and gets its privileges on the tables through userapi_app, which the
+# migrations, run as the owner, grant them to.
+set -e
+password="${APP_PASSWORD:-$(cat "$APP_PASSWORD_FILE")}"
+psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" -v password="$password" <<'SQL'
+SELECT 'CREATE ROLE userapi_app NOLOGIN NOSUPERUSER NOBYPASSRLS'
+WHERE NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'userapi_app')\gexec
+CREATE ROLE userapi LOGIN NOSUPERUSER NOBYPASSRLS PASSWORD :'password' IN ROLE userapi_app;
+SQL
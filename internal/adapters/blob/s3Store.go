@@ -0,0 +1,239 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"userapi/app/internal/adapters/tracing"
+	"userapi/app/internal/core/domain"
+)
+
+// S3Store keeps blobs in a bucket of an S3 compatible object store, such as
+// AWS S3 or MinIO. Objects are addressed path-style, which every
+// implementation supports, and requests are signed with AWS Signature
+// Version 4.
+type S3Store struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	client          *http.Client
+	now             func() time.Time
+}
+
+// NewS3Store returns a store for the bucket served at endpoint, for example
+// https://s3.eu-west-1.amazonaws.com or http://localhost:9000.
+func NewS3Store(endpoint string, region string, bucket string, accessKeyID string, secretAccessKey string) (*S3Store, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("the s3 bucket is required")
+	}
+	return &S3Store{
+		endpoint:        parsed,
+		region:          region,
+		bucket:          bucket,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		client:          &http.Client{Timeout: 30 * time.Second, Transport: tracing.NewTransport(nil)},
+		now:             time.Now,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, contentType string, data []byte) error {
+	request, err := s.newRequest(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", contentType)
+	response, err := s.do(request, data)
+	if err != nil {
+		return fmt.Errorf("could not store %s: %w", key, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not store %s: %w", key, responseError(response))
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (domain.Blob, error) {
+	request, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return domain.Blob{}, err
+	}
+	response, err := s.do(request, nil)
+	if err != nil {
+		return domain.Blob{}, fmt.Errorf("could not read %s: %w", key, err)
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return domain.Blob{}, domain.ErrNotFound
+	default:
+		return domain.Blob{}, fmt.Errorf("could not read %s: %w", key, responseError(response))
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return domain.Blob{}, fmt.Errorf("could not read %s: %w", key, err)
+	}
+	modTime, _ := http.ParseTime(response.Header.Get("Last-Modified"))
+	return domain.Blob{ContentType: response.Header.Get("Content-Type"), Data: data, ModTime: modTime}, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	request, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	response, err := s.do(request, nil)
+	if err != nil {
+		return fmt.Errorf("could not delete %s: %w", key, err)
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return fmt.Errorf("could not delete %s: %w", key, responseError(response))
+}
+
+func (s *S3Store) newRequest(ctx context.Context, method string, key string, body []byte) (*http.Request, error) {
+	segments := strings.Split(key, "/")
+	for index, segment := range segments {
+		segments[index] = escape(segment)
+	}
+	objectURL := s.endpoint.Scheme + "://" + s.endpoint.Host + strings.TrimSuffix(s.endpoint.EscapedPath(), "/") +
+		"/" + escape(s.bucket) + "/" + strings.Join(segments, "/")
+	request, err := http.NewRequestWithContext(ctx, method, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %q: %w", key, err)
+	}
+	return request, nil
+}
+
+func (s *S3Store) do(request *http.Request, body []byte) (*http.Response, error) {
+	payloadHash := sha256.Sum256(body)
+	signV4(request, hex.EncodeToString(payloadHash[:]), s.accessKeyID, s.secretAccessKey, s.region, s.now())
+	return s.client.Do(request)
+}
+
+func responseError(response *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	return fmt.Errorf("s3 responded %s: %s", response.Status, strings.TrimSpace(string(message)))
+}
+
+// signV4 adds the AWS Signature Version 4 authorization of an S3 request. It
+// signs the host and every header already set on the request.
+func signV4(request *http.Request, payloadHash string, accessKeyID string, secretAccessKey string, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/" + region + "/s3/aws4_request"
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := request.URL.Query()
+	queryNames := make([]string, 0, len(query))
+	for name := range query {
+		queryNames = append(queryNames, name)
+	}
+	sort.Strings(queryNames)
+	var canonicalQuery []string
+	for _, name := range queryNames {
+		for _, value := range query[name] {
+			canonicalQuery = append(canonicalQuery, escape(name)+"="+escape(value))
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		strings.Join(canonicalQuery, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + secretAccessKey)
+	for _, part := range strings.Split(scope, "/") {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escape percent-encodes everything but the unreserved characters, as the
+// canonical requests of Signature Version 4 require.
+func escape(s string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			escaped.WriteByte(b)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
+
+func (s *S3Store) HealthCheckName() string {
+	return "blob-store"
+}
+
+// CheckHealth checks that the bucket exists and that the credentials can
+// reach it.
+func (s *S3Store) CheckHealth(ctx context.Context) error {
+	bucketURL := s.endpoint.Scheme + "://" + s.endpoint.Host + strings.TrimSuffix(s.endpoint.EscapedPath(), "/") + "/" + escape(s.bucket)
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, bucketURL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid bucket %q: %w", s.bucket, err)
+	}
+	response, err := s.do(request, nil)
+	if err != nil {
+		return fmt.Errorf("could not reach the bucket: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not reach the bucket: s3 responded %s", response.Status)
+	}
+	return nil
+}
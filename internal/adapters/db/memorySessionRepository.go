@@ -0,0 +1,133 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"userapi/app/internal/core/domain"
+)
+
+// MemorySessionRepository keeps sessions in process. Sessions are lost on
+// restart and are not shared between replicas, so it only suits single
+// instance deployments and tests.
+type MemorySessionRepository struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]domain.Session
+	now      func() time.Time
+}
+
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{sessions: make(map[string]map[string]domain.Session), now: time.Now}
+}
+
+func (m *MemorySessionRepository) tenantSessions(tenantId string) map[string]domain.Session {
+	sessions, ok := m.sessions[tenantId]
+	if !ok {
+		sessions = make(map[string]domain.Session)
+		m.sessions[tenantId] = sessions
+	}
+	return sessions
+}
+
+func (m *MemorySessionRepository) active(session domain.Session) bool {
+	return session.RevokedAt.IsZero() && session.RefreshExpiresAt.After(m.now())
+}
+
+func (m *MemorySessionRepository) CreateSession(ctx context.Context, tenantId string, session domain.Session) (domain.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	session.SessionID = generateUUID()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	session.Token, session.RefreshToken = "", ""
+	m.tenantSessions(tenantId)[session.SessionID] = session
+	return session, nil
+}
+
+func (m *MemorySessionRepository) RetrieveSession(ctx context.Context, tenantId string, sessionId string) (domain.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.tenantSessions(tenantId)[sessionId]
+	if !ok {
+		return domain.Session{}, domain.ErrNotFound
+	}
+	return session, nil
+}
+
+func (m *MemorySessionRepository) RetrieveSessions(ctx context.Context, tenantId string, userId string) ([]domain.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sessions := []domain.Session{}
+	for _, session := range m.tenantSessions(tenantId) {
+		if session.UserID == userId && m.active(session) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+func (m *MemorySessionRepository) TouchSession(ctx context.Context, tenantId string, sessionId string, ipAddress string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sessions := m.tenantSessions(tenantId)
+	session, ok := sessions[sessionId]
+	if !ok {
+		return nil
+	}
+	session.LastSeenAt = m.now()
+	if ipAddress != "" {
+		session.IPAddress = ipAddress
+	}
+	sessions[sessionId] = session
+	return nil
+}
+
+func (m *MemorySessionRepository) RotateRefreshToken(ctx context.Context, tenantId string, sessionId string, oldHash []byte, newHash []byte, expiresAt time.Time) (domain.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sessions := m.tenantSessions(tenantId)
+	session, ok := sessions[sessionId]
+	if !ok || !m.active(session) || !bytes.Equal(session.RefreshTokenHash, oldHash) {
+		return domain.Session{}, domain.ErrInvalidToken
+	}
+	session.RefreshTokenHash = newHash
+	session.RefreshExpiresAt = expiresAt
+	session.LastSeenAt = m.now()
+	sessions[sessionId] = session
+	return session, nil
+}
+
+func (m *MemorySessionRepository) RevokeSession(ctx context.Context, tenantId string, userId string, sessionId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sessions := m.tenantSessions(tenantId)
+	session, ok := sessions[sessionId]
+	if !ok || session.UserID != userId || !session.RevokedAt.IsZero() {
+		return domain.ErrNotFound
+	}
+	session.RevokedAt = m.now()
+	sessions[sessionId] = session
+	return nil
+}
+
+func (m *MemorySessionRepository) RevokeSessions(ctx context.Context, tenantId string, userId string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sessions := m.tenantSessions(tenantId)
+	revoked := 0
+	for sessionId, session := range sessions {
+		if session.UserID == userId && session.RevokedAt.IsZero() {
+			session.RevokedAt = m.now()
+			sessions[sessionId] = session
+			revoked++
+		}
+	}
+	return revoked, nil
+}
@@ -0,0 +1,26 @@
+DROP TABLE IF EXISTS postal_addresses;
+DROP TABLE IF EXISTS contact_methods;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
+DROP TABLE IF EXISTS attribute_schemas;
+DROP TABLE IF EXISTS scheduled_actions;
+DROP TABLE IF EXISTS user_status_transitions;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS mfa_challenges;
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS mfa_factors;
+DROP TABLE IF EXISTS credentials;
+DROP TABLE IF EXISTS phone_verifications;
+DROP TABLE IF EXISTS erasure_certificates;
+DROP TABLE IF EXISTS privacy_requests;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS tenants;
+
+DROP TYPE IF EXISTS contact_kind;
+DROP TYPE IF EXISTS scheduled_action_status;
+DROP TYPE IF EXISTS scheduled_action_kind;
+DROP TYPE IF EXISTS mfa_factor_status;
+DROP TYPE IF EXISTS mfa_factor_kind;
+DROP TYPE IF EXISTS privacy_request_status;
+DROP TYPE IF EXISTS privacy_request_kind;
+DROP TYPE IF EXISTS user_status;
@@ -0,0 +1,428 @@
+-- The initial schema. It is written to also bring databases created by the
+-- init.sql of earlier versions up to date, so every statement is idempotent.
+
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'user_status') THEN
+        CREATE TYPE user_status AS ENUM ('ACTIVE', 'DEACTIVATED', 'PENDING', 'SUSPENDED', 'LOCKED');
+    END IF;
+    -- INACTIVE users of earlier versions are DEACTIVATED.
+    IF EXISTS (SELECT 1 FROM pg_enum WHERE enumtypid = 'user_status'::regtype AND enumlabel = 'INACTIVE') THEN
+        ALTER TYPE user_status RENAME VALUE 'INACTIVE' TO 'DEACTIVATED';
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'privacy_request_kind') THEN
+        CREATE TYPE privacy_request_kind AS ENUM ('EXPORT', 'ERASURE');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'privacy_request_status') THEN
+        CREATE TYPE privacy_request_status AS ENUM ('PENDING', 'COMPLETED', 'FAILED');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'mfa_factor_kind') THEN
+        CREATE TYPE mfa_factor_kind AS ENUM ('TOTP', 'WEBAUTHN');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'mfa_factor_status') THEN
+        CREATE TYPE mfa_factor_status AS ENUM ('PENDING', 'ACTIVE');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'scheduled_action_kind') THEN
+        CREATE TYPE scheduled_action_kind AS ENUM ('DEACTIVATE', 'REACTIVATE');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'scheduled_action_status') THEN
+        CREATE TYPE scheduled_action_status AS ENUM ('PENDING', 'DONE', 'CANCELLED', 'FAILED');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'contact_kind') THEN
+        CREATE TYPE contact_kind AS ENUM ('EMAIL', 'PHONE');
+    END IF;
+END$$;
+ALTER TYPE user_status ADD VALUE IF NOT EXISTS 'PENDING';
+ALTER TYPE user_status ADD VALUE IF NOT EXISTS 'SUSPENDED';
+ALTER TYPE user_status ADD VALUE IF NOT EXISTS 'LOCKED';
+
+CREATE TABLE IF NOT EXISTS tenants (
+    tenant_id  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    name       VARCHAR(100) NOT NULL,
+    slug       VARCHAR(63)  NOT NULL UNIQUE,
+    disabled   BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+
+    CONSTRAINT slug_format CHECK (slug ~ '^[a-z0-9]([a-z0-9-]*[a-z0-9])?$')
+);
+
+INSERT INTO tenants (name, slug) VALUES ('Default', 'default') ON CONFLICT (slug) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS  users (
+    user_id   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants (tenant_id),
+
+    first_name VARCHAR(50) NOT NULL,
+    last_name  VARCHAR(50) NOT NULL,
+    email      TEXT NOT NULL,
+    phone      TEXT,
+    age        INTEGER,
+    status     user_status NOT NULL DEFAULT 'ACTIVE',
+    email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    phone_verified_at TIMESTAMPTZ,
+    status_reason     TEXT,
+    suspended_until   TIMESTAMPTZ,
+    -- custom attributes, validated against the attribute schema of the tenant.
+    attributes        JSONB NOT NULL DEFAULT '{}',
+
+    -- envelope encryption of configured fields, see internal/adapters/encryption.
+    email_index  BYTEA,
+    pii_key_id   VARCHAR(64),
+    pii_data_key BYTEA,
+    erased_at    TIMESTAMPTZ,
+
+    CONSTRAINT first_name_len CHECK (char_length(first_name) BETWEEN 2 AND 50),
+    CONSTRAINT last_name_len  CHECK (char_length(last_name)  BETWEEN 2 AND 50),
+    CONSTRAINT email_format  CHECK (pii_key_id IS NOT NULL OR email ~* '^[A-Z0-9._%+-]+@[A-Z0-9.-]+\.[A-Z]{2,}$'),
+    CONSTRAINT phone_format  CHECK (pii_key_id IS NOT NULL OR phone IS NULL OR phone ~ '^\+[1-9][0-9]{1,14}$'), 
+    CONSTRAINT age_positive  CHECK (age IS NULL OR age > 0),
+    CONSTRAINT email_unique_per_tenant UNIQUE (tenant_id, email),
+    CONSTRAINT email_index_unique_per_tenant UNIQUE (tenant_id, email_index)
+);
+
+-- The users of databases created by earlier versions belong to the default tenant.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants (tenant_id);
+UPDATE users SET tenant_id = (SELECT tenant_id FROM tenants WHERE slug = 'default') WHERE tenant_id IS NULL;
+ALTER TABLE users ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMPTZ;
+-- Encrypted fields no longer fit the VARCHAR columns of earlier versions.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_index BYTEA;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pii_key_id VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pii_data_key BYTEA;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at TIMESTAMPTZ;
+ALTER TABLE users ALTER COLUMN email TYPE TEXT;
+ALTER TABLE users ALTER COLUMN phone TYPE TEXT;
+-- Earlier versions only accepted Sri Lankan numbers, written in E.164 from now on.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS email_format;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS phone_format;
+UPDATE users SET phone = '+94' || substr(phone, 2) WHERE pii_key_id IS NULL AND phone ~ '^0[0-9]{9}$';
+ALTER TABLE users ADD CONSTRAINT email_format CHECK (pii_key_id IS NOT NULL OR email ~* '^[A-Z0-9._%+-]+@[A-Z0-9.-]+\.[A-Z]{2,}$');
+ALTER TABLE users ADD CONSTRAINT phone_format CHECK (pii_key_id IS NOT NULL OR phone IS NULL OR phone ~ '^\+[1-9][0-9]{1,14}$');
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'users'::regclass AND conname = 'email_unique_per_tenant') THEN
+        ALTER TABLE users ADD CONSTRAINT email_unique_per_tenant UNIQUE (tenant_id, email);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'users'::regclass AND conname = 'email_index_unique_per_tenant') THEN
+        ALTER TABLE users ADD CONSTRAINT email_index_unique_per_tenant UNIQUE (tenant_id, email_index);
+    END IF;
+END$$;
+
+-- Lifecycle columns, for databases created by earlier versions.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_reason TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMPTZ;
+-- Custom attributes.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS users_attributes_idx ON users USING GIN (attributes jsonb_path_ops);
+
+-- Row level security does not apply to superusers. Run the server as a regular role
+-- for the users_tenant_isolation policy to take effect.
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE users FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS users_tenant_isolation ON users;
+CREATE POLICY users_tenant_isolation ON users
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+
+-- Privacy requests and certificates outlive the user they refer to, so user_id is not a foreign key.
+CREATE TABLE IF NOT EXISTS privacy_requests (
+    request_id   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id    UUID NOT NULL REFERENCES tenants (tenant_id),
+    user_id      UUID NOT NULL,
+
+    kind         privacy_request_kind   NOT NULL,
+    status       privacy_request_status NOT NULL DEFAULT 'PENDING',
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    due_at       TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ,
+    error        TEXT
+);
+
+CREATE TABLE IF NOT EXISTS erasure_certificates (
+    certificate_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id      UUID NOT NULL REFERENCES tenants (tenant_id),
+    request_id     UUID NOT NULL UNIQUE REFERENCES privacy_requests (request_id),
+    user_id        UUID NOT NULL,
+
+    erased_fields  TEXT[] NOT NULL,
+    erased_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- One pending code per user. The code is stored hashed together with the phone it was sent to.
+CREATE TABLE IF NOT EXISTS phone_verifications (
+    user_id      UUID PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+    tenant_id    UUID NOT NULL REFERENCES tenants (tenant_id),
+
+    code_hash    BYTEA NOT NULL,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Password credentials of the users that can log in, hashed with argon2id in PHC string format.
+CREATE TABLE IF NOT EXISTS credentials (
+    user_id             UUID PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+    tenant_id           UUID NOT NULL REFERENCES tenants (tenant_id),
+
+    password_hash       TEXT NOT NULL,
+    failed_attempts     INTEGER NOT NULL DEFAULT 0,
+    locked_until        TIMESTAMPTZ,
+    password_changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Second factors of the users. Factors stay PENDING until a first code or assertion confirms them.
+CREATE TABLE IF NOT EXISTS mfa_factors (
+    factor_id      UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id      UUID NOT NULL REFERENCES tenants (tenant_id),
+    user_id        UUID NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+
+    kind           mfa_factor_kind   NOT NULL,
+    name           VARCHAR(64)       NOT NULL,
+    status         mfa_factor_status NOT NULL DEFAULT 'PENDING',
+    -- TOTP factors, the secret is sealed like the user PII when a keyring is configured.
+    totp_secret    TEXT,
+    pii_key_id     VARCHAR(64),
+    pii_data_key   BYTEA,
+    last_used_step BIGINT,
+    -- WebAuthn factors, the public key is COSE encoded.
+    credential_id  BYTEA,
+    public_key     BYTEA,
+    sign_count     BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at   TIMESTAMPTZ,
+
+    CONSTRAINT credential_id_unique_per_tenant UNIQUE (tenant_id, credential_id)
+);
+
+-- Single use recovery codes, stored hashed.
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    code_id    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id  UUID NOT NULL REFERENCES tenants (tenant_id),
+    user_id    UUID NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+
+    code_hash  BYTEA NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Pending WebAuthn ceremonies, deleted when they are consumed.
+CREATE TABLE IF NOT EXISTS mfa_challenges (
+    challenge_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id    UUID NOT NULL REFERENCES tenants (tenant_id),
+    user_id      UUID NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+
+    purpose      VARCHAR(32) NOT NULL,
+    challenge    BYTEA NOT NULL,
+    expires_at   TIMESTAMPTZ NOT NULL
+);
+
+-- Sessions issued on login. Only a hash of the refresh token is stored.
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id          UUID NOT NULL REFERENCES tenants (tenant_id),
+    user_id            UUID NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+
+    refresh_token_hash BYTEA NOT NULL,
+    user_agent         TEXT,
+    ip_address         VARCHAR(64),
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_seen_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at         TIMESTAMPTZ NOT NULL,
+    revoked_at         TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS sessions_user_idx ON sessions (tenant_id, user_id);
+
+-- Status history of the users, with the reason given for each change.
+CREATE TABLE IF NOT EXISTS user_status_transitions (
+    transition_id   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id       UUID NOT NULL REFERENCES tenants (tenant_id),
+    user_id         UUID NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+
+    from_status     user_status NOT NULL,
+    to_status       user_status NOT NULL,
+    reason          TEXT,
+    suspended_until TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS user_status_transitions_user_idx ON user_status_transitions (tenant_id, user_id);
+
+-- Status changes scheduled for later, run by the scheduler of the leader replica.
+CREATE TABLE IF NOT EXISTS scheduled_actions (
+    action_id   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id   UUID NOT NULL REFERENCES tenants (tenant_id),
+    user_id     UUID NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+
+    kind        scheduled_action_kind NOT NULL,
+    status      scheduled_action_status NOT NULL DEFAULT 'PENDING',
+    run_at      TIMESTAMPTZ NOT NULL,
+    reason      TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    executed_at TIMESTAMPTZ,
+    error       TEXT
+);
+CREATE INDEX IF NOT EXISTS scheduled_actions_user_idx ON scheduled_actions (tenant_id, user_id);
+CREATE INDEX IF NOT EXISTS scheduled_actions_due_idx ON scheduled_actions (tenant_id, run_at) WHERE status = 'PENDING';
+
+-- JSON Schema the custom attributes of the users of a tenant must satisfy.
+CREATE TABLE IF NOT EXISTS attribute_schemas (
+    tenant_id  UUID PRIMARY KEY REFERENCES tenants (tenant_id),
+    schema     JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Groups of users. A group can also be a member of other groups; the service
+-- keeps the nesting free of cycles.
+CREATE TABLE IF NOT EXISTS groups (
+    group_id    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id   UUID NOT NULL REFERENCES tenants (tenant_id),
+    name        TEXT NOT NULL,
+    description TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS groups_tenant_idx ON groups (tenant_id, name);
+
+-- Exactly one of user_id and member_group_id is set on each row.
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id        UUID NOT NULL REFERENCES groups (group_id) ON DELETE CASCADE,
+    tenant_id       UUID NOT NULL REFERENCES tenants (tenant_id),
+    user_id         UUID REFERENCES users (user_id) ON DELETE CASCADE,
+    member_group_id UUID REFERENCES groups (group_id) ON DELETE CASCADE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((user_id IS NULL) <> (member_group_id IS NULL))
+);
+CREATE UNIQUE INDEX IF NOT EXISTS group_members_user_idx ON group_members (group_id, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS group_members_group_idx ON group_members (group_id, member_group_id) WHERE member_group_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS group_members_by_user_idx ON group_members (tenant_id, user_id) WHERE user_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS group_members_by_group_idx ON group_members (tenant_id, member_group_id) WHERE member_group_id IS NOT NULL;
+
+-- Email addresses and phone numbers of users. The primary one of each kind is
+-- mirrored in the email and phone columns of users.
+CREATE TABLE IF NOT EXISTS contact_methods (
+    contact_id   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id    UUID NOT NULL REFERENCES tenants (tenant_id),
+    user_id      UUID NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    kind         contact_kind NOT NULL,
+    label        TEXT NOT NULL,
+    value        TEXT NOT NULL,
+    is_primary   BOOLEAN NOT NULL DEFAULT FALSE,
+    pii_key_id   TEXT,
+    pii_data_key BYTEA,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS contact_methods_user_idx ON contact_methods (tenant_id, user_id, kind);
+CREATE UNIQUE INDEX IF NOT EXISTS contact_methods_primary_idx ON contact_methods (user_id, kind) WHERE is_primary;
+
+CREATE TABLE IF NOT EXISTS postal_addresses (
+    address_id   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id    UUID NOT NULL REFERENCES tenants (tenant_id),
+    user_id      UUID NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    label        TEXT NOT NULL,
+    line1        TEXT NOT NULL,
+    line2        TEXT,
+    city         TEXT NOT NULL,
+    region       TEXT,
+    postal_code  TEXT,
+    country      TEXT NOT NULL,
+    is_primary   BOOLEAN NOT NULL DEFAULT FALSE,
+    pii_key_id   TEXT,
+    pii_data_key BYTEA,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS postal_addresses_user_idx ON postal_addresses (tenant_id, user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS postal_addresses_primary_idx ON postal_addresses (user_id) WHERE is_primary;
+
+ALTER TABLE privacy_requests ENABLE ROW LEVEL SECURITY;
+ALTER TABLE privacy_requests FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS privacy_requests_tenant_isolation ON privacy_requests;
+CREATE POLICY privacy_requests_tenant_isolation ON privacy_requests
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE erasure_certificates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE erasure_certificates FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS erasure_certificates_tenant_isolation ON erasure_certificates;
+CREATE POLICY erasure_certificates_tenant_isolation ON erasure_certificates
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE phone_verifications ENABLE ROW LEVEL SECURITY;
+ALTER TABLE phone_verifications FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS phone_verifications_tenant_isolation ON phone_verifications;
+CREATE POLICY phone_verifications_tenant_isolation ON phone_verifications
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE credentials ENABLE ROW LEVEL SECURITY;
+ALTER TABLE credentials FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS credentials_tenant_isolation ON credentials;
+CREATE POLICY credentials_tenant_isolation ON credentials
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE mfa_factors ENABLE ROW LEVEL SECURITY;
+ALTER TABLE mfa_factors FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS mfa_factors_tenant_isolation ON mfa_factors;
+CREATE POLICY mfa_factors_tenant_isolation ON mfa_factors
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE mfa_recovery_codes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE mfa_recovery_codes FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS mfa_recovery_codes_tenant_isolation ON mfa_recovery_codes;
+CREATE POLICY mfa_recovery_codes_tenant_isolation ON mfa_recovery_codes
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE mfa_challenges ENABLE ROW LEVEL SECURITY;
+ALTER TABLE mfa_challenges FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS mfa_challenges_tenant_isolation ON mfa_challenges;
+CREATE POLICY mfa_challenges_tenant_isolation ON mfa_challenges
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE sessions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE sessions FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS sessions_tenant_isolation ON sessions;
+CREATE POLICY sessions_tenant_isolation ON sessions
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE user_status_transitions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE user_status_transitions FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_status_transitions_tenant_isolation ON user_status_transitions;
+CREATE POLICY user_status_transitions_tenant_isolation ON user_status_transitions
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE scheduled_actions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE scheduled_actions FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS scheduled_actions_tenant_isolation ON scheduled_actions;
+CREATE POLICY scheduled_actions_tenant_isolation ON scheduled_actions
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE attribute_schemas ENABLE ROW LEVEL SECURITY;
+ALTER TABLE attribute_schemas FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS attribute_schemas_tenant_isolation ON attribute_schemas;
+CREATE POLICY attribute_schemas_tenant_isolation ON attribute_schemas
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE groups ENABLE ROW LEVEL SECURITY;
+ALTER TABLE groups FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS groups_tenant_isolation ON groups;
+CREATE POLICY groups_tenant_isolation ON groups
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE group_members ENABLE ROW LEVEL SECURITY;
+ALTER TABLE group_members FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS group_members_tenant_isolation ON group_members;
+CREATE POLICY group_members_tenant_isolation ON group_members
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE contact_methods ENABLE ROW LEVEL SECURITY;
+ALTER TABLE contact_methods FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS contact_methods_tenant_isolation ON contact_methods;
+CREATE POLICY contact_methods_tenant_isolation ON contact_methods
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
+ALTER TABLE postal_addresses ENABLE ROW LEVEL SECURITY;
+ALTER TABLE postal_addresses FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS postal_addresses_tenant_isolation ON postal_addresses;
+CREATE POLICY postal_addresses_tenant_isolation ON postal_addresses
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::uuid);
@@ -0,0 +1,16 @@
+-- The transactional outbox of the user events.
+CREATE TABLE IF NOT EXISTS outbox (
+    sequence        BIGSERIAL PRIMARY KEY,
+    event_id        UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    tenant_id       UUID NOT NULL REFERENCES tenants (tenant_id),
+    user_id         UUID NOT NULL,
+    event_type      TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    occurred_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error      TEXT,
+    published_at    TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (sequence) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_published_idx ON outbox (published_at) WHERE published_at IS NOT NULL;
@@ -0,0 +1,9 @@
+-- The login roles granted userapi_app lose their membership with it.
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM pg_roles WHERE rolname = 'userapi_app') THEN
+        DROP OWNED BY userapi_app;
+        DROP ROLE userapi_app;
+    END IF;
+END
+$$;
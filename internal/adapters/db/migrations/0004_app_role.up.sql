@@ -0,0 +1,19 @@
+-- The role the server runs as. Row level security, which isolates the tenants,
+-- does not apply to superusers nor to roles with BYPASSRLS, so the server must
+-- not connect as the owner of the database: deployments create a login role
+-- that is a member of userapi_app, and migrate as the owner.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'userapi_app') THEN
+        CREATE ROLE userapi_app NOLOGIN NOSUPERUSER NOBYPASSRLS;
+    END IF;
+END
+$$;
+GRANT USAGE ON SCHEMA public TO userapi_app;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO userapi_app;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO userapi_app;
+-- The server reads the applied migrations for its readiness probe only.
+REVOKE INSERT, UPDATE, DELETE ON schema_migrations FROM userapi_app;
+-- Tables of later migrations, created by the same owner.
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO userapi_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO userapi_app;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS webhook_deliveries_finished_idx;
+DROP INDEX IF EXISTS webhook_deliveries_user_idx;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,7 @@
+-- The deliveries name the user of their event, so they are erased with the
+-- user, and the finished ones are deleted once old.
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS user_id UUID;
+UPDATE webhook_deliveries SET user_id = (payload ->> 'userId')::uuid WHERE user_id IS NULL;
+ALTER TABLE webhook_deliveries ALTER COLUMN user_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS webhook_deliveries_user_idx ON webhook_deliveries (tenant_id, user_id);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_finished_idx ON webhook_deliveries (tenant_id, created_at) WHERE status <> 'PENDING';
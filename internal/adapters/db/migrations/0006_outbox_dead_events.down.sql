@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS outbox_dead_idx;
+DROP INDEX IF EXISTS outbox_user_pending_idx;
+DROP INDEX IF EXISTS outbox_pending_idx;
+ALTER TABLE outbox DROP COLUMN IF EXISTS dead_at;
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (sequence) WHERE published_at IS NULL;
@@ -2,7 +2,11 @@ package db
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"userapi/app/internal/core/domain"
 
@@ -10,44 +14,72 @@ import (
 )
 
 type MockUserRepository struct {
-	users map[string]domain.User
+	users map[string]map[string]domain.User
+
+	// outbox holds the events of the writes, read by the event dispatcher
+	// from another goroutine.
+	outboxMu sync.Mutex
+	outbox   []domain.Event
+	sequence int64
 }
 
 func NewMockUserRepository() *MockUserRepository {
-	return &MockUserRepository{users: make(map[string]domain.User)}
+	return &MockUserRepository{users: make(map[string]map[string]domain.User)}
 }
 
-func (m *MockUserRepository) Close() {
+func (m *MockUserRepository) Close() error {
+	return nil
 }
 
 func generateUUID() string {
 	return uuid.New().String()
 }
 
-func (m *MockUserRepository) RetrieveUser(ctx context.Context, s string) (domain.User, error) {
+func (m *MockUserRepository) tenantUsers(tenantId string) map[string]domain.User {
+	users, ok := m.users[tenantId]
+	if !ok {
+		users = make(map[string]domain.User)
+		m.users[tenantId] = users
+	}
+	return users
+}
+
+func (m *MockUserRepository) RetrieveUser(ctx context.Context, tenantId string, s string) (domain.User, error) {
 	_ = ctx
-	if user, ok := m.users[s]; ok {
+	if user, ok := m.tenantUsers(tenantId)[s]; ok {
 		return user, nil
 	}
-	return domain.User{}, errors.New("user not found")
+	return domain.User{}, fmt.Errorf("user %w", domain.ErrNotFound)
+}
+
+func (m *MockUserRepository) RetrieveUserByEmail(ctx context.Context, tenantId string, email string) (domain.User, error) {
+	_ = ctx
+	for _, user := range m.tenantUsers(tenantId) {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return domain.User{}, fmt.Errorf("user %w", domain.ErrNotFound)
 }
 
-func (m *MockUserRepository) CreateUser(ctx context.Context, user domain.User) (domain.User, error) {
+func (m *MockUserRepository) CreateUser(ctx context.Context, tenantId string, user domain.User) (domain.User, error) {
 	_ = ctx
 	userId := generateUUID()
 	user.UserID = userId
-	m.users[userId] = user
+	m.tenantUsers(tenantId)[userId] = user
+	m.storeEvents(ctx, tenantId, domain.User{}, user)
 	return user, nil
 }
 
-func (m *MockUserRepository) UpdateUser(ctx context.Context, s string, user domain.User) (domain.User, error) {
+func (m *MockUserRepository) UpdateUser(ctx context.Context, tenantId string, s string, user domain.User) (domain.User, error) {
 	// get the user.
-	_ = ctx
-	currentUser, ok := m.users[s]
+	users := m.tenantUsers(tenantId)
+	currentUser, ok := users[s]
 	if !ok {
 		// Not trying to create a new user.
-		return domain.User{}, errors.New("user not found")
+		return domain.User{}, fmt.Errorf("user %w", domain.ErrNotFound)
 	}
+	before := currentUser
 	if user.FirstName != "" {
 		currentUser.FirstName = user.FirstName
 	}
@@ -55,6 +87,9 @@ func (m *MockUserRepository) UpdateUser(ctx context.Context, s string, user doma
 		currentUser.LastName = user.LastName
 	}
 	if user.Email != "" {
+		if !strings.EqualFold(currentUser.Email, user.Email) {
+			currentUser.EmailVerified = false
+		}
 		currentUser.Email = user.Email
 	}
 	if user.Age != 0 {
@@ -63,21 +98,168 @@ func (m *MockUserRepository) UpdateUser(ctx context.Context, s string, user doma
 	if user.Status != 0 {
 		currentUser.Status = user.Status
 	}
-	m.users[s] = currentUser
+	if user.Attributes != nil {
+		currentUser.Attributes = user.Attributes
+	}
+	users[s] = currentUser
+	m.storeEvents(ctx, tenantId, before, currentUser)
 	return currentUser, nil
 }
 
-func (m *MockUserRepository) DeleteUser(ctx context.Context, s string) error {
+func (m *MockUserRepository) SetEmailVerified(ctx context.Context, tenantId string, s string, verified bool) (domain.User, error) {
 	_ = ctx
-	delete(m.users, s)
+	users := m.tenantUsers(tenantId)
+	user, ok := users[s]
+	if !ok {
+		return domain.User{}, fmt.Errorf("user %w", domain.ErrNotFound)
+	}
+	user.EmailVerified = verified
+	users[s] = user
+	return user, nil
+}
+
+func (m *MockUserRepository) DeleteUser(ctx context.Context, tenantId string, s string) error {
+	users := m.tenantUsers(tenantId)
+	if _, ok := users[s]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(users, s)
+	m.storeEvents(ctx, tenantId, domain.User{UserID: s}, domain.User{})
 	return nil
 }
 
-func (m *MockUserRepository) RetrieveAllUsers(ctx context.Context) ([]domain.User, error) {
+func (m *MockUserRepository) RetrieveAllUsers(ctx context.Context, tenantId string) ([]domain.User, error) {
 	_ = ctx
-	users := make([]domain.User, len(m.users))
-	for _, user := range m.users {
+	users := make([]domain.User, 0, len(m.users[tenantId]))
+	for _, user := range m.users[tenantId] {
 		users = append(users, user)
 	}
 	return users, nil
 }
+
+func (m *MockUserRepository) RetrieveUsersByAttributes(ctx context.Context, tenantId string, attributes map[string]any) ([]domain.User, error) {
+	_ = ctx
+	users := make([]domain.User, 0)
+	for _, user := range m.users[tenantId] {
+		if user.HasAttributes(attributes) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (m *MockUserRepository) RetrieveUsersPage(ctx context.Context, tenantId string, attributes map[string]any, after string, limit int) ([]domain.User, error) {
+	users, _ := m.RetrieveUsersByAttributes(ctx, tenantId, attributes)
+	return usersPage(users, after, limit), nil
+}
+
+// usersPage returns, by user id, at most limit of the users after the given
+// user id.
+func usersPage(users []domain.User, after string, limit int) []domain.User {
+	users = slices.DeleteFunc(users, func(user domain.User) bool { return user.UserID <= after })
+	slices.SortFunc(users, func(a, b domain.User) int { return strings.Compare(a.UserID, b.UserID) })
+	return users[:min(limit, len(users))]
+}
+
+func (m *MockUserRepository) TransitionUserStatus(ctx context.Context, tenantId string, s string, transition domain.StatusTransition) (domain.User, error) {
+	users := m.tenantUsers(tenantId)
+	user, ok := users[s]
+	if !ok {
+		return domain.User{}, fmt.Errorf("user %w", domain.ErrNotFound)
+	}
+	if user.Status != transition.From {
+		return domain.User{}, domain.ErrInvalidTransition
+	}
+	user.Status = transition.To
+	user.StatusReason = transition.Reason
+	user.SuspendedUntil = transition.SuspendedUntil
+	users[s] = user
+	m.storeEvents(ctx, tenantId, user, user)
+	return user, nil
+}
+
+func (m *MockUserRepository) UpdateUserWithTransition(ctx context.Context, tenantId string, s string, user domain.User, transition domain.StatusTransition) (domain.User, error) {
+	before, ok := m.tenantUsers(tenantId)[s]
+	if !ok {
+		return domain.User{}, fmt.Errorf("user %w", domain.ErrNotFound)
+	}
+	if _, err := m.TransitionUserStatus(context.Background(), tenantId, s, transition); err != nil {
+		return domain.User{}, err
+	}
+	updated, err := m.UpdateUser(context.Background(), tenantId, s, user)
+	if err != nil {
+		return domain.User{}, err
+	}
+	m.storeEvents(ctx, tenantId, before, updated)
+	return updated, nil
+}
+
+func (m *MockUserRepository) RetrieveStatusTransitions(ctx context.Context, tenantId string, s string) ([]domain.StatusTransition, error) {
+	return []domain.StatusTransition{}, nil
+}
+
+func (m *MockUserRepository) storeEvents(ctx context.Context, tenantId string, before domain.User, after domain.User) {
+	raise := domain.EventsFromContext(ctx)
+	if raise == nil {
+		return
+	}
+	events := raise(before, after)
+	m.outboxMu.Lock()
+	defer m.outboxMu.Unlock()
+	for _, event := range events {
+		m.sequence++
+		event.EventID = generateUUID()
+		event.Sequence = m.sequence
+		event.TenantID = tenantId
+		event.OccurredAt = time.Now()
+		m.outbox = append(m.outbox, event)
+	}
+}
+
+func (m *MockUserRepository) RetrieveDueEvents(ctx context.Context, now time.Time, limit int) ([]domain.Event, error) {
+	_ = ctx
+	m.outboxMu.Lock()
+	defer m.outboxMu.Unlock()
+	var due []domain.Event
+	waiting := map[string]bool{}
+	for _, event := range m.outbox {
+		if event.NextAttemptAt.After(now) {
+			waiting[event.UserID] = true
+		}
+		if !waiting[event.UserID] && len(due) < limit {
+			due = append(due, event)
+		}
+	}
+	return due, nil
+}
+
+// MarkEventPublished drops the event, the mock keeps no published events.
+func (m *MockUserRepository) MarkEventPublished(ctx context.Context, sequence int64) error {
+	_ = ctx
+	m.outboxMu.Lock()
+	defer m.outboxMu.Unlock()
+	m.outbox = slices.DeleteFunc(m.outbox, func(event domain.Event) bool { return event.Sequence == sequence })
+	return nil
+}
+
+func (m *MockUserRepository) MarkEventFailed(ctx context.Context, sequence int64, message string, next time.Time) error {
+	_, _ = ctx, message
+	m.outboxMu.Lock()
+	defer m.outboxMu.Unlock()
+	for index := range m.outbox {
+		if m.outbox[index].Sequence == sequence {
+			m.outbox[index].Attempts++
+			m.outbox[index].NextAttemptAt = next
+		}
+	}
+	return nil
+}
+
+// MarkEventDead drops the event, the mock keeps no dead events.
+func (m *MockUserRepository) MarkEventDead(ctx context.Context, sequence int64, message string) error {
+	return m.MarkEventPublished(ctx, sequence)
+}
+
+func (m *MockUserRepository) DeleteFinishedEvents(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
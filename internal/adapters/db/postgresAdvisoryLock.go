@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AdvisoryLock is a leader lock on a Postgres session level advisory lock. The
+// lock lives as long as the pooled connection that took it, so a replica that
+// crashes or loses the database gives up the leadership on its own.
+type AdvisoryLock struct {
+	pool *pgxpool.Pool
+	key  int64
+
+	mu   sync.Mutex
+	conn *pgxpool.Conn
+}
+
+// NewAdvisoryLock returns a leader lock on the advisory lock with the given key.
+func (repository *PostgresRepository) NewAdvisoryLock(key int64) *AdvisoryLock {
+	return &AdvisoryLock{pool: repository.pool, key: key}
+}
+
+func (lock *AdvisoryLock) TryLock(ctx context.Context) (bool, error) {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	if lock.conn != nil {
+		if err := lock.conn.Ping(ctx); err == nil {
+			return true, nil
+		}
+		// The session holding the lock is gone, and the lock with it.
+		lock.conn.Release()
+		lock.conn = nil
+	}
+	conn, err := lock.pool.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", lock.key).Scan(&locked); err != nil {
+		conn.Release()
+		return false, err
+	}
+	if !locked {
+		conn.Release()
+		return false, nil
+	}
+	lock.conn = conn
+	return true, nil
+}
+
+func (lock *AdvisoryLock) Unlock(ctx context.Context) error {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	if lock.conn == nil {
+		return nil
+	}
+	defer func() {
+		lock.conn.Release()
+		lock.conn = nil
+	}()
+	_, err := lock.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", lock.key)
+	return err
+}
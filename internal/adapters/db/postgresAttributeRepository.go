@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+)
+
+func (repository *PostgresRepository) RetrieveAttributeSchema(ctx context.Context, tenantId string) (domain.AttributeSchema, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.AttributeSchema{}, err
+	}
+	var record sqlc.AttributeSchema
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.RetrieveAttributeSchema(ctx, tenantUuid)
+		return err
+	})
+	if err != nil {
+		return domain.AttributeSchema{}, notFoundOr(err)
+	}
+	return getAttributeSchemaFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) SaveAttributeSchema(ctx context.Context, tenantId string, schema []byte) (domain.AttributeSchema, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.AttributeSchema{}, err
+	}
+	var record sqlc.AttributeSchema
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.UpsertAttributeSchema(ctx, sqlc.UpsertAttributeSchemaParams{TenantID: tenantUuid, Schema: schema})
+		return err
+	})
+	if err != nil {
+		return domain.AttributeSchema{}, err
+	}
+	return getAttributeSchemaFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) DeleteAttributeSchema(ctx context.Context, tenantId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	var deleted int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		deleted, err = q.DeleteAttributeSchema(ctx, tenantUuid)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func getAttributeSchemaFromRecord(record sqlc.AttributeSchema) domain.AttributeSchema {
+	return domain.AttributeSchema{
+		TenantID:  record.TenantID.String(),
+		Schema:    record.Schema,
+		UpdatedAt: getTimeFromTimestampRecord(record.UpdatedAt),
+	}
+}
@@ -0,0 +1,506 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/adapters/encryption"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// addressField names the street, city and postal code of postal addresses in
+// PII_ENCRYPTED_FIELDS. The region and the country stay in the clear.
+const addressField = "address"
+
+func (repository *PostgresRepository) CreateContactMethod(ctx context.Context, tenantId string, contact domain.ContactMethod) (domain.ContactMethod, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.ContactMethod{}, err
+	}
+	userUuid, err := uuid.Parse(contact.UserID)
+	if err != nil {
+		return domain.ContactMethod{}, domain.ErrNotFound
+	}
+	value := contact.Value
+	keyID, dataKey, err := repository.sealRecordFields(contactField(contact.Kind), &value)
+	if err != nil {
+		return domain.ContactMethod{}, err
+	}
+	var record sqlc.ContactMethod
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		if _, err := lockLiveUser(ctx, q, tenantUuid, userUuid); err != nil {
+			return err
+		}
+		if contact.Primary {
+			err := q.ClearPrimaryContactMethod(ctx, sqlc.ClearPrimaryContactMethodParams{TenantID: tenantUuid, UserID: userUuid, Kind: sqlc.ContactKind(contact.Kind)})
+			if err != nil {
+				return err
+			}
+		}
+		record, err = q.CreateContactMethod(ctx, sqlc.CreateContactMethodParams{
+			TenantID:   tenantUuid,
+			UserID:     userUuid,
+			Kind:       sqlc.ContactKind(contact.Kind),
+			Label:      contact.Label,
+			Value:      value,
+			IsPrimary:  contact.Primary,
+			PiiKeyID:   keyID,
+			PiiDataKey: dataKey,
+		})
+		return err
+	})
+	if err != nil {
+		return domain.ContactMethod{}, err
+	}
+	return repository.openContactMethodRecord(record)
+}
+
+func (repository *PostgresRepository) RetrieveContactMethods(ctx context.Context, tenantId string, userId string, kind domain.ContactKind) ([]domain.ContactMethod, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+	var records []sqlc.ContactMethod
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveContactMethodsByUser(ctx, sqlc.RetrieveContactMethodsByUserParams{TenantID: tenantUuid, UserID: userUuid, Kind: sqlc.ContactKind(kind)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	contacts := make([]domain.ContactMethod, 0, len(records))
+	for _, record := range records {
+		contact, err := repository.openContactMethodRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+	return contacts, nil
+}
+
+func (repository *PostgresRepository) UpdateContactMethod(ctx context.Context, tenantId string, contact domain.ContactMethod) (domain.ContactMethod, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.ContactMethod{}, err
+	}
+	userUuid, err := uuid.Parse(contact.UserID)
+	if err != nil {
+		return domain.ContactMethod{}, domain.ErrNotFound
+	}
+	contactUuid, err := uuid.Parse(contact.ContactID)
+	if err != nil {
+		return domain.ContactMethod{}, domain.ErrNotFound
+	}
+	value := contact.Value
+	keyID, dataKey, err := repository.sealRecordFields(contactField(contact.Kind), &value)
+	if err != nil {
+		return domain.ContactMethod{}, err
+	}
+	var record sqlc.ContactMethod
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.UpdateContactMethod(ctx, sqlc.UpdateContactMethodParams{
+			TenantID:   tenantUuid,
+			UserID:     userUuid,
+			Kind:       sqlc.ContactKind(contact.Kind),
+			ContactID:  contactUuid,
+			Label:      contact.Label,
+			Value:      value,
+			PiiKeyID:   keyID,
+			PiiDataKey: dataKey,
+		})
+		return err
+	})
+	if err != nil {
+		return domain.ContactMethod{}, notFoundOr(err)
+	}
+	return repository.openContactMethodRecord(record)
+}
+
+func (repository *PostgresRepository) SetPrimaryContactMethod(ctx context.Context, tenantId string, userId string, kind domain.ContactKind, contactId string) (domain.ContactMethod, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.ContactMethod{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.ContactMethod{}, domain.ErrNotFound
+	}
+	contactUuid, err := uuid.Parse(contactId)
+	if err != nil {
+		return domain.ContactMethod{}, domain.ErrNotFound
+	}
+	var record sqlc.ContactMethod
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		err := q.ClearPrimaryContactMethod(ctx, sqlc.ClearPrimaryContactMethodParams{TenantID: tenantUuid, UserID: userUuid, Kind: sqlc.ContactKind(kind)})
+		if err != nil {
+			return err
+		}
+		record, err = q.SetPrimaryContactMethod(ctx, sqlc.SetPrimaryContactMethodParams{TenantID: tenantUuid, UserID: userUuid, Kind: sqlc.ContactKind(kind), ContactID: contactUuid})
+		return err
+	})
+	if err != nil {
+		return domain.ContactMethod{}, notFoundOr(err)
+	}
+	return repository.openContactMethodRecord(record)
+}
+
+func (repository *PostgresRepository) DeleteContactMethod(ctx context.Context, tenantId string, userId string, kind domain.ContactKind, contactId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	contactUuid, err := uuid.Parse(contactId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	var deleted int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		deleted, err = q.DeleteContactMethod(ctx, sqlc.DeleteContactMethodParams{TenantID: tenantUuid, UserID: userUuid, Kind: sqlc.ContactKind(kind), ContactID: contactUuid})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) CreatePostalAddress(ctx context.Context, tenantId string, address domain.PostalAddress) (domain.PostalAddress, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.PostalAddress{}, err
+	}
+	userUuid, err := uuid.Parse(address.UserID)
+	if err != nil {
+		return domain.PostalAddress{}, domain.ErrNotFound
+	}
+	line1, line2, city, postalCode := address.Line1, address.Line2, address.City, address.PostalCode
+	keyID, dataKey, err := repository.sealRecordFields(addressField, &line1, &line2, &city, &postalCode)
+	if err != nil {
+		return domain.PostalAddress{}, err
+	}
+	var record sqlc.PostalAddress
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		if _, err := lockLiveUser(ctx, q, tenantUuid, userUuid); err != nil {
+			return err
+		}
+		if address.Primary {
+			if err := q.ClearPrimaryPostalAddress(ctx, sqlc.ClearPrimaryPostalAddressParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+				return err
+			}
+		}
+		record, err = q.CreatePostalAddress(ctx, sqlc.CreatePostalAddressParams{
+			TenantID:   tenantUuid,
+			UserID:     userUuid,
+			Label:      address.Label,
+			Line1:      line1,
+			Line2:      pgtype.Text{String: line2, Valid: line2 != ""},
+			City:       city,
+			Region:     pgtype.Text{String: address.Region, Valid: address.Region != ""},
+			PostalCode: pgtype.Text{String: postalCode, Valid: postalCode != ""},
+			Country:    address.Country,
+			IsPrimary:  address.Primary,
+			PiiKeyID:   keyID,
+			PiiDataKey: dataKey,
+		})
+		return err
+	})
+	if err != nil {
+		return domain.PostalAddress{}, err
+	}
+	return repository.openPostalAddressRecord(record)
+}
+
+func (repository *PostgresRepository) RetrievePostalAddresses(ctx context.Context, tenantId string, userId string) ([]domain.PostalAddress, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+	var records []sqlc.PostalAddress
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrievePostalAddressesByUser(ctx, sqlc.RetrievePostalAddressesByUserParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]domain.PostalAddress, 0, len(records))
+	for _, record := range records {
+		address, err := repository.openPostalAddressRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+func (repository *PostgresRepository) UpdatePostalAddress(ctx context.Context, tenantId string, address domain.PostalAddress) (domain.PostalAddress, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.PostalAddress{}, err
+	}
+	userUuid, err := uuid.Parse(address.UserID)
+	if err != nil {
+		return domain.PostalAddress{}, domain.ErrNotFound
+	}
+	addressUuid, err := uuid.Parse(address.AddressID)
+	if err != nil {
+		return domain.PostalAddress{}, domain.ErrNotFound
+	}
+	line1, line2, city, postalCode := address.Line1, address.Line2, address.City, address.PostalCode
+	keyID, dataKey, err := repository.sealRecordFields(addressField, &line1, &line2, &city, &postalCode)
+	if err != nil {
+		return domain.PostalAddress{}, err
+	}
+	var record sqlc.PostalAddress
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.UpdatePostalAddress(ctx, sqlc.UpdatePostalAddressParams{
+			TenantID:   tenantUuid,
+			UserID:     userUuid,
+			AddressID:  addressUuid,
+			Label:      address.Label,
+			Line1:      line1,
+			Line2:      pgtype.Text{String: line2, Valid: line2 != ""},
+			City:       city,
+			Region:     pgtype.Text{String: address.Region, Valid: address.Region != ""},
+			PostalCode: pgtype.Text{String: postalCode, Valid: postalCode != ""},
+			Country:    address.Country,
+			PiiKeyID:   keyID,
+			PiiDataKey: dataKey,
+		})
+		return err
+	})
+	if err != nil {
+		return domain.PostalAddress{}, notFoundOr(err)
+	}
+	return repository.openPostalAddressRecord(record)
+}
+
+func (repository *PostgresRepository) SetPrimaryPostalAddress(ctx context.Context, tenantId string, userId string, addressId string) (domain.PostalAddress, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.PostalAddress{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.PostalAddress{}, domain.ErrNotFound
+	}
+	addressUuid, err := uuid.Parse(addressId)
+	if err != nil {
+		return domain.PostalAddress{}, domain.ErrNotFound
+	}
+	var record sqlc.PostalAddress
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		if err := q.ClearPrimaryPostalAddress(ctx, sqlc.ClearPrimaryPostalAddressParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		record, err = q.SetPrimaryPostalAddress(ctx, sqlc.SetPrimaryPostalAddressParams{TenantID: tenantUuid, UserID: userUuid, AddressID: addressUuid})
+		return err
+	})
+	if err != nil {
+		return domain.PostalAddress{}, notFoundOr(err)
+	}
+	return repository.openPostalAddressRecord(record)
+}
+
+func (repository *PostgresRepository) DeletePostalAddress(ctx context.Context, tenantId string, userId string, addressId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	addressUuid, err := uuid.Parse(addressId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	var deleted int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		deleted, err = q.DeletePostalAddress(ctx, sqlc.DeletePostalAddressParams{TenantID: tenantUuid, UserID: userUuid, AddressID: addressUuid})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// reencryptContacts rewrites the contact methods and postal addresses of a
+// tenant that are not sealed under the active key.
+func (repository *PostgresRepository) reencryptContacts(ctx context.Context, q *sqlc.Queries, tenantID uuid.UUID) error {
+	contacts, err := q.RetrieveContactMethodsNotOnKey(ctx, sqlc.RetrieveContactMethodsNotOnKeyParams{TenantID: tenantID, ActiveKeyID: repository.pii.ActiveKeyID()})
+	if err != nil {
+		return err
+	}
+	for _, record := range contacts {
+		contact, err := repository.openContactMethodRecord(record)
+		if err != nil {
+			return fmt.Errorf("could not decrypt contact method %s: %w", record.ContactID, err)
+		}
+		keyID, dataKey, err := repository.sealRecordFields(contactField(contact.Kind), &contact.Value)
+		if err != nil {
+			return err
+		}
+		if !keyID.Valid && !record.PiiKeyID.Valid {
+			continue
+		}
+		err = q.UpdateContactMethodPii(ctx, sqlc.UpdateContactMethodPiiParams{
+			TenantID:   tenantID,
+			ContactID:  record.ContactID,
+			Value:      contact.Value,
+			PiiKeyID:   keyID,
+			PiiDataKey: dataKey,
+		})
+		if err != nil {
+			return fmt.Errorf("could not re-encrypt contact method %s: %w", record.ContactID, err)
+		}
+	}
+	addresses, err := q.RetrievePostalAddressesNotOnKey(ctx, sqlc.RetrievePostalAddressesNotOnKeyParams{TenantID: tenantID, ActiveKeyID: repository.pii.ActiveKeyID()})
+	if err != nil {
+		return err
+	}
+	for _, record := range addresses {
+		address, err := repository.openPostalAddressRecord(record)
+		if err != nil {
+			return fmt.Errorf("could not decrypt postal address %s: %w", record.AddressID, err)
+		}
+		keyID, dataKey, err := repository.sealRecordFields(addressField, &address.Line1, &address.Line2, &address.City, &address.PostalCode)
+		if err != nil {
+			return err
+		}
+		if !keyID.Valid && !record.PiiKeyID.Valid {
+			continue
+		}
+		err = q.UpdatePostalAddressPii(ctx, sqlc.UpdatePostalAddressPiiParams{
+			TenantID:   tenantID,
+			AddressID:  record.AddressID,
+			Line1:      address.Line1,
+			Line2:      pgtype.Text{String: address.Line2, Valid: address.Line2 != ""},
+			City:       address.City,
+			PostalCode: pgtype.Text{String: address.PostalCode, Valid: address.PostalCode != ""},
+			PiiKeyID:   keyID,
+			PiiDataKey: dataKey,
+		})
+		if err != nil {
+			return fmt.Errorf("could not re-encrypt postal address %s: %w", record.AddressID, err)
+		}
+	}
+	return nil
+}
+
+func contactField(kind domain.ContactKind) string {
+	if kind == domain.ContactPhone {
+		return phoneField
+	}
+	return emailField
+}
+
+// sealRecordFields encrypts the non-empty values under a new data key when the
+// field is configured for encryption. Otherwise the values are left alone and
+// no key is returned.
+func (repository *PostgresRepository) sealRecordFields(field string, values ...*string) (pgtype.Text, []byte, error) {
+	pii := repository.pii
+	if pii == nil || !pii.Encrypts(field) {
+		return pgtype.Text{}, nil, nil
+	}
+	dataKey, err := pii.NewDataKey()
+	if err != nil {
+		return pgtype.Text{}, nil, err
+	}
+	for _, value := range values {
+		if *value == "" {
+			continue
+		}
+		if *value, err = pii.Seal(dataKey, field, *value); err != nil {
+			return pgtype.Text{}, nil, err
+		}
+	}
+	return pgtype.Text{String: dataKey.KeyID, Valid: true}, dataKey.Wrapped, nil
+}
+
+// openRecordFields decrypts the sealed values with the data key of the record.
+func (repository *PostgresRepository) openRecordFields(field string, keyID pgtype.Text, wrapped []byte, values ...*string) error {
+	if !keyID.Valid {
+		return nil
+	}
+	pii := repository.pii
+	if pii == nil {
+		return errors.New("the record is encrypted but no keyring is configured")
+	}
+	dataKey, err := pii.UnwrapDataKey(keyID.String, wrapped)
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		if !encryption.IsSealed(*value) {
+			continue
+		}
+		if *value, err = pii.Open(dataKey, field, *value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) openContactMethodRecord(record sqlc.ContactMethod) (domain.ContactMethod, error) {
+	contact := domain.ContactMethod{
+		ContactID: record.ContactID.String(),
+		UserID:    record.UserID.String(),
+		Kind:      domain.ContactKind(record.Kind),
+		Label:     record.Label,
+		Value:     record.Value,
+		Primary:   record.IsPrimary,
+		CreatedAt: getTimeFromTimestampRecord(record.CreatedAt),
+	}
+	if err := repository.openRecordFields(contactField(contact.Kind), record.PiiKeyID, record.PiiDataKey, &contact.Value); err != nil {
+		return domain.ContactMethod{}, err
+	}
+	return contact, nil
+}
+
+func (repository *PostgresRepository) openPostalAddressRecord(record sqlc.PostalAddress) (domain.PostalAddress, error) {
+	address := domain.PostalAddress{
+		AddressID:  record.AddressID.String(),
+		UserID:     record.UserID.String(),
+		Label:      record.Label,
+		Line1:      record.Line1,
+		Line2:      getStringFromTextRecord(record.Line2),
+		City:       record.City,
+		Region:     getStringFromTextRecord(record.Region),
+		PostalCode: getStringFromTextRecord(record.PostalCode),
+		Country:    record.Country,
+		Primary:    record.IsPrimary,
+		CreatedAt:  getTimeFromTimestampRecord(record.CreatedAt),
+	}
+	err := repository.openRecordFields(addressField, record.PiiKeyID, record.PiiDataKey, &address.Line1, &address.Line2, &address.City, &address.PostalCode)
+	if err != nil {
+		return domain.PostalAddress{}, err
+	}
+	return address, nil
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (repository *PostgresRepository) SaveCredential(ctx context.Context, tenantId string, credential domain.Credential) (domain.Credential, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Credential{}, err
+	}
+	userUuid, err := uuid.Parse(credential.UserID)
+	if err != nil {
+		return domain.Credential{}, err
+	}
+	var record sqlc.Credential
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.UpsertCredential(ctx, sqlc.UpsertCredentialParams{
+			TenantID:     tenantUuid,
+			UserID:       userUuid,
+			PasswordHash: credential.PasswordHash,
+		})
+		return err
+	})
+	if err != nil {
+		return domain.Credential{}, err
+	}
+	return getCredentialFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) RetrieveCredential(ctx context.Context, tenantId string, userId string) (domain.Credential, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Credential{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.Credential{}, err
+	}
+	var record sqlc.Credential
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.RetrieveCredential(ctx, sqlc.RetrieveCredentialParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return domain.Credential{}, notFoundOr(err)
+	}
+	return getCredentialFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) CountLoginFailure(ctx context.Context, tenantId string, userId string) (domain.Credential, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Credential{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.Credential{}, err
+	}
+	var record sqlc.Credential
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.CountLoginFailure(ctx, sqlc.CountLoginFailureParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return domain.Credential{}, notFoundOr(err)
+	}
+	return getCredentialFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) LockCredential(ctx context.Context, tenantId string, userId string, until time.Time) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return err
+	}
+	return repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		return q.LockCredential(ctx, sqlc.LockCredentialParams{
+			TenantID:    tenantUuid,
+			UserID:      userUuid,
+			LockedUntil: pgtype.Timestamptz{Time: until, Valid: true},
+		})
+	})
+}
+
+func (repository *PostgresRepository) ResetLoginFailures(ctx context.Context, tenantId string, userId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return err
+	}
+	return repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		return q.ResetLoginFailures(ctx, sqlc.ResetLoginFailuresParams{TenantID: tenantUuid, UserID: userUuid})
+	})
+}
+
+func getCredentialFromRecord(record sqlc.Credential) domain.Credential {
+	return domain.Credential{
+		UserID:            record.UserID.String(),
+		PasswordHash:      record.PasswordHash,
+		FailedAttempts:    int(record.FailedAttempts),
+		LockedUntil:       getTimeFromTimestampRecord(record.LockedUntil),
+		PasswordChangedAt: getTimeFromTimestampRecord(record.PasswordChangedAt),
+	}
+}
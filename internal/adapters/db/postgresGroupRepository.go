@@ -0,0 +1,332 @@
+package db
+
+import (
+	"context"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (repository *PostgresRepository) CreateGroup(ctx context.Context, tenantId string, group domain.Group) (domain.Group, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Group{}, err
+	}
+	var record sqlc.Group
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.CreateGroup(ctx, sqlc.CreateGroupParams{
+			TenantID:    tenantUuid,
+			Name:        group.Name,
+			Description: pgtype.Text{String: group.Description, Valid: group.Description != ""},
+		})
+		return err
+	})
+	if err != nil {
+		return domain.Group{}, err
+	}
+	return getGroupFromRecord(record, nil), nil
+}
+
+func (repository *PostgresRepository) RetrieveGroup(ctx context.Context, tenantId string, groupId string) (domain.Group, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Group{}, err
+	}
+	groupUuid, err := uuid.Parse(groupId)
+	if err != nil {
+		return domain.Group{}, domain.ErrNotFound
+	}
+	var record sqlc.Group
+	var members []sqlc.GroupMember
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		if record, err = q.RetrieveGroupById(ctx, sqlc.RetrieveGroupByIdParams{TenantID: tenantUuid, GroupID: groupUuid}); err != nil {
+			return err
+		}
+		members, err = q.RetrieveGroupMembers(ctx, sqlc.RetrieveGroupMembersParams{TenantID: tenantUuid, GroupID: groupUuid})
+		return err
+	})
+	if err != nil {
+		return domain.Group{}, notFoundOr(err)
+	}
+	return getGroupFromRecord(record, members), nil
+}
+
+func (repository *PostgresRepository) RetrieveAllGroups(ctx context.Context, tenantId string) ([]domain.Group, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	var records []sqlc.Group
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveAllGroups(ctx, tenantUuid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]domain.Group, 0, len(records))
+	for _, record := range records {
+		groups = append(groups, getGroupFromRecord(record, nil))
+	}
+	return groups, nil
+}
+
+func (repository *PostgresRepository) UpdateGroup(ctx context.Context, tenantId string, groupId string, group domain.Group) (domain.Group, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Group{}, err
+	}
+	groupUuid, err := uuid.Parse(groupId)
+	if err != nil {
+		return domain.Group{}, domain.ErrNotFound
+	}
+	var record sqlc.Group
+	var members []sqlc.GroupMember
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.UpdateGroupById(ctx, sqlc.UpdateGroupByIdParams{
+			Name:        pgtype.Text{String: group.Name, Valid: group.Name != ""},
+			Description: pgtype.Text{String: group.Description, Valid: group.Description != ""},
+			TenantID:    tenantUuid,
+			GroupID:     groupUuid,
+		})
+		if err != nil {
+			return err
+		}
+		members, err = q.RetrieveGroupMembers(ctx, sqlc.RetrieveGroupMembersParams{TenantID: tenantUuid, GroupID: groupUuid})
+		return err
+	})
+	if err != nil {
+		return domain.Group{}, notFoundOr(err)
+	}
+	return getGroupFromRecord(record, members), nil
+}
+
+func (repository *PostgresRepository) DeleteGroup(ctx context.Context, tenantId string, groupId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	groupUuid, err := uuid.Parse(groupId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	var deleted int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		deleted, err = q.DeleteGroupById(ctx, sqlc.DeleteGroupByIdParams{TenantID: tenantUuid, GroupID: groupUuid})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) AddGroupUser(ctx context.Context, tenantId string, groupId string, userId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	groupUuid, err := uuid.Parse(groupId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	return repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		// the foreign keys are checked without the row level security, so the
+		// group and the user are looked up in the tenant first.
+		if _, err := q.RetrieveGroupById(ctx, sqlc.RetrieveGroupByIdParams{TenantID: tenantUuid, GroupID: groupUuid}); err != nil {
+			return notFoundOr(err)
+		}
+		if _, err := q.RetrieveUserById(ctx, sqlc.RetrieveUserByIdParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return notFoundOr(err)
+		}
+		return q.AddGroupUser(ctx, sqlc.AddGroupUserParams{
+			TenantID: tenantUuid,
+			GroupID:  groupUuid,
+			UserID:   pgtype.UUID{Bytes: userUuid, Valid: true},
+		})
+	})
+}
+
+func (repository *PostgresRepository) RemoveGroupUser(ctx context.Context, tenantId string, groupId string, userId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	groupUuid, err := uuid.Parse(groupId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	var removed int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		removed, err = q.RemoveGroupUser(ctx, sqlc.RemoveGroupUserParams{
+			TenantID: tenantUuid,
+			GroupID:  groupUuid,
+			UserID:   pgtype.UUID{Bytes: userUuid, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) AddSubgroup(ctx context.Context, tenantId string, groupId string, memberGroupId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	groupUuid, err := uuid.Parse(groupId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	memberGroupUuid, err := uuid.Parse(memberGroupId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	return repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		// two concurrent additions could each pass the check below and close a
+		// cycle together, so the nesting changes of a tenant are serialized.
+		if err := q.LockGroupHierarchy(ctx, tenantUuid.String()); err != nil {
+			return err
+		}
+		for _, id := range []uuid.UUID{groupUuid, memberGroupUuid} {
+			if _, err := q.RetrieveGroupById(ctx, sqlc.RetrieveGroupByIdParams{TenantID: tenantUuid, GroupID: id}); err != nil {
+				return notFoundOr(err)
+			}
+		}
+		if groupUuid == memberGroupUuid {
+			return domain.ErrGroupCycle
+		}
+		cycle, err := q.IsGroupDescendant(ctx, sqlc.IsGroupDescendantParams{TenantID: tenantUuid, AncestorID: memberGroupUuid, GroupID: groupUuid})
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return domain.ErrGroupCycle
+		}
+		return q.AddGroupSubgroup(ctx, sqlc.AddGroupSubgroupParams{
+			TenantID:      tenantUuid,
+			GroupID:       groupUuid,
+			MemberGroupID: pgtype.UUID{Bytes: memberGroupUuid, Valid: true},
+		})
+	})
+}
+
+func (repository *PostgresRepository) RemoveSubgroup(ctx context.Context, tenantId string, groupId string, memberGroupId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	groupUuid, err := uuid.Parse(groupId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	memberGroupUuid, err := uuid.Parse(memberGroupId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	var removed int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		removed, err = q.RemoveGroupSubgroup(ctx, sqlc.RemoveGroupSubgroupParams{
+			TenantID:      tenantUuid,
+			GroupID:       groupUuid,
+			MemberGroupID: pgtype.UUID{Bytes: memberGroupUuid, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) RetrieveEffectiveGroupUsers(ctx context.Context, tenantId string, groupId string) ([]domain.User, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	groupUuid, err := uuid.Parse(groupId)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+	var users []sqlc.User
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		if _, err := q.RetrieveGroupById(ctx, sqlc.RetrieveGroupByIdParams{TenantID: tenantUuid, GroupID: groupUuid}); err != nil {
+			return notFoundOr(err)
+		}
+		users, err = q.RetrieveEffectiveGroupUsers(ctx, sqlc.RetrieveEffectiveGroupUsersParams{GroupID: groupUuid, TenantID: tenantUuid})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repository.openUserRecords(users)
+}
+
+func (repository *PostgresRepository) RetrieveUserGroups(ctx context.Context, tenantId string, userId string) ([]domain.GroupMembership, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+	var records []sqlc.RetrieveEffectiveGroupsByUserRow
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveEffectiveGroupsByUser(ctx, sqlc.RetrieveEffectiveGroupsByUserParams{
+			TenantID: tenantUuid,
+			UserID:   pgtype.UUID{Bytes: userUuid, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	memberships := make([]domain.GroupMembership, 0, len(records))
+	for _, record := range records {
+		memberships = append(memberships, domain.GroupMembership{GroupID: record.GroupID.String(), Name: record.Name, Direct: record.Direct})
+	}
+	return memberships, nil
+}
+
+func getGroupFromRecord(record sqlc.Group, members []sqlc.GroupMember) domain.Group {
+	group := domain.Group{
+		GroupID:     record.GroupID.String(),
+		Name:        record.Name,
+		Description: getStringFromTextRecord(record.Description),
+		CreatedAt:   getTimeFromTimestampRecord(record.CreatedAt),
+		UpdatedAt:   getTimeFromTimestampRecord(record.UpdatedAt),
+	}
+	for _, member := range members {
+		groupMember := domain.GroupMember{AddedAt: getTimeFromTimestampRecord(member.CreatedAt)}
+		if member.UserID.Valid {
+			groupMember.MemberID, groupMember.Kind = uuid.UUID(member.UserID.Bytes).String(), domain.MemberUser
+		} else {
+			groupMember.MemberID, groupMember.Kind = uuid.UUID(member.MemberGroupID.Bytes).String(), domain.MemberGroup
+		}
+		group.Members = append(group.Members, groupMember)
+	}
+	return group
+}
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (repository *PostgresRepository) TransitionUserStatus(ctx context.Context, tenantId string, userId string, transition domain.StatusTransition) (domain.User, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	var updated domain.User
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		if _, err := lockLiveUser(ctx, q, tenantUuid, userUuid); err != nil {
+			return err
+		}
+		if updated, err = repository.transitionUserStatus(ctx, q, tenantUuid, userUuid, transition); err != nil {
+			return err
+		}
+		return storeEvents(ctx, q, tenantUuid, updated, updated)
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+	return updated, nil
+}
+
+func (repository *PostgresRepository) UpdateUserWithTransition(ctx context.Context, tenantId string, userId string, user domain.User, transition domain.StatusTransition) (domain.User, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	params, err := getUpdateParamsFromUser(tenantUuid, userUuid, user)
+	if err != nil {
+		return domain.User{}, err
+	}
+	var updated domain.User
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		row, err := lockLiveUser(ctx, q, tenantUuid, userUuid)
+		if err != nil {
+			return err
+		}
+		before, err := repository.openUserRecord(row)
+		if err != nil {
+			return err
+		}
+		if _, err := repository.transitionUserStatus(ctx, q, tenantUuid, userUuid, transition); err != nil {
+			return err
+		}
+		if updated, err = repository.updateUser(ctx, q, params); err != nil {
+			return err
+		}
+		return storeEvents(ctx, q, tenantUuid, before, updated)
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+	return updated, nil
+}
+
+// transitionUserStatus moves the user to its new status and records the
+// transition, with the queries of a transaction.
+func (repository *PostgresRepository) transitionUserStatus(ctx context.Context, q *sqlc.Queries, tenantUuid uuid.UUID, userUuid uuid.UUID, transition domain.StatusTransition) (domain.User, error) {
+	from, to := getStatusRecordFromUserStatus(transition.From), getStatusRecordFromUserStatus(transition.To)
+	if !from.Valid || !to.Valid {
+		return domain.User{}, domain.ErrInvalidTransition
+	}
+	reason := pgtype.Text{String: transition.Reason, Valid: transition.Reason != ""}
+	suspendedUntil := pgtype.Timestamptz{Time: transition.SuspendedUntil, Valid: !transition.SuspendedUntil.IsZero()}
+	row, err := q.TransitionUserStatus(ctx, sqlc.TransitionUserStatusParams{
+		ToStatus:       to.UserStatus,
+		Reason:         reason,
+		SuspendedUntil: suspendedUntil,
+		TenantID:       tenantUuid,
+		UserID:         userUuid,
+		FromStatus:     from.UserStatus,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.User{}, fmt.Errorf("%w: the status of user %s has changed", domain.ErrInvalidTransition, userUuid)
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+	_, err = q.CreateStatusTransition(ctx, sqlc.CreateStatusTransitionParams{
+		TenantID:       tenantUuid,
+		UserID:         userUuid,
+		FromStatus:     from.UserStatus,
+		ToStatus:       to.UserStatus,
+		Reason:         reason,
+		SuspendedUntil: suspendedUntil,
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+	return repository.openUserRecord(row)
+}
+
+func (repository *PostgresRepository) RetrieveStatusTransitions(ctx context.Context, tenantId string, userId string) ([]domain.StatusTransition, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, err
+	}
+	var records []sqlc.UserStatusTransition
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveStatusTransitionsByUser(ctx, sqlc.RetrieveStatusTransitionsByUserParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	transitions := make([]domain.StatusTransition, 0, len(records))
+	for _, record := range records {
+		transitions = append(transitions, getStatusTransitionFromRecord(record))
+	}
+	return transitions, nil
+}
+
+func getStatusTransitionFromRecord(record sqlc.UserStatusTransition) domain.StatusTransition {
+	return domain.StatusTransition{
+		TransitionID:   record.TransitionID.String(),
+		UserID:         record.UserID.String(),
+		From:           getUserStatusFromStatusRecord(sqlc.NullUserStatus{UserStatus: record.FromStatus, Valid: true}),
+		To:             getUserStatusFromStatusRecord(sqlc.NullUserStatus{UserStatus: record.ToStatus, Valid: true}),
+		Reason:         getStringFromTextRecord(record.Reason),
+		SuspendedUntil: getTimeFromTimestampRecord(record.SuspendedUntil),
+		CreatedAt:      getTimeFromTimestampRecord(record.CreatedAt),
+	}
+}
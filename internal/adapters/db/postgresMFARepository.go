@@ -0,0 +1,367 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const totpSecretField = "totp_secret"
+
+func (repository *PostgresRepository) CreateMFAFactor(ctx context.Context, tenantId string, factor domain.MFAFactor) (domain.MFAFactor, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	userUuid, err := uuid.Parse(factor.UserID)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	params := sqlc.CreateMFAFactorParams{
+		TenantID:     tenantUuid,
+		UserID:       userUuid,
+		Kind:         sqlc.MfaFactorKind(factor.Kind),
+		Name:         factor.Name,
+		Status:       sqlc.MfaFactorStatus(factor.Status),
+		CredentialID: factor.WebAuthn.CredentialID,
+		PublicKey:    factor.WebAuthn.PublicKey,
+		SignCount:    int64(factor.WebAuthn.SignCount),
+	}
+	if factor.Kind == domain.MFAFactorTOTP {
+		params.TotpSecret = pgtype.Text{String: factor.TOTPSecret, Valid: true}
+		if pii := repository.pii; pii != nil {
+			// TOTP secrets are sealed whenever a keyring is configured, whatever the PII fields.
+			dataKey, err := pii.NewDataKey()
+			if err != nil {
+				return domain.MFAFactor{}, err
+			}
+			if params.TotpSecret.String, err = pii.Seal(dataKey, totpSecretField, factor.TOTPSecret); err != nil {
+				return domain.MFAFactor{}, err
+			}
+			params.PiiKeyID = pgtype.Text{String: dataKey.KeyID, Valid: true}
+			params.PiiDataKey = dataKey.Wrapped
+		}
+	}
+	var record sqlc.MfaFactor
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.CreateMFAFactor(ctx, params)
+		return err
+	})
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	return repository.openMFAFactorRecord(record)
+}
+
+func (repository *PostgresRepository) RetrieveMFAFactor(ctx context.Context, tenantId string, userId string, factorId string) (domain.MFAFactor, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	factorUuid, err := uuid.Parse(factorId)
+	if err != nil {
+		return domain.MFAFactor{}, domain.ErrNotFound
+	}
+	var record sqlc.MfaFactor
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.RetrieveMFAFactorById(ctx, sqlc.RetrieveMFAFactorByIdParams{TenantID: tenantUuid, UserID: userUuid, FactorID: factorUuid})
+		return err
+	})
+	if err != nil {
+		return domain.MFAFactor{}, notFoundOr(err)
+	}
+	return repository.openMFAFactorRecord(record)
+}
+
+func (repository *PostgresRepository) RetrieveMFAFactorByCredentialID(ctx context.Context, tenantId string, userId string, credentialId []byte) (domain.MFAFactor, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	var record sqlc.MfaFactor
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.RetrieveMFAFactorByCredentialId(ctx, sqlc.RetrieveMFAFactorByCredentialIdParams{TenantID: tenantUuid, UserID: userUuid, CredentialID: credentialId})
+		return err
+	})
+	if err != nil {
+		return domain.MFAFactor{}, notFoundOr(err)
+	}
+	return repository.openMFAFactorRecord(record)
+}
+
+func (repository *PostgresRepository) RetrieveMFAFactors(ctx context.Context, tenantId string, userId string) ([]domain.MFAFactor, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, err
+	}
+	var records []sqlc.MfaFactor
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveMFAFactorsByUser(ctx, sqlc.RetrieveMFAFactorsByUserParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	factors := make([]domain.MFAFactor, len(records))
+	for i, record := range records {
+		if factors[i], err = repository.openMFAFactorRecord(record); err != nil {
+			return nil, err
+		}
+	}
+	return factors, nil
+}
+
+func (repository *PostgresRepository) UseTOTPFactor(ctx context.Context, tenantId string, userId string, factorId string, step int64) (domain.MFAFactor, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	factorUuid, err := uuid.Parse(factorId)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	var record sqlc.MfaFactor
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.UseTOTPFactor(ctx, sqlc.UseTOTPFactorParams{
+			TenantID:     tenantUuid,
+			UserID:       userUuid,
+			FactorID:     factorUuid,
+			LastUsedStep: pgtype.Int8{Int64: step, Valid: true},
+		})
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.MFAFactor{}, domain.ErrInvalidCode
+	}
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	return repository.openMFAFactorRecord(record)
+}
+
+func (repository *PostgresRepository) UseWebAuthnFactor(ctx context.Context, tenantId string, userId string, factorId string, signCount uint32) (domain.MFAFactor, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	factorUuid, err := uuid.Parse(factorId)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	var record sqlc.MfaFactor
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.UseWebAuthnFactor(ctx, sqlc.UseWebAuthnFactorParams{
+			TenantID:  tenantUuid,
+			UserID:    userUuid,
+			FactorID:  factorUuid,
+			SignCount: int64(signCount),
+		})
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.MFAFactor{}, domain.ErrMFAFailed
+	}
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	return repository.openMFAFactorRecord(record)
+}
+
+func (repository *PostgresRepository) DeleteMFAFactor(ctx context.Context, tenantId string, userId string, factorId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return err
+	}
+	factorUuid, err := uuid.Parse(factorId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	var deleted int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		deleted, err = q.DeleteMFAFactor(ctx, sqlc.DeleteMFAFactorParams{TenantID: tenantUuid, UserID: userUuid, FactorID: factorUuid})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) ReplaceRecoveryCodes(ctx context.Context, tenantId string, userId string, codeHashes [][]byte) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return err
+	}
+	return repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		if err := q.DeleteRecoveryCodes(ctx, sqlc.DeleteRecoveryCodesParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		for _, codeHash := range codeHashes {
+			err := q.CreateRecoveryCode(ctx, sqlc.CreateRecoveryCodeParams{TenantID: tenantUuid, UserID: userUuid, CodeHash: codeHash})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (repository *PostgresRepository) UseRecoveryCode(ctx context.Context, tenantId string, userId string, codeHash []byte) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return err
+	}
+	var used int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		used, err = q.UseRecoveryCode(ctx, sqlc.UseRecoveryCodeParams{TenantID: tenantUuid, UserID: userUuid, CodeHash: codeHash})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return domain.ErrInvalidCode
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) CreateMFAChallenge(ctx context.Context, tenantId string, challenge domain.MFAChallenge) (domain.MFAChallenge, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.MFAChallenge{}, err
+	}
+	userUuid, err := uuid.Parse(challenge.UserID)
+	if err != nil {
+		return domain.MFAChallenge{}, err
+	}
+	var record sqlc.MfaChallenge
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.CreateMFAChallenge(ctx, sqlc.CreateMFAChallengeParams{
+			TenantID:  tenantUuid,
+			UserID:    userUuid,
+			Purpose:   challenge.Purpose,
+			Challenge: challenge.Challenge,
+			ExpiresAt: pgtype.Timestamptz{Time: challenge.ExpiresAt, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return domain.MFAChallenge{}, err
+	}
+	return getMFAChallengeFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) ConsumeMFAChallenge(ctx context.Context, tenantId string, userId string, challengeId string, purpose string) (domain.MFAChallenge, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.MFAChallenge{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.MFAChallenge{}, err
+	}
+	challengeUuid, err := uuid.Parse(challengeId)
+	if err != nil {
+		return domain.MFAChallenge{}, domain.ErrNotFound
+	}
+	var record sqlc.MfaChallenge
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.ConsumeMFAChallenge(ctx, sqlc.ConsumeMFAChallengeParams{
+			TenantID:    tenantUuid,
+			UserID:      userUuid,
+			ChallengeID: challengeUuid,
+			Purpose:     purpose,
+		})
+		return err
+	})
+	if err != nil {
+		return domain.MFAChallenge{}, notFoundOr(err)
+	}
+	return getMFAChallengeFromRecord(record), nil
+}
+
+// openMFAFactorRecord maps an mfa_factors row to a domain.MFAFactor, decrypting a sealed TOTP secret.
+func (repository *PostgresRepository) openMFAFactorRecord(record sqlc.MfaFactor) (domain.MFAFactor, error) {
+	factor := domain.MFAFactor{
+		FactorID:     record.FactorID.String(),
+		UserID:       record.UserID.String(),
+		Kind:         domain.MFAFactorKind(record.Kind),
+		Name:         record.Name,
+		Status:       domain.MFAFactorStatus(record.Status),
+		TOTPSecret:   getStringFromTextRecord(record.TotpSecret),
+		LastUsedStep: record.LastUsedStep.Int64,
+		WebAuthn: domain.WebAuthnCredential{
+			CredentialID: record.CredentialID,
+			PublicKey:    record.PublicKey,
+			SignCount:    uint32(record.SignCount), //nolint:gosec // counters are stored from uint32 values.
+		},
+		CreatedAt:  getTimeFromTimestampRecord(record.CreatedAt),
+		LastUsedAt: getTimeFromTimestampRecord(record.LastUsedAt),
+	}
+	if !record.PiiKeyID.Valid {
+		return factor, nil
+	}
+	pii := repository.pii
+	if pii == nil {
+		return domain.MFAFactor{}, errors.New("the factor is encrypted but no keyring is configured")
+	}
+	dataKey, err := pii.UnwrapDataKey(record.PiiKeyID.String, record.PiiDataKey)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	if factor.TOTPSecret, err = pii.Open(dataKey, totpSecretField, factor.TOTPSecret); err != nil {
+		return domain.MFAFactor{}, err
+	}
+	return factor, nil
+}
+
+func getMFAChallengeFromRecord(record sqlc.MfaChallenge) domain.MFAChallenge {
+	return domain.MFAChallenge{
+		ChallengeID: record.ChallengeID.String(),
+		UserID:      record.UserID.String(),
+		Purpose:     record.Purpose,
+		Challenge:   record.Challenge,
+		ExpiresAt:   getTimeFromTimestampRecord(record.ExpiresAt),
+	}
+}
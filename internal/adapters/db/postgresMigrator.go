@@ -0,0 +1,300 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationsLockKey is the Postgres advisory lock key held while migrating, so
+// replicas starting together apply each migration once.
+const migrationsLockKey int64 = 0x757365726d696772
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a versioned schema change, read from the
+// migrations/<version>_<name>.up.sql and .down.sql files.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migrations returns the migrations built into the binary, by version.
+func Migrations() ([]Migration, error) {
+	return loadMigrations(migrationFiles, "migrations")
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+// MigrationStatus is a migration and when it was applied, AppliedAt being
+// zero for the pending ones.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations to the database, recording the applied
+// versions in the schema_migrations table.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// NewMigrator returns a migrator of the database with the migrations built
+// into the binary.
+func (repository *PostgresRepository) NewMigrator() (*Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, fmt.Errorf("could not load the migrations: %w", err)
+	}
+	return &Migrator{pool: repository.pool, migrations: migrations}, nil
+}
+
+// Latest returns the version of the last migration, 0 without migrations.
+func (migrator *Migrator) Latest() int {
+	if len(migrator.migrations) == 0 {
+		return 0
+	}
+	return migrator.migrations[len(migrator.migrations)-1].Version
+}
+
+// Up applies the pending migrations.
+func (migrator *Migrator) Up(ctx context.Context) error {
+	return migrator.Goto(ctx, migrator.Latest())
+}
+
+// Down reverts the last applied migration.
+func (migrator *Migrator) Down(ctx context.Context) error {
+	return migrator.locked(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return errors.New("no migration is applied")
+		}
+		last := slices.Max(applied)
+		for _, migration := range migrator.migrations {
+			if migration.Version == last {
+				return revert(ctx, conn, migration)
+			}
+		}
+		return fmt.Errorf("migration %d is not known to this binary", last)
+	})
+}
+
+// Goto applies or reverts migrations until version is the last applied one.
+// Version 0 reverts every migration.
+func (migrator *Migrator) Goto(ctx context.Context, version int) error {
+	if version != 0 && !slices.ContainsFunc(migrator.migrations, func(m Migration) bool { return m.Version == version }) {
+		return fmt.Errorf("unknown migration %d", version)
+	}
+	return migrator.locked(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, known := range applied {
+			if known > version && !slices.ContainsFunc(migrator.migrations, func(m Migration) bool { return m.Version == known }) {
+				return fmt.Errorf("migration %d is not known to this binary", known)
+			}
+		}
+		for index := len(migrator.migrations) - 1; index >= 0; index-- {
+			migration := migrator.migrations[index]
+			if migration.Version > version && slices.Contains(applied, migration.Version) {
+				if err := revert(ctx, conn, migration); err != nil {
+					return err
+				}
+			}
+		}
+		for _, migration := range migrator.migrations {
+			if migration.Version <= version && !slices.Contains(applied, migration.Version) {
+				if err := apply(ctx, conn, migration); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Status lists the migrations and whether they are applied.
+func (migrator *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	rows, _ := migrator.pool.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	appliedAt := map[int]time.Time{}
+	var version int
+	var at time.Time
+	_, err := pgx.ForEachRow(rows, []any{&version, &at}, func() error {
+		appliedAt[version] = at
+		return nil
+	})
+	// Nothing is applied before the first migration creates schema_migrations.
+	var pgErr *pgconn.PgError
+	if err != nil && !(errors.As(err, &pgErr) && pgErr.Code == undefinedTable) {
+		return nil, fmt.Errorf("could not read the applied migrations: %w", err)
+	}
+	statuses := make([]MigrationStatus, 0, len(migrator.migrations))
+	for _, migration := range migrator.migrations {
+		statuses = append(statuses, MigrationStatus{Version: migration.Version, Name: migration.Name, AppliedAt: appliedAt[migration.Version]})
+	}
+	return statuses, nil
+}
+
+func (migrator *Migrator) HealthCheckName() string {
+	return "migrations"
+}
+
+// CheckHealth fails while migrations are pending. Migrations newer than the
+// binary are fine, they are expected while a deployment rolls out.
+func (migrator *Migrator) CheckHealth(ctx context.Context) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending int
+	for _, status := range statuses {
+		if status.AppliedAt.IsZero() {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d migrations are pending", pending)
+	}
+	return nil
+}
+
+// locked runs migrate on a connection holding the migrations advisory lock,
+// waiting for the other replicas to be done.
+func (migrator *Migrator) locked(ctx context.Context, migrate func(conn *pgxpool.Conn) error) error {
+	conn, err := migrator.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("could not connect to the database: %w", err)
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationsLockKey); err != nil {
+		return fmt.Errorf("could not take the migrations lock: %w", err)
+	}
+	defer func() {
+		// The lock goes with the session if the unlock fails.
+		_, _ = conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationsLockKey)
+	}()
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return migrate(conn)
+}
+
+// undefinedTable is the SQLSTATE of a missing table.
+const undefinedTable = "42P01"
+
+func ensureMigrationsTable(ctx context.Context, conn *pgxpool.Conn) error {
+	_, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`)
+	if err != nil {
+		return fmt.Errorf("could not create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) ([]int, error) {
+	rows, err := conn.Query(ctx, "SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("could not read the applied migrations: %w", err)
+	}
+	versions, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("could not read the applied migrations: %w", err)
+	}
+	return versions, nil
+}
+
+// apply runs a migration in a transaction along with the record of it.
+func apply(ctx context.Context, conn *pgxpool.Conn, migration Migration) error {
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migration.Up); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("could not apply migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+func revert(ctx context.Context, conn *pgxpool.Conn, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("migration %d_%s cannot be reverted, it has no down file", migration.Version, migration.Name)
+	}
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migration.Down); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("could not revert migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
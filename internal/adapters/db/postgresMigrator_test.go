@@ -0,0 +1,415 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migration is built in")
+	}
+	for index, migration := range migrations {
+		if migration.Version != index+1 {
+			t.Errorf("migration %d_%s should be version %d", migration.Version, migration.Name, index+1)
+		}
+		if migration.Down == "" {
+			t.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+	}
+}
+
+// TestGeneratedModels checks that the sqlc models, generated from
+// config/database/schema.sql, have the tables, columns and enum values of the
+// schema the migrations build.
+func TestGeneratedModels(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := newSchemaModel()
+	for _, migration := range migrations {
+		schema.apply(migration.Up)
+	}
+	structs, enums := generatedModels(t, "user/models.go")
+
+	for table, columns := range schema.tables {
+		name := goName(singular(table))
+		fields, ok := structs[name]
+		if !ok {
+			t.Errorf("table %s has no generated model %s, regenerate the sqlc code", table, name)
+			continue
+		}
+		expected := make([]string, 0, len(columns))
+		for _, column := range columns {
+			expected = append(expected, goName(column))
+		}
+		if !slices.Equal(fields, expected) {
+			t.Errorf("model %s has the fields\n%v\nthe migrated table %s has\n%v", name, fields, table, expected)
+		}
+		delete(structs, name)
+	}
+	for name := range structs {
+		if !strings.HasPrefix(name, "Null") {
+			t.Errorf("model %s has no migrated table", name)
+		}
+	}
+	for enum, values := range schema.enums {
+		name := goName(enum)
+		if !slices.Equal(enums[name], values) {
+			t.Errorf("enum %s has the values %v, the migrated type %s has %v", name, enums[name], enum, values)
+		}
+		delete(enums, name)
+	}
+	for name := range enums {
+		t.Errorf("enum %s has no migrated type", name)
+	}
+}
+
+// TestMigrationsUpgradeBaseline checks that migrating a database created by
+// the init.sql of the first versions, testdata/baseline.sql, gives the tables
+// and columns of a fresh one.
+func TestMigrationsUpgradeBaseline(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := os.ReadFile("testdata/baseline.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh, upgraded := newSchemaModel(), newSchemaModel()
+	upgraded.apply(string(baseline))
+	for _, migration := range migrations {
+		fresh.apply(migration.Up)
+		upgraded.apply(migration.Up)
+	}
+	for table, columns := range fresh.tables {
+		expected := slices.Sorted(slices.Values(columns))
+		actual := slices.Sorted(slices.Values(upgraded.tables[table]))
+		if !slices.Equal(actual, expected) {
+			t.Errorf("upgraded table %s has the columns\n%v\na fresh one has\n%v", table, actual, expected)
+		}
+	}
+}
+
+// TestMigratorUpgradesBaseline migrates a database created by the init.sql of
+// the first versions, holding users, in the empty database TEST_DATABASE_URL
+// names. It connects as a superuser, row level security left aside. The
+// tables are created in a schema of their own, dropped afterwards.
+func TestMigratorUpgradesBaseline(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	schema := fmt.Sprintf("migrator_test_%d", time.Now().UnixNano())
+	admin, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if _, err := admin.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	baseline, err := os.ReadFile("testdata/baseline.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Exec(ctx, string(baseline)); err != nil {
+		t.Fatal(err)
+	}
+	_, err = pool.Exec(ctx, `INSERT INTO users (first_name, last_name, email, phone, status) VALUES
+    ('Nimal', 'Perera', 'nimal@example.com', '0771234567', 'ACTIVE'),
+    ('Kamala', 'Silva', 'kamala@example.com', '+94771234568', 'INACTIVE')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator := &Migrator{pool: pool, migrations: migrations}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := pool.Query(ctx, `SELECT u.email, u.phone, u.status::text, t.slug
+    FROM users u JOIN tenants t USING (tenant_id) ORDER BY u.email`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var migrated []string
+	for rows.Next() {
+		var email, phone, status, tenant string
+		if err := rows.Scan(&email, &phone, &status, &tenant); err != nil {
+			t.Fatal(err)
+		}
+		migrated = append(migrated, strings.Join([]string{email, phone, status, tenant}, " "))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"kamala@example.com +94771234568 DEACTIVATED default",
+		"nimal@example.com +94771234567 ACTIVE default",
+	}
+	if !slices.Equal(migrated, expected) {
+		t.Errorf("migrated users are\n%v\nexpected\n%v", migrated, expected)
+	}
+	if _, err := pool.Exec(ctx, "INSERT INTO users (tenant_id, first_name, last_name, email, phone) SELECT tenant_id, 'Ada', 'Lovelace', 'ada@example.com', '+442079460000' FROM tenants WHERE slug = 'default'"); err != nil {
+		t.Errorf("a user with a phone outside Sri Lanka is rejected: %v", err)
+	}
+}
+
+// schemaModel follows the tables and enums through the statements of the
+// migrations. It knows the statements the migrations use, the others are
+// ignored.
+type schemaModel struct {
+	tables map[string][]string
+	enums  map[string][]string
+}
+
+func newSchemaModel() *schemaModel {
+	return &schemaModel{tables: map[string][]string{}, enums: map[string][]string{}}
+}
+
+var (
+	statementStart = regexp.MustCompile(`(?i)\b(CREATE|ALTER|DROP)\s+(TABLE|TYPE)\b`)
+	createTable    = regexp.MustCompile(`(?is)^CREATE TABLE (IF NOT EXISTS )?\s*(\w+)\s*\((.*)\)$`)
+	addColumn      = regexp.MustCompile(`(?is)^ALTER TABLE (\w+) ADD COLUMN (IF NOT EXISTS )?(\w+)`)
+	dropColumn     = regexp.MustCompile(`(?is)^ALTER TABLE (\w+) DROP COLUMN (IF EXISTS )?(\w+)`)
+	renameColumn   = regexp.MustCompile(`(?is)^ALTER TABLE (\w+) RENAME COLUMN (\w+) TO (\w+)`)
+	dropTable      = regexp.MustCompile(`(?is)^DROP TABLE (IF EXISTS )?(\w+)`)
+	createEnum     = regexp.MustCompile(`(?is)^CREATE TYPE (\w+) AS ENUM \((.*)\)$`)
+	addValue       = regexp.MustCompile(`(?is)^ALTER TYPE (\w+) ADD VALUE (IF NOT EXISTS )?'(\w+)'`)
+	renameValue    = regexp.MustCompile(`(?is)^ALTER TYPE (\w+) RENAME VALUE '(\w+)' TO '(\w+)'`)
+	dropType       = regexp.MustCompile(`(?is)^DROP TYPE (IF EXISTS )?(\w+)`)
+	tableElement   = regexp.MustCompile(`(?i)^(CONSTRAINT|CHECK|PRIMARY|UNIQUE|FOREIGN|EXCLUDE)\b`)
+)
+
+func (s *schemaModel) apply(sql string) {
+	for _, statement := range splitStatements(sql) {
+		if body, ok := strings.CutPrefix(statement, "DO $$"); ok {
+			// The conditional statements of DO blocks are applied as if their
+			// condition holds, the ones applying twice are idempotent here.
+			s.apply(strings.TrimSuffix(body, "$$"))
+			continue
+		}
+		location := statementStart.FindStringIndex(statement)
+		if location == nil {
+			continue
+		}
+		s.applyStatement(statement[location[0]:])
+	}
+}
+
+func (s *schemaModel) applyStatement(statement string) {
+	if match := createTable.FindStringSubmatch(statement); match != nil {
+		if _, ok := s.tables[match[2]]; ok {
+			return
+		}
+		var columns []string
+		for _, element := range splitTopLevel(match[3], ',') {
+			if element == "" || tableElement.MatchString(element) {
+				continue
+			}
+			columns = append(columns, strings.Fields(element)[0])
+		}
+		s.tables[match[2]] = columns
+	} else if match := addColumn.FindStringSubmatch(statement); match != nil {
+		if !slices.Contains(s.tables[match[1]], match[3]) {
+			s.tables[match[1]] = append(s.tables[match[1]], match[3])
+		}
+	} else if match := dropColumn.FindStringSubmatch(statement); match != nil {
+		s.tables[match[1]] = slices.DeleteFunc(s.tables[match[1]], func(column string) bool { return column == match[3] })
+	} else if match := renameColumn.FindStringSubmatch(statement); match != nil {
+		if index := slices.Index(s.tables[match[1]], match[2]); index >= 0 {
+			s.tables[match[1]][index] = match[3]
+		}
+	} else if match := dropTable.FindStringSubmatch(statement); match != nil {
+		delete(s.tables, match[2])
+	} else if match := createEnum.FindStringSubmatch(statement); match != nil {
+		if _, ok := s.enums[match[1]]; ok {
+			return
+		}
+		var values []string
+		for _, value := range splitTopLevel(match[2], ',') {
+			values = append(values, strings.Trim(value, "'"))
+		}
+		s.enums[match[1]] = values
+	} else if match := addValue.FindStringSubmatch(statement); match != nil {
+		if !slices.Contains(s.enums[match[1]], match[3]) {
+			s.enums[match[1]] = append(s.enums[match[1]], match[3])
+		}
+	} else if match := renameValue.FindStringSubmatch(statement); match != nil {
+		if index := slices.Index(s.enums[match[1]], match[2]); index >= 0 {
+			s.enums[match[1]][index] = match[3]
+		}
+	} else if match := dropType.FindStringSubmatch(statement); match != nil {
+		delete(s.enums, match[2])
+	}
+}
+
+// splitStatements splits sql on the semicolons outside of comments, quotes and
+// dollar quoted bodies.
+func splitStatements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	for index := 0; index < len(sql); index++ {
+		rest := sql[index:]
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			index += end - 1
+			continue
+		case strings.HasPrefix(rest, "$$"):
+			end := strings.Index(rest[2:], "$$")
+			if end < 0 {
+				end = len(rest) - 4
+			}
+			current.WriteString(rest[:end+4])
+			index += end + 3
+			continue
+		case rest[0] == '\'':
+			end := strings.IndexByte(rest[1:], '\'')
+			if end < 0 {
+				end = len(rest) - 2
+			}
+			current.WriteString(rest[:end+2])
+			index += end + 1
+			continue
+		case rest[0] == ';':
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteByte(rest[0])
+	}
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+// splitTopLevel splits text on the separators outside of parentheses.
+func splitTopLevel(text string, separator byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	for index := 0; index < len(text); index++ {
+		switch text[index] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case separator:
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(text[start:index]))
+				start = index + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(text[start:]))
+}
+
+// generatedModels returns the fields of the structs and the values of the
+// string enums declared in the generated file.
+func generatedModels(t *testing.T, path string) (structs map[string][]string, enums map[string][]string) {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, enums = map[string][]string{}, map[string][]string{}
+	for _, declaration := range file.Decls {
+		general, ok := declaration.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range general.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				if structType, ok := spec.Type.(*ast.StructType); ok {
+					fields := []string{}
+					for _, field := range structType.Fields.List {
+						for _, name := range field.Names {
+							fields = append(fields, name.Name)
+						}
+					}
+					structs[spec.Name.Name] = fields
+				}
+			case *ast.ValueSpec:
+				typeName, ok := spec.Type.(*ast.Ident)
+				if !ok || len(spec.Values) != 1 {
+					continue
+				}
+				if literal, ok := spec.Values[0].(*ast.BasicLit); ok && literal.Kind == token.STRING {
+					value, _ := strconv.Unquote(literal.Value)
+					enums[typeName.Name] = append(enums[typeName.Name], value)
+				}
+			}
+		}
+	}
+	return structs, enums
+}
+
+// goName is the sqlc name of a table or column.
+func goName(name string) string {
+	var builder strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "id" {
+			builder.WriteString("ID")
+			continue
+		}
+		builder.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return builder.String()
+}
+
+// singular is the sqlc singular of the table names used here.
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "sses"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	}
+	return strings.TrimSuffix(name, "s")
+}
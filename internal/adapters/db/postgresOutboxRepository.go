@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// storeEvents stores the events ctx raises for the write from before to after,
+// with the queries of the transaction of the write.
+func storeEvents(ctx context.Context, q *sqlc.Queries, tenantUuid uuid.UUID, before domain.User, after domain.User) error {
+	raise := domain.EventsFromContext(ctx)
+	if raise == nil {
+		return nil
+	}
+	for _, event := range raise(before, after) {
+		userUuid, err := uuid.Parse(event.UserID)
+		if err != nil {
+			return fmt.Errorf("could not store the %s event: %w", event.Type, err)
+		}
+		err = q.CreateOutboxEvent(ctx, sqlc.CreateOutboxEventParams{
+			TenantID:  tenantUuid,
+			UserID:    userUuid,
+			EventType: string(event.Type),
+			Payload:   event.Data,
+		})
+		if err != nil {
+			return fmt.Errorf("could not store the %s event: %w", event.Type, err)
+		}
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) RetrieveDueEvents(ctx context.Context, now time.Time, limit int) ([]domain.Event, error) {
+	records, err := repository.q.RetrieveDueOutboxEvents(ctx, sqlc.RetrieveDueOutboxEventsParams{
+		Now:   pgtype.Timestamptz{Time: now, Valid: true},
+		Limit: int32(limit), //nolint:gosec
+	})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]domain.Event, 0, len(records))
+	for _, record := range records {
+		events = append(events, getEventFromOutboxRecord(record))
+	}
+	return events, nil
+}
+
+func (repository *PostgresRepository) MarkEventPublished(ctx context.Context, sequence int64) error {
+	return repository.q.MarkOutboxEventPublished(ctx, sequence)
+}
+
+func (repository *PostgresRepository) MarkEventFailed(ctx context.Context, sequence int64, message string, next time.Time) error {
+	return repository.q.MarkOutboxEventFailed(ctx, sqlc.MarkOutboxEventFailedParams{
+		LastError:     pgtype.Text{String: message, Valid: message != ""},
+		NextAttemptAt: pgtype.Timestamptz{Time: next, Valid: true},
+		Sequence:      sequence,
+	})
+}
+
+func (repository *PostgresRepository) MarkEventDead(ctx context.Context, sequence int64, message string) error {
+	return repository.q.MarkOutboxEventDead(ctx, sqlc.MarkOutboxEventDeadParams{
+		LastError: pgtype.Text{String: message, Valid: message != ""},
+		Sequence:  sequence,
+	})
+}
+
+func (repository *PostgresRepository) DeleteFinishedEvents(ctx context.Context, before time.Time) (int64, error) {
+	return repository.q.DeleteFinishedOutboxEvents(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+}
+
+func getEventFromOutboxRecord(record sqlc.Outbox) domain.Event {
+	return domain.Event{
+		EventID:       record.EventID.String(),
+		Sequence:      record.Sequence,
+		Type:          domain.EventType(record.EventType),
+		TenantID:      record.TenantID.String(),
+		UserID:        record.UserID.String(),
+		OccurredAt:    getTimeFromTimestampRecord(record.OccurredAt),
+		Data:          record.Payload,
+		Attempts:      int(record.Attempts),
+		NextAttemptAt: getTimeFromTimestampRecord(record.NextAttemptAt),
+	}
+}
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/adapters/encryption"
+	"userapi/app/internal/core/domain"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	emailField = "email"
+	phoneField = "phone"
+)
+
+// sealedFields are the values written to the PII columns of a users row.
+type sealedFields struct {
+	Email      string
+	Phone      pgtype.Text
+	EmailIndex []byte
+	KeyID      pgtype.Text
+	DataKey    []byte
+}
+
+func newFieldEncryptor(keyringPath string, fields string) (*encryption.FieldEncryptor, error) {
+	keyring, err := encryption.LoadKeyring(keyringPath)
+	if err != nil {
+		return nil, err
+	}
+	return encryption.NewFieldEncryptor(keyring, strings.Split(fields, ","))
+}
+
+// sealFields encrypts the configured fields under a new data key. Without a
+// keyring the values are returned unchanged.
+func (repository *PostgresRepository) sealFields(email string, phone pgtype.Text) (sealedFields, error) {
+	fields := sealedFields{Email: email, Phone: phone}
+	pii := repository.pii
+	if pii == nil {
+		return fields, nil
+	}
+	dataKey, err := pii.NewDataKey()
+	if err != nil {
+		return sealedFields{}, err
+	}
+	fields.KeyID = pgtype.Text{String: dataKey.KeyID, Valid: true}
+	fields.DataKey = dataKey.Wrapped
+	if pii.Encrypts(emailField) {
+		fields.EmailIndex = pii.BlindIndex(emailField, email)
+		if fields.Email, err = pii.Seal(dataKey, emailField, email); err != nil {
+			return sealedFields{}, err
+		}
+	}
+	if pii.Encrypts(phoneField) && phone.Valid {
+		if fields.Phone.String, err = pii.Seal(dataKey, phoneField, phone.String); err != nil {
+			return sealedFields{}, err
+		}
+	}
+	return fields, nil
+}
+
+// sealUpdateParams encrypts the fields being updated with the data key of the
+// stored row. Rows written before encryption was enabled keep plaintext values
+// until they are re-encrypted by the pii-rekey command.
+func (repository *PostgresRepository) sealUpdateParams(ctx context.Context, q *sqlc.Queries, params *sqlc.UpdateUserByIdParams) error {
+	pii := repository.pii
+	if pii == nil || (!params.Email.Valid && !params.Phone.Valid) {
+		return nil
+	}
+	if pii.Encrypts(emailField) && params.Email.Valid {
+		params.EmailIndex = pii.BlindIndex(emailField, params.Email.String)
+	}
+	record, err := q.RetrieveUserById(ctx, sqlc.RetrieveUserByIdParams{TenantID: params.TenantID, UserID: params.UserID})
+	if err != nil {
+		return err
+	}
+	if !record.PiiKeyID.Valid {
+		return nil
+	}
+	dataKey, err := pii.UnwrapDataKey(record.PiiKeyID.String, record.PiiDataKey)
+	if err != nil {
+		return err
+	}
+	if pii.Encrypts(emailField) && params.Email.Valid {
+		if params.Email.String, err = pii.Seal(dataKey, emailField, params.Email.String); err != nil {
+			return err
+		}
+	}
+	if pii.Encrypts(phoneField) && params.Phone.Valid && encryption.IsSealed(record.Phone.String) {
+		// Leave an unchanged phone alone, so it stays verified.
+		current, err := pii.Open(dataKey, phoneField, record.Phone.String)
+		if err != nil {
+			return err
+		}
+		if current == params.Phone.String {
+			params.Phone = pgtype.Text{}
+		}
+	}
+	if pii.Encrypts(phoneField) && params.Phone.Valid {
+		if params.Phone.String, err = pii.Seal(dataKey, phoneField, params.Phone.String); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openUserRecord maps a users row to a domain.User, decrypting sealed fields.
+func (repository *PostgresRepository) openUserRecord(record sqlc.User) (domain.User, error) {
+	user := getUserFromUserRecord(record)
+	if !record.PiiKeyID.Valid {
+		return user, nil
+	}
+	pii := repository.pii
+	if pii == nil {
+		return domain.User{}, errors.New("the user is encrypted but no keyring is configured")
+	}
+	dataKey, err := pii.UnwrapDataKey(record.PiiKeyID.String, record.PiiDataKey)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if encryption.IsSealed(user.Email) {
+		if user.Email, err = pii.Open(dataKey, emailField, user.Email); err != nil {
+			return domain.User{}, err
+		}
+	}
+	if encryption.IsSealed(user.Phone) {
+		if user.Phone, err = pii.Open(dataKey, phoneField, user.Phone); err != nil {
+			return domain.User{}, err
+		}
+	}
+	return user, nil
+}
+
+func (repository *PostgresRepository) openUserRecords(records []sqlc.User) ([]domain.User, error) {
+	users := make([]domain.User, len(records))
+	for index, record := range records {
+		user, err := repository.openUserRecord(record)
+		if err != nil {
+			return []domain.User{}, err
+		}
+		users[index] = user
+	}
+	return users, nil
+}
+
+// ReencryptUsers re-encrypts every user that is not sealed under the active key
+// of the keyring, including rows written before encryption was enabled, along
+// with their contact methods and postal addresses. It returns the number of
+// users that were rewritten.
+func (repository *PostgresRepository) ReencryptUsers(ctx context.Context) (int, error) {
+	if repository.pii == nil {
+		return 0, errors.New("no keyring is configured")
+	}
+	tenants, err := repository.q.RetrieveAllTenants(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not retrieve the tenants: %w", err)
+	}
+	count := 0
+	for _, tenant := range tenants {
+		err := repository.inTenant(ctx, tenant.TenantID, func(q *sqlc.Queries) error {
+			records, err := q.RetrieveUsersNotOnKey(ctx, sqlc.RetrieveUsersNotOnKeyParams{
+				TenantID:    tenant.TenantID,
+				ActiveKeyID: repository.pii.ActiveKeyID(),
+			})
+			if err != nil {
+				return err
+			}
+			for _, record := range records {
+				user, err := repository.openUserRecord(record)
+				if err != nil {
+					return fmt.Errorf("could not decrypt user %s: %w", record.UserID, err)
+				}
+				phone := pgtype.Text{String: user.Phone, Valid: user.Phone != ""}
+				fields, err := repository.sealFields(user.Email, phone)
+				if err != nil {
+					return err
+				}
+				err = q.UpdateUserPii(ctx, sqlc.UpdateUserPiiParams{
+					TenantID:   tenant.TenantID,
+					UserID:     record.UserID,
+					Email:      fields.Email,
+					Phone:      fields.Phone,
+					EmailIndex: fields.EmailIndex,
+					PiiKeyID:   fields.KeyID,
+					PiiDataKey: fields.DataKey,
+				})
+				if err != nil {
+					return fmt.Errorf("could not re-encrypt user %s: %w", record.UserID, err)
+				}
+				count++
+			}
+			return repository.reencryptContacts(ctx, q, tenant.TenantID)
+		})
+		if err != nil {
+			return count, fmt.Errorf("could not re-encrypt the users of tenant %s: %w", tenant.Slug, err)
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,288 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// erasedFields lists the user fields overwritten by AnonymizeUserById.
+// The password, the second factors, the sessions, the scheduled actions, the
+// group memberships, the contact methods, the postal addresses, the events,
+// the webhook deliveries and any pending verification code are deleted with
+// them, and the reasons are cleared from the status history. The avatar is
+// deleted by the privacy service beforehand.
+var erasedFields = []string{"firstName", "lastName", "email", "phone", "age", "attributes", "password", "mfaFactors", "sessions", "statusReasons", "scheduledActions", "groupMemberships", "contactMethods", "postalAddresses", "avatar", "outboxEvents", "webhookDeliveries"}
+
+func (repository *PostgresRepository) CreatePrivacyRequest(ctx context.Context, tenantId string, request domain.PrivacyRequest) (domain.PrivacyRequest, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.PrivacyRequest{}, err
+	}
+	userUuid, err := uuid.Parse(request.UserID)
+	if err != nil {
+		return domain.PrivacyRequest{}, err
+	}
+	var record sqlc.PrivacyRequest
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.CreatePrivacyRequest(ctx, sqlc.CreatePrivacyRequestParams{
+			TenantID: tenantUuid,
+			UserID:   userUuid,
+			Kind:     sqlc.PrivacyRequestKind(request.Kind),
+			Status:   sqlc.PrivacyRequestStatus(request.Status),
+			DueAt:    pgtype.Timestamptz{Time: request.DueAt, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return domain.PrivacyRequest{}, err
+	}
+	return getPrivacyRequestFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) CompletePrivacyRequest(ctx context.Context, tenantId string, requestId string, status domain.PrivacyRequestStatus, message string) (domain.PrivacyRequest, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.PrivacyRequest{}, err
+	}
+	requestUuid, err := uuid.Parse(requestId)
+	if err != nil {
+		return domain.PrivacyRequest{}, err
+	}
+	var record sqlc.PrivacyRequest
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.CompletePrivacyRequest(ctx, sqlc.CompletePrivacyRequestParams{
+			Status:    sqlc.PrivacyRequestStatus(status),
+			Error:     pgtype.Text{String: message, Valid: message != ""},
+			TenantID:  tenantUuid,
+			RequestID: requestUuid,
+		})
+		return err
+	})
+	if err != nil {
+		return domain.PrivacyRequest{}, notFoundOr(err)
+	}
+	return getPrivacyRequestFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) RetrievePrivacyRequest(ctx context.Context, tenantId string, requestId string) (domain.PrivacyRequest, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.PrivacyRequest{}, err
+	}
+	requestUuid, err := uuid.Parse(requestId)
+	if err != nil {
+		return domain.PrivacyRequest{}, err
+	}
+	var record sqlc.PrivacyRequest
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.RetrievePrivacyRequestById(ctx, sqlc.RetrievePrivacyRequestByIdParams{TenantID: tenantUuid, RequestID: requestUuid})
+		return err
+	})
+	if err != nil {
+		return domain.PrivacyRequest{}, notFoundOr(err)
+	}
+	return getPrivacyRequestFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) RetrievePrivacyRequests(ctx context.Context, tenantId string) ([]domain.PrivacyRequest, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return []domain.PrivacyRequest{}, err
+	}
+	var records []sqlc.PrivacyRequest
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrievePrivacyRequests(ctx, tenantUuid)
+		return err
+	})
+	if err != nil {
+		return []domain.PrivacyRequest{}, err
+	}
+	return getPrivacyRequestsFromRecords(records), nil
+}
+
+func (repository *PostgresRepository) RetrievePrivacyRequestsByUser(ctx context.Context, tenantId string, userId string) ([]domain.PrivacyRequest, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return []domain.PrivacyRequest{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return []domain.PrivacyRequest{}, err
+	}
+	var records []sqlc.PrivacyRequest
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrievePrivacyRequestsByUser(ctx, sqlc.RetrievePrivacyRequestsByUserParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return []domain.PrivacyRequest{}, err
+	}
+	return getPrivacyRequestsFromRecords(records), nil
+}
+
+func (repository *PostgresRepository) RetrieveErasureCertificatesByUser(ctx context.Context, tenantId string, userId string) ([]domain.ErasureCertificate, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return []domain.ErasureCertificate{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return []domain.ErasureCertificate{}, err
+	}
+	var records []sqlc.ErasureCertificate
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveErasureCertificatesByUser(ctx, sqlc.RetrieveErasureCertificatesByUserParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return []domain.ErasureCertificate{}, err
+	}
+	certificates := make([]domain.ErasureCertificate, len(records))
+	for index, record := range records {
+		certificates[index] = getErasureCertificateFromRecord(record)
+	}
+	return certificates, nil
+}
+
+// EraseUser overwrites the personal data of the user in place, so rows that
+// reference the user stay valid. Dropping the wrapped data key also makes any
+// encrypted copy of the old values unreadable. The events and webhook
+// deliveries of the user are deleted, as they hold copies of the old values.
+// The user is deactivated through the transition table, the transition kept
+// in the status history without the reasons of the earlier ones.
+func (repository *PostgresRepository) EraseUser(ctx context.Context, tenantId string, userId string, requestId string) (domain.ErasureCertificate, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.ErasureCertificate{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.ErasureCertificate{}, err
+	}
+	requestUuid, err := uuid.Parse(requestId)
+	if err != nil {
+		return domain.ErasureCertificate{}, err
+	}
+	var certificate sqlc.ErasureCertificate
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		row, err := lockLiveUser(ctx, q, tenantUuid, userUuid)
+		if err != nil {
+			return err
+		}
+		if err := q.ClearStatusTransitionReasonsByUser(ctx, sqlc.ClearStatusTransitionReasonsByUserParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		if status := getUserStatusFromStatusRecord(row.Status); status != domain.DEACTIVATED {
+			if !status.CanTransitionTo(domain.DEACTIVATED) {
+				return fmt.Errorf("%w: a %s user cannot be deactivated", domain.ErrInvalidTransition, status.String())
+			}
+			transition := domain.StatusTransition{From: status, To: domain.DEACTIVATED, Reason: "erased"}
+			if _, err := repository.transitionUserStatus(ctx, q, tenantUuid, userUuid, transition); err != nil {
+				return err
+			}
+		}
+		if _, err := q.AnonymizeUserById(ctx, sqlc.AnonymizeUserByIdParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return notFoundOr(err)
+		}
+		if err := q.DeleteCredential(ctx, sqlc.DeleteCredentialParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		if err := q.DeletePhoneVerification(ctx, sqlc.DeletePhoneVerificationParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		if err := q.DeleteMFAFactorsByUser(ctx, sqlc.DeleteMFAFactorsByUserParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		if err := q.DeleteRecoveryCodes(ctx, sqlc.DeleteRecoveryCodesParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		if err := q.DeleteMFAChallenges(ctx, sqlc.DeleteMFAChallengesParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		if err := q.DeleteSessionsByUser(ctx, sqlc.DeleteSessionsByUserParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		if err := q.DeleteScheduledActionsByUser(ctx, sqlc.DeleteScheduledActionsByUserParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		if err := q.DeleteContactMethodsByUser(ctx, sqlc.DeleteContactMethodsByUserParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		if err := q.DeletePostalAddressesByUser(ctx, sqlc.DeletePostalAddressesByUserParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		if err := q.DeleteOutboxEventsByUser(ctx, sqlc.DeleteOutboxEventsByUserParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		if err := q.DeleteWebhookDeliveriesByUser(ctx, sqlc.DeleteWebhookDeliveriesByUserParams{TenantID: tenantUuid, UserID: userUuid}); err != nil {
+			return err
+		}
+		if err := q.DeleteGroupMembershipsByUser(ctx, sqlc.DeleteGroupMembershipsByUserParams{TenantID: tenantUuid, UserID: pgtype.UUID{Bytes: userUuid, Valid: true}}); err != nil {
+			return err
+		}
+		certificate, err = q.CreateErasureCertificate(ctx, sqlc.CreateErasureCertificateParams{
+			TenantID:     tenantUuid,
+			RequestID:    requestUuid,
+			UserID:       userUuid,
+			ErasedFields: erasedFields,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.CompletePrivacyRequest(ctx, sqlc.CompletePrivacyRequestParams{
+			Status:    sqlc.PrivacyRequestStatusCOMPLETED,
+			TenantID:  tenantUuid,
+			RequestID: requestUuid,
+		})
+		return err
+	})
+	if err != nil {
+		return domain.ErasureCertificate{}, err
+	}
+	return getErasureCertificateFromRecord(certificate), nil
+}
+
+func getTimeFromTimestampRecord(t pgtype.Timestamptz) time.Time {
+	if t.Valid {
+		return t.Time
+	}
+	return time.Time{}
+}
+
+func getPrivacyRequestFromRecord(record sqlc.PrivacyRequest) domain.PrivacyRequest {
+	return domain.PrivacyRequest{
+		RequestID:   record.RequestID.String(),
+		UserID:      record.UserID.String(),
+		Kind:        domain.PrivacyRequestKind(record.Kind),
+		Status:      domain.PrivacyRequestStatus(record.Status),
+		RequestedAt: getTimeFromTimestampRecord(record.RequestedAt),
+		DueAt:       getTimeFromTimestampRecord(record.DueAt),
+		CompletedAt: getTimeFromTimestampRecord(record.CompletedAt),
+		Error:       getStringFromTextRecord(record.Error),
+	}
+}
+
+func getPrivacyRequestsFromRecords(records []sqlc.PrivacyRequest) []domain.PrivacyRequest {
+	requests := make([]domain.PrivacyRequest, len(records))
+	for index, record := range records {
+		requests[index] = getPrivacyRequestFromRecord(record)
+	}
+	return requests
+}
+
+func getErasureCertificateFromRecord(record sqlc.ErasureCertificate) domain.ErasureCertificate {
+	return domain.ErasureCertificate{
+		CertificateID: record.CertificateID.String(),
+		RequestID:     record.RequestID.String(),
+		UserID:        record.UserID.String(),
+		ErasedFields:  record.ErasedFields,
+		ErasedAt:      getTimeFromTimestampRecord(record.ErasedAt),
+	}
+}
@@ -2,14 +2,21 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/adapters/encryption"
+	"userapi/app/internal/adapters/tracing"
 	"userapi/app/internal/core/domain"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -17,29 +24,55 @@ import (
 type PostgresRepository struct {
 	q    *sqlc.Queries
 	pool *pgxpool.Pool
+	// pii encrypts the configured user fields. It is nil when no keyring is configured.
+	pii *encryption.FieldEncryptor
 }
 
-func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Config locates the database, and the keyring encrypting the PII columns.
+type Config struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Database string
+	SSLMode  string
+	// KeyringFile is the keyring of the EncryptedFields. They are stored in
+	// clear when it is empty.
+	KeyringFile     string
+	EncryptedFields []string
+}
+
+// ConnString returns the URL of the database.
+func (c Config) ConnString() string {
+	connURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:     "/" + c.Database,
+		RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
 	}
-	return fallback
+	return connURL.String()
 }
-func NewPostgresRepository() *PostgresRepository {
-	host := getEnv("PG_HOST", "localhost")
-	port := getEnv("PG_PORT", "5432")
-	user := getEnv("PG_USER", "postgres")
-	password := getEnv("PG_PASSWORD", "yaalalabs")
-	databaseName := getEnv("PG_DATABASE", "userapi")
-	sslmode := getEnv("PG_SSLMODE", "disable")
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", host, port, user, password, databaseName, sslmode)
-	pool, err := pgxpool.New(context.Background(), connStr)
+
+// NewPostgresRepository returns a repository of the database of the config.
+// The pool connects lazily, so errors are about the settings.
+func NewPostgresRepository(config Config) (*PostgresRepository, error) {
+	poolConfig, err := pgxpool.ParseConfig(config.ConnString())
+	if err != nil {
+		return nil, fmt.Errorf("invalid database settings: %w", err)
+	}
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+	var encryptor *encryption.FieldEncryptor
+	if config.KeyringFile != "" {
+		if encryptor, err = newFieldEncryptor(config.KeyringFile, strings.Join(config.EncryptedFields, ",")); err != nil {
+			return nil, fmt.Errorf("could not load the keyring: %w", err)
+		}
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
-		log.Fatal(err)
-		return nil
+		return nil, fmt.Errorf("could not create the connection pool: %w", err)
 	}
-	queries := sqlc.New(pool)
-	return &PostgresRepository{q: queries, pool: pool}
+	return &PostgresRepository{q: sqlc.New(pool), pool: pool, pii: encryptor}, nil
 }
 
 func (repository *PostgresRepository) Close() error {
@@ -47,49 +80,243 @@ func (repository *PostgresRepository) Close() error {
 	return nil
 }
 
-func (repository *PostgresRepository) CreateUser(ctx context.Context, user domain.User) (domain.User, error) {
+// CheckRowSecurity fails when the role the repository connects as bypasses row
+// level security, being a superuser or BYPASSRLS, which leaves the tenants
+// unisolated.
+func (repository *PostgresRepository) CheckRowSecurity(ctx context.Context) error {
+	var role string
+	var bypasses bool
+	err := repository.pool.QueryRow(ctx, "SELECT rolname, rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&role, &bypasses)
+	if err != nil {
+		return fmt.Errorf("could not read the database role: %w", err)
+	}
+	if bypasses {
+		return fmt.Errorf("the database role %s bypasses row level security, connect as a member of userapi_app instead", role)
+	}
+	return nil
+}
+
+// inTenant runs fn in a transaction with app.tenant_id set, so the row level
+// security policies on the users table apply on top of the explicit filters.
+func (repository *PostgresRepository) inTenant(ctx context.Context, tenantID uuid.UUID, fn func(q *sqlc.Queries) error) error {
+	tx, err := repository.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	q := repository.q.WithTx(tx)
+	if err := q.SetTenant(ctx, tenantID.String()); err != nil {
+		return fmt.Errorf("could not set the tenant: %w", err)
+	}
+	if err := fn(q); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (repository *PostgresRepository) CreateUser(ctx context.Context, tenantId string, user domain.User) (domain.User, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.User{}, err
+	}
 	params := parseUserToCreateUserParams(user)
-	newUser, err := repository.q.CreateUser(ctx, params)
+	params.TenantID = tenantUuid
+	if params.Attributes, err = marshalAttributes(user.Attributes); err != nil {
+		return domain.User{}, err
+	}
+	fields, err := repository.sealFields(params.Email, params.Phone)
 	if err != nil {
 		return domain.User{}, err
 	}
-	user.UserID = newUser.UserID.String()
-	user = getUserFromUserRecord(newUser)
-
-	return user, nil
+	params.Email = fields.Email
+	params.Phone = fields.Phone
+	params.EmailIndex = fields.EmailIndex
+	params.PiiKeyID = fields.KeyID
+	params.PiiDataKey = fields.DataKey
+	var created domain.User
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		newUser, err := q.CreateUser(ctx, params)
+		if err != nil {
+			return err
+		}
+		if created, err = repository.openUserRecord(newUser); err != nil {
+			return err
+		}
+		return storeEvents(ctx, q, tenantUuid, domain.User{}, created)
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+	return created, nil
 }
 
-func (repository *PostgresRepository) RetrieveUser(ctx context.Context, userId string) (domain.User, error) {
+func (repository *PostgresRepository) RetrieveUser(ctx context.Context, tenantId string, userId string) (domain.User, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.User{}, err
+	}
 	userUuid, err := uuid.Parse(userId)
 	if err != nil {
 		return domain.User{}, err
 	}
-	user, err := repository.q.RetrieveUserById(ctx, userUuid)
+	var user sqlc.User
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		user, err = q.RetrieveUserById(ctx, sqlc.RetrieveUserByIdParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return domain.User{}, notFoundOr(err)
+	}
+	return repository.openUserRecord(user)
+}
+
+func (repository *PostgresRepository) RetrieveUserByEmail(ctx context.Context, tenantId string, email string) (domain.User, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
 	if err != nil {
 		return domain.User{}, err
 	}
-	returnUser := getUserFromUserRecord(user)
-	return returnUser, nil
+	params := sqlc.RetrieveUserByEmailParams{
+		TenantID: tenantUuid,
+		Email:    pgtype.Text{String: email, Valid: true},
+	}
+	if repository.pii != nil && repository.pii.Encrypts(emailField) {
+		params.EmailIndex = repository.pii.BlindIndex(emailField, email)
+	}
+	var user sqlc.User
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		user, err = q.RetrieveUserByEmail(ctx, params)
+		return err
+	})
+	if err != nil {
+		return domain.User{}, notFoundOr(err)
+	}
+	return repository.openUserRecord(user)
 }
 
-func (repository *PostgresRepository) RetrieveAllUsers(ctx context.Context) ([]domain.User, error) {
-	allUsers, err := repository.q.RetrieveAllUsers(ctx)
+func (repository *PostgresRepository) RetrieveAllUsers(ctx context.Context, tenantId string) ([]domain.User, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
 	if err != nil {
 		return []domain.User{}, err
 	}
-	users := make([]domain.User, len(allUsers))
-	for index, user := range allUsers {
-		users[index] = getUserFromUserRecord(user)
+	var allUsers []sqlc.User
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		allUsers, err = q.RetrieveAllUsers(ctx, tenantUuid)
+		return err
+	})
+	if err != nil {
+		return []domain.User{}, err
+	}
+	return repository.openUserRecords(allUsers)
+}
+
+func (repository *PostgresRepository) RetrieveUsersPage(ctx context.Context, tenantId string, attributes map[string]any, after string, limit int) ([]domain.User, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return []domain.User{}, err
+	}
+	// The nil uuid sorts before every user id.
+	afterUuid := uuid.Nil
+	if after != "" {
+		if afterUuid, err = uuid.Parse(after); err != nil {
+			return []domain.User{}, err
+		}
+	}
+	filter, err := marshalAttributes(attributes)
+	if err != nil {
+		return []domain.User{}, err
+	}
+	var users []sqlc.User
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		users, err = q.RetrieveUsersPage(ctx, sqlc.RetrieveUsersPageParams{
+			TenantID:   tenantUuid,
+			Attributes: filter,
+			UserID:     afterUuid,
+			Limit:      int32(limit),
+		})
+		return err
+	})
+	if err != nil {
+		return []domain.User{}, err
+	}
+	return repository.openUserRecords(users)
+}
+
+func (repository *PostgresRepository) RetrieveUsersByAttributes(ctx context.Context, tenantId string, attributes map[string]any) ([]domain.User, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return []domain.User{}, err
+	}
+	filter, err := marshalAttributes(attributes)
+	if err != nil {
+		return []domain.User{}, err
+	}
+	var users []sqlc.User
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		users, err = q.RetrieveUsersByAttributes(ctx, sqlc.RetrieveUsersByAttributesParams{TenantID: tenantUuid, Attributes: filter})
+		return err
+	})
+	if err != nil {
+		return []domain.User{}, err
 	}
-	return users, nil
+	return repository.openUserRecords(users)
 }
 
-func (repository *PostgresRepository) UpdateUser(ctx context.Context, userId string, user domain.User) (domain.User, error) {
+func (repository *PostgresRepository) UpdateUser(ctx context.Context, tenantId string, userId string, user domain.User) (domain.User, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.User{}, err
+	}
 	userUuid, err := uuid.Parse(userId)
 	if err != nil {
 		return domain.User{}, err
 	}
+	params, err := getUpdateParamsFromUser(tenantUuid, userUuid, user)
+	if err != nil {
+		return domain.User{}, err
+	}
+	var updated domain.User
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		// The events of the update need the user as it was, locked so that
+		// no other update or the erasure comes in between.
+		row, err := lockLiveUser(ctx, q, tenantUuid, userUuid)
+		if err != nil {
+			return err
+		}
+		var before domain.User
+		if domain.EventsFromContext(ctx) != nil {
+			if before, err = repository.openUserRecord(row); err != nil {
+				return err
+			}
+		}
+		if updated, err = repository.updateUser(ctx, q, params); err != nil {
+			return err
+		}
+		return storeEvents(ctx, q, tenantUuid, before, updated)
+	})
+	if err != nil {
+		return domain.User{}, notFoundOr(err)
+	}
+	return updated, nil
+}
+
+// updateUser writes the fields set in params, with the queries of a
+// transaction.
+func (repository *PostgresRepository) updateUser(ctx context.Context, q *sqlc.Queries, params sqlc.UpdateUserByIdParams) (domain.User, error) {
+	if err := repository.sealUpdateParams(ctx, q, &params); err != nil {
+		return domain.User{}, err
+	}
+	row, err := q.UpdateUserById(ctx, params)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return repository.openUserRecord(row)
+}
+
+// getUpdateParamsFromUser returns the parameters updating the fields of the
+// user that are set, leaving the others as they are.
+func getUpdateParamsFromUser(tenantUuid uuid.UUID, userUuid uuid.UUID, user domain.User) (sqlc.UpdateUserByIdParams, error) {
 	params := sqlc.UpdateUserByIdParams{}
+	params.TenantID = tenantUuid
 	params.UserID = userUuid
 	if user.FirstName != "" {
 		params.FirstName = pgtype.Text{String: user.FirstName, Valid: true}
@@ -119,49 +346,84 @@ func (repository *PostgresRepository) UpdateUser(ctx context.Context, userId str
 	} else {
 		params.Age = pgtype.Int4{Int32: 0, Valid: false}
 	}
-	switch user.Status {
-	case domain.ACTIVE:
-		params.Status = sqlc.NullUserStatus{
-			UserStatus: sqlc.UserStatusACTIVE,
-			Valid:      true,
-		}
-	case domain.INACTIVE:
-		params.Status = sqlc.NullUserStatus{
-			UserStatus: sqlc.UserStatusINACTIVE,
-			Valid:      true,
-		}
-	default:
-		params.Status = sqlc.NullUserStatus{
-			UserStatus: sqlc.UserStatusACTIVE,
-			Valid:      false,
+	params.Status = getStatusRecordFromUserStatus(user.Status)
+	if user.Attributes != nil {
+		var err error
+		if params.Attributes, err = marshalAttributes(user.Attributes); err != nil {
+			return sqlc.UpdateUserByIdParams{}, err
 		}
 	}
-	row, err := repository.q.UpdateUserById(ctx, params)
+	return params, nil
+}
+
+func (repository *PostgresRepository) DeleteUser(ctx context.Context, tenantId string, userId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
 	if err != nil {
-		return domain.User{}, err
+		return err
 	}
-
-	returnUser := domain.User{
-		UserID:    row.UserID.String(),
-		FirstName: row.FirstName,
-		LastName:  row.LastName,
-		Email:     row.Email,
-		Age:       int(row.Age.Int32),
-		Status:    getUserStatusFromStatusRecord(row.Status),
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return err
 	}
-	return returnUser, nil
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		deleted, err := q.DeleteUserById(ctx, sqlc.DeleteUserByIdParams{TenantID: tenantUuid, UserID: userUuid})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return domain.ErrNotFound
+		}
+		return storeEvents(ctx, q, tenantUuid, domain.User{UserID: userId}, domain.User{})
+	})
+	if err != nil {
+		return err
+	}
+	return nil
 }
 
-func (repository *PostgresRepository) DeleteUser(ctx context.Context, userId string) error {
+func (repository *PostgresRepository) SetEmailVerified(ctx context.Context, tenantId string, userId string, verified bool) (domain.User, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.User{}, err
+	}
 	userUuid, err := uuid.Parse(userId)
 	if err != nil {
+		return domain.User{}, err
+	}
+	var row sqlc.User
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		if _, err := lockLiveUser(ctx, q, tenantUuid, userUuid); err != nil {
+			return err
+		}
+		row, err = q.SetEmailVerified(ctx, sqlc.SetEmailVerifiedParams{TenantID: tenantUuid, UserID: userUuid, EmailVerified: verified})
 		return err
+	})
+	if err != nil {
+		return domain.User{}, notFoundOr(err)
 	}
-	err = repository.q.DeleteUserById(ctx, userUuid)
+	return repository.openUserRecord(row)
+}
+
+// lockLiveUser locks the row of the user until the end of the transaction.
+// It fails with domain.ErrAlreadyErased for an erased user, who must not get
+// personal data or a status again.
+func lockLiveUser(ctx context.Context, q *sqlc.Queries, tenantUuid uuid.UUID, userUuid uuid.UUID) (sqlc.User, error) {
+	row, err := q.LockUserById(ctx, sqlc.LockUserByIdParams{TenantID: tenantUuid, UserID: userUuid})
 	if err != nil {
-		return err
+		return sqlc.User{}, notFoundOr(err)
 	}
-	return nil
+	if row.ErasedAt.Valid {
+		return sqlc.User{}, domain.ErrAlreadyErased
+	}
+	return row, nil
+}
+
+// notFoundOr translates a missing row into domain.ErrNotFound.
+func notFoundOr(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrNotFound
+	}
+	return err
 }
 
 func getStringFromTextRecord(s pgtype.Text) string {
@@ -171,15 +433,30 @@ func getStringFromTextRecord(s pgtype.Text) string {
 	return ""
 }
 
+// userStatusRecords maps the domain statuses to the user_status enum.
+var userStatusRecords = map[domain.UserStatus]sqlc.UserStatus{
+	domain.ACTIVE:      sqlc.UserStatusACTIVE,
+	domain.DEACTIVATED: sqlc.UserStatusDEACTIVATED,
+	domain.PENDING:     sqlc.UserStatusPENDING,
+	domain.SUSPENDED:   sqlc.UserStatusSUSPENDED,
+	domain.LOCKED:      sqlc.UserStatusLOCKED,
+}
+
+// getStatusRecordFromUserStatus returns an invalid record, leaving the column
+// unchanged, for the zero status.
+func getStatusRecordFromUserStatus(s domain.UserStatus) sqlc.NullUserStatus {
+	record, ok := userStatusRecords[s]
+	return sqlc.NullUserStatus{UserStatus: record, Valid: ok}
+}
+
 func getUserStatusFromStatusRecord(s sqlc.NullUserStatus) domain.UserStatus {
 	if !s.Valid {
 		return domain.UserStatus(0)
 	}
-	if s.UserStatus == sqlc.UserStatusACTIVE {
-		return domain.ACTIVE
-	}
-	if s.UserStatus == sqlc.UserStatusINACTIVE {
-		return domain.INACTIVE
+	for status, record := range userStatusRecords {
+		if record == s.UserStatus {
+			return status
+		}
 	}
 	return domain.ACTIVE
 }
@@ -193,9 +470,27 @@ func getUserFromUserRecord(userRecord sqlc.User) domain.User {
 	user.Status = getUserStatusFromStatusRecord(userRecord.Status)
 	user.UserID = userRecord.UserID.String()
 	user.Age = int(userRecord.Age.Int32)
+	user.EmailVerified = userRecord.EmailVerified
+	user.PhoneVerifiedAt = getTimeFromTimestampRecord(userRecord.PhoneVerifiedAt)
+	user.StatusReason = getStringFromTextRecord(userRecord.StatusReason)
+	user.SuspendedUntil = getTimeFromTimestampRecord(userRecord.SuspendedUntil)
+	// the column is JSONB, so it always holds valid JSON.
+	_ = json.Unmarshal(userRecord.Attributes, &user.Attributes)
 	return user
 }
 
+// marshalAttributes encodes the attributes of a user as a JSON object, empty when there are none.
+func marshalAttributes(attributes map[string]any) ([]byte, error) {
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	blob, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode the attributes: %w", err)
+	}
+	return blob, nil
+}
+
 func parseUserToCreateUserParams(user domain.User) sqlc.CreateUserParams {
 	params := sqlc.CreateUserParams{}
 	if user.FirstName != "" {
@@ -223,16 +518,26 @@ func parseUserToCreateUserParams(user domain.User) sqlc.CreateUserParams {
 	} else {
 		params.Age = pgtype.Int4{Int32: 0, Valid: false}
 	}
-	if user.Status == domain.INACTIVE {
-		params.Status = sqlc.NullUserStatus{
-			UserStatus: sqlc.UserStatusINACTIVE,
-			Valid:      true,
-		}
-	} else {
-		params.Status = sqlc.NullUserStatus{
-			UserStatus: sqlc.UserStatusACTIVE,
-			Valid:      true,
-		}
+	params.Status = getStatusRecordFromUserStatus(user.Status)
+	if !params.Status.Valid {
+		params.Status = sqlc.NullUserStatus{UserStatus: sqlc.UserStatusACTIVE, Valid: true}
 	}
 	return params
 }
+
+func (repository *PostgresRepository) HealthCheckName() string {
+	return "postgres"
+}
+
+// CheckHealth pings the database with a connection of the pool.
+func (repository *PostgresRepository) CheckHealth(ctx context.Context) error {
+	if err := repository.pool.Ping(ctx); err != nil {
+		return fmt.Errorf("could not reach the database: %w", err)
+	}
+	return nil
+}
+
+// PoolStat returns a snapshot of the statistics of the connection pool.
+func (repository *PostgresRepository) PoolStat() *pgxpool.Stat {
+	return repository.pool.Stat()
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (repository *PostgresRepository) CreateScheduledAction(ctx context.Context, tenantId string, action domain.ScheduledAction) (domain.ScheduledAction, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.ScheduledAction{}, err
+	}
+	userUuid, err := uuid.Parse(action.UserID)
+	if err != nil {
+		return domain.ScheduledAction{}, err
+	}
+	var record sqlc.ScheduledAction
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.CreateScheduledAction(ctx, sqlc.CreateScheduledActionParams{
+			TenantID: tenantUuid,
+			UserID:   userUuid,
+			Kind:     sqlc.ScheduledActionKind(action.Kind),
+			RunAt:    pgtype.Timestamptz{Time: action.RunAt, Valid: true},
+			Reason:   pgtype.Text{String: action.Reason, Valid: action.Reason != ""},
+		})
+		return err
+	})
+	if err != nil {
+		return domain.ScheduledAction{}, err
+	}
+	return getScheduledActionFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) RetrieveScheduledActions(ctx context.Context, tenantId string, userId string) ([]domain.ScheduledAction, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, err
+	}
+	var records []sqlc.ScheduledAction
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveScheduledActionsByUser(ctx, sqlc.RetrieveScheduledActionsByUserParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]domain.ScheduledAction, 0, len(records))
+	for _, record := range records {
+		actions = append(actions, getScheduledActionFromRecord(record))
+	}
+	return actions, nil
+}
+
+func (repository *PostgresRepository) CancelScheduledAction(ctx context.Context, tenantId string, userId string, actionId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return err
+	}
+	actionUuid, err := uuid.Parse(actionId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	var cancelled int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		cancelled, err = q.CancelScheduledAction(ctx, sqlc.CancelScheduledActionParams{TenantID: tenantUuid, UserID: userUuid, ActionID: actionUuid})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if cancelled == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) RetrieveDueScheduledActions(ctx context.Context, tenantId string, now time.Time, limit int) ([]domain.ScheduledAction, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	var records []sqlc.ScheduledAction
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveDueScheduledActions(ctx, sqlc.RetrieveDueScheduledActionsParams{
+			TenantID: tenantUuid,
+			RunAt:    pgtype.Timestamptz{Time: now, Valid: true},
+			Limit:    int32(limit),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]domain.ScheduledAction, 0, len(records))
+	for _, record := range records {
+		actions = append(actions, getScheduledActionFromRecord(record))
+	}
+	return actions, nil
+}
+
+func (repository *PostgresRepository) CompleteScheduledAction(ctx context.Context, tenantId string, actionId string, status domain.ScheduledActionStatus, message string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	actionUuid, err := uuid.Parse(actionId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	var completed int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		completed, err = q.CompleteScheduledAction(ctx, sqlc.CompleteScheduledActionParams{
+			TenantID: tenantUuid,
+			ActionID: actionUuid,
+			Status:   sqlc.ScheduledActionStatus(status),
+			Error:    pgtype.Text{String: message, Valid: message != ""},
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if completed == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) RetrieveExpiredSuspensions(ctx context.Context, tenantId string, now time.Time) ([]string, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	var userUuids []uuid.UUID
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		userUuids, err = q.RetrieveExpiredSuspensions(ctx, sqlc.RetrieveExpiredSuspensionsParams{
+			TenantID:       tenantUuid,
+			SuspendedUntil: pgtype.Timestamptz{Time: now, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	userIds := make([]string, 0, len(userUuids))
+	for _, userUuid := range userUuids {
+		userIds = append(userIds, userUuid.String())
+	}
+	return userIds, nil
+}
+
+func getScheduledActionFromRecord(record sqlc.ScheduledAction) domain.ScheduledAction {
+	return domain.ScheduledAction{
+		ActionID:   record.ActionID.String(),
+		UserID:     record.UserID.String(),
+		Kind:       domain.ScheduledActionKind(record.Kind),
+		Status:     domain.ScheduledActionStatus(record.Status),
+		RunAt:      getTimeFromTimestampRecord(record.RunAt),
+		Reason:     getStringFromTextRecord(record.Reason),
+		CreatedAt:  getTimeFromTimestampRecord(record.CreatedAt),
+		ExecutedAt: getTimeFromTimestampRecord(record.ExecutedAt),
+		Error:      getStringFromTextRecord(record.Error),
+	}
+}
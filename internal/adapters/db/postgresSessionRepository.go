@@ -0,0 +1,193 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (repository *PostgresRepository) CreateSession(ctx context.Context, tenantId string, session domain.Session) (domain.Session, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	userUuid, err := uuid.Parse(session.UserID)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	var record sqlc.Session
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.CreateSession(ctx, sqlc.CreateSessionParams{
+			TenantID:         tenantUuid,
+			UserID:           userUuid,
+			RefreshTokenHash: session.RefreshTokenHash,
+			UserAgent:        pgtype.Text{String: session.UserAgent, Valid: session.UserAgent != ""},
+			IpAddress:        pgtype.Text{String: session.IPAddress, Valid: session.IPAddress != ""},
+			ExpiresAt:        pgtype.Timestamptz{Time: session.RefreshExpiresAt, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return getSessionFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) RetrieveSession(ctx context.Context, tenantId string, sessionId string) (domain.Session, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	sessionUuid, err := uuid.Parse(sessionId)
+	if err != nil {
+		return domain.Session{}, domain.ErrNotFound
+	}
+	var record sqlc.Session
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.RetrieveSessionById(ctx, sqlc.RetrieveSessionByIdParams{TenantID: tenantUuid, SessionID: sessionUuid})
+		return err
+	})
+	if err != nil {
+		return domain.Session{}, notFoundOr(err)
+	}
+	return getSessionFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) RetrieveSessions(ctx context.Context, tenantId string, userId string) ([]domain.Session, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, err
+	}
+	var records []sqlc.Session
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveActiveSessionsByUser(ctx, sqlc.RetrieveActiveSessionsByUserParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]domain.Session, 0, len(records))
+	for _, record := range records {
+		sessions = append(sessions, getSessionFromRecord(record))
+	}
+	return sessions, nil
+}
+
+func (repository *PostgresRepository) TouchSession(ctx context.Context, tenantId string, sessionId string, ipAddress string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	sessionUuid, err := uuid.Parse(sessionId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	return repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		return q.TouchSession(ctx, sqlc.TouchSessionParams{
+			IpAddress: pgtype.Text{String: ipAddress, Valid: ipAddress != ""},
+			TenantID:  tenantUuid,
+			SessionID: sessionUuid,
+		})
+	})
+}
+
+func (repository *PostgresRepository) RotateRefreshToken(ctx context.Context, tenantId string, sessionId string, oldHash []byte, newHash []byte, expiresAt time.Time) (domain.Session, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	sessionUuid, err := uuid.Parse(sessionId)
+	if err != nil {
+		return domain.Session{}, domain.ErrInvalidToken
+	}
+	var record sqlc.Session
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.RotateSessionRefreshToken(ctx, sqlc.RotateSessionRefreshTokenParams{
+			NewHash:   newHash,
+			ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+			TenantID:  tenantUuid,
+			SessionID: sessionUuid,
+			OldHash:   oldHash,
+		})
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Session{}, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return getSessionFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) RevokeSession(ctx context.Context, tenantId string, userId string, sessionId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return err
+	}
+	sessionUuid, err := uuid.Parse(sessionId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	var revoked int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		revoked, err = q.RevokeSession(ctx, sqlc.RevokeSessionParams{TenantID: tenantUuid, UserID: userUuid, SessionID: sessionUuid})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) RevokeSessions(ctx context.Context, tenantId string, userId string) (int, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return 0, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return 0, err
+	}
+	var revoked int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		revoked, err = q.RevokeSessionsByUser(ctx, sqlc.RevokeSessionsByUserParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(revoked), nil
+}
+
+func getSessionFromRecord(record sqlc.Session) domain.Session {
+	return domain.Session{
+		SessionID:        record.SessionID.String(),
+		UserID:           record.UserID.String(),
+		RefreshTokenHash: record.RefreshTokenHash,
+		RefreshExpiresAt: getTimeFromTimestampRecord(record.ExpiresAt),
+		UserAgent:        getStringFromTextRecord(record.UserAgent),
+		IPAddress:        getStringFromTextRecord(record.IpAddress),
+		CreatedAt:        getTimeFromTimestampRecord(record.CreatedAt),
+		LastSeenAt:       getTimeFromTimestampRecord(record.LastSeenAt),
+		RevokedAt:        getTimeFromTimestampRecord(record.RevokedAt),
+	}
+}
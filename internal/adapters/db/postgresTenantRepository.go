@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+)
+
+func (repository *PostgresRepository) CreateTenant(ctx context.Context, tenant domain.Tenant) (domain.Tenant, error) {
+	record, err := repository.q.CreateTenant(ctx, sqlc.CreateTenantParams{Name: tenant.Name, Slug: tenant.Slug})
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	return getTenantFromTenantRecord(record), nil
+}
+
+func (repository *PostgresRepository) RetrieveTenant(ctx context.Context, tenantId string) (domain.Tenant, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	record, err := repository.q.RetrieveTenantById(ctx, tenantUuid)
+	if err != nil {
+		return domain.Tenant{}, notFoundOr(err)
+	}
+	return getTenantFromTenantRecord(record), nil
+}
+
+func (repository *PostgresRepository) RetrieveTenantBySlug(ctx context.Context, slug string) (domain.Tenant, error) {
+	record, err := repository.q.RetrieveTenantBySlug(ctx, slug)
+	if err != nil {
+		return domain.Tenant{}, notFoundOr(err)
+	}
+	return getTenantFromTenantRecord(record), nil
+}
+
+func (repository *PostgresRepository) RetrieveAllTenants(ctx context.Context) ([]domain.Tenant, error) {
+	records, err := repository.q.RetrieveAllTenants(ctx)
+	if err != nil {
+		return []domain.Tenant{}, err
+	}
+	tenants := make([]domain.Tenant, len(records))
+	for index, record := range records {
+		tenants[index] = getTenantFromTenantRecord(record)
+	}
+	return tenants, nil
+}
+
+func (repository *PostgresRepository) DisableTenant(ctx context.Context, tenantId string) (domain.Tenant, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	record, err := repository.q.DisableTenantById(ctx, tenantUuid)
+	if err != nil {
+		return domain.Tenant{}, notFoundOr(err)
+	}
+	return getTenantFromTenantRecord(record), nil
+}
+
+func getTenantFromTenantRecord(record sqlc.Tenant) domain.Tenant {
+	return domain.Tenant{
+		TenantID: record.TenantID.String(),
+		Name:     record.Name,
+		Slug:     record.Slug,
+		Disabled: record.Disabled,
+	}
+}
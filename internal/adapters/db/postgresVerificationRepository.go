@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (repository *PostgresRepository) SavePhoneVerification(ctx context.Context, tenantId string, verification domain.PhoneVerification) (domain.PhoneVerification, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.PhoneVerification{}, err
+	}
+	userUuid, err := uuid.Parse(verification.UserID)
+	if err != nil {
+		return domain.PhoneVerification{}, err
+	}
+	var record sqlc.PhoneVerification
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.UpsertPhoneVerification(ctx, sqlc.UpsertPhoneVerificationParams{
+			TenantID:  tenantUuid,
+			UserID:    userUuid,
+			CodeHash:  verification.CodeHash,
+			ExpiresAt: pgtype.Timestamptz{Time: verification.ExpiresAt, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return domain.PhoneVerification{}, err
+	}
+	return getPhoneVerificationFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) RetrievePhoneVerification(ctx context.Context, tenantId string, userId string) (domain.PhoneVerification, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.PhoneVerification{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.PhoneVerification{}, err
+	}
+	var record sqlc.PhoneVerification
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.RetrievePhoneVerification(ctx, sqlc.RetrievePhoneVerificationParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return domain.PhoneVerification{}, notFoundOr(err)
+	}
+	return getPhoneVerificationFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) CountPhoneVerificationAttempt(ctx context.Context, tenantId string, userId string) (domain.PhoneVerification, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.PhoneVerification{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.PhoneVerification{}, err
+	}
+	var record sqlc.PhoneVerification
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.CountPhoneVerificationAttempt(ctx, sqlc.CountPhoneVerificationAttemptParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return domain.PhoneVerification{}, notFoundOr(err)
+	}
+	return getPhoneVerificationFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) LockPhoneVerification(ctx context.Context, tenantId string, userId string, until time.Time) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return err
+	}
+	return repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		return q.LockPhoneVerification(ctx, sqlc.LockPhoneVerificationParams{
+			TenantID:    tenantUuid,
+			UserID:      userUuid,
+			LockedUntil: pgtype.Timestamptz{Time: until, Valid: true},
+		})
+	})
+}
+
+func (repository *PostgresRepository) ConfirmPhoneVerification(ctx context.Context, tenantId string, userId string) (domain.User, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	var row sqlc.User
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		if _, err := lockLiveUser(ctx, q, tenantUuid, userUuid); err != nil {
+			return err
+		}
+		row, err = q.SetPhoneVerified(ctx, sqlc.SetPhoneVerifiedParams{TenantID: tenantUuid, UserID: userUuid})
+		if err != nil {
+			return err
+		}
+		return q.DeletePhoneVerification(ctx, sqlc.DeletePhoneVerificationParams{TenantID: tenantUuid, UserID: userUuid})
+	})
+	if err != nil {
+		return domain.User{}, notFoundOr(err)
+	}
+	return repository.openUserRecord(row)
+}
+
+func getPhoneVerificationFromRecord(record sqlc.PhoneVerification) domain.PhoneVerification {
+	return domain.PhoneVerification{
+		UserID:      record.UserID.String(),
+		CodeHash:    record.CodeHash,
+		ExpiresAt:   getTimeFromTimestampRecord(record.ExpiresAt),
+		Attempts:    int(record.Attempts),
+		LockedUntil: getTimeFromTimestampRecord(record.LockedUntil),
+	}
+}
@@ -0,0 +1,319 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"userapi/app/internal/adapters/db/user"
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (repository *PostgresRepository) CreateWebhook(ctx context.Context, tenantId string, webhook domain.Webhook) (domain.Webhook, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	eventTypes := make([]string, 0, len(webhook.EventTypes))
+	for _, eventType := range webhook.EventTypes {
+		eventTypes = append(eventTypes, string(eventType))
+	}
+	var record sqlc.Webhook
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.CreateWebhook(ctx, sqlc.CreateWebhookParams{
+			TenantID:   tenantUuid,
+			Url:        webhook.URL,
+			EventTypes: eventTypes,
+			Secret:     webhook.Secret,
+		})
+		return err
+	})
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	return getWebhookFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) RetrieveWebhook(ctx context.Context, tenantId string, webhookId string) (domain.Webhook, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	webhookUuid, err := uuid.Parse(webhookId)
+	if err != nil {
+		return domain.Webhook{}, domain.ErrNotFound
+	}
+	var record sqlc.Webhook
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.RetrieveWebhookById(ctx, sqlc.RetrieveWebhookByIdParams{TenantID: tenantUuid, WebhookID: webhookUuid})
+		return err
+	})
+	if err != nil {
+		return domain.Webhook{}, notFoundOr(err)
+	}
+	return getWebhookFromRecord(record), nil
+}
+
+func (repository *PostgresRepository) RetrieveWebhooks(ctx context.Context, tenantId string) ([]domain.Webhook, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	var records []sqlc.Webhook
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveWebhooks(ctx, tenantUuid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make([]domain.Webhook, 0, len(records))
+	for _, record := range records {
+		webhooks = append(webhooks, getWebhookFromRecord(record))
+	}
+	return webhooks, nil
+}
+
+func (repository *PostgresRepository) DeleteWebhook(ctx context.Context, tenantId string, webhookId string) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	webhookUuid, err := uuid.Parse(webhookId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	var deleted int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		deleted, err = q.DeleteWebhook(ctx, sqlc.DeleteWebhookParams{TenantID: tenantUuid, WebhookID: webhookUuid})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) CreateWebhookDeliveries(ctx context.Context, tenantId string, event domain.Event) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	eventUuid, err := uuid.Parse(event.EventID)
+	if err != nil {
+		return err
+	}
+	userUuid, err := uuid.Parse(event.UserID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("could not encode the event: %w", err)
+	}
+	return repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		_, err := q.CreateWebhookDeliveries(ctx, sqlc.CreateWebhookDeliveriesParams{
+			EventID:   eventUuid,
+			EventType: string(event.Type),
+			Payload:   payload,
+			UserID:    userUuid,
+			TenantID:  tenantUuid,
+		})
+		return err
+	})
+}
+
+func (repository *PostgresRepository) RetrieveWebhookDeliveries(ctx context.Context, tenantId string, webhookId string, limit int) ([]domain.WebhookDelivery, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	webhookUuid, err := uuid.Parse(webhookId)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+	var records []sqlc.WebhookDelivery
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveWebhookDeliveries(ctx, sqlc.RetrieveWebhookDeliveriesParams{
+			TenantID:  tenantUuid,
+			WebhookID: webhookUuid,
+			Limit:     int32(limit), //nolint:gosec
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return getWebhookDeliveriesFromRecords(records)
+}
+
+func (repository *PostgresRepository) RetrieveWebhookDeliveriesByUser(ctx context.Context, tenantId string, userId string) ([]domain.WebhookDelivery, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	userUuid, err := uuid.Parse(userId)
+	if err != nil {
+		return nil, err
+	}
+	var records []sqlc.WebhookDelivery
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveWebhookDeliveriesByUser(ctx, sqlc.RetrieveWebhookDeliveriesByUserParams{TenantID: tenantUuid, UserID: userUuid})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return getWebhookDeliveriesFromRecords(records)
+}
+
+func (repository *PostgresRepository) RetrieveDueWebhookDeliveries(ctx context.Context, tenantId string, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	var records []sqlc.WebhookDelivery
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		records, err = q.RetrieveDueWebhookDeliveries(ctx, sqlc.RetrieveDueWebhookDeliveriesParams{
+			TenantID:      tenantUuid,
+			NextAttemptAt: pgtype.Timestamptz{Time: now, Valid: true},
+			Limit:         int32(limit), //nolint:gosec
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return getWebhookDeliveriesFromRecords(records)
+}
+
+func (repository *PostgresRepository) RecordWebhookAttempt(ctx context.Context, tenantId string, deliveryId string, attempt domain.WebhookAttempt, status domain.WebhookDeliveryStatus, next time.Time) error {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return err
+	}
+	deliveryUuid, err := uuid.Parse(deliveryId)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	blob, err := json.Marshal(attempt)
+	if err != nil {
+		return err
+	}
+	var recorded int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		recorded, err = q.RecordWebhookAttempt(ctx, sqlc.RecordWebhookAttemptParams{
+			Status:        sqlc.WebhookDeliveryStatus(status),
+			Attempt:       blob,
+			NextAttemptAt: pgtype.Timestamptz{Time: next, Valid: true},
+			TenantID:      tenantUuid,
+			DeliveryID:    deliveryUuid,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if recorded == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (repository *PostgresRepository) RedeliverWebhookDelivery(ctx context.Context, tenantId string, webhookId string, deliveryId string) (domain.WebhookDelivery, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	webhookUuid, err := uuid.Parse(webhookId)
+	if err != nil {
+		return domain.WebhookDelivery{}, domain.ErrNotFound
+	}
+	deliveryUuid, err := uuid.Parse(deliveryId)
+	if err != nil {
+		return domain.WebhookDelivery{}, domain.ErrNotFound
+	}
+	var record sqlc.WebhookDelivery
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		record, err = q.RedeliverWebhookDelivery(ctx, sqlc.RedeliverWebhookDeliveryParams{
+			TenantID:   tenantUuid,
+			WebhookID:  webhookUuid,
+			DeliveryID: deliveryUuid,
+		})
+		return err
+	})
+	if err != nil {
+		return domain.WebhookDelivery{}, notFoundOr(err)
+	}
+	return getWebhookDeliveryFromRecord(record)
+}
+
+func getWebhookFromRecord(record sqlc.Webhook) domain.Webhook {
+	eventTypes := make([]domain.EventType, 0, len(record.EventTypes))
+	for _, eventType := range record.EventTypes {
+		eventTypes = append(eventTypes, domain.EventType(eventType))
+	}
+	return domain.Webhook{
+		WebhookID:  record.WebhookID.String(),
+		URL:        record.Url,
+		EventTypes: eventTypes,
+		Secret:     record.Secret,
+		CreatedAt:  getTimeFromTimestampRecord(record.CreatedAt),
+	}
+}
+
+func (repository *PostgresRepository) DeleteFinishedWebhookDeliveries(ctx context.Context, tenantId string, before time.Time) (int64, error) {
+	tenantUuid, err := uuid.Parse(tenantId)
+	if err != nil {
+		return 0, err
+	}
+	var deleted int64
+	err = repository.inTenant(ctx, tenantUuid, func(q *sqlc.Queries) error {
+		deleted, err = q.DeleteFinishedWebhookDeliveries(ctx, sqlc.DeleteFinishedWebhookDeliveriesParams{
+			TenantID:  tenantUuid,
+			CreatedAt: pgtype.Timestamptz{Time: before, Valid: true},
+		})
+		return err
+	})
+	return deleted, err
+}
+
+func getWebhookDeliveryFromRecord(record sqlc.WebhookDelivery) (domain.WebhookDelivery, error) {
+	var attempts []domain.WebhookAttempt
+	if err := json.Unmarshal(record.Attempts, &attempts); err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("could not decode the attempts of delivery %s: %w", record.DeliveryID, err)
+	}
+	return domain.WebhookDelivery{
+		DeliveryID:    record.DeliveryID.String(),
+		WebhookID:     record.WebhookID.String(),
+		UserID:        record.UserID.String(),
+		EventID:       record.EventID.String(),
+		EventType:     domain.EventType(record.EventType),
+		Payload:       record.Payload,
+		Status:        domain.WebhookDeliveryStatus(record.Status),
+		Failures:      int(record.Failures),
+		Attempts:      attempts,
+		NextAttemptAt: getTimeFromTimestampRecord(record.NextAttemptAt),
+		CreatedAt:     getTimeFromTimestampRecord(record.CreatedAt),
+		DeliveredAt:   getTimeFromTimestampRecord(record.DeliveredAt),
+	}, nil
+}
+
+func getWebhookDeliveriesFromRecords(records []sqlc.WebhookDelivery) ([]domain.WebhookDelivery, error) {
+	deliveries := make([]domain.WebhookDelivery, 0, len(records))
+	for _, record := range records {
+		delivery, err := getWebhookDeliveryFromRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
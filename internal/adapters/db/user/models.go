@@ -12,11 +12,311 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ContactKind string
+
+const (
+	ContactKindEMAIL ContactKind = "EMAIL"
+	ContactKindPHONE ContactKind = "PHONE"
+)
+
+func (e *ContactKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ContactKind(s)
+	case string:
+		*e = ContactKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ContactKind: %T", src)
+	}
+	return nil
+}
+
+type NullContactKind struct {
+	ContactKind ContactKind
+	Valid       bool // Valid is true if ContactKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullContactKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.ContactKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ContactKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullContactKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ContactKind), nil
+}
+
+type MfaFactorKind string
+
+const (
+	MfaFactorKindTOTP     MfaFactorKind = "TOTP"
+	MfaFactorKindWEBAUTHN MfaFactorKind = "WEBAUTHN"
+)
+
+func (e *MfaFactorKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = MfaFactorKind(s)
+	case string:
+		*e = MfaFactorKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for MfaFactorKind: %T", src)
+	}
+	return nil
+}
+
+type NullMfaFactorKind struct {
+	MfaFactorKind MfaFactorKind
+	Valid         bool // Valid is true if MfaFactorKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullMfaFactorKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.MfaFactorKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.MfaFactorKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullMfaFactorKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.MfaFactorKind), nil
+}
+
+type MfaFactorStatus string
+
+const (
+	MfaFactorStatusPENDING MfaFactorStatus = "PENDING"
+	MfaFactorStatusACTIVE  MfaFactorStatus = "ACTIVE"
+)
+
+func (e *MfaFactorStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = MfaFactorStatus(s)
+	case string:
+		*e = MfaFactorStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for MfaFactorStatus: %T", src)
+	}
+	return nil
+}
+
+type NullMfaFactorStatus struct {
+	MfaFactorStatus MfaFactorStatus
+	Valid           bool // Valid is true if MfaFactorStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullMfaFactorStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.MfaFactorStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.MfaFactorStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullMfaFactorStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.MfaFactorStatus), nil
+}
+
+type PrivacyRequestKind string
+
+const (
+	PrivacyRequestKindEXPORT  PrivacyRequestKind = "EXPORT"
+	PrivacyRequestKindERASURE PrivacyRequestKind = "ERASURE"
+)
+
+func (e *PrivacyRequestKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PrivacyRequestKind(s)
+	case string:
+		*e = PrivacyRequestKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PrivacyRequestKind: %T", src)
+	}
+	return nil
+}
+
+type NullPrivacyRequestKind struct {
+	PrivacyRequestKind PrivacyRequestKind
+	Valid              bool // Valid is true if PrivacyRequestKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPrivacyRequestKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.PrivacyRequestKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PrivacyRequestKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPrivacyRequestKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PrivacyRequestKind), nil
+}
+
+type PrivacyRequestStatus string
+
+const (
+	PrivacyRequestStatusPENDING   PrivacyRequestStatus = "PENDING"
+	PrivacyRequestStatusCOMPLETED PrivacyRequestStatus = "COMPLETED"
+	PrivacyRequestStatusFAILED    PrivacyRequestStatus = "FAILED"
+)
+
+func (e *PrivacyRequestStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PrivacyRequestStatus(s)
+	case string:
+		*e = PrivacyRequestStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PrivacyRequestStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPrivacyRequestStatus struct {
+	PrivacyRequestStatus PrivacyRequestStatus
+	Valid                bool // Valid is true if PrivacyRequestStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPrivacyRequestStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PrivacyRequestStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PrivacyRequestStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPrivacyRequestStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PrivacyRequestStatus), nil
+}
+
+type ScheduledActionKind string
+
+const (
+	ScheduledActionKindDEACTIVATE ScheduledActionKind = "DEACTIVATE"
+	ScheduledActionKindREACTIVATE ScheduledActionKind = "REACTIVATE"
+)
+
+func (e *ScheduledActionKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ScheduledActionKind(s)
+	case string:
+		*e = ScheduledActionKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ScheduledActionKind: %T", src)
+	}
+	return nil
+}
+
+type NullScheduledActionKind struct {
+	ScheduledActionKind ScheduledActionKind
+	Valid               bool // Valid is true if ScheduledActionKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullScheduledActionKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.ScheduledActionKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ScheduledActionKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullScheduledActionKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ScheduledActionKind), nil
+}
+
+type ScheduledActionStatus string
+
+const (
+	ScheduledActionStatusPENDING   ScheduledActionStatus = "PENDING"
+	ScheduledActionStatusDONE      ScheduledActionStatus = "DONE"
+	ScheduledActionStatusCANCELLED ScheduledActionStatus = "CANCELLED"
+	ScheduledActionStatusFAILED    ScheduledActionStatus = "FAILED"
+)
+
+func (e *ScheduledActionStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ScheduledActionStatus(s)
+	case string:
+		*e = ScheduledActionStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ScheduledActionStatus: %T", src)
+	}
+	return nil
+}
+
+type NullScheduledActionStatus struct {
+	ScheduledActionStatus ScheduledActionStatus
+	Valid                 bool // Valid is true if ScheduledActionStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullScheduledActionStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.ScheduledActionStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ScheduledActionStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullScheduledActionStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ScheduledActionStatus), nil
+}
+
 type UserStatus string
 
 const (
-	UserStatusACTIVE   UserStatus = "ACTIVE"
-	UserStatusINACTIVE UserStatus = "INACTIVE"
+	UserStatusACTIVE      UserStatus = "ACTIVE"
+	UserStatusDEACTIVATED UserStatus = "DEACTIVATED"
+	UserStatusPENDING     UserStatus = "PENDING"
+	UserStatusSUSPENDED   UserStatus = "SUSPENDED"
+	UserStatusLOCKED      UserStatus = "LOCKED"
 )
 
 func (e *UserStatus) Scan(src interface{}) error {
@@ -54,12 +354,279 @@ func (ns NullUserStatus) Value() (driver.Value, error) {
 	return string(ns.UserStatus), nil
 }
 
-type User struct {
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPENDING   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusSUCCEEDED WebhookDeliveryStatus = "SUCCEEDED"
+	WebhookDeliveryStatusDEAD      WebhookDeliveryStatus = "DEAD"
+)
+
+func (e *WebhookDeliveryStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WebhookDeliveryStatus(s)
+	case string:
+		*e = WebhookDeliveryStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WebhookDeliveryStatus: %T", src)
+	}
+	return nil
+}
+
+type NullWebhookDeliveryStatus struct {
+	WebhookDeliveryStatus WebhookDeliveryStatus
+	Valid                 bool // Valid is true if WebhookDeliveryStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWebhookDeliveryStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.WebhookDeliveryStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WebhookDeliveryStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWebhookDeliveryStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WebhookDeliveryStatus), nil
+}
+
+type AttributeSchema struct {
+	TenantID  uuid.UUID
+	Schema    []byte
+	UpdatedAt pgtype.Timestamptz
+}
+
+type ContactMethod struct {
+	ContactID  uuid.UUID
+	TenantID   uuid.UUID
+	UserID     uuid.UUID
+	Kind       ContactKind
+	Label      string
+	Value      string
+	IsPrimary  bool
+	PiiKeyID   pgtype.Text
+	PiiDataKey []byte
+	CreatedAt  pgtype.Timestamptz
+}
+
+type Credential struct {
+	UserID            uuid.UUID
+	TenantID          uuid.UUID
+	PasswordHash      string
+	FailedAttempts    int32
+	LockedUntil       pgtype.Timestamptz
+	PasswordChangedAt pgtype.Timestamptz
+}
+
+type ErasureCertificate struct {
+	CertificateID uuid.UUID
+	TenantID      uuid.UUID
+	RequestID     uuid.UUID
+	UserID        uuid.UUID
+	ErasedFields  []string
+	ErasedAt      pgtype.Timestamptz
+}
+
+type Group struct {
+	GroupID     uuid.UUID
+	TenantID    uuid.UUID
+	Name        string
+	Description pgtype.Text
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type GroupMember struct {
+	GroupID       uuid.UUID
+	TenantID      uuid.UUID
+	UserID        pgtype.UUID
+	MemberGroupID pgtype.UUID
+	CreatedAt     pgtype.Timestamptz
+}
+
+type MfaChallenge struct {
+	ChallengeID uuid.UUID
+	TenantID    uuid.UUID
+	UserID      uuid.UUID
+	Purpose     string
+	Challenge   []byte
+	ExpiresAt   pgtype.Timestamptz
+}
+
+type MfaFactor struct {
+	FactorID     uuid.UUID
+	TenantID     uuid.UUID
+	UserID       uuid.UUID
+	Kind         MfaFactorKind
+	Name         string
+	Status       MfaFactorStatus
+	TotpSecret   pgtype.Text
+	PiiKeyID     pgtype.Text
+	PiiDataKey   []byte
+	LastUsedStep pgtype.Int8
+	CredentialID []byte
+	PublicKey    []byte
+	SignCount    int64
+	CreatedAt    pgtype.Timestamptz
+	LastUsedAt   pgtype.Timestamptz
+}
+
+type MfaRecoveryCode struct {
+	CodeID    uuid.UUID
+	TenantID  uuid.UUID
 	UserID    uuid.UUID
-	FirstName string
-	LastName  string
-	Email     string
-	Phone     pgtype.Text
-	Age       pgtype.Int4
-	Status    NullUserStatus
+	CodeHash  []byte
+	UsedAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type Outbox struct {
+	Sequence      int64
+	EventID       uuid.UUID
+	TenantID      uuid.UUID
+	UserID        uuid.UUID
+	EventType     string
+	Payload       []byte
+	OccurredAt    pgtype.Timestamptz
+	Attempts      int32
+	NextAttemptAt pgtype.Timestamptz
+	LastError     pgtype.Text
+	PublishedAt   pgtype.Timestamptz
+	DeadAt        pgtype.Timestamptz
+}
+
+type PhoneVerification struct {
+	UserID      uuid.UUID
+	TenantID    uuid.UUID
+	CodeHash    []byte
+	ExpiresAt   pgtype.Timestamptz
+	Attempts    int32
+	LockedUntil pgtype.Timestamptz
+	CreatedAt   pgtype.Timestamptz
+}
+
+type PostalAddress struct {
+	AddressID  uuid.UUID
+	TenantID   uuid.UUID
+	UserID     uuid.UUID
+	Label      string
+	Line1      string
+	Line2      pgtype.Text
+	City       string
+	Region     pgtype.Text
+	PostalCode pgtype.Text
+	Country    string
+	IsPrimary  bool
+	PiiKeyID   pgtype.Text
+	PiiDataKey []byte
+	CreatedAt  pgtype.Timestamptz
+}
+
+type PrivacyRequest struct {
+	RequestID   uuid.UUID
+	TenantID    uuid.UUID
+	UserID      uuid.UUID
+	Kind        PrivacyRequestKind
+	Status      PrivacyRequestStatus
+	RequestedAt pgtype.Timestamptz
+	DueAt       pgtype.Timestamptz
+	CompletedAt pgtype.Timestamptz
+	Error       pgtype.Text
+}
+
+type ScheduledAction struct {
+	ActionID   uuid.UUID
+	TenantID   uuid.UUID
+	UserID     uuid.UUID
+	Kind       ScheduledActionKind
+	Status     ScheduledActionStatus
+	RunAt      pgtype.Timestamptz
+	Reason     pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+	ExecutedAt pgtype.Timestamptz
+	Error      pgtype.Text
+}
+
+type Session struct {
+	SessionID        uuid.UUID
+	TenantID         uuid.UUID
+	UserID           uuid.UUID
+	RefreshTokenHash []byte
+	UserAgent        pgtype.Text
+	IpAddress        pgtype.Text
+	CreatedAt        pgtype.Timestamptz
+	LastSeenAt       pgtype.Timestamptz
+	ExpiresAt        pgtype.Timestamptz
+	RevokedAt        pgtype.Timestamptz
+}
+
+type Tenant struct {
+	TenantID  uuid.UUID
+	Name      string
+	Slug      string
+	Disabled  bool
+	CreatedAt pgtype.Timestamptz
+}
+
+type User struct {
+	UserID          uuid.UUID
+	TenantID        uuid.UUID
+	FirstName       string
+	LastName        string
+	Email           string
+	Phone           pgtype.Text
+	Age             pgtype.Int4
+	Status          NullUserStatus
+	EmailVerified   bool
+	PhoneVerifiedAt pgtype.Timestamptz
+	StatusReason    pgtype.Text
+	SuspendedUntil  pgtype.Timestamptz
+	Attributes      []byte
+	EmailIndex      []byte
+	PiiKeyID        pgtype.Text
+	PiiDataKey      []byte
+	ErasedAt        pgtype.Timestamptz
+}
+
+type UserStatusTransition struct {
+	TransitionID   uuid.UUID
+	TenantID       uuid.UUID
+	UserID         uuid.UUID
+	FromStatus     UserStatus
+	ToStatus       UserStatus
+	Reason         pgtype.Text
+	SuspendedUntil pgtype.Timestamptz
+	CreatedAt      pgtype.Timestamptz
+}
+
+type Webhook struct {
+	WebhookID  uuid.UUID
+	TenantID   uuid.UUID
+	Url        string
+	EventTypes []string
+	Secret     string
+	CreatedAt  pgtype.Timestamptz
+}
+
+type WebhookDelivery struct {
+	DeliveryID    uuid.UUID
+	TenantID      uuid.UUID
+	WebhookID     uuid.UUID
+	EventID       uuid.UUID
+	EventType     string
+	Payload       []byte
+	Status        WebhookDeliveryStatus
+	Failures      int32
+	Attempts      []byte
+	NextAttemptAt pgtype.Timestamptz
+	CreatedAt     pgtype.Timestamptz
+	DeliveredAt   pgtype.Timestamptz
+	UserID        uuid.UUID
 }
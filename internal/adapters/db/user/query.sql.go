@@ -14,54 +14,75 @@ import (
 
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
-    first_name, last_name, email, phone, age, status
+    tenant_id, first_name, last_name, email, phone, age, status, email_index, pii_key_id, pii_data_key, attributes
 ) VALUES (
-             $1, $2, $3,$4, $5, $6
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
          )
-RETURNING user_id, first_name, last_name, email, phone, age, status
+RETURNING user_id, tenant_id, first_name, last_name, email, phone, age, status, email_verified, phone_verified_at, status_reason, suspended_until, attributes, email_index, pii_key_id, pii_data_key, erased_at
 `
 
 type CreateUserParams struct {
-	FirstName string
-	LastName  string
-	Email     string
-	Phone     pgtype.Text
-	Age       pgtype.Int4
-	Status    NullUserStatus
+	TenantID   uuid.UUID
+	FirstName  string
+	LastName   string
+	Email      string
+	Phone      pgtype.Text
+	Age        pgtype.Int4
+	Status     NullUserStatus
+	EmailIndex []byte
+	PiiKeyID   pgtype.Text
+	PiiDataKey []byte
+	Attributes []byte
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
 	row := q.db.QueryRow(ctx, createUser,
+		arg.TenantID,
 		arg.FirstName,
 		arg.LastName,
 		arg.Email,
 		arg.Phone,
 		arg.Age,
 		arg.Status,
+		arg.EmailIndex,
+		arg.PiiKeyID,
+		arg.PiiDataKey,
+		arg.Attributes,
 	)
 	var i User
 	err := row.Scan(
 		&i.UserID,
+		&i.TenantID,
 		&i.FirstName,
 		&i.LastName,
 		&i.Email,
 		&i.Phone,
 		&i.Age,
 		&i.Status,
+		&i.EmailVerified,
+		&i.PhoneVerifiedAt,
+		&i.StatusReason,
+		&i.SuspendedUntil,
+		&i.Attributes,
+		&i.EmailIndex,
+		&i.PiiKeyID,
+		&i.PiiDataKey,
+		&i.ErasedAt,
 	)
 	return i, err
 }
 
 const createUserDefault = `-- name: CreateUserDefault :one
 INSERT INTO users (
-    first_name, last_name, email, phone, age
+    tenant_id, first_name, last_name, email, phone, age
 ) VALUES (
-             $1, $2, $3,$4, $5
+             $1, $2, $3, $4, $5, $6
           )
-RETURNING user_id, first_name, last_name, email, phone, age, status
+RETURNING user_id, tenant_id, first_name, last_name, email, phone, age, status, email_verified, phone_verified_at, status_reason, suspended_until, attributes, email_index, pii_key_id, pii_data_key, erased_at
 `
 
 type CreateUserDefaultParams struct {
+	TenantID  uuid.UUID
 	FirstName string
 	LastName  string
 	Email     string
@@ -71,6 +92,7 @@ type CreateUserDefaultParams struct {
 
 func (q *Queries) CreateUserDefault(ctx context.Context, arg CreateUserDefaultParams) (User, error) {
 	row := q.db.QueryRow(ctx, createUserDefault,
+		arg.TenantID,
 		arg.FirstName,
 		arg.LastName,
 		arg.Email,
@@ -80,31 +102,83 @@ func (q *Queries) CreateUserDefault(ctx context.Context, arg CreateUserDefaultPa
 	var i User
 	err := row.Scan(
 		&i.UserID,
+		&i.TenantID,
 		&i.FirstName,
 		&i.LastName,
 		&i.Email,
 		&i.Phone,
 		&i.Age,
 		&i.Status,
+		&i.EmailVerified,
+		&i.PhoneVerifiedAt,
+		&i.StatusReason,
+		&i.SuspendedUntil,
+		&i.Attributes,
+		&i.EmailIndex,
+		&i.PiiKeyID,
+		&i.PiiDataKey,
+		&i.ErasedAt,
 	)
 	return i, err
 }
 
-const deleteUserById = `-- name: DeleteUserById :exec
-DELETE FROM users WHERE user_id = $1
+const deleteUserById = `-- name: DeleteUserById :execrows
+DELETE FROM users WHERE tenant_id = $1 AND user_id = $2
 `
 
-func (q *Queries) DeleteUserById(ctx context.Context, userID uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteUserById, userID)
-	return err
+type DeleteUserByIdParams struct {
+	TenantID uuid.UUID
+	UserID   uuid.UUID
+}
+
+func (q *Queries) DeleteUserById(ctx context.Context, arg DeleteUserByIdParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserById, arg.TenantID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const lockUserById = `-- name: LockUserById :one
+SELECT user_id, tenant_id, first_name, last_name, email, phone, age, status, email_verified, phone_verified_at, status_reason, suspended_until, attributes, email_index, pii_key_id, pii_data_key, erased_at FROM users WHERE tenant_id = $1 AND user_id = $2 FOR UPDATE
+`
+
+type LockUserByIdParams struct {
+	TenantID uuid.UUID
+	UserID   uuid.UUID
+}
+
+func (q *Queries) LockUserById(ctx context.Context, arg LockUserByIdParams) (User, error) {
+	row := q.db.QueryRow(ctx, lockUserById, arg.TenantID, arg.UserID)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.TenantID,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.Phone,
+		&i.Age,
+		&i.Status,
+		&i.EmailVerified,
+		&i.PhoneVerifiedAt,
+		&i.StatusReason,
+		&i.SuspendedUntil,
+		&i.Attributes,
+		&i.EmailIndex,
+		&i.PiiKeyID,
+		&i.PiiDataKey,
+		&i.ErasedAt,
+	)
+	return i, err
 }
 
 const retrieveAllUsers = `-- name: RetrieveAllUsers :many
-SELECT user_id, first_name, last_name, email, phone, age, status FROM users
+SELECT user_id, tenant_id, first_name, last_name, email, phone, age, status, email_verified, phone_verified_at, status_reason, suspended_until, attributes, email_index, pii_key_id, pii_data_key, erased_at FROM users WHERE tenant_id = $1
 `
 
-func (q *Queries) RetrieveAllUsers(ctx context.Context) ([]User, error) {
-	rows, err := q.db.Query(ctx, retrieveAllUsers)
+func (q *Queries) RetrieveAllUsers(ctx context.Context, tenantID uuid.UUID) ([]User, error) {
+	rows, err := q.db.Query(ctx, retrieveAllUsers, tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -114,12 +188,22 @@ func (q *Queries) RetrieveAllUsers(ctx context.Context) ([]User, error) {
 		var i User
 		if err := rows.Scan(
 			&i.UserID,
+			&i.TenantID,
 			&i.FirstName,
 			&i.LastName,
 			&i.Email,
 			&i.Phone,
 			&i.Age,
 			&i.Status,
+			&i.EmailVerified,
+			&i.PhoneVerifiedAt,
+			&i.StatusReason,
+			&i.SuspendedUntil,
+			&i.Attributes,
+			&i.EmailIndex,
+			&i.PiiKeyID,
+			&i.PiiDataKey,
+			&i.ErasedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -131,25 +215,277 @@ func (q *Queries) RetrieveAllUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
+const retrieveUserByEmail = `-- name: RetrieveUserByEmail :one
+SELECT user_id, tenant_id, first_name, last_name, email, phone, age, status, email_verified, phone_verified_at, status_reason, suspended_until, attributes, email_index, pii_key_id, pii_data_key, erased_at FROM users
+WHERE tenant_id = $1
+  AND (email = $2 OR email_index = $3)
+LIMIT 1
+`
+
+type RetrieveUserByEmailParams struct {
+	TenantID   uuid.UUID
+	Email      pgtype.Text
+	EmailIndex []byte
+}
+
+func (q *Queries) RetrieveUserByEmail(ctx context.Context, arg RetrieveUserByEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, retrieveUserByEmail, arg.TenantID, arg.Email, arg.EmailIndex)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.TenantID,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.Phone,
+		&i.Age,
+		&i.Status,
+		&i.EmailVerified,
+		&i.PhoneVerifiedAt,
+		&i.StatusReason,
+		&i.SuspendedUntil,
+		&i.Attributes,
+		&i.EmailIndex,
+		&i.PiiKeyID,
+		&i.PiiDataKey,
+		&i.ErasedAt,
+	)
+	return i, err
+}
+
 const retrieveUserById = `-- name: RetrieveUserById :one
-SELECT user_id, first_name, last_name, email, phone, age, status FROM users WHERE user_id = $1 LIMIT 1
+SELECT user_id, tenant_id, first_name, last_name, email, phone, age, status, email_verified, phone_verified_at, status_reason, suspended_until, attributes, email_index, pii_key_id, pii_data_key, erased_at FROM users WHERE tenant_id = $1 AND user_id = $2 LIMIT 1
+`
+
+type RetrieveUserByIdParams struct {
+	TenantID uuid.UUID
+	UserID   uuid.UUID
+}
+
+func (q *Queries) RetrieveUserById(ctx context.Context, arg RetrieveUserByIdParams) (User, error) {
+	row := q.db.QueryRow(ctx, retrieveUserById, arg.TenantID, arg.UserID)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.TenantID,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.Phone,
+		&i.Age,
+		&i.Status,
+		&i.EmailVerified,
+		&i.PhoneVerifiedAt,
+		&i.StatusReason,
+		&i.SuspendedUntil,
+		&i.Attributes,
+		&i.EmailIndex,
+		&i.PiiKeyID,
+		&i.PiiDataKey,
+		&i.ErasedAt,
+	)
+	return i, err
+}
+
+const retrieveUsersByAttributes = `-- name: RetrieveUsersByAttributes :many
+SELECT user_id, tenant_id, first_name, last_name, email, phone, age, status, email_verified, phone_verified_at, status_reason, suspended_until, attributes, email_index, pii_key_id, pii_data_key, erased_at FROM users WHERE tenant_id = $1 AND attributes @> $2
+`
+
+type RetrieveUsersByAttributesParams struct {
+	TenantID   uuid.UUID
+	Attributes []byte
+}
+
+func (q *Queries) RetrieveUsersByAttributes(ctx context.Context, arg RetrieveUsersByAttributesParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, retrieveUsersByAttributes, arg.TenantID, arg.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.UserID,
+			&i.TenantID,
+			&i.FirstName,
+			&i.LastName,
+			&i.Email,
+			&i.Phone,
+			&i.Age,
+			&i.Status,
+			&i.EmailVerified,
+			&i.PhoneVerifiedAt,
+			&i.StatusReason,
+			&i.SuspendedUntil,
+			&i.Attributes,
+			&i.EmailIndex,
+			&i.PiiKeyID,
+			&i.PiiDataKey,
+			&i.ErasedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retrieveUsersNotOnKey = `-- name: RetrieveUsersNotOnKey :many
+SELECT user_id, tenant_id, first_name, last_name, email, phone, age, status, email_verified, phone_verified_at, status_reason, suspended_until, attributes, email_index, pii_key_id, pii_data_key, erased_at FROM users
+WHERE tenant_id = $1 AND pii_key_id IS DISTINCT FROM $2::text
+`
+
+type RetrieveUsersNotOnKeyParams struct {
+	TenantID    uuid.UUID
+	ActiveKeyID string
+}
+
+func (q *Queries) RetrieveUsersNotOnKey(ctx context.Context, arg RetrieveUsersNotOnKeyParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, retrieveUsersNotOnKey, arg.TenantID, arg.ActiveKeyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.UserID,
+			&i.TenantID,
+			&i.FirstName,
+			&i.LastName,
+			&i.Email,
+			&i.Phone,
+			&i.Age,
+			&i.Status,
+			&i.EmailVerified,
+			&i.PhoneVerifiedAt,
+			&i.StatusReason,
+			&i.SuspendedUntil,
+			&i.Attributes,
+			&i.EmailIndex,
+			&i.PiiKeyID,
+			&i.PiiDataKey,
+			&i.ErasedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retrieveUsersPage = `-- name: RetrieveUsersPage :many
+SELECT user_id, tenant_id, first_name, last_name, email, phone, age, status, email_verified, phone_verified_at, status_reason, suspended_until, attributes, email_index, pii_key_id, pii_data_key, erased_at FROM users
+WHERE tenant_id = $1 AND attributes @> $2 AND user_id > $3
+ORDER BY user_id
+LIMIT $4
 `
 
-func (q *Queries) RetrieveUserById(ctx context.Context, userID uuid.UUID) (User, error) {
-	row := q.db.QueryRow(ctx, retrieveUserById, userID)
+type RetrieveUsersPageParams struct {
+	TenantID   uuid.UUID
+	Attributes []byte
+	UserID     uuid.UUID
+	Limit      int32
+}
+
+func (q *Queries) RetrieveUsersPage(ctx context.Context, arg RetrieveUsersPageParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, retrieveUsersPage,
+		arg.TenantID,
+		arg.Attributes,
+		arg.UserID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.UserID,
+			&i.TenantID,
+			&i.FirstName,
+			&i.LastName,
+			&i.Email,
+			&i.Phone,
+			&i.Age,
+			&i.Status,
+			&i.EmailVerified,
+			&i.PhoneVerifiedAt,
+			&i.StatusReason,
+			&i.SuspendedUntil,
+			&i.Attributes,
+			&i.EmailIndex,
+			&i.PiiKeyID,
+			&i.PiiDataKey,
+			&i.ErasedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setEmailVerified = `-- name: SetEmailVerified :one
+UPDATE users
+SET email_verified = $3
+WHERE tenant_id = $1 AND user_id = $2
+RETURNING user_id, tenant_id, first_name, last_name, email, phone, age, status, email_verified, phone_verified_at, status_reason, suspended_until, attributes, email_index, pii_key_id, pii_data_key, erased_at
+`
+
+type SetEmailVerifiedParams struct {
+	TenantID      uuid.UUID
+	UserID        uuid.UUID
+	EmailVerified bool
+}
+
+func (q *Queries) SetEmailVerified(ctx context.Context, arg SetEmailVerifiedParams) (User, error) {
+	row := q.db.QueryRow(ctx, setEmailVerified, arg.TenantID, arg.UserID, arg.EmailVerified)
 	var i User
 	err := row.Scan(
 		&i.UserID,
+		&i.TenantID,
 		&i.FirstName,
 		&i.LastName,
 		&i.Email,
 		&i.Phone,
 		&i.Age,
 		&i.Status,
+		&i.EmailVerified,
+		&i.PhoneVerifiedAt,
+		&i.StatusReason,
+		&i.SuspendedUntil,
+		&i.Attributes,
+		&i.EmailIndex,
+		&i.PiiKeyID,
+		&i.PiiDataKey,
+		&i.ErasedAt,
 	)
 	return i, err
 }
 
+const setTenant = `-- name: SetTenant :exec
+SELECT set_config('app.tenant_id', $1::text, TRUE)
+`
+
+func (q *Queries) SetTenant(ctx context.Context, tenantID string) error {
+	_, err := q.db.Exec(ctx, setTenant, tenantID)
+	return err
+}
+
 const updateUserById = `-- name: UpdateUserById :one
 UPDATE users
 SET
@@ -158,32 +494,36 @@ SET
     email      = COALESCE($3, email),
     age        = COALESCE($4, age),
     phone      = COALESCE($5, phone),
-    status     = COALESCE($6, status)
-WHERE user_id = $7
-RETURNING user_id, first_name, last_name, email, age, phone, status
+    status     = COALESCE($6, status),
+    email_index = COALESCE($7, email_index),
+    attributes = COALESCE($8, attributes),
+    -- a new address must be verified again. Sealed emails are compared by their blind index.
+    email_verified = CASE
+        WHEN $3::text IS NULL THEN email_verified
+        WHEN $7::bytea IS NOT NULL THEN email_verified AND email_index IS NOT DISTINCT FROM $7
+        ELSE email_verified AND email = $3
+    END,
+    phone_verified_at = CASE
+        WHEN $5::text IS NULL OR $5 = phone THEN phone_verified_at
+    END
+WHERE tenant_id = $9 AND user_id = $10
+RETURNING user_id, tenant_id, first_name, last_name, email, phone, age, status, email_verified, phone_verified_at, status_reason, suspended_until, attributes, email_index, pii_key_id, pii_data_key, erased_at
 `
 
 type UpdateUserByIdParams struct {
-	FirstName pgtype.Text
-	LastName  pgtype.Text
-	Email     pgtype.Text
-	Age       pgtype.Int4
-	Phone     pgtype.Text
-	Status    NullUserStatus
-	UserID    uuid.UUID
+	FirstName  pgtype.Text
+	LastName   pgtype.Text
+	Email      pgtype.Text
+	Age        pgtype.Int4
+	Phone      pgtype.Text
+	Status     NullUserStatus
+	EmailIndex []byte
+	Attributes []byte
+	TenantID   uuid.UUID
+	UserID     uuid.UUID
 }
 
-type UpdateUserByIdRow struct {
-	UserID    uuid.UUID
-	FirstName string
-	LastName  string
-	Email     string
-	Age       pgtype.Int4
-	Phone     pgtype.Text
-	Status    NullUserStatus
-}
-
-func (q *Queries) UpdateUserById(ctx context.Context, arg UpdateUserByIdParams) (UpdateUserByIdRow, error) {
+func (q *Queries) UpdateUserById(ctx context.Context, arg UpdateUserByIdParams) (User, error) {
 	row := q.db.QueryRow(ctx, updateUserById,
 		arg.FirstName,
 		arg.LastName,
@@ -191,17 +531,64 @@ func (q *Queries) UpdateUserById(ctx context.Context, arg UpdateUserByIdParams)
 		arg.Age,
 		arg.Phone,
 		arg.Status,
+		arg.EmailIndex,
+		arg.Attributes,
+		arg.TenantID,
 		arg.UserID,
 	)
-	var i UpdateUserByIdRow
+	var i User
 	err := row.Scan(
 		&i.UserID,
+		&i.TenantID,
 		&i.FirstName,
 		&i.LastName,
 		&i.Email,
-		&i.Age,
 		&i.Phone,
+		&i.Age,
 		&i.Status,
+		&i.EmailVerified,
+		&i.PhoneVerifiedAt,
+		&i.StatusReason,
+		&i.SuspendedUntil,
+		&i.Attributes,
+		&i.EmailIndex,
+		&i.PiiKeyID,
+		&i.PiiDataKey,
+		&i.ErasedAt,
 	)
 	return i, err
 }
+
+const updateUserPii = `-- name: UpdateUserPii :exec
+UPDATE users
+SET
+    email        = $3,
+    phone        = $4,
+    email_index  = $5,
+    pii_key_id   = $6,
+    pii_data_key = $7
+WHERE tenant_id = $1 AND user_id = $2
+`
+
+type UpdateUserPiiParams struct {
+	TenantID   uuid.UUID
+	UserID     uuid.UUID
+	Email      string
+	Phone      pgtype.Text
+	EmailIndex []byte
+	PiiKeyID   pgtype.Text
+	PiiDataKey []byte
+}
+
+func (q *Queries) UpdateUserPii(ctx context.Context, arg UpdateUserPiiParams) error {
+	_, err := q.db.Exec(ctx, updateUserPii,
+		arg.TenantID,
+		arg.UserID,
+		arg.Email,
+		arg.Phone,
+		arg.EmailIndex,
+		arg.PiiKeyID,
+		arg.PiiDataKey,
+	)
+	return err
+}
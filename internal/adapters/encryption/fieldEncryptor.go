@@ -0,0 +1,154 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealedPrefix marks sealed values, so they can be told apart from plaintext
+// written before a field was configured for encryption.
+const sealedPrefix = "enc:v1:"
+
+// FieldEncryptor implements envelope encryption of individual record fields.
+// Each record gets its own data key, which is wrapped with the active key of the
+// keyring. Fields are sealed with AES-GCM using the field name as additional data.
+type FieldEncryptor struct {
+	keyring *Keyring
+	fields  map[string]bool
+}
+
+// DataKey is a plaintext data key together with its wrapped form.
+type DataKey struct {
+	KeyID   string
+	Key     []byte
+	Wrapped []byte
+}
+
+func NewFieldEncryptor(keyring *Keyring, fields []string) (*FieldEncryptor, error) {
+	if keyring.ActiveKeyID == "" {
+		return nil, errors.New("the keyring has no active key")
+	}
+	encryptor := &FieldEncryptor{keyring: keyring, fields: make(map[string]bool, len(fields))}
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			encryptor.fields[field] = true
+		}
+	}
+	return encryptor, nil
+}
+
+// Encrypts reports whether the given field is configured to be encrypted.
+func (e *FieldEncryptor) Encrypts(field string) bool {
+	return e.fields[field]
+}
+
+func (e *FieldEncryptor) ActiveKeyID() string {
+	return e.keyring.ActiveKeyID
+}
+
+// NewDataKey generates a data key wrapped with the active key.
+func (e *FieldEncryptor) NewDataKey() (DataKey, error) {
+	key, err := randomKey()
+	if err != nil {
+		return DataKey{}, err
+	}
+	kek, err := e.keyring.key(e.keyring.ActiveKeyID)
+	if err != nil {
+		return DataKey{}, err
+	}
+	wrapped, err := seal(kek, key, []byte(e.keyring.ActiveKeyID))
+	if err != nil {
+		return DataKey{}, fmt.Errorf("could not wrap the data key: %w", err)
+	}
+	return DataKey{KeyID: e.keyring.ActiveKeyID, Key: key, Wrapped: wrapped}, nil
+}
+
+// UnwrapDataKey recovers a data key wrapped with the given key of the keyring.
+func (e *FieldEncryptor) UnwrapDataKey(keyID string, wrapped []byte) (DataKey, error) {
+	kek, err := e.keyring.key(keyID)
+	if err != nil {
+		return DataKey{}, err
+	}
+	key, err := open(kek, wrapped, []byte(keyID))
+	if err != nil {
+		return DataKey{}, fmt.Errorf("could not unwrap the data key: %w", err)
+	}
+	return DataKey{KeyID: keyID, Key: key, Wrapped: wrapped}, nil
+}
+
+func (e *FieldEncryptor) Seal(dataKey DataKey, field string, plaintext string) (string, error) {
+	ciphertext, err := seal(dataKey.Key, []byte(plaintext), []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("could not encrypt %s: %w", field, err)
+	}
+	return sealedPrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (e *FieldEncryptor) Open(dataKey DataKey, field string, ciphertext string) (string, error) {
+	if !IsSealed(ciphertext) {
+		return "", fmt.Errorf("%s is not encrypted", field)
+	}
+	blob, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, sealedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("could not decode %s: %w", field, err)
+	}
+	plaintext, err := open(dataKey.Key, blob, []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt %s: %w", field, err)
+	}
+	return string(plaintext), nil
+}
+
+// IsSealed reports whether the value was produced by Seal.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// BlindIndex returns a keyed hash of the normalised value, so encrypted values
+// can still be looked up and checked for uniqueness.
+func (e *FieldEncryptor) BlindIndex(field string, value string) []byte {
+	mac := hmac.New(sha256.New, e.keyring.indexKey)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return mac.Sum(nil)
+}
+
+func seal(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,69 @@
+package encryption
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newTestEncryptor(t *testing.T) (*Keyring, *FieldEncryptor) {
+	t.Helper()
+	keyring, err := NewKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.AddKey("k1"); err != nil {
+		t.Fatal(err)
+	}
+	encryptor, err := NewFieldEncryptor(keyring, []string{"email", "phone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring, encryptor
+}
+
+func TestFieldEncryptor(t *testing.T) {
+	t.Run("Seal and open", func(t *testing.T) {
+		_, encryptor := newTestEncryptor(t)
+		dataKey, err := encryptor.NewDataKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext, err := encryptor.Seal(dataKey, "email", "john.doe@mail.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ciphertext == "john.doe@mail.com" {
+			t.Fatal("Value was not encrypted")
+		}
+		unwrapped, err := encryptor.UnwrapDataKey(dataKey.KeyID, dataKey.Wrapped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plaintext, err := encryptor.Open(unwrapped, "email", ciphertext)
+		if err != nil || plaintext != "john.doe@mail.com" {
+			t.Fatal("Could not decrypt the value", plaintext, err)
+		}
+		if _, err := encryptor.Open(unwrapped, "phone", ciphertext); err == nil {
+			t.Fatal("Ciphertext should be bound to its field")
+		}
+	})
+	t.Run("Old keys still unwrap after rotation", func(t *testing.T) {
+		keyring, encryptor := newTestEncryptor(t)
+		dataKey, _ := encryptor.NewDataKey()
+		if err := keyring.AddKey("k2"); err != nil {
+			t.Fatal(err)
+		}
+		if encryptor.ActiveKeyID() != "k2" {
+			t.Fatal("The new key should be active")
+		}
+		if _, err := encryptor.UnwrapDataKey("k1", dataKey.Wrapped); err != nil {
+			t.Fatal("Could not unwrap a data key of the previous key", err)
+		}
+	})
+	t.Run("Blind index is normalised", func(t *testing.T) {
+		_, encryptor := newTestEncryptor(t)
+		if !bytes.Equal(encryptor.BlindIndex("email", "John@Mail.com "), encryptor.BlindIndex("email", "john@mail.com")) {
+			t.Fatal("Blind index should ignore case and surrounding spaces")
+		}
+	})
+}
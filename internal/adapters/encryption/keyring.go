@@ -0,0 +1,122 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const keySize = 32
+
+// Keyring holds the key encryption keys used to wrap per-record data keys and
+// the key used for blind indexes. It is stored as a JSON file:
+//
+//	{"activeKeyId": "2026-01", "keys": {"2026-01": "<base64>"}, "indexKey": "<base64>"}
+type Keyring struct {
+	ActiveKeyID string            `json:"activeKeyId"`
+	Keys        map[string]string `json:"keys"`
+	IndexKey    string            `json:"indexKey"`
+
+	keys     map[string][]byte
+	indexKey []byte
+}
+
+func LoadKeyring(path string) (*Keyring, error) {
+	blob, err := os.ReadFile(path) //nolint:gosec // the path comes from the operator.
+	if err != nil {
+		return nil, fmt.Errorf("could not read the keyring: %w", err)
+	}
+	keyring := &Keyring{}
+	if err := json.Unmarshal(blob, keyring); err != nil {
+		return nil, fmt.Errorf("could not decode the keyring: %w", err)
+	}
+	if err := keyring.decode(); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+// NewKeyring returns a keyring with a fresh index key and no key encryption keys.
+func NewKeyring() (*Keyring, error) {
+	indexKey, err := randomKey()
+	if err != nil {
+		return nil, err
+	}
+	keyring := &Keyring{Keys: map[string]string{}, IndexKey: base64.StdEncoding.EncodeToString(indexKey)}
+	return keyring, keyring.decode()
+}
+
+// AddKey generates a key encryption key and makes it the active one.
+func (k *Keyring) AddKey(keyID string) error {
+	if _, ok := k.keys[keyID]; ok {
+		return fmt.Errorf("key %s already exists", keyID)
+	}
+	key, err := randomKey()
+	if err != nil {
+		return err
+	}
+	k.Keys[keyID] = base64.StdEncoding.EncodeToString(key)
+	k.keys[keyID] = key
+	k.ActiveKeyID = keyID
+	return nil
+}
+
+func (k *Keyring) Save(path string) error {
+	blob, err := json.MarshalIndent(k, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, blob, 0o600)
+}
+
+func (k *Keyring) key(keyID string) ([]byte, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %s is not in the keyring", keyID)
+	}
+	return key, nil
+}
+
+func (k *Keyring) decode() error {
+	k.keys = make(map[string][]byte, len(k.Keys))
+	for keyID, encoded := range k.Keys {
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return fmt.Errorf("invalid key %s: %w", keyID, err)
+		}
+		k.keys[keyID] = key
+	}
+	if k.ActiveKeyID != "" {
+		if _, ok := k.keys[k.ActiveKeyID]; !ok {
+			return fmt.Errorf("active key %s is not in the keyring", k.ActiveKeyID)
+		}
+	}
+	indexKey, err := decodeKey(k.IndexKey)
+	if err != nil {
+		return fmt.Errorf("invalid index key: %w", err)
+	}
+	k.indexKey = indexKey
+	return nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != keySize {
+		return nil, errors.New("keys must be 32 bytes")
+	}
+	return key, nil
+}
+
+func randomKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("could not generate a key: %w", err)
+	}
+	return key, nil
+}
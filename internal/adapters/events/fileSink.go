@@ -0,0 +1,47 @@
+// Package events holds the sinks the event dispatcher publishes the user
+// events to.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"userapi/app/internal/core/domain"
+)
+
+// FileSink appends the events to a JSON lines file. It stands in for a real
+// consumer during local testing.
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) EventSinkName() string {
+	return "file"
+}
+
+func (s *FileSink) Publish(ctx context.Context, event domain.Event) error {
+	_ = ctx
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("could not encode the event: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not open the event file: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("could not write to the event file: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,175 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"userapi/app/internal/core/domain"
+)
+
+const (
+	natsDefaultPort = "4222"
+	natsTimeout     = 10 * time.Second
+)
+
+// NATSSink publishes the events to a NATS server, on the subject of the
+// prefix followed by the event type, such as userapi.user.created. The
+// Nats-Msg-Id header carries the event id, so that a JetStream stream on the
+// subjects drops the events published twice; the NATS connector for Kafka
+// relays them from there to a Kafka topic.
+//
+// The sink speaks the client protocol itself, over one connection dialed on
+// the first event and again after an error. Every publication waits for the
+// server to answer a PING, so an event the server did not take is an error.
+type NATSSink struct {
+	addr    string
+	prefix  string
+	connect []byte
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewNATSSink returns a sink for the server at rawURL, nats://host:port, with
+// the user and password or the token of the URL.
+func NewNATSSink(rawURL string, prefix string) (*NATSSink, error) {
+	serverURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nats url: %w", err)
+	}
+	if serverURL.Scheme != "nats" || serverURL.Hostname() == "" {
+		return nil, fmt.Errorf("invalid nats url %q: want nats://host:port", serverURL.Redacted())
+	}
+	port := serverURL.Port()
+	if port == "" {
+		port = natsDefaultPort
+	}
+	options := map[string]any{"verbose": false, "pedantic": false, "headers": true, "name": "userapi", "lang": "go"}
+	if serverURL.User != nil {
+		if password, ok := serverURL.User.Password(); ok {
+			options["user"], options["pass"] = serverURL.User.Username(), password
+		} else {
+			options["auth_token"] = serverURL.User.Username()
+		}
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	return &NATSSink{
+		addr:    net.JoinHostPort(serverURL.Hostname(), port),
+		prefix:  strings.TrimSuffix(prefix, "."),
+		connect: connect,
+	}, nil
+}
+
+func (s *NATSSink) EventSinkName() string {
+	return "nats"
+}
+
+func (s *NATSSink) Publish(ctx context.Context, event domain.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("could not encode the event: %w", err)
+	}
+	header := "NATS/1.0\r\nNats-Msg-Id: " + event.EventID + "\r\n\r\n"
+	subject := string(event.Type)
+	if s.prefix != "" {
+		subject = s.prefix + "." + subject
+	}
+	message := fmt.Sprintf("HPUB %s %d %d\r\n%s%s\r\nPING\r\n", subject, len(header), len(header)+len(payload), header, payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		if err := s.dial(ctx); err != nil {
+			return fmt.Errorf("could not connect to nats: %w", err)
+		}
+	}
+	if err := s.roundTrip(ctx, message); err != nil {
+		s.close()
+		return fmt.Errorf("could not publish to nats: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection to the server.
+func (s *NATSSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.close()
+	return nil
+}
+
+func (s *NATSSink) dial(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: natsTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.conn, s.reader = conn, bufio.NewReader(conn)
+	s.setDeadline(ctx)
+	line, err := s.reader.ReadString('\n')
+	if err == nil && !strings.HasPrefix(line, "INFO ") {
+		err = fmt.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+	if err == nil && strings.Contains(line, `"tls_required":true`) {
+		err = errors.New("the server requires tls")
+	}
+	if err == nil {
+		err = s.roundTrip(ctx, "CONNECT "+string(s.connect)+"\r\nPING\r\n")
+	}
+	if err != nil {
+		s.close()
+		return err
+	}
+	return nil
+}
+
+// roundTrip sends the commands, ending with a PING, and waits for the PONG.
+func (s *NATSSink) roundTrip(ctx context.Context, commands string) error {
+	s.setDeadline(ctx)
+	if _, err := s.conn.Write([]byte(commands)); err != nil {
+		return err
+	}
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("the server answered %s", line)
+		}
+	}
+}
+
+func (s *NATSSink) setDeadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(natsTimeout)
+	}
+	_ = s.conn.SetDeadline(deadline)
+}
+
+func (s *NATSSink) close() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn, s.reader = nil, nil
+	}
+}
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"userapi/app/internal/adapters/tracing"
+	"userapi/app/internal/core/domain"
+)
+
+// responseLimit bounds the response body recorded with an attempt.
+const responseLimit = 1024
+
+// WebhookSender posts the deliveries of the webhook subscriptions, signed with
+// their secret. Redirects are not followed, so they fail the attempt, and
+// connections to addresses that are not public are refused, whatever the host
+// of the webhook resolves to when posting.
+type WebhookSender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewWebhookSender() *WebhookSender {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: dialPublic}
+	return &WebhookSender{
+		client: &http.Client{
+			// No proxy: the dialer must see the address of the webhook.
+			Transport: tracing.NewTransport(&http.Transport{
+				DialContext:         dialer.DialContext,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 10 * time.Second,
+			}),
+			Timeout: 10 * time.Second,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+func (s *WebhookSender) SendWebhook(ctx context.Context, webhook domain.Webhook, delivery domain.WebhookDelivery) domain.WebhookAttempt {
+	start := s.now()
+	attempt := domain.WebhookAttempt{AttemptedAt: start}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "userapi-webhooks")
+	request.Header.Set("X-Webhook-ID", webhook.WebhookID)
+	request.Header.Set("X-Webhook-Delivery", delivery.DeliveryID)
+	request.Header.Set("X-Event-ID", delivery.EventID)
+	request.Header.Set("X-Event-Type", string(delivery.EventType))
+	request.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(start.Unix(), 10))
+	request.Header.Set("X-Webhook-Signature", "sha256="+domain.SignWebhook(webhook.Secret, start, delivery.Payload))
+	response, err := s.client.Do(request)
+	if err != nil {
+		attempt.Duration = s.now().Sub(start)
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(response.Body, responseLimit))
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	attempt.Duration = s.now().Sub(start)
+	attempt.StatusCode = response.StatusCode
+	attempt.Response = strings.ToValidUTF8(string(body), "")
+	return attempt
+}
+
+// dialPublic refuses the connections to addresses that are not public.
+func dialPublic(network string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("could not parse the address %s: %w", address, err)
+	}
+	if !domain.IsPublicAddress(addrPort.Addr()) {
+		return fmt.Errorf("%s is not a public address", addrPort.Addr())
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"userapi/app/internal/adapters/tracing"
+	"userapi/app/internal/core/domain"
+)
+
+// WebhookSink posts every event as JSON to a URL. Any response but a 2xx is
+// an error, and the event is posted again later.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{url: url, client: &http.Client{Transport: tracing.NewTransport(nil), Timeout: 10 * time.Second}}
+}
+
+func (s *WebhookSink) EventSinkName() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, event domain.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("could not encode the event: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Event-ID", event.EventID)
+	request.Header.Set("X-Event-Type", string(event.Type))
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("the webhook answered %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxAttributeSchemaBytes bounds the size of an uploaded attribute schema.
+const maxAttributeSchemaBytes = 64 << 10
+
+// GetAttributeSchema godoc
+// @Summary Get the attribute schema of a tenant
+// @Description Returns the JSON Schema the custom attributes of the users of the tenant must satisfy. Requires the admin token.
+// @Tags admin
+// @Produce json
+// @Param tenant_id  path string true "Tenant ID"
+// @Success 200 {object} AttributeSchemaResponse
+// @Failure 404 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /admin/tenants/{tenant_id}/attribute-schema [get]
+func getAttributeSchema(service ports.TenantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := chi.URLParam(r, "tenantId")
+		schema, err := service.GetAttributeSchema(r.Context(), tenantID)
+		switch {
+		case errors.Is(err, domain.ErrNotConfigured):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("the tenant %s has no attribute schema", tenantID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the attribute schema: %w", err).Error())
+			http.Error(w, "could not retrieve the attribute schema", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, parseAttributeSchemaToDTO(schema))
+	}
+}
+
+// PutAttributeSchema godoc
+// @Summary Set the attribute schema of a tenant
+// @Description Replaces the JSON Schema the custom attributes of the users of the tenant must satisfy. The body is the schema itself, JSON Schema draft 2020-12 unless its $schema names another draft. Formats are asserted and $ref can only point inside the schema. Existing users are checked when their attributes next change. Requires the admin token.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param tenant_id  path string true "Tenant ID"
+// @Param schema body object true "JSON Schema"
+// @Success 200 {object} AttributeSchemaResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /admin/tenants/{tenant_id}/attribute-schema [put]
+func putAttributeSchema(service ports.TenantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := chi.URLParam(r, "tenantId")
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAttributeSchemaBytes))
+		if err != nil {
+			http.Error(w, fmt.Errorf("could not read the request body: %w", err).Error(), http.StatusBadRequest)
+			return
+		}
+		schema, err := service.SetAttributeSchema(r.Context(), tenantID, body)
+		switch {
+		case errors.Is(err, domain.ErrNotConfigured):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		case errors.Is(err, domain.ErrInvalidSchema):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not save the attribute schema: %w", err).Error())
+			http.Error(w, "could not save the attribute schema", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("set the attribute schema", "tenantId", tenantID)
+		writeJSON(w, http.StatusOK, parseAttributeSchemaToDTO(schema))
+	}
+}
+
+// DeleteAttributeSchema godoc
+// @Summary Delete the attribute schema of a tenant
+// @Description Removes the attribute schema, so any attributes are accepted. Requires the admin token.
+// @Tags admin
+// @Param tenant_id  path string true "Tenant ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /admin/tenants/{tenant_id}/attribute-schema [delete]
+func deleteAttributeSchema(service ports.TenantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := chi.URLParam(r, "tenantId")
+		err := service.DeleteAttributeSchema(r.Context(), tenantID)
+		switch {
+		case errors.Is(err, domain.ErrNotConfigured):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("the tenant %s has no attribute schema", tenantID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not delete the attribute schema: %w", err).Error())
+			http.Error(w, "could not delete the attribute schema", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("deleted the attribute schema", "tenantId", tenantID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SetPassword godoc
+// @Summary Set the password of a user
+// @Description Stores an argon2id hash of the password. The password must satisfy the password policy. Requires a session of the user, who must give their current password when they have one, or the admin token. Wrong current passwords count towards the login lockout.
+// @Tags auth
+// @Accept json
+// @Param user_id  path string true "User ID"
+// @Param request body SetPasswordRequest true "New password"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/password [post]
+func setPassword(service ports.CredentialService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		request := SetPasswordRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		var err error
+		if caller, _ := principalFromContext(r.Context()); caller.admin {
+			err = service.SetPassword(r.Context(), userID, request.Password)
+		} else {
+			err = service.ChangePassword(r.Context(), userID, request.CurrentPassword, request.Password)
+		}
+		switch {
+		case errors.Is(err, domain.ErrWeakPassword):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrInvalidCredentials):
+			http.Error(w, "the current password is wrong", http.StatusForbidden)
+			return
+		case errors.Is(err, domain.ErrLockedOut):
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not set the password: %w", err).Error())
+			http.Error(w, "could not set the password", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Login godoc
+// @Summary Log in with email and password
+// @Description Returns a signed session token. Users with an active second factor get an MFA token for /auth/login:mfa instead. Five failed attempts lock the account for 15 minutes.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body LoginRequest true "Credentials"
+// @Success 200 {object} SessionResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/login [post]
+func login(service ports.CredentialService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := LoginRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		session, err := service.Login(r.Context(), request.Email, request.Password)
+		switch {
+		case errors.Is(err, domain.ErrInvalidCredentials):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		case errors.Is(err, domain.ErrLockedOut):
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not log in: %w", err).Error())
+			http.Error(w, "could not log in", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, parseSessionToSessionDTO(session))
+	}
+}
+
+// RequestPasswordReset godoc
+// @Summary Request a password reset
+// @Description Emails a password reset link when a user has the email. The response does not reveal whether it does.
+// @Tags auth
+// @Accept json
+// @Param request body PasswordResetRequest true "Email"
+// @Success 202
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /auth/password-reset [post]
+func requestPasswordReset(service ports.CredentialService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := PasswordResetRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		err := service.RequestPasswordReset(r.Context(), request.Email)
+		switch {
+		case errors.Is(err, domain.ErrNotConfigured):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not request the password reset: %w", err).Error())
+			http.Error(w, "could not request the password reset", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("if the email is registered, a reset link has been sent"))
+	}
+}
+
+// ConfirmPasswordReset godoc
+// @Summary Reset a password
+// @Description Sets a new password with the token from the reset email. The token works once and also lifts a lockout.
+// @Tags auth
+// @Accept json
+// @Param request body ConfirmPasswordResetRequest true "Token and new password"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/password-reset:confirm [post]
+func confirmPasswordReset(service ports.CredentialService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := ConfirmPasswordResetRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		err := service.ResetPassword(r.Context(), request.Token, request.Password)
+		switch {
+		case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrNotFound):
+			logging.FromContext(r.Context()).Info(fmt.Errorf("rejected a password reset: %w", err).Error())
+			http.Error(w, domain.ErrInvalidToken.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrWeakPassword):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not reset the password: %w", err).Error())
+			http.Error(w, "could not reset the password", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// decodeRequest decodes and validates a JSON body, answering 400 when either fails.
+func decodeRequest(w http.ResponseWriter, r *http.Request, validator ports.Validator, request any) bool {
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		http.Error(w, fmt.Errorf("could not decode the request body: %w", err).Error(), http.StatusBadRequest)
+		return false
+	}
+	if err := validator.Struct(request); err != nil {
+		http.Error(w, fmt.Errorf("could not validate the request: %w", err).Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	purposeClaim  = "purpose"
+	subjectClaim  = "sub"
+	authTimeClaim = "auth_time"
+	issuedAtClaim = "iat"
+	// sessionPurpose and mfaLoginPurpose are the purposes of the tokens
+	// accepted as bearer tokens, issued on login.
+	sessionPurpose  = "session"
+	mfaLoginPurpose = "mfa_login"
+)
+
+// principal is the authenticated caller of a request: the admin, presenting
+// the admin token, or a user, presenting a token issued on login. Anonymous
+// requests have none.
+type principal struct {
+	admin     bool
+	userID    string
+	tenantID  string
+	sessionID string
+	// purpose is the purpose claim of the token of the user, session or
+	// mfa_login while the second factor is pending.
+	purpose string
+	// authTime is when the user last entered their credentials.
+	authTime time.Time
+}
+
+type principalKey struct{}
+
+func contextWithPrincipal(ctx context.Context, caller principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, caller)
+}
+
+// principalFromContext returns the caller of the request, false for anonymous
+// requests.
+func principalFromContext(ctx context.Context) (principal, bool) {
+	caller, ok := ctx.Value(principalKey{}).(principal)
+	return caller, ok
+}
+
+// authenticate puts the caller presenting a bearer token into the request
+// context. Requests without an Authorization header go through anonymous,
+// the ones with a token that is neither the admin token nor a valid session
+// or MFA login token are rejected.
+func authenticate(signer ports.TokenSigner, adminToken string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization := r.Header.Get("Authorization")
+			if authorization == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			presented, ok := strings.CutPrefix(authorization, bearerAuthPrefix)
+			if !ok {
+				unauthorized(w, "unsupported authorization scheme")
+				return
+			}
+			if adminToken != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(adminToken)) == 1 {
+				next.ServeHTTP(w, r.WithContext(contextWithPrincipal(r.Context(), principal{admin: true})))
+				return
+			}
+			if signer == nil {
+				unauthorized(w, "invalid bearer token")
+				return
+			}
+			claims, err := signer.Verify(presented)
+			if err != nil {
+				unauthorized(w, fmt.Errorf("invalid bearer token: %w", err).Error())
+				return
+			}
+			caller, err := principalFromClaims(claims)
+			if err != nil {
+				unauthorized(w, fmt.Errorf("invalid bearer token: %w", err).Error())
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(contextWithPrincipal(r.Context(), caller)))
+		})
+	}
+}
+
+func principalFromClaims(claims map[string]any) (principal, error) {
+	caller := principal{}
+	caller.purpose, _ = claims[purposeClaim].(string)
+	caller.userID, _ = claims[subjectClaim].(string)
+	caller.tenantID, _ = claims[tenantClaim].(string)
+	caller.sessionID, _ = claims[sessionClaim].(string)
+	if caller.purpose != sessionPurpose && caller.purpose != mfaLoginPurpose {
+		return principal{}, fmt.Errorf("a %q token is not a bearer token", caller.purpose)
+	}
+	if caller.userID == "" || caller.tenantID == "" {
+		return principal{}, fmt.Errorf("the token has no %s or %s claim", subjectClaim, tenantClaim)
+	}
+	authTime, ok := claims[authTimeClaim].(float64)
+	if !ok {
+		authTime, _ = claims[issuedAtClaim].(float64)
+	}
+	caller.authTime = time.Unix(int64(authTime), 0)
+	return caller, nil
+}
+
+// requireAdmin only lets the admin through.
+func requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, ok := principalFromContext(r.Context())
+		switch {
+		case !ok:
+			unauthorized(w, "authentication required")
+		case !caller.admin:
+			http.Error(w, "only the admin can do this", http.StatusForbidden)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// requireUser lets the admin through, and the user named by the userId path
+// parameter with a session token.
+func requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, ok := principalFromContext(r.Context())
+		switch {
+		case !ok:
+			unauthorized(w, "authentication required")
+		case caller.admin:
+			next.ServeHTTP(w, r)
+		case caller.purpose != sessionPurpose || caller.userID != chi.URLParam(r, "userId"):
+			http.Error(w, "only the user or the admin can do this", http.StatusForbidden)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// requireLoginOf lets through the admin and the user named by the userId path
+// parameter, with a session token or the MFA token of a login waiting for the
+// second factor.
+func requireLoginOf(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, ok := principalFromContext(r.Context())
+		switch {
+		case !ok:
+			unauthorized(w, "authentication required")
+		case caller.admin:
+			next.ServeHTTP(w, r)
+		case caller.userID != chi.URLParam(r, "userId"):
+			http.Error(w, "only the user or the admin can do this", http.StatusForbidden)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// requireRecentLogin makes users who logged in more than maxAge ago log in
+// again, answering with the step up challenge of RFC 9470. It goes after
+// requireUser, the admin is let through.
+func requireRecentLogin(maxAge time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller, _ := principalFromContext(r.Context())
+			if !caller.admin && time.Since(caller.authTime) > maxAge {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_user_authentication", error_description="log in again", max_age=%d`, int(maxAge.Seconds())))
+				http.Error(w, "log in again to do this", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// unauthorized answers 401 with the Bearer challenge.
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	http.Error(w, message, http.StatusUnauthorized)
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"userapi/app/internal/adapters/token"
+	"userapi/app/internal/core/domain"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	testUserID      = "5d41402a-bc4b-4a76-b971-9d911017c592"
+	testOtherTenant = "0cc175b9-c0f1-4b6a-831c-399e26977266"
+)
+
+// userToken returns a token of the test user in the test tenant.
+func userToken(t *testing.T, signer *token.HMACSigner, purpose string) string {
+	t.Helper()
+	signed, err := signer.Sign(map[string]any{
+		purposeClaim:  purpose,
+		subjectClaim:  testUserID,
+		tenantClaim:   testTenantID,
+		authTimeClaim: time.Now().Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// newAuthRouter mounts the authentication and tenant middleware of the API
+// routes in front of a user route and an admin route answering with the
+// tenant of the request.
+func newAuthRouter(signer *token.HMACSigner, defaultSlug string) http.Handler {
+	router := chi.NewRouter()
+	router.Use(authenticate(signer, testAdminToken))
+	router.Use(resolveTenant(nil, defaultSlug))
+	tenant := func(w http.ResponseWriter, r *http.Request) {
+		tenantID, _ := domain.TenantFromContext(r.Context())
+		_, _ = w.Write([]byte(tenantID))
+	}
+	router.With(requireUser).Get("/users/{userId}", tenant)
+	router.With(requireAdmin).Get("/users", tenant)
+	return router
+}
+
+func TestAuthentication(t *testing.T) {
+	signer := token.NewHMACSigner([]byte("test-secret"))
+	router := newAuthRouter(signer, "")
+	session := bearerAuthPrefix + userToken(t, signer, sessionPurpose)
+	tests := []struct {
+		name          string
+		target        string
+		authorization string
+		tenant        string
+		status        int
+		// body is the tenant the route ran in, for the allowed requests.
+		body string
+	}{
+		{"no token on a user route", "/users/" + testUserID, "", testTenantID, http.StatusUnauthorized, ""},
+		{"no token on an admin route", "/users", "", testTenantID, http.StatusUnauthorized, ""},
+		{"unsupported scheme", "/users", "Basic YWRtaW46YWRtaW4=", testTenantID, http.StatusUnauthorized, ""},
+		{"forged token", "/users/" + testUserID, bearerAuthPrefix + userToken(t, token.NewHMACSigner([]byte("other")), sessionPurpose), "", http.StatusUnauthorized, ""},
+		{"user token on an admin route", "/users", session, "", http.StatusForbidden, ""},
+		{"user token on another user", "/users/" + testOtherTenant, session, "", http.StatusForbidden, ""},
+		{"MFA login token on a user route", "/users/" + testUserID, bearerAuthPrefix + userToken(t, signer, mfaLoginPurpose), "", http.StatusForbidden, ""},
+		{"user token on the user", "/users/" + testUserID, session, "", http.StatusOK, testTenantID},
+		{"user token with its tenant header", "/users/" + testUserID, session, testTenantID, http.StatusOK, testTenantID},
+		{"user token with a wrong tenant header", "/users/" + testUserID, session, testOtherTenant, http.StatusBadRequest, ""},
+		{"admin token on an admin route", "/users", bearerAuthPrefix + testAdminToken, testOtherTenant, http.StatusOK, testOtherTenant},
+		{"admin token on a user route", "/users/" + testUserID, bearerAuthPrefix + testAdminToken, testTenantID, http.StatusOK, testTenantID},
+		{"admin token with an invalid tenant header", "/users", bearerAuthPrefix + testAdminToken, "acme", http.StatusBadRequest, ""},
+		{"admin token without a tenant", "/users", bearerAuthPrefix + testAdminToken, "", http.StatusBadRequest, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, test.target, nil)
+			if test.authorization != "" {
+				request.Header.Set("Authorization", test.authorization)
+			}
+			if test.tenant != "" {
+				request.Header.Set(tenantHeader, test.tenant)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != test.status {
+				t.Fatalf("expected status %d, got %d: %s", test.status, recorder.Code, recorder.Body.String())
+			}
+			if recorder.Code == http.StatusUnauthorized && recorder.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected a WWW-Authenticate challenge")
+			}
+			if test.body != "" && recorder.Body.String() != test.body {
+				t.Errorf("expected the tenant %s, got %s", test.body, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestTenantFromRequest(t *testing.T) {
+	admin := principal{admin: true}
+	user := principal{userID: testUserID, tenantID: testTenantID, purpose: sessionPurpose}
+	tests := []struct {
+		name     string
+		caller   *principal
+		host     string
+		header   string
+		tenantID string
+		slug     string
+		fails    bool
+	}{
+		{"anonymous on a subdomain", nil, "acme.users.example.com", "", "", "acme", false},
+		{"anonymous on an IP address", nil, "127.0.0.1:8080", "", "", "", false},
+		{"admin with a header", &admin, "acme.users.example.com", testOtherTenant, testOtherTenant, "", false},
+		{"admin with an invalid header", &admin, "localhost", "acme", "", "", true},
+		{"user", &user, "acme.users.example.com", "", testTenantID, "", false},
+		{"user with a wrong header", &user, "localhost", testOtherTenant, "", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/users", nil)
+			request.Host = test.host
+			if test.header != "" {
+				request.Header.Set(tenantHeader, test.header)
+			}
+			if test.caller != nil {
+				request = request.WithContext(contextWithPrincipal(request.Context(), *test.caller))
+			}
+			tenantID, slug, err := tenantFromRequest(request)
+			if (err != nil) != test.fails {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tenantID != test.tenantID || slug != test.slug {
+				t.Errorf("expected %q %q, got %q %q", test.tenantID, test.slug, tenantID, slug)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultAvatarMaxBytes limits the size of avatar uploads when
+// Server.AvatarMaxBytes is not set.
+const defaultAvatarMaxBytes = 5 << 20
+
+// avatarCacheControl lets clients keep an avatar for a few minutes and then
+// revalidate it with its ETag, since a new upload keeps the same URL.
+const avatarCacheControl = "private, max-age=300, must-revalidate"
+
+// PutAvatar godoc
+// @Summary Upload the avatar of a user
+// @Description Replaces the avatar of a user with a JPEG, PNG or WebP image, sent as the request body or as the "file" field of a multipart form. The format is detected from the content. Metadata such as EXIF is removed, and square thumbnails are made from JPEG and PNG images. Requires a session of the user or the admin token.
+// @Tags users
+// @Accept image/jpeg
+// @Accept image/png
+// @Accept image/webp
+// @Accept multipart/form-data
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Success 200 {object} AvatarResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/avatar [put]
+func putAvatar(service ports.AvatarService, maxBytes int64) http.HandlerFunc {
+	if maxBytes <= 0 {
+		maxBytes = defaultAvatarMaxBytes
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		data, err := readAvatarUpload(w, r, maxBytes)
+		var maxBytesError *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesError):
+			http.Error(w, fmt.Sprintf("the image is larger than %d bytes", maxBytes), http.StatusRequestEntityTooLarge)
+			return
+		case err != nil:
+			http.Error(w, fmt.Errorf("could not read the image: %w", err).Error(), http.StatusBadRequest)
+			return
+		}
+		avatar, err := service.SetAvatar(r.Context(), userID, data)
+		switch {
+		case errors.Is(err, domain.ErrUnsupportedImage):
+			http.Error(w, fmt.Sprintf("%s, use JPEG, PNG or WebP", err), http.StatusUnsupportedMediaType)
+			return
+		case errors.Is(err, domain.ErrInvalidImage):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not store the avatar: %w", err).Error())
+			http.Error(w, "could not store the avatar", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("stored an avatar", "userId", userID, "contentType", avatar.ContentType)
+		writeJSON(w, http.StatusOK, parseAvatarToDTO(avatar))
+	}
+}
+
+// readAvatarUpload returns the image sent as the body, or as the file field of
+// a multipart form, reading at most maxBytes.
+func readAvatarUpload(w http.ResponseWriter, r *http.Request, maxBytes int64) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return io.ReadAll(r.Body)
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New(`the form has no "file" field`)
+			}
+			return nil, err
+		}
+		if part.FormName() == "file" {
+			return io.ReadAll(part)
+		}
+	}
+}
+
+// GetAvatar godoc
+// @Summary Get the avatar of a user
+// @Description Returns the smallest thumbnail at least size pixels wide, or the full image without a size or when no thumbnail is large enough. Responses carry an ETag and can be revalidated with If-None-Match. Requires a session of the user or the admin token.
+// @Tags users
+// @Produce image/jpeg
+// @Produce image/png
+// @Produce image/webp
+// @Param user_id  path string true "User ID"
+// @Param size  query int false "Width in pixels"
+// @Success 200 {file} binary
+// @Success 304
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/avatar [get]
+func getAvatar(service ports.AvatarService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		size := 0
+		if value := r.URL.Query().Get("size"); value != "" {
+			var err error
+			if size, err = strconv.Atoi(value); err != nil || size <= 0 {
+				http.Error(w, "size must be a positive number of pixels", http.StatusBadRequest)
+				return
+			}
+		}
+		blob, err := service.GetAvatar(r.Context(), userID, size)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("user %s has no avatar", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the avatar: %w", err).Error())
+			http.Error(w, "could not retrieve the avatar", http.StatusInternalServerError)
+			return
+		}
+		hash := sha256.Sum256(blob.Data)
+		w.Header().Set("Content-Type", blob.ContentType)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(hash[:16])+`"`)
+		w.Header().Set("Cache-Control", avatarCacheControl)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, r, "", blob.ModTime, bytes.NewReader(blob.Data))
+	}
+}
+
+// DeleteAvatar godoc
+// @Summary Remove the avatar of a user
+// @Description Removes the avatar of a user and its thumbnails. Requires a session of the user or the admin token.
+// @Tags users
+// @Param user_id  path string true "User ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/avatar [delete]
+func deleteAvatar(service ports.AvatarService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		err := service.DeleteAvatar(r.Context(), userID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not delete the avatar: %w", err).Error())
+			http.Error(w, "could not delete the avatar", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("deleted an avatar", "userId", userID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
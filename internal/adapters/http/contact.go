@@ -0,0 +1,280 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetContactMethods godoc
+// @Summary Get the emails or phones of a user
+// @Description Lists the email addresses or phone numbers of a user, the primary one first. The primary one is the email or phone of the user. Requires a session of the user or the admin token.
+// @Tags users
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Success 200 {array} ContactMethodResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/emails [get]
+// @Router /users/{user_id}/phones [get]
+func getContactMethods(service ports.ContactService, kind domain.ContactKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		contacts, err := service.GetContactMethods(r.Context(), userID, kind)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the contact methods: %w", err).Error())
+			http.Error(w, "could not retrieve the contact methods", http.StatusInternalServerError)
+			return
+		}
+		contactDTOs := make([]ContactMethodResponse, len(contacts))
+		for i, contact := range contacts {
+			contactDTOs[i] = parseContactMethodToDTO(contact)
+		}
+		writeJSON(w, http.StatusOK, contactDTOs)
+	}
+}
+
+// PostContactMethod godoc
+// @Summary Add an email or a phone to a user
+// @Description Adds an email address, or a phone number in E.164 format. The first one of its kind, or one marked primary, becomes the email or phone of the user. Requires a session of the user or the admin token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Param request body ContactMethodRequest true "Contact method"
+// @Success 201 {object} ContactMethodResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/emails [post]
+// @Router /users/{user_id}/phones [post]
+func postContactMethod(service ports.ContactService, validator ports.Validator, kind domain.ContactKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		request := ContactMethodRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		contact, err := service.AddContactMethod(r.Context(), userID, domain.ContactMethod{
+			Kind:    kind,
+			Label:   request.Label,
+			Value:   request.Value,
+			Primary: request.Primary,
+		})
+		if !handleContactError(w, r, err, fmt.Sprintf("could not retrieve the user %s", userID), "could not add the contact method") {
+			return
+		}
+		logging.FromContext(r.Context()).Info("added a contact method", "userId", userID, "contactId", contact.ContactID, "kind", string(kind))
+		writeJSON(w, http.StatusCreated, parseContactMethodToDTO(contact))
+	}
+}
+
+// PatchContactMethod godoc
+// @Summary Update an email or a phone of a user
+// @Description Changes the label or the value of a contact method, or makes it primary. Changing the primary one changes the email or phone of the user. Requires a session of the user or the admin token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Param contact_id  path string true "Contact method ID"
+// @Param request body UpdateContactMethodRequest true "Changes"
+// @Success 200 {object} ContactMethodResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/emails/{contact_id} [patch]
+// @Router /users/{user_id}/phones/{contact_id} [patch]
+func patchContactMethod(service ports.ContactService, validator ports.Validator, kind domain.ContactKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		contactID := chi.URLParam(r, "contactId")
+		request := UpdateContactMethodRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		contact, err := service.UpdateContactMethod(r.Context(), userID, domain.ContactMethod{
+			ContactID: contactID,
+			Kind:      kind,
+			Label:     request.Label,
+			Value:     request.Value,
+			Primary:   request.Primary,
+		})
+		if !handleContactError(w, r, err, fmt.Sprintf("could not retrieve the contact method %s", contactID), "could not update the contact method") {
+			return
+		}
+		logging.FromContext(r.Context()).Info("updated a contact method", "userId", userID, "contactId", contactID, "kind", string(kind))
+		writeJSON(w, http.StatusOK, parseContactMethodToDTO(contact))
+	}
+}
+
+// DeleteContactMethod godoc
+// @Summary Remove an email or a phone from a user
+// @Description Removes a contact method. The primary one cannot be removed; make another one primary first. Requires a session of the user or the admin token.
+// @Tags users
+// @Param user_id  path string true "User ID"
+// @Param contact_id  path string true "Contact method ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/emails/{contact_id} [delete]
+// @Router /users/{user_id}/phones/{contact_id} [delete]
+func deleteContactMethod(service ports.ContactService, kind domain.ContactKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		contactID := chi.URLParam(r, "contactId")
+		err := service.DeleteContactMethod(r.Context(), userID, kind, contactID)
+		if !handleContactError(w, r, err, fmt.Sprintf("could not retrieve the contact method %s", contactID), "could not delete the contact method") {
+			return
+		}
+		logging.FromContext(r.Context()).Info("deleted a contact method", "userId", userID, "contactId", contactID, "kind", string(kind))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetPostalAddresses godoc
+// @Summary Get the postal addresses of a user
+// @Description Lists the postal addresses of a user, the primary one first. Requires a session of the user or the admin token.
+// @Tags users
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Success 200 {array} PostalAddressResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/addresses [get]
+func getPostalAddresses(service ports.ContactService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		addresses, err := service.GetPostalAddresses(r.Context(), userID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the postal addresses: %w", err).Error())
+			http.Error(w, "could not retrieve the postal addresses", http.StatusInternalServerError)
+			return
+		}
+		addressDTOs := make([]PostalAddressResponse, len(addresses))
+		for i, address := range addresses {
+			addressDTOs[i] = parsePostalAddressToDTO(address)
+		}
+		writeJSON(w, http.StatusOK, addressDTOs)
+	}
+}
+
+// PostPostalAddress godoc
+// @Summary Add a postal address to a user
+// @Description Adds a postal address. The country is an ISO 3166-1 alpha-2 code; the postal code must follow its format, and some countries require a region or a postal code. The first address, or one marked primary, becomes the primary one. Requires a session of the user or the admin token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Param request body PostalAddressRequest true "Postal address"
+// @Success 201 {object} PostalAddressResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/addresses [post]
+func postPostalAddress(service ports.ContactService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		request := PostalAddressRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		address, err := service.AddPostalAddress(r.Context(), userID, request.getPostalAddress())
+		if !handleContactError(w, r, err, fmt.Sprintf("could not retrieve the user %s", userID), "could not add the postal address") {
+			return
+		}
+		logging.FromContext(r.Context()).Info("added a postal address", "userId", userID, "addressId", address.AddressID)
+		writeJSON(w, http.StatusCreated, parsePostalAddressToDTO(address))
+	}
+}
+
+// PatchPostalAddress godoc
+// @Summary Update a postal address of a user
+// @Description Changes the fields given, or makes the address primary. The whole address is checked again. Requires a session of the user or the admin token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Param address_id  path string true "Address ID"
+// @Param request body UpdatePostalAddressRequest true "Changes"
+// @Success 200 {object} PostalAddressResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/addresses/{address_id} [patch]
+func patchPostalAddress(service ports.ContactService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		addressID := chi.URLParam(r, "addressId")
+		request := UpdatePostalAddressRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		update := request.getPostalAddress()
+		update.AddressID = addressID
+		address, err := service.UpdatePostalAddress(r.Context(), userID, update)
+		if !handleContactError(w, r, err, fmt.Sprintf("could not retrieve the postal address %s", addressID), "could not update the postal address") {
+			return
+		}
+		logging.FromContext(r.Context()).Info("updated a postal address", "userId", userID, "addressId", addressID)
+		writeJSON(w, http.StatusOK, parsePostalAddressToDTO(address))
+	}
+}
+
+// DeletePostalAddress godoc
+// @Summary Remove a postal address from a user
+// @Description Removes a postal address. When it was the primary one, the oldest remaining address becomes primary. Requires a session of the user or the admin token.
+// @Tags users
+// @Param user_id  path string true "User ID"
+// @Param address_id  path string true "Address ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/addresses/{address_id} [delete]
+func deletePostalAddress(service ports.ContactService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		addressID := chi.URLParam(r, "addressId")
+		err := service.DeletePostalAddress(r.Context(), userID, addressID)
+		if !handleContactError(w, r, err, fmt.Sprintf("could not retrieve the postal address %s", addressID), "could not delete the postal address") {
+			return
+		}
+		logging.FromContext(r.Context()).Info("deleted a postal address", "userId", userID, "addressId", addressID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleContactError writes the response for an error of the contact service
+// and reports whether there was none.
+func handleContactError(w http.ResponseWriter, r *http.Request, err error, notFound string, failure string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, domain.ErrInvalidContact), errors.Is(err, domain.ErrInvalidAddress):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrPrimaryContact), errors.Is(err, domain.ErrAlreadyErased):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, notFound, http.StatusNotFound)
+	default:
+		logging.FromContext(r.Context()).Error(fmt.Errorf("%s: %w", failure, err).Error())
+		http.Error(w, failure, http.StatusInternalServerError)
+	}
+	return false
+}
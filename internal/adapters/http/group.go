@@ -0,0 +1,336 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// PostGroup godoc
+// @Summary Create a group
+// @Description Creates an empty group. Requires the admin token.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param request body CreateGroupRequest true "Group"
+// @Success 201 {object} GroupResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups [post]
+func postGroup(service ports.GroupService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := CreateGroupRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		group, err := service.CreateGroup(r.Context(), domain.Group{Name: request.Name, Description: request.Description})
+		if err != nil {
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not create the group: %w", err).Error())
+			http.Error(w, "could not create the group", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("created a group", "groupId", group.GroupID)
+		writeJSON(w, http.StatusCreated, parseGroupToDTO(group))
+	}
+}
+
+// GetGroups godoc
+// @Summary Get all groups
+// @Description Lists the groups by name, without their members. Requires the admin token.
+// @Tags groups
+// @Produce json
+// @Success 200 {array} GroupResponse
+// @Failure 500 {object} map[string]string
+// @Router /groups [get]
+func getGroups(service ports.GroupService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groups, err := service.GetGroups(r.Context())
+		if err != nil {
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the groups: %w", err).Error())
+			http.Error(w, "could not retrieve the groups", http.StatusInternalServerError)
+			return
+		}
+		groupDTOs := make([]GroupResponse, len(groups))
+		for i, group := range groups {
+			groupDTOs[i] = parseGroupToDTO(group)
+		}
+		writeJSON(w, http.StatusOK, groupDTOs)
+	}
+}
+
+// GetGroup godoc
+// @Summary Get a group
+// @Description Returns a group with its direct members, users and nested groups. Requires the admin token.
+// @Tags groups
+// @Produce json
+// @Param group_id  path string true "Group ID"
+// @Success 200 {object} GroupResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{group_id} [get]
+func getGroup(service ports.GroupService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		group, err := service.GetGroup(r.Context(), groupID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the group %s", groupID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the group: %w", err).Error())
+			http.Error(w, "could not retrieve the group", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, parseGroupToDTO(group))
+	}
+}
+
+// PatchGroup godoc
+// @Summary Update a group
+// @Description Changes the name or the description of a group. Fields left out are kept. Requires the admin token.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param group_id  path string true "Group ID"
+// @Param request body GroupRequest true "Group"
+// @Success 200 {object} GroupResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{group_id} [patch]
+func patchGroup(service ports.GroupService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		request := GroupRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		group, err := service.UpdateGroup(r.Context(), groupID, domain.Group{Name: request.Name, Description: request.Description})
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the group %s", groupID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not update the group: %w", err).Error())
+			http.Error(w, "could not update the group", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("updated a group", "groupId", groupID)
+		writeJSON(w, http.StatusOK, parseGroupToDTO(group))
+	}
+}
+
+// DeleteGroup godoc
+// @Summary Delete a group
+// @Description Deletes a group. Its members are kept, and it is removed from the groups it was nested in. Requires the admin token.
+// @Tags groups
+// @Param group_id  path string true "Group ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{group_id} [delete]
+func deleteGroup(service ports.GroupService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		err := service.DeleteGroup(r.Context(), groupID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the group %s", groupID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not delete the group: %w", err).Error())
+			http.Error(w, "could not delete the group", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("deleted a group", "groupId", groupID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetGroupMembers godoc
+// @Summary Get the effective members of a group
+// @Description Lists the users who are members of the group, directly or through nested groups. Requires the admin token.
+// @Tags groups
+// @Produce json
+// @Param group_id  path string true "Group ID"
+// @Success 200 {array} UserResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{group_id}/members [get]
+func getGroupMembers(service ports.GroupService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		users, err := service.GetGroupMembers(r.Context(), groupID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the group %s", groupID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the group members: %w", err).Error())
+			http.Error(w, "could not retrieve the group members", http.StatusInternalServerError)
+			return
+		}
+		userDTOs := make([]UserResponse, len(users))
+		for i, user := range users {
+			userDTOs[i] = parseUserToUserDTO(user)
+		}
+		writeJSON(w, http.StatusOK, userDTOs)
+	}
+}
+
+// PutGroupMember godoc
+// @Summary Add a user to a group
+// @Description Adds a user to a group. Adding a member again does nothing. Requires the admin token.
+// @Tags groups
+// @Param group_id  path string true "Group ID"
+// @Param user_id  path string true "User ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{group_id}/members/{user_id} [put]
+func putGroupMember(service ports.GroupService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		userID := chi.URLParam(r, "userId")
+		err := service.AddGroupUser(r.Context(), groupID, userID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the group %s or the user %s", groupID, userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not add the group member: %w", err).Error())
+			http.Error(w, "could not add the group member", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("added a group member", "groupId", groupID, "userId", userID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeleteGroupMember godoc
+// @Summary Remove a user from a group
+// @Description Removes a direct member from a group. The user stays a member through any nested group. Requires the admin token.
+// @Tags groups
+// @Param group_id  path string true "Group ID"
+// @Param user_id  path string true "User ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{group_id}/members/{user_id} [delete]
+func deleteGroupMember(service ports.GroupService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		userID := chi.URLParam(r, "userId")
+		err := service.RemoveGroupUser(r.Context(), groupID, userID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("the user %s is not a member of the group %s", userID, groupID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not remove the group member: %w", err).Error())
+			http.Error(w, "could not remove the group member", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("removed a group member", "groupId", groupID, "userId", userID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PutSubgroup godoc
+// @Summary Nest a group in another
+// @Description Makes a group a member of another, so its users become members of that group as well. A group cannot end up containing itself. Requires the admin token.
+// @Tags groups
+// @Param group_id  path string true "Group ID"
+// @Param member_group_id  path string true "ID of the group to nest"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{group_id}/groups/{member_group_id} [put]
+func putSubgroup(service ports.GroupService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		memberGroupID := chi.URLParam(r, "memberGroupId")
+		err := service.AddSubgroup(r.Context(), groupID, memberGroupID)
+		switch {
+		case errors.Is(err, domain.ErrGroupCycle):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the group %s or the group %s", groupID, memberGroupID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not nest the group: %w", err).Error())
+			http.Error(w, "could not nest the group", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("nested a group", "groupId", groupID, "memberGroupId", memberGroupID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeleteSubgroup godoc
+// @Summary Remove a nested group
+// @Description Removes a group from the groups directly nested in another. Requires the admin token.
+// @Tags groups
+// @Param group_id  path string true "Group ID"
+// @Param member_group_id  path string true "ID of the nested group"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{group_id}/groups/{member_group_id} [delete]
+func deleteSubgroup(service ports.GroupService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		memberGroupID := chi.URLParam(r, "memberGroupId")
+		err := service.RemoveSubgroup(r.Context(), groupID, memberGroupID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("the group %s is not nested in the group %s", memberGroupID, groupID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not remove the nested group: %w", err).Error())
+			http.Error(w, "could not remove the nested group", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("removed a nested group", "groupId", groupID, "memberGroupId", memberGroupID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetUserGroups godoc
+// @Summary Get the groups of a user
+// @Description Lists the groups the user belongs to, directly or through nested groups, by name. Requires a session of the user or the admin token.
+// @Tags users
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Success 200 {array} UserGroupResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/groups [get]
+func getUserGroups(service ports.GroupService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		memberships, err := service.GetUserGroups(r.Context(), userID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the groups of the user: %w", err).Error())
+			http.Error(w, "could not retrieve the groups of the user", http.StatusInternalServerError)
+			return
+		}
+		groupDTOs := make([]UserGroupResponse, len(memberships))
+		for i, membership := range memberships {
+			groupDTOs[i] = parseGroupMembershipToDTO(membership)
+		}
+		writeJSON(w, http.StatusOK, groupDTOs)
+	}
+}
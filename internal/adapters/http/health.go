@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"userapi/app/internal/core/ports"
+)
+
+// healthCheckTimeout bounds each check, so that a hung dependency fails its
+// check instead of the probe.
+const healthCheckTimeout = 2 * time.Second
+
+// runHealthChecks runs the checks concurrently and reports them in order.
+func runHealthChecks(ctx context.Context, checkers []ports.HealthChecker) []HealthCheckResponse {
+	results := make([]HealthCheckResponse, len(checkers))
+	var wait sync.WaitGroup
+	for index, checker := range checkers {
+		wait.Go(func() {
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			err := checker.CheckHealth(checkCtx)
+			results[index] = HealthCheckResponse{Name: checker.HealthCheckName(), Status: "ok", LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				results[index].Status = "failing"
+				results[index].Error = err.Error()
+			}
+		})
+	}
+	wait.Wait()
+	return results
+}
+
+// GetLivez godoc
+//
+//	@Summary		Liveness probe
+//	@Description	Succeeds while the process can serve requests, whatever the state of its dependencies.
+//	@Tags health
+//	@Produce		plain
+//	@Success		200	{string}	string	"ok"
+//	@Router			/livez [get]
+func getLivez(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// GetReadyz godoc
+//
+//	@Summary		Readiness probe
+//	@Description	Succeeds when every dependency check passes. It fails as soon as the server starts shutting down, so that load balancers stop sending it requests before it stops accepting them.
+//	@Tags health
+//	@Produce		plain
+//	@Success		200	{string}	string	"ok"
+//	@Failure		503	{string}	string	"the failing checks"
+//	@Router			/readyz [get]
+func getReadyz(server *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if server.draining.Load() {
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		for _, result := range runHealthChecks(r.Context(), server.HealthCheckers) {
+			if result.Status != "ok" {
+				http.Error(w, result.Name+" is failing", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		getLivez(w, r)
+	}
+}
+
+// GetHealthz godoc
+//
+//	@Summary		Health of the dependencies
+//	@Description	Runs every dependency check. With the verbose parameter, the status and latency of each check are returned as JSON; error details are included, so keep this route off the public ingress.
+//	@Tags health
+//	@Produce		plain
+//	@Produce		json
+//	@Param			verbose	query	bool	false	"Report each check"
+//	@Success		200	{object}	HealthResponse
+//	@Failure		503	{object}	HealthResponse
+//	@Router			/healthz [get]
+func getHealthz(server *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := HealthResponse{Status: "ok", Checks: runHealthChecks(r.Context(), server.HealthCheckers)}
+		if server.draining.Load() {
+			response.Status = "draining"
+		}
+		for _, check := range response.Checks {
+			if check.Status != "ok" {
+				response.Status = "failing"
+			}
+		}
+		status := http.StatusOK
+		if response.Status != "ok" {
+			status = http.StatusServiceUnavailable
+		}
+		if !r.URL.Query().Has("verbose") {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(response.Status + "\n"))
+			return
+		}
+		writeJSON(w, status, response)
+	}
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ActivateUser godoc
+// @Summary Activate a user
+// @Description Moves a pending or locked user to active. Requires the admin token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Param request body StatusChangeRequest false "Reason"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /users/{user_id}:activate [post]
+func activateUser(service ports.UserService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := StatusChangeRequest{}
+		if r.ContentLength != 0 && !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		changeStatus(w, r, func(ctx context.Context, userID string) (domain.User, error) {
+			return service.ActivateUser(ctx, userID, request.Reason)
+		})
+	}
+}
+
+// SuspendUser godoc
+// @Summary Suspend a user
+// @Description Suspends an active user with a reason, optionally until a given time. The sessions of the user are revoked. Requires the admin token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Param request body SuspendUserRequest true "Reason and end of the suspension"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /users/{user_id}:suspend [post]
+func suspendUser(service ports.UserService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := SuspendUserRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		var until time.Time
+		if request.Until != nil {
+			until = *request.Until
+		}
+		changeStatus(w, r, func(ctx context.Context, userID string) (domain.User, error) {
+			return service.SuspendUser(ctx, userID, request.Reason, until)
+		})
+	}
+}
+
+// ReactivateUser godoc
+// @Summary Reactivate a user
+// @Description Moves a suspended or deactivated user back to active. Requires the admin token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Param request body StatusChangeRequest false "Reason"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /users/{user_id}:reactivate [post]
+func reactivateUser(service ports.UserService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := StatusChangeRequest{}
+		if r.ContentLength != 0 && !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		changeStatus(w, r, func(ctx context.Context, userID string) (domain.User, error) {
+			return service.ReactivateUser(ctx, userID, request.Reason)
+		})
+	}
+}
+
+// changeStatus runs a status action on the user of the path and writes the result.
+func changeStatus(w http.ResponseWriter, r *http.Request, action func(context.Context, string) (domain.User, error)) {
+	userID := chi.URLParam(r, "userId")
+	user, err := action(r.Context(), userID)
+	switch {
+	case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, domain.ErrAlreadyErased):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, domain.ErrReasonRequired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+		return
+	case err != nil:
+		logging.FromContext(r.Context()).Error(fmt.Errorf("could not change the status: %w", err).Error())
+		http.Error(w, "could not change the status", http.StatusInternalServerError)
+		return
+	}
+	logging.FromContext(r.Context()).Info("changed the status of a user", "userId", userID, "status", user.Status.String())
+	writeJSON(w, http.StatusOK, parseUserToUserDTO(user))
+}
+
+// GetStatusHistory godoc
+//
+//	@Summary		Get the status history of a user
+//	@Description	Lists the status changes of a user with their reasons, newest first. Requires a session of the user or the admin token.
+//	@Tags users
+//	@Produce		json
+//	@Param user_id  path string true "User ID"
+//	@Success		200	{array} StatusTransitionResponse
+//	@Failure		404	{object}	map[string]string
+//	@Failure		500	{object}	map[string]string
+//	@Router			/users/{user_id}/status-history [get]
+func getStatusHistory(service ports.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		transitions, err := service.GetStatusTransitions(r.Context(), userID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the status history: %w", err).Error())
+			http.Error(w, "could not retrieve the status history", http.StatusInternalServerError)
+			return
+		}
+		transitionDTOs := make([]StatusTransitionResponse, len(transitions))
+		for i, transition := range transitions {
+			transitionDTOs[i] = parseStatusTransitionToDTO(transition)
+		}
+		writeJSON(w, http.StatusOK, transitionDTOs)
+	}
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"userapi/app/internal/adapters/metrics"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// instrumentRequests records every request in the HTTP metrics, labeled by
+// route pattern. The pattern is matched before the request is served, so
+// that the in-flight gauge carries it too.
+func instrumentRequests(routes chi.Routes, httpMetrics *metrics.HTTPMetrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := matchRoute(routes, r)
+			start := time.Now()
+			httpMetrics.InFlight.WithLabelValues(r.Method, route).Inc()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			completed := false
+			defer func() {
+				httpMetrics.InFlight.WithLabelValues(r.Method, route).Dec()
+				status := ww.Status()
+				switch {
+				case !completed:
+					// The handler panicked, the recoverer answers with a 500.
+					status = http.StatusInternalServerError
+				case status == 0:
+					status = http.StatusOK
+				}
+				httpMetrics.Requests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+				httpMetrics.Duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+			}()
+			next.ServeHTTP(ww, r)
+			completed = true
+		})
+	}
+}
+
+// matchRoute returns the pattern of the route serving the request, or
+// "unmatched".
+func matchRoute(routes chi.Routes, r *http.Request) string {
+	path := r.URL.Path
+	if routeContext := chi.RouteContext(r.Context()); routeContext != nil && routeContext.RoutePath != "" {
+		// StripSlashes leaves the path to route here.
+		path = routeContext.RoutePath
+	}
+	match := chi.NewRouteContext()
+	if !routes.Match(match, r.Method, path) {
+		return "unmatched"
+	}
+	return match.RoutePattern()
+}
@@ -0,0 +1,335 @@
+package http
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/skip2/go-qrcode"
+)
+
+// qrCodeScale is the size in pixels of a QR code module.
+const qrCodeScale = 4
+
+// EnrollTOTP godoc
+// @Summary Enroll a TOTP factor
+// @Description Creates a pending TOTP factor and returns its secret as an otpauth URI and a QR code. The factor is activated by confirming a first code. Requires a session of the user, logged in within STEP_UP_MAX_AGE, or the admin token.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Param request body EnrollTOTPRequest false "Factor name"
+// @Success 201 {object} TOTPEnrollmentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/mfa/totp [post]
+func enrollTOTP(service ports.MFAService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		request := EnrollTOTPRequest{}
+		if r.ContentLength != 0 && !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		enrollment, err := service.EnrollTOTP(r.Context(), userID, request.Name)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not enroll the totp factor: %w", err).Error())
+			http.Error(w, "could not enroll the totp factor", http.StatusInternalServerError)
+			return
+		}
+		response := TOTPEnrollmentResponse{
+			Factor: parseMFAFactorToDTO(enrollment.Factor),
+			Secret: enrollment.Secret,
+			URI:    enrollment.URI,
+		}
+		// A negative size is the size of a module, around the smallest code.
+		image, err := qrcode.Encode(enrollment.URI, qrcode.Medium, -qrCodeScale)
+		if err == nil {
+			response.QRCode = "data:image/png;base64," + base64.StdEncoding.EncodeToString(image)
+		} else {
+			// The URI and the secret can still be entered by hand.
+			logging.FromContext(r.Context()).Warn(fmt.Errorf("could not render the qr code: %w", err).Error())
+		}
+		writeJSON(w, http.StatusCreated, response)
+	}
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm a TOTP factor
+// @Description Activates a pending TOTP factor with a code from the authenticator app. Requires a session of the user, logged in within STEP_UP_MAX_AGE, or the admin token.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Param factor_id  path string true "Factor ID"
+// @Param request body ConfirmTOTPRequest true "Code"
+// @Success 200 {object} MFAFactorResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/mfa/totp/{factor_id}:confirm [post]
+func confirmTOTP(service ports.MFAService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		factorID := chi.URLParam(r, "factorId")
+		request := ConfirmTOTPRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		factor, err := service.ConfirmTOTP(r.Context(), userID, factorID, request.Code)
+		switch {
+		case errors.Is(err, domain.ErrInvalidCode):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrAlreadyVerified):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the factor %s", factorID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not confirm the totp factor: %w", err).Error())
+			http.Error(w, "could not confirm the totp factor", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, parseMFAFactorToDTO(factor))
+	}
+}
+
+// BeginWebAuthnRegistration godoc
+// @Summary Start a WebAuthn registration
+// @Description Returns the options for navigator.credentials.create. The challenge expires after 5 minutes. Requires a session of the user, logged in within STEP_UP_MAX_AGE, or the admin token.
+// @Tags mfa
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Success 200 {object} WebAuthnOptionsResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /users/{user_id}/mfa/webauthn/registration [post]
+func beginWebAuthnRegistration(service ports.MFAService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		options, err := service.BeginWebAuthnRegistration(r.Context(), userID)
+		if !writeWebAuthnError(w, r, userID, err) {
+			return
+		}
+		writeJSON(w, http.StatusOK, parseWebAuthnOptionsToDTO(options, true))
+	}
+}
+
+// FinishWebAuthnRegistration godoc
+// @Summary Finish a WebAuthn registration
+// @Description Verifies the response of navigator.credentials.create and stores the credential as an active factor. Requires a session of the user, logged in within STEP_UP_MAX_AGE, or the admin token.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Param request body FinishWebAuthnRegistrationRequest true "Authenticator response"
+// @Success 201 {object} MFAFactorResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /users/{user_id}/mfa/webauthn/registration:finish [post]
+func finishWebAuthnRegistration(service ports.MFAService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		request := FinishWebAuthnRegistrationRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		factor, err := service.FinishWebAuthnRegistration(r.Context(), userID, request.getRegistration())
+		if errors.Is(err, domain.ErrMFAFailed) {
+			logging.FromContext(r.Context()).Info(fmt.Errorf("rejected a webauthn registration: %w", err).Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !writeWebAuthnError(w, r, userID, err) {
+			return
+		}
+		writeJSON(w, http.StatusCreated, parseMFAFactorToDTO(factor))
+	}
+}
+
+// BeginWebAuthnAssertion godoc
+// @Summary Start a WebAuthn assertion
+// @Description Returns the options for navigator.credentials.get, allowing the security keys of the user. The signed response completes a login with POST /auth/login:mfa. Requires the MFA token of a login of the user, a session of the user or the admin token.
+// @Tags mfa
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Success 200 {object} WebAuthnOptionsResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /users/{user_id}/mfa/webauthn/assertion [post]
+func beginWebAuthnAssertion(service ports.MFAService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		options, err := service.BeginWebAuthnAssertion(r.Context(), userID)
+		if !writeWebAuthnError(w, r, userID, err) {
+			return
+		}
+		writeJSON(w, http.StatusOK, parseWebAuthnOptionsToDTO(options, false))
+	}
+}
+
+// writeWebAuthnError answers the errors shared by the WebAuthn routes and
+// reports whether there was none.
+func writeWebAuthnError(w http.ResponseWriter, r *http.Request, userID string, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, domain.ErrNotConfigured):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+	case errors.Is(err, domain.ErrNotFound):
+		logging.FromContext(r.Context()).Info(err.Error())
+		http.Error(w, fmt.Sprintf("could not find the user %s or a security key of the user", userID), http.StatusNotFound)
+	default:
+		logging.FromContext(r.Context()).Error(fmt.Errorf("webauthn ceremony failed: %w", err).Error())
+		http.Error(w, "could not complete the webauthn ceremony", http.StatusInternalServerError)
+	}
+	return false
+}
+
+// GenerateRecoveryCodes godoc
+// @Summary Generate recovery codes
+// @Description Replaces the recovery codes of the user with 10 new single use codes. They are only shown once. Requires a session of the user, logged in within STEP_UP_MAX_AGE, or the admin token.
+// @Tags mfa
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Success 201 {object} RecoveryCodesResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/mfa/recovery-codes [post]
+func generateRecoveryCodes(service ports.MFAService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		codes, err := service.GenerateRecoveryCodes(r.Context(), userID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not generate the recovery codes: %w", err).Error())
+			http.Error(w, "could not generate the recovery codes", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, RecoveryCodesResponse{Codes: codes})
+	}
+}
+
+// CompleteLogin godoc
+// @Summary Complete a login with a second factor
+// @Description Exchanges the MFA token returned by /auth/login and a TOTP code, a recovery code or a WebAuthn assertion for a session token. Failed attempts count towards the login lockout.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body CompleteLoginRequest true "MFA token and second factor"
+// @Success 200 {object} SessionResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/login:mfa [post]
+func completeLogin(service ports.CredentialService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := CompleteLoginRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		session, err := service.CompleteLogin(r.Context(), request.MFAToken, request.getVerification())
+		switch {
+		case errors.Is(err, domain.ErrInvalidToken):
+			http.Error(w, domain.ErrInvalidToken.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrMFAFailed):
+			logging.FromContext(r.Context()).Info(err.Error())
+			http.Error(w, domain.ErrMFAFailed.Error(), http.StatusUnauthorized)
+			return
+		case errors.Is(err, domain.ErrLockedOut):
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		case errors.Is(err, domain.ErrNotConfigured):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not complete the login: %w", err).Error())
+			http.Error(w, "could not log in", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, parseSessionToSessionDTO(session))
+	}
+}
+
+// GetMFAFactors godoc
+//
+//	@Summary		Get the factors of a user
+//	@Description	Lists the TOTP and WebAuthn factors of a user of the tenant. Requires the admin token.
+//	@Tags admin
+//	@Produce		json
+//	@Param tenant_id  path string true "Tenant ID"
+//	@Param user_id  path string true "User ID"
+//	@Success		200	{array} MFAFactorResponse
+//	@Failure		404	{object}	map[string]string
+//	@Router			/admin/tenants/{tenant_id}/users/{user_id}/mfa/factors [get]
+func getMFAFactors(service ports.MFAService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		factors, err := service.GetMFAFactors(r.Context(), userID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the factors: %w", err).Error())
+			http.Error(w, "could not retrieve the factors", http.StatusInternalServerError)
+			return
+		}
+		factorDTOs := make([]MFAFactorResponse, len(factors))
+		for i, factor := range factors {
+			factorDTOs[i] = parseMFAFactorToDTO(factor)
+		}
+		writeJSON(w, http.StatusOK, factorDTOs)
+	}
+}
+
+// RevokeMFAFactor godoc
+// @Summary Revoke a factor
+// @Description Deletes a TOTP or WebAuthn factor of a user of the tenant. Requires the admin token.
+// @Tags admin
+// @Param tenant_id  path string true "Tenant ID"
+// @Param user_id  path string true "User ID"
+// @Param factor_id  path string true "Factor ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/tenants/{tenant_id}/users/{user_id}/mfa/factors/{factor_id} [delete]
+func revokeMFAFactor(service ports.MFAService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		factorID := chi.URLParam(r, "factorId")
+		err := service.RevokeMFAFactor(r.Context(), userID, factorID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not find the factor %s", factorID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not revoke the factor: %w", err).Error())
+			http.Error(w, "could not revoke the factor", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("revoked an mfa factor", "userId", userID, "factorId", factorID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -1,15 +1,28 @@
 package http
 
-import "userapi/app/internal/core/domain"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"userapi/app/internal/core/domain"
+)
 
 type UserResponse struct {
-	UserID    string `json:"userId,omitempty"`
-	FirstName string `json:"firstname,omitempty"`
-	LastName  string `json:"lastname,omitempty"`
-	Email     string `json:"email,omitempty"`
-	Phone     string `json:"phone,omitempty"`
-	Age       int    `json:"age,omitempty"`
-	Status    string `json:"status,omitempty"`
+	UserID          string     `json:"userId,omitempty"`
+	FirstName       string     `json:"firstname,omitempty"`
+	LastName        string     `json:"lastname,omitempty"`
+	Email           string     `json:"email,omitempty"`
+	Phone           string     `json:"phone,omitempty"`
+	Age             int        `json:"age,omitempty"`
+	Status          string     `json:"status,omitempty"`
+	StatusReason    string     `json:"statusReason,omitempty"`
+	SuspendedUntil  *time.Time `json:"suspendedUntil,omitempty"`
+	EmailVerified   bool       `json:"emailVerified"`
+	PhoneVerifiedAt *time.Time `json:"phoneVerifiedAt,omitempty"`
+	// Attributes are the custom attributes of the user.
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 type CreateUserRequest struct {
@@ -18,6 +31,10 @@ type CreateUserRequest struct {
 	Email     string `json:"email" validate:"required,email"`
 	Phone     string `json:"phone,omitempty" validate:"omitempty,e164"`
 	Age       int    `json:"age,omitempty" validate:"omitempty,gte=0,lte=150"`
+	// Status defaults to active.
+	Status string `json:"status,omitempty" validate:"omitempty,oneof=pending active"`
+	// Attributes must match the attribute schema of the tenant, when it has one.
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 // UserRequest The generic user request
@@ -27,19 +44,36 @@ type UserRequest struct {
 	Email     string `json:"email,omitempty" validate:"omitempty,email"`
 	Phone     string `json:"phone,omitempty" validate:"omitempty,e164"`
 	Age       int    `json:"age,omitempty" validate:"omitempty,gte=0,lte=150"`
-	Status    string `json:"status,omitempty" validate:"omitempty,oneof=active inactive"`
+	// Status must be reachable from the current status, see the transition table.
+	Status       string `json:"status,omitempty" validate:"omitempty,oneof=pending active suspended locked deactivated inactive"`
+	StatusReason string `json:"statusReason,omitempty" validate:"omitempty,max=500"`
+	// Attributes replace the custom attributes as a whole and must match the
+	// attribute schema of the tenant, when it has one.
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 func parseUserToUserDTO(user domain.User) UserResponse {
-	return UserResponse{
-		UserID:    user.UserID,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
-		Phone:     user.Phone,
-		Age:       user.Age,
-		Status:    user.Status.String(),
+	response := UserResponse{
+		UserID:        user.UserID,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Email:         user.Email,
+		Phone:         user.Phone,
+		Age:           user.Age,
+		Status:        user.Status.String(),
+		StatusReason:  user.StatusReason,
+		EmailVerified: user.EmailVerified,
+		Attributes:    user.Attributes,
+	}
+	if !user.PhoneVerifiedAt.IsZero() {
+		phoneVerifiedAt := user.PhoneVerifiedAt
+		response.PhoneVerifiedAt = &phoneVerifiedAt
 	}
+	if !user.SuspendedUntil.IsZero() {
+		suspendedUntil := user.SuspendedUntil
+		response.SuspendedUntil = &suspendedUntil
+	}
+	return response
 }
 
 func (request CreateUserRequest) getUser() domain.User {
@@ -49,6 +83,8 @@ func (request CreateUserRequest) getUser() domain.User {
 	user.Email = request.Email
 	user.Phone = request.Phone
 	user.Age = request.Age
+	user.Status = domain.ParseUserStatus(request.Status)
+	user.Attributes = request.Attributes
 	return user
 }
 
@@ -59,11 +95,777 @@ func (request UserRequest) getUser() domain.User {
 	user.Email = request.Email
 	user.Phone = request.Phone
 	user.Age = request.Age
-	switch request.Status {
-	case "active":
-		user.Status = domain.ACTIVE
-	case "inactive":
-		user.Status = domain.INACTIVE
-	}
+	user.Status = domain.ParseUserStatus(request.Status)
+	user.StatusReason = request.StatusReason
+	user.Attributes = request.Attributes
 	return user
 }
+
+type StatusChangeRequest struct {
+	Reason string `json:"reason,omitempty" validate:"omitempty,max=500"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+	// Until is when the user is reactivated, the suspension is open ended when omitted.
+	Until *time.Time `json:"until,omitempty"`
+}
+
+type StatusTransitionResponse struct {
+	TransitionID   string     `json:"transitionId"`
+	From           string     `json:"from"`
+	To             string     `json:"to"`
+	Reason         string     `json:"reason,omitempty"`
+	SuspendedUntil *time.Time `json:"suspendedUntil,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+func parseStatusTransitionToDTO(transition domain.StatusTransition) StatusTransitionResponse {
+	response := StatusTransitionResponse{
+		TransitionID: transition.TransitionID,
+		From:         transition.From.String(),
+		To:           transition.To.String(),
+		Reason:       transition.Reason,
+		CreatedAt:    transition.CreatedAt,
+	}
+	if !transition.SuspendedUntil.IsZero() {
+		response.SuspendedUntil = &transition.SuspendedUntil
+	}
+	return response
+}
+
+type ScheduleActionRequest struct {
+	Action string    `json:"action" validate:"required,oneof=deactivate reactivate"`
+	RunAt  time.Time `json:"runAt" validate:"required"`
+	Reason string    `json:"reason,omitempty" validate:"omitempty,max=500"`
+}
+
+type ScheduledActionResponse struct {
+	ScheduleID string     `json:"scheduleId"`
+	Action     string     `json:"action"`
+	Status     string     `json:"status"`
+	RunAt      time.Time  `json:"runAt"`
+	Reason     string     `json:"reason,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExecutedAt *time.Time `json:"executedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+func parseScheduledActionToDTO(action domain.ScheduledAction) ScheduledActionResponse {
+	response := ScheduledActionResponse{
+		ScheduleID: action.ActionID,
+		Action:     strings.ToLower(string(action.Kind)),
+		Status:     strings.ToLower(string(action.Status)),
+		RunAt:      action.RunAt,
+		Reason:     action.Reason,
+		CreatedAt:  action.CreatedAt,
+		Error:      action.Error,
+	}
+	if !action.ExecutedAt.IsZero() {
+		response.ExecutedAt = &action.ExecutedAt
+	}
+	return response
+}
+
+type ConfirmVerificationRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ConfirmPhoneVerificationRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type SetPasswordRequest struct {
+	// CurrentPassword is required from users who already have a password,
+	// not from the admin.
+	CurrentPassword string `json:"currentPassword"`
+	Password        string `json:"password" validate:"required"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type SessionResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"tokenType"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// MFARequired is set when Token is an MFA token for /auth/login:mfa.
+	MFARequired bool `json:"mfaRequired,omitempty"`
+	// The session fields are only set when sessions are stored.
+	SessionID        string     `json:"sessionId,omitempty"`
+	RefreshToken     string     `json:"refreshToken,omitempty"`
+	RefreshExpiresAt *time.Time `json:"refreshExpiresAt,omitempty"`
+}
+
+type RefreshSessionRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+type UserSessionResponse struct {
+	SessionID  string    `json:"sessionId"`
+	UserAgent  string    `json:"userAgent,omitempty"`
+	IPAddress  string    `json:"ipAddress,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+type RevokedSessionsResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+func parseSessionToSessionDTO(session domain.Session) SessionResponse {
+	if session.MFARequired {
+		return SessionResponse{Token: session.Token, TokenType: "MFA", ExpiresAt: session.ExpiresAt, MFARequired: true}
+	}
+	response := SessionResponse{Token: session.Token, TokenType: "Bearer", ExpiresAt: session.ExpiresAt, SessionID: session.SessionID, RefreshToken: session.RefreshToken}
+	if !session.RefreshExpiresAt.IsZero() {
+		response.RefreshExpiresAt = &session.RefreshExpiresAt
+	}
+	return response
+}
+
+func parseSessionToUserSessionDTO(session domain.Session) UserSessionResponse {
+	return UserSessionResponse{
+		SessionID:  session.SessionID,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.RefreshExpiresAt,
+	}
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ConfirmPasswordResetRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type TenantResponse struct {
+	TenantID string `json:"tenantId,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Slug     string `json:"slug,omitempty"`
+	Disabled bool   `json:"disabled"`
+}
+
+type CreateTenantRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+	Slug string `json:"slug" validate:"required,hostname_rfc1123,max=63,lowercase"`
+}
+
+func parseTenantToTenantDTO(tenant domain.Tenant) TenantResponse {
+	return TenantResponse{
+		TenantID: tenant.TenantID,
+		Name:     tenant.Name,
+		Slug:     tenant.Slug,
+		Disabled: tenant.Disabled,
+	}
+}
+
+func (request CreateTenantRequest) getTenant() domain.Tenant {
+	return domain.Tenant{Name: request.Name, Slug: request.Slug}
+}
+
+type PrivacyRequestResponse struct {
+	RequestID   string     `json:"requestId"`
+	UserID      string     `json:"userId"`
+	Kind        string     `json:"kind"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	DueAt       time.Time  `json:"dueAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+type ErasureCertificateResponse struct {
+	CertificateID string    `json:"certificateId"`
+	RequestID     string    `json:"requestId"`
+	UserID        string    `json:"userId"`
+	ErasedFields  []string  `json:"erasedFields"`
+	ErasedAt      time.Time `json:"erasedAt"`
+}
+
+type DataExportResponse struct {
+	ExportedAt          time.Time                    `json:"exportedAt"`
+	Profile             UserResponse                 `json:"profile"`
+	PrivacyRequests     []PrivacyRequestResponse     `json:"privacyRequests"`
+	ErasureCertificates []ErasureCertificateResponse `json:"erasureCertificates"`
+	StatusHistory       []StatusTransitionResponse   `json:"statusHistory"`
+	PhoneVerification   *PhoneVerificationResponse   `json:"phoneVerification,omitempty"`
+	Credential          *CredentialResponse          `json:"credential,omitempty"`
+	MFAFactors          []MFAFactorResponse          `json:"mfaFactors"`
+	Sessions            []UserSessionResponse        `json:"sessions"`
+	ScheduledActions    []ScheduledActionResponse    `json:"scheduledActions"`
+	Groups              []UserGroupResponse          `json:"groups"`
+	ContactMethods      []ContactMethodResponse      `json:"contactMethods"`
+	PostalAddresses     []PostalAddressResponse      `json:"postalAddresses"`
+	WebhookDeliveries   []WebhookDeliveryResponse    `json:"webhookDeliveries"`
+	// Avatar is the uploaded image as a data URL.
+	Avatar string `json:"avatar,omitempty"`
+}
+
+// CredentialResponse describes the password of a user, without its hash.
+type CredentialResponse struct {
+	PasswordChangedAt time.Time  `json:"passwordChangedAt"`
+	FailedAttempts    int        `json:"failedAttempts"`
+	LockedUntil       *time.Time `json:"lockedUntil,omitempty"`
+}
+
+// PhoneVerificationResponse describes a pending phone verification, without its code.
+type PhoneVerificationResponse struct {
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	Attempts    int        `json:"attempts"`
+	LockedUntil *time.Time `json:"lockedUntil,omitempty"`
+}
+
+func parsePrivacyRequestToDTO(request domain.PrivacyRequest) PrivacyRequestResponse {
+	response := PrivacyRequestResponse{
+		RequestID:   request.RequestID,
+		UserID:      request.UserID,
+		Kind:        string(request.Kind),
+		Status:      string(request.Status),
+		RequestedAt: request.RequestedAt,
+		DueAt:       request.DueAt,
+		Error:       request.Error,
+	}
+	if !request.CompletedAt.IsZero() {
+		completedAt := request.CompletedAt
+		response.CompletedAt = &completedAt
+	}
+	return response
+}
+
+func parseDataExportToDTO(export domain.UserDataExport) DataExportResponse {
+	response := DataExportResponse{
+		ExportedAt:          export.ExportedAt,
+		Profile:             parseUserToUserDTO(export.User),
+		PrivacyRequests:     make([]PrivacyRequestResponse, len(export.PrivacyRequests)),
+		ErasureCertificates: make([]ErasureCertificateResponse, len(export.ErasureCertificates)),
+		StatusHistory:       make([]StatusTransitionResponse, len(export.StatusTransitions)),
+		MFAFactors:          make([]MFAFactorResponse, len(export.MFAFactors)),
+		Sessions:            make([]UserSessionResponse, len(export.Sessions)),
+		ScheduledActions:    make([]ScheduledActionResponse, len(export.ScheduledActions)),
+		Groups:              make([]UserGroupResponse, len(export.Groups)),
+		ContactMethods:      make([]ContactMethodResponse, len(export.ContactMethods)),
+		PostalAddresses:     make([]PostalAddressResponse, len(export.PostalAddresses)),
+		WebhookDeliveries:   make([]WebhookDeliveryResponse, len(export.WebhookDeliveries)),
+	}
+	for i, request := range export.PrivacyRequests {
+		response.PrivacyRequests[i] = parsePrivacyRequestToDTO(request)
+	}
+	for i, certificate := range export.ErasureCertificates {
+		response.ErasureCertificates[i] = ErasureCertificateResponse{
+			CertificateID: certificate.CertificateID,
+			RequestID:     certificate.RequestID,
+			UserID:        certificate.UserID,
+			ErasedFields:  certificate.ErasedFields,
+			ErasedAt:      certificate.ErasedAt,
+		}
+	}
+	for i, transition := range export.StatusTransitions {
+		response.StatusHistory[i] = parseStatusTransitionToDTO(transition)
+	}
+	for i, factor := range export.MFAFactors {
+		response.MFAFactors[i] = parseMFAFactorToDTO(factor)
+	}
+	for i, session := range export.Sessions {
+		response.Sessions[i] = parseSessionToUserSessionDTO(session)
+	}
+	for i, action := range export.ScheduledActions {
+		response.ScheduledActions[i] = parseScheduledActionToDTO(action)
+	}
+	for i, membership := range export.Groups {
+		response.Groups[i] = parseGroupMembershipToDTO(membership)
+	}
+	for i, contact := range export.ContactMethods {
+		response.ContactMethods[i] = parseContactMethodToDTO(contact)
+	}
+	for i, address := range export.PostalAddresses {
+		response.PostalAddresses[i] = parsePostalAddressToDTO(address)
+	}
+	for i, delivery := range export.WebhookDeliveries {
+		response.WebhookDeliveries[i] = parseWebhookDeliveryToDTO(delivery)
+	}
+	if avatar := export.Avatar; avatar != nil {
+		response.Avatar = "data:" + avatar.ContentType + ";base64," + base64.StdEncoding.EncodeToString(avatar.Data)
+	}
+	if pending := export.PhoneVerification; pending != nil {
+		response.PhoneVerification = &PhoneVerificationResponse{ExpiresAt: pending.ExpiresAt, Attempts: pending.Attempts}
+		if !pending.LockedUntil.IsZero() {
+			lockedUntil := pending.LockedUntil
+			response.PhoneVerification.LockedUntil = &lockedUntil
+		}
+	}
+	if credential := export.Credential; credential != nil {
+		response.Credential = &CredentialResponse{PasswordChangedAt: credential.PasswordChangedAt, FailedAttempts: credential.FailedAttempts}
+		if !credential.LockedUntil.IsZero() {
+			lockedUntil := credential.LockedUntil
+			response.Credential.LockedUntil = &lockedUntil
+		}
+	}
+	return response
+}
+
+type CompleteLoginRequest struct {
+	MFAToken     string                    `json:"mfaToken" validate:"required"`
+	Code         string                    `json:"code,omitempty" validate:"omitempty,numeric,len=6"`
+	RecoveryCode string                    `json:"recoveryCode,omitempty" validate:"omitempty,max=32"`
+	Assertion    *WebAuthnAssertionRequest `json:"assertion,omitempty"`
+}
+
+type MFAFactorResponse struct {
+	FactorID   string     `json:"factorId"`
+	Kind       string     `json:"kind"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+type EnrollTOTPRequest struct {
+	Name string `json:"name,omitempty" validate:"omitempty,max=64"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Factor MFAFactorResponse `json:"factor"`
+	Secret string            `json:"secret"`
+	URI    string            `json:"otpauthUri"`
+	// QRCode is the otpauth URI as a PNG data URI.
+	QRCode string `json:"qrCode"`
+}
+
+type ConfirmTOTPRequest struct {
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+type RecoveryCodesResponse struct {
+	Codes []string `json:"codes"`
+}
+
+// WebAuthnOptionsResponse carries the options for navigator.credentials.create
+// or navigator.credentials.get in publicKey. Binary values are base64url encoded.
+type WebAuthnOptionsResponse struct {
+	ChallengeID string                   `json:"challengeId"`
+	PublicKey   WebAuthnPublicKeyOptions `json:"publicKey"`
+}
+
+type WebAuthnPublicKeyOptions struct {
+	Challenge          string                         `json:"challenge"`
+	RelyingParty       *WebAuthnEntity                `json:"rp,omitempty"`
+	RelyingPartyID     string                         `json:"rpId,omitempty"`
+	User               *WebAuthnEntity                `json:"user,omitempty"`
+	Parameters         []WebAuthnCredentialParameter  `json:"pubKeyCredParams,omitempty"`
+	Timeout            int64                          `json:"timeout"`
+	ExcludeCredentials []WebAuthnCredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AllowCredentials   []WebAuthnCredentialDescriptor `json:"allowCredentials,omitempty"`
+	Attestation        string                         `json:"attestation,omitempty"`
+	UserVerification   string                         `json:"userVerification"`
+}
+
+type WebAuthnEntity struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+type WebAuthnCredentialParameter struct {
+	Type      string `json:"type"`
+	Algorithm int    `json:"alg"`
+}
+
+type WebAuthnCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type FinishWebAuthnRegistrationRequest struct {
+	ChallengeID       string `json:"challengeId" validate:"required,uuid"`
+	Name              string `json:"name,omitempty" validate:"omitempty,max=64"`
+	ClientDataJSON    string `json:"clientDataJSON" validate:"required,base64rawurl"`
+	AttestationObject string `json:"attestationObject" validate:"required,base64rawurl"`
+}
+
+type WebAuthnAssertionRequest struct {
+	ChallengeID       string `json:"challengeId" validate:"required,uuid"`
+	CredentialID      string `json:"credentialId" validate:"required,base64rawurl"`
+	ClientDataJSON    string `json:"clientDataJSON" validate:"required,base64rawurl"`
+	AuthenticatorData string `json:"authenticatorData" validate:"required,base64rawurl"`
+	Signature         string `json:"signature" validate:"required,base64rawurl"`
+}
+
+func parseMFAFactorToDTO(factor domain.MFAFactor) MFAFactorResponse {
+	response := MFAFactorResponse{
+		FactorID:  factor.FactorID,
+		Kind:      string(factor.Kind),
+		Name:      factor.Name,
+		Status:    string(factor.Status),
+		CreatedAt: factor.CreatedAt,
+	}
+	if !factor.LastUsedAt.IsZero() {
+		lastUsedAt := factor.LastUsedAt
+		response.LastUsedAt = &lastUsedAt
+	}
+	return response
+}
+
+func parseWebAuthnOptionsToDTO(options domain.WebAuthnOptions, registration bool) WebAuthnOptionsResponse {
+	publicKey := WebAuthnPublicKeyOptions{
+		Challenge:        base64.RawURLEncoding.EncodeToString(options.Challenge),
+		Timeout:          options.Timeout.Milliseconds(),
+		UserVerification: "preferred",
+	}
+	descriptors := make([]WebAuthnCredentialDescriptor, len(options.CredentialIDs))
+	for i, credentialID := range options.CredentialIDs {
+		descriptors[i] = WebAuthnCredentialDescriptor{Type: "public-key", ID: base64.RawURLEncoding.EncodeToString(credentialID)}
+	}
+	if registration {
+		publicKey.RelyingParty = &WebAuthnEntity{ID: options.RelyingPartyID, Name: options.RelyingPartyName}
+		publicKey.User = &WebAuthnEntity{
+			ID:          base64.RawURLEncoding.EncodeToString([]byte(options.UserID)),
+			Name:        options.UserName,
+			DisplayName: options.UserName,
+		}
+		for _, algorithm := range options.Algorithms {
+			publicKey.Parameters = append(publicKey.Parameters, WebAuthnCredentialParameter{Type: "public-key", Algorithm: algorithm})
+		}
+		publicKey.ExcludeCredentials = descriptors
+		publicKey.Attestation = "none"
+	} else {
+		publicKey.RelyingPartyID = options.RelyingPartyID
+		publicKey.AllowCredentials = descriptors
+	}
+	return WebAuthnOptionsResponse{ChallengeID: options.ChallengeID, PublicKey: publicKey}
+}
+
+// getAssertion decodes the base64url fields of the request. They are validated
+// by then, so decoding cannot fail.
+func (request WebAuthnAssertionRequest) getAssertion() *domain.WebAuthnAssertion {
+	return &domain.WebAuthnAssertion{
+		ChallengeID:       request.ChallengeID,
+		CredentialID:      decodeBase64URL(request.CredentialID),
+		ClientDataJSON:    decodeBase64URL(request.ClientDataJSON),
+		AuthenticatorData: decodeBase64URL(request.AuthenticatorData),
+		Signature:         decodeBase64URL(request.Signature),
+	}
+}
+
+func (request FinishWebAuthnRegistrationRequest) getRegistration() domain.WebAuthnRegistration {
+	return domain.WebAuthnRegistration{
+		ChallengeID:       request.ChallengeID,
+		Name:              request.Name,
+		ClientDataJSON:    decodeBase64URL(request.ClientDataJSON),
+		AttestationObject: decodeBase64URL(request.AttestationObject),
+	}
+}
+
+func (request CompleteLoginRequest) getVerification() domain.MFAVerification {
+	verification := domain.MFAVerification{Code: request.Code, RecoveryCode: request.RecoveryCode}
+	if request.Assertion != nil {
+		verification.Assertion = request.Assertion.getAssertion()
+	}
+	return verification
+}
+
+func decodeBase64URL(value string) []byte {
+	decoded, _ := base64.RawURLEncoding.DecodeString(value)
+	return decoded
+}
+
+type AttributeSchemaResponse struct {
+	TenantID  string          `json:"tenantId"`
+	Schema    json.RawMessage `json:"schema" swaggertype:"object"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+func parseAttributeSchemaToDTO(schema domain.AttributeSchema) AttributeSchemaResponse {
+	return AttributeSchemaResponse{TenantID: schema.TenantID, Schema: schema.Schema, UpdatedAt: schema.UpdatedAt}
+}
+
+type CreateGroupRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description,omitempty" validate:"omitempty,max=500"`
+}
+
+type GroupRequest struct {
+	Name        string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Description string `json:"description,omitempty" validate:"omitempty,max=500"`
+}
+
+// GroupMemberResponse is a direct member of a group, in the shape of a SCIM
+// group member.
+type GroupMemberResponse struct {
+	Value string `json:"value"`
+	// Type is User or Group.
+	Type string `json:"type"`
+	Ref  string `json:"$ref"`
+}
+
+type GroupResponse struct {
+	GroupID     string                `json:"groupId"`
+	Name        string                `json:"name"`
+	Description string                `json:"description,omitempty"`
+	Members     []GroupMemberResponse `json:"members"`
+	CreatedAt   time.Time             `json:"createdAt"`
+	UpdatedAt   time.Time             `json:"updatedAt"`
+}
+
+// UserGroupResponse is a group a user belongs to, in the shape of an entry
+// of the groups of a SCIM user.
+type UserGroupResponse struct {
+	Value   string `json:"value"`
+	Display string `json:"display"`
+	// Type is direct, or indirect when the user belongs to the group through nested groups.
+	Type string `json:"type"`
+	Ref  string `json:"$ref"`
+}
+
+func parseGroupToDTO(group domain.Group) GroupResponse {
+	response := GroupResponse{
+		GroupID:     group.GroupID,
+		Name:        group.Name,
+		Description: group.Description,
+		Members:     make([]GroupMemberResponse, len(group.Members)),
+		CreatedAt:   group.CreatedAt,
+		UpdatedAt:   group.UpdatedAt,
+	}
+	for i, member := range group.Members {
+		response.Members[i] = GroupMemberResponse{Value: member.MemberID, Type: "User", Ref: "/users/" + member.MemberID}
+		if member.Kind == domain.MemberGroup {
+			response.Members[i] = GroupMemberResponse{Value: member.MemberID, Type: "Group", Ref: "/groups/" + member.MemberID}
+		}
+	}
+	return response
+}
+
+func parseGroupMembershipToDTO(membership domain.GroupMembership) UserGroupResponse {
+	response := UserGroupResponse{Value: membership.GroupID, Display: membership.Name, Type: "indirect", Ref: "/groups/" + membership.GroupID}
+	if membership.Direct {
+		response.Type = "direct"
+	}
+	return response
+}
+
+type ContactMethodRequest struct {
+	// Label is home, work, mobile or other.
+	Label string `json:"label" validate:"required,oneof=home work mobile other"`
+	// Value is an email address, or a phone number in E.164 format.
+	Value string `json:"value" validate:"required,max=254"`
+	// Primary makes the contact method the email or phone of the user.
+	Primary bool `json:"primary,omitempty"`
+}
+
+type UpdateContactMethodRequest struct {
+	Label string `json:"label,omitempty" validate:"omitempty,oneof=home work mobile other"`
+	Value string `json:"value,omitempty" validate:"omitempty,max=254"`
+	// Primary makes the contact method the email or phone of the user. It cannot be unset.
+	Primary bool `json:"primary,omitempty"`
+}
+
+type ContactMethodResponse struct {
+	ContactID string    `json:"contactId"`
+	Label     string    `json:"label"`
+	Value     string    `json:"value"`
+	Primary   bool      `json:"primary"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func parseContactMethodToDTO(contact domain.ContactMethod) ContactMethodResponse {
+	return ContactMethodResponse{
+		ContactID: contact.ContactID,
+		Label:     contact.Label,
+		Value:     contact.Value,
+		Primary:   contact.Primary,
+		CreatedAt: contact.CreatedAt,
+	}
+}
+
+type PostalAddressRequest struct {
+	// Label is home, work or other.
+	Label string `json:"label" validate:"required,oneof=home work other"`
+	Line1 string `json:"line1" validate:"required,max=200"`
+	Line2 string `json:"line2,omitempty" validate:"omitempty,max=200"`
+	City  string `json:"city" validate:"required,max=100"`
+	// Region is the state, province or territory, required in some countries.
+	Region string `json:"region,omitempty" validate:"omitempty,max=100"`
+	// PostalCode must follow the format of the country.
+	PostalCode string `json:"postalCode,omitempty" validate:"omitempty,max=20"`
+	// Country is an ISO 3166-1 alpha-2 code.
+	Country string `json:"country" validate:"required,len=2"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type UpdatePostalAddressRequest struct {
+	Label      string `json:"label,omitempty" validate:"omitempty,oneof=home work other"`
+	Line1      string `json:"line1,omitempty" validate:"omitempty,max=200"`
+	Line2      string `json:"line2,omitempty" validate:"omitempty,max=200"`
+	City       string `json:"city,omitempty" validate:"omitempty,max=100"`
+	Region     string `json:"region,omitempty" validate:"omitempty,max=100"`
+	PostalCode string `json:"postalCode,omitempty" validate:"omitempty,max=20"`
+	Country    string `json:"country,omitempty" validate:"omitempty,len=2"`
+	// Primary makes the address the primary one. It cannot be unset.
+	Primary bool `json:"primary,omitempty"`
+}
+
+type PostalAddressResponse struct {
+	AddressID  string    `json:"addressId"`
+	Label      string    `json:"label"`
+	Line1      string    `json:"line1"`
+	Line2      string    `json:"line2,omitempty"`
+	City       string    `json:"city"`
+	Region     string    `json:"region,omitempty"`
+	PostalCode string    `json:"postalCode,omitempty"`
+	Country    string    `json:"country"`
+	Primary    bool      `json:"primary"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func (request PostalAddressRequest) getPostalAddress() domain.PostalAddress {
+	return domain.PostalAddress{
+		Label:      request.Label,
+		Line1:      request.Line1,
+		Line2:      request.Line2,
+		City:       request.City,
+		Region:     request.Region,
+		PostalCode: request.PostalCode,
+		Country:    strings.ToUpper(request.Country),
+		Primary:    request.Primary,
+	}
+}
+
+func (request UpdatePostalAddressRequest) getPostalAddress() domain.PostalAddress {
+	return domain.PostalAddress{
+		Label:      request.Label,
+		Line1:      request.Line1,
+		Line2:      request.Line2,
+		City:       request.City,
+		Region:     request.Region,
+		PostalCode: request.PostalCode,
+		Country:    strings.ToUpper(request.Country),
+		Primary:    request.Primary,
+	}
+}
+
+func parsePostalAddressToDTO(address domain.PostalAddress) PostalAddressResponse {
+	return PostalAddressResponse{
+		AddressID:  address.AddressID,
+		Label:      address.Label,
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+		Primary:    address.Primary,
+		CreatedAt:  address.CreatedAt,
+	}
+}
+
+type AvatarResponse struct {
+	ContentType string `json:"contentType"`
+	// Sizes are the thumbnails that can be requested with the size parameter.
+	Sizes []int `json:"sizes"`
+}
+
+func parseAvatarToDTO(avatar domain.Avatar) AvatarResponse {
+	return AvatarResponse{ContentType: avatar.ContentType, Sizes: avatar.Sizes}
+}
+
+type HealthCheckResponse struct {
+	Name string `json:"name"`
+	// Status is ok or failing.
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type HealthResponse struct {
+	// Status is ok, failing or draining.
+	Status string                `json:"status"`
+	Checks []HealthCheckResponse `json:"checks"`
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" validate:"required,url,max=2000"`
+	// EventTypes are among user.created, user.updated, user.deleted and user.status_changed.
+	EventTypes []string `json:"eventTypes" validate:"required,min=1,dive,required"`
+	// Secret signs the deliveries, generated when left out.
+	Secret string `json:"secret,omitempty" validate:"omitempty,min=16,max=200"`
+}
+
+type WebhookResponse struct {
+	WebhookID  string   `json:"webhookId"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	// Secret is only returned when the webhook is created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func parseWebhookToDTO(webhook domain.Webhook) WebhookResponse {
+	response := WebhookResponse{
+		WebhookID:  webhook.WebhookID,
+		URL:        webhook.URL,
+		EventTypes: make([]string, len(webhook.EventTypes)),
+		Secret:     webhook.Secret,
+		CreatedAt:  webhook.CreatedAt,
+	}
+	for i, eventType := range webhook.EventTypes {
+		response.EventTypes[i] = string(eventType)
+	}
+	return response
+}
+
+type WebhookAttemptResponse struct {
+	AttemptedAt time.Time `json:"attemptedAt"`
+	DurationMs  float64   `json:"durationMs"`
+	// StatusCode is the status of the response, left out when there was none.
+	StatusCode int `json:"statusCode,omitempty"`
+	// Response is the beginning of the response body.
+	Response string `json:"response,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type WebhookDeliveryResponse struct {
+	DeliveryID string `json:"deliveryId"`
+	EventID    string `json:"eventId"`
+	EventType  string `json:"eventType"`
+	// Status is pending, succeeded or dead.
+	Status        string                   `json:"status"`
+	Attempts      []WebhookAttemptResponse `json:"attempts"`
+	NextAttemptAt *time.Time               `json:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time                `json:"createdAt"`
+	DeliveredAt   *time.Time               `json:"deliveredAt,omitempty"`
+}
+
+func parseWebhookDeliveryToDTO(delivery domain.WebhookDelivery) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		DeliveryID: delivery.DeliveryID,
+		EventID:    delivery.EventID,
+		EventType:  string(delivery.EventType),
+		Status:     strings.ToLower(string(delivery.Status)),
+		Attempts:   make([]WebhookAttemptResponse, len(delivery.Attempts)),
+		CreatedAt:  delivery.CreatedAt,
+	}
+	for i, attempt := range delivery.Attempts {
+		response.Attempts[i] = WebhookAttemptResponse{
+			AttemptedAt: attempt.AttemptedAt,
+			DurationMs:  float64(attempt.Duration.Microseconds()) / 1000,
+			StatusCode:  attempt.StatusCode,
+			Response:    attempt.Response,
+			Error:       attempt.Error,
+		}
+	}
+	if delivery.Status == domain.DeliveryPending {
+		response.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	if !delivery.DeliveredAt.IsZero() {
+		response.DeliveredAt = &delivery.DeliveredAt
+	}
+	return response
+}
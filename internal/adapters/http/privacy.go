@@ -0,0 +1,187 @@
+package http
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ExportUserData godoc
+//
+//	@Summary		Export the data of a user
+//	@Description	Returns everything held about a user as JSON, or as a ZIP archive when application/zip is accepted or format=zip is given. The export is recorded as a privacy request. Requires a session of the user or the admin token.
+//	@Tags privacy
+//	@Produce		json
+//	@Produce		application/zip
+//	@Param user_id  path string true "User ID"
+//	@Param format  query string false "json or zip"
+//	@Success		200	{object} DataExportResponse
+//	@Failure		404	{object}	map[string]string
+//	@Router			/users/{user_id}/data-export [get]
+func getDataExport(service ports.PrivacyService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		export, err := service.ExportUserData(r.Context(), userID)
+		if err != nil {
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not export the user data: %w", err).Error())
+			http.Error(w, fmt.Sprintf("could not export the data of user %s", userID), http.StatusNotFound)
+			return
+		}
+		response := parseDataExportToDTO(export)
+		if r.URL.Query().Get("format") == "zip" || strings.Contains(r.Header.Get("Accept"), "application/zip") {
+			writeDataExportArchive(w, userID, response, export.Avatar)
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// writeDataExportArchive writes the export as a ZIP with one JSON document per
+// section, and the avatar as an image file.
+func writeDataExportArchive(w http.ResponseWriter, userID string, response DataExportResponse, avatar *domain.Blob) {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%s-export.zip"`, userID))
+	w.WriteHeader(http.StatusOK)
+	archive := zip.NewWriter(w)
+	type section struct {
+		name string
+		body any
+	}
+	sections := []section{
+		{"profile.json", response.Profile},
+		{"privacy-requests.json", response.PrivacyRequests},
+		{"erasure-certificates.json", response.ErasureCertificates},
+		{"status-history.json", response.StatusHistory},
+		{"mfa-factors.json", response.MFAFactors},
+		{"sessions.json", response.Sessions},
+		{"scheduled-actions.json", response.ScheduledActions},
+		{"groups.json", response.Groups},
+		{"contact-methods.json", response.ContactMethods},
+		{"postal-addresses.json", response.PostalAddresses},
+		{"webhook-deliveries.json", response.WebhookDeliveries},
+	}
+	if response.PhoneVerification != nil {
+		sections = append(sections, section{"phone-verification.json", response.PhoneVerification})
+	}
+	if response.Credential != nil {
+		sections = append(sections, section{"credential.json", response.Credential})
+	}
+	sections = append(sections, section{"export.json", map[string]any{"userId": userID, "exportedAt": response.ExportedAt}})
+	for _, section := range sections {
+		file, err := archive.Create(section.name)
+		if err == nil {
+			encoder := json.NewEncoder(file)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(section.body)
+		}
+		if err != nil {
+			slog.Error(fmt.Errorf("could not write %s to the export: %w", section.name, err).Error())
+			return
+		}
+	}
+	if avatar != nil {
+		name := "avatar." + strings.TrimPrefix(avatar.ContentType, "image/")
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: avatar.ModTime})
+		if err == nil {
+			_, err = file.Write(avatar.Data)
+		}
+		if err != nil {
+			slog.Error(fmt.Errorf("could not write %s to the export: %w", name, err).Error())
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		slog.Error(fmt.Errorf("could not finish the export archive: %w", err).Error())
+	}
+}
+
+// EraseUser godoc
+// @Summary Erase a user
+// @Description Irreversibly anonymizes the personal data of a user while keeping the record, and issues an erasure certificate. Requires the admin token.
+// @Tags privacy
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Success 200 {object} PrivacyRequestResponse
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} PrivacyRequestResponse
+// @Router /users/{user_id}:erase [post]
+func eraseUser(service ports.PrivacyService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		request, err := service.EraseUser(r.Context(), userID)
+		switch {
+		case errors.Is(err, domain.ErrAlreadyErased):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil && request.RequestID != "":
+			// The request was recorded, return it so the failure can be followed up.
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not erase the user: %w", err).Error())
+			writeJSON(w, http.StatusInternalServerError, parsePrivacyRequestToDTO(request))
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not erase the user: %w", err).Error())
+			http.Error(w, fmt.Sprintf("could not erase user %s", userID), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, parsePrivacyRequestToDTO(request))
+	}
+}
+
+// GetPrivacyRequests godoc
+//
+//	@Summary		Get all privacy requests
+//	@Description	Lists the export and erasure requests of the tenant with their status and due date, newest first. Requires the admin token.
+//	@Tags privacy
+//	@Produce		json
+//	@Success		200	{array} PrivacyRequestResponse
+//	@Failure		500	{object}	map[string]string
+//	@Router			/privacy-requests [get]
+func getPrivacyRequests(service ports.PrivacyService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requests, err := service.GetPrivacyRequests(r.Context())
+		if err != nil {
+			logging.FromContext(r.Context()).Error(fmt.Errorf("error getting the privacy requests: %w", err).Error())
+			http.Error(w, "could not retrieve the privacy requests", http.StatusInternalServerError)
+			return
+		}
+		requestDTOs := make([]PrivacyRequestResponse, len(requests))
+		for i, request := range requests {
+			requestDTOs[i] = parsePrivacyRequestToDTO(request)
+		}
+		writeJSON(w, http.StatusOK, requestDTOs)
+	}
+}
+
+// GetPrivacyRequest godoc
+//
+//	@Summary		Get a privacy request
+//	@Description	Retrieves an export or erasure request by id. Requires the admin token.
+//	@Tags privacy
+//	@Produce		json
+//	@Param request_id  path string true "Request ID"
+//	@Success		200	{object} PrivacyRequestResponse
+//	@Failure		404	{object}	map[string]string
+//	@Router			/privacy-requests/{request_id} [get]
+func getPrivacyRequest(service ports.PrivacyService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := chi.URLParam(r, "requestId")
+		request, err := service.GetPrivacyRequest(r.Context(), requestID)
+		if err != nil {
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the privacy request: %w", err).Error())
+			http.Error(w, fmt.Sprintf("could not retrieve the privacy request %s", requestID), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, parsePrivacyRequestToDTO(request))
+	}
+}
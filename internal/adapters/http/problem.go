@@ -0,0 +1,110 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details document.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// TraceID identifies the trace of the failed request.
+	TraceID string `json:"traceId,omitempty"`
+}
+
+// acceptsProblems tells whether the client asked for problem documents.
+func acceptsProblems(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == problemContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// problemResponses turns the plain text error responses of the handlers into
+// problem documents for the clients that accept them. Other clients keep
+// getting the plain text.
+func problemResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsProblems(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		pw := &problemWriter{ResponseWriter: w, request: r}
+		next.ServeHTTP(pw, r)
+		pw.finish()
+	})
+}
+
+// problemWriter holds back plain text error bodies until the handler is done.
+type problemWriter struct {
+	http.ResponseWriter
+	request     *http.Request
+	status      int
+	wroteHeader bool
+	converting  bool
+	detail      bytes.Buffer
+}
+
+func (w *problemWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if status >= http.StatusBadRequest && mediaType == "text/plain" {
+		w.converting = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *problemWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.converting {
+		return w.detail.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *problemWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *problemWriter) finish() {
+	if !w.converting {
+		return
+	}
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(w.status),
+		Status:   w.status,
+		Detail:   strings.TrimSpace(w.detail.String()),
+		Instance: w.request.URL.Path,
+	}
+	if spanContext := trace.SpanContextFromContext(w.request.Context()); spanContext.IsValid() {
+		problem.TraceID = spanContext.TraceID().String()
+	}
+	body, _ := json.Marshal(problem)
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(body)
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"userapi/app/internal/adapters/logging"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestLogger puts a logger carrying the chi request id, and the trace and
+// span ids when the request is traced, into the request context and logs one
+// line per request. Only the route pattern is logged, so ids in the path and
+// request bodies never reach the logs.
+func requestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			requestLogger := logger.With("requestId", middleware.GetReqID(r.Context()))
+			if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+				requestLogger = requestLogger.With("traceId", spanContext.TraceID().String(), "spanId", spanContext.SpanID().String())
+			}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(logging.ContextWithLogger(r.Context(), requestLogger)))
+			route := "unmatched"
+			if routeContext := chi.RouteContext(r.Context()); routeContext != nil && routeContext.RoutePattern() != "" {
+				route = routeContext.RoutePattern()
+			}
+			level := slog.LevelInfo
+			switch {
+			case route == "/livez" || route == "/readyz":
+				// Probes would otherwise drown the other requests.
+				level = slog.LevelDebug
+			case ww.Status() >= http.StatusInternalServerError:
+				level = slog.LevelError
+			}
+			requestLogger.Log(r.Context(), level, "request completed",
+				"method", r.Method,
+				"route", route,
+				"status", ww.Status(),
+				"bytes", ww.BytesWritten(),
+				"duration", time.Since(start),
+			)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ScheduleAction godoc
+// @Summary Schedule a status change
+// @Description Deactivates or reactivates the user at the given time, for instance on the end date of a contractor. A single replica runs the due actions, about once a minute. Requires the admin token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Param request body ScheduleActionRequest true "Action and time"
+// @Success 201 {object} ScheduledActionResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/schedules [post]
+func postSchedule(service ports.ScheduleService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		request := ScheduleActionRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		action, err := service.ScheduleAction(r.Context(), userID, domain.ScheduledAction{
+			Kind:   domain.ScheduledActionKind(strings.ToUpper(request.Action)),
+			RunAt:  request.RunAt,
+			Reason: request.Reason,
+		})
+		switch {
+		case errors.Is(err, domain.ErrInvalidSchedule):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not schedule the action: %w", err).Error())
+			http.Error(w, "could not schedule the action", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("scheduled an action", "userId", userID, "scheduleId", action.ActionID, "action", request.Action)
+		writeJSON(w, http.StatusCreated, parseScheduledActionToDTO(action))
+	}
+}
+
+// GetSchedules godoc
+//
+//	@Summary		Get the scheduled status changes of a user
+//	@Description	Lists the scheduled actions of a user, pending or not, by time. Requires the admin token.
+//	@Tags users
+//	@Produce		json
+//	@Param user_id  path string true "User ID"
+//	@Success		200	{array} ScheduledActionResponse
+//	@Failure		404	{object}	map[string]string
+//	@Failure		500	{object}	map[string]string
+//	@Router			/users/{user_id}/schedules [get]
+func getSchedules(service ports.ScheduleService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		actions, err := service.GetScheduledActions(r.Context(), userID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the scheduled actions: %w", err).Error())
+			http.Error(w, "could not retrieve the scheduled actions", http.StatusInternalServerError)
+			return
+		}
+		actionDTOs := make([]ScheduledActionResponse, len(actions))
+		for i, action := range actions {
+			actionDTOs[i] = parseScheduledActionToDTO(action)
+		}
+		writeJSON(w, http.StatusOK, actionDTOs)
+	}
+}
+
+// CancelSchedule godoc
+// @Summary Cancel a scheduled status change
+// @Description Cancels a pending scheduled action of a user. Requires the admin token.
+// @Tags users
+// @Param user_id  path string true "User ID"
+// @Param schedule_id  path string true "Schedule ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/schedules/{schedule_id} [delete]
+func cancelSchedule(service ports.ScheduleService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		scheduleID := chi.URLParam(r, "scheduleId")
+		err := service.CancelScheduledAction(r.Context(), userID, scheduleID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not find the pending action %s", scheduleID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not cancel the scheduled action: %w", err).Error())
+			http.Error(w, "could not cancel the scheduled action", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("cancelled a scheduled action", "userId", userID, "scheduleId", scheduleID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
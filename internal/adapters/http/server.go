@@ -6,30 +6,223 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "userapi/app/docs"
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/adapters/metrics"
+	"userapi/app/internal/core/domain"
 	"userapi/app/internal/core/ports"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 type Server struct {
-	UserService ports.UserService
+	UserService   ports.UserService
+	TenantService ports.TenantService
+	// PrivacyService serves the data subject routes when set.
+	PrivacyService ports.PrivacyService
+	// VerificationService serves the contact verification routes when set.
+	VerificationService ports.VerificationService
+	// CredentialService serves the password and /auth routes when set.
+	CredentialService ports.CredentialService
+	// MFAService serves the second factor routes when set.
+	MFAService ports.MFAService
+	// SessionService serves the session routes and rejects the tokens of
+	// revoked sessions when set. It needs the TokenSigner.
+	SessionService ports.SessionService
+	// ScheduleService serves the scheduled status change routes when set.
+	ScheduleService ports.ScheduleService
+	// GroupService serves the group routes when set.
+	GroupService ports.GroupService
+	// ContactService serves the email, phone and postal address routes of users when set.
+	ContactService ports.ContactService
+	// AvatarService serves the avatar routes when set.
+	AvatarService ports.AvatarService
+	// WebhookService serves the webhook subscription routes when set.
+	WebhookService ports.WebhookService
+	// AvatarMaxBytes limits the size of avatar uploads, to 5 MiB when zero.
+	AvatarMaxBytes int64
+	// Metrics, when set, records the requests and is served on /metrics.
+	Metrics     *prometheus.Registry
+	TokenSigner ports.TokenSigner
 	Router      *chi.Mux
 	Validator   ports.Validator
-	httpServer  *http.Server
+	// DefaultTenant is the slug used when a request does not name a tenant.
+	DefaultTenant string
+	// StepUpMaxAge is how recent the login of users must be for them to
+	// change their second factors, 10 minutes by default.
+	StepUpMaxAge time.Duration
+	// AdminToken authenticates the admin, on the /admin routes and on the API
+	// routes only the admin may use. The /admin routes are not mounted when it
+	// is empty.
+	AdminToken string
+	// RateLimiter, when set, limits the requests of each client to the API
+	// and admin routes.
+	RateLimiter *RateLimiter
+	// Addr is the address the server listens on, :8080 by default.
+	Addr         string
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// HealthCheckers are run by the /readyz and /healthz probes.
+	HealthCheckers []ports.HealthChecker
+	// DrainDelay is how long Stop keeps serving after failing the readiness
+	// probe, for load balancers to take the server out of rotation.
+	DrainDelay time.Duration
+	// mu guards httpServer, as Stop may run before or while Start does.
+	mu         sync.Mutex
+	httpServer *http.Server
+	draining   atomic.Bool
+	routesOnce sync.Once
 }
 
 func initServer(server *Server) {
-	server.Router.Get("/users", getAllUsers(server.UserService))
-	server.Router.Get("/users/{userId}", getUser(server.UserService))
-	server.Router.Post("/users", postUser(server.UserService, server.Validator))
-	server.Router.Delete("/users/{userId}", deleteUser(server.UserService))
-	server.Router.Patch("/users/{userId}", patchUser(server.UserService, server.Validator))
+	if server.Metrics != nil {
+		server.Router.Use(instrumentRequests(server.Router, metrics.NewHTTPMetrics(server.Metrics)))
+		server.Router.Method(http.MethodGet, "/metrics", promhttp.HandlerFor(server.Metrics, promhttp.HandlerOpts{}))
+	}
+	server.Router.Get("/livez", getLivez)
+	server.Router.Get("/readyz", getReadyz(server))
+	server.Router.Get("/healthz", getHealthz(server))
+	server.Router.Group(func(router chi.Router) {
+		if server.RateLimiter != nil {
+			router.Use(server.RateLimiter.limit)
+		}
+		router.Use(authenticate(server.TokenSigner, server.AdminToken))
+		router.Use(resolveTenant(server.TenantService, server.DefaultTenant))
+		router.Use(clientInfo)
+		if server.SessionService != nil && server.TokenSigner != nil {
+			router.Use(checkSession(server.SessionService))
+		}
+		// The routes without either are the login ones, and the confirmations
+		// carrying a token.
+		admin := router.With(requireAdmin)
+		self := router.With(requireUser)
+		admin.Get("/users", getAllUsers(server.UserService))
+		self.Get("/users/{userId}", getUser(server.UserService))
+		admin.Post("/users", postUser(server.UserService, server.Validator))
+		admin.Delete("/users/{userId}", deleteUser(server.UserService))
+		admin.Patch("/users/{userId}", patchUser(server.UserService, server.Validator))
+		admin.Post("/users/{userId}:activate", activateUser(server.UserService, server.Validator))
+		admin.Post("/users/{userId}:suspend", suspendUser(server.UserService, server.Validator))
+		admin.Post("/users/{userId}:reactivate", reactivateUser(server.UserService, server.Validator))
+		self.Get("/users/{userId}/status-history", getStatusHistory(server.UserService))
+		if server.VerificationService != nil {
+			self.Post("/users/{userId}/email-verification", requestEmailVerification(server.VerificationService))
+			router.Post("/email-verification:confirm", confirmEmailVerification(server.VerificationService, server.Validator))
+			self.Post("/users/{userId}/phone-verification", requestPhoneVerification(server.VerificationService))
+			self.Post("/users/{userId}/phone-verification:confirm", confirmPhoneVerification(server.VerificationService, server.Validator))
+		}
+		if server.CredentialService != nil {
+			self.Post("/users/{userId}/password", setPassword(server.CredentialService, server.Validator))
+			router.Post("/auth/login", login(server.CredentialService, server.Validator))
+			router.Post("/auth/password-reset", requestPasswordReset(server.CredentialService, server.Validator))
+			router.Post("/auth/password-reset:confirm", confirmPasswordReset(server.CredentialService, server.Validator))
+			if server.MFAService != nil {
+				router.Post("/auth/login:mfa", completeLogin(server.CredentialService, server.Validator))
+			}
+		}
+		if server.MFAService != nil {
+			stepUp := self.With(requireRecentLogin(server.StepUpMaxAge))
+			stepUp.Post("/users/{userId}/mfa/totp", enrollTOTP(server.MFAService, server.Validator))
+			stepUp.Post("/users/{userId}/mfa/totp/{factorId}:confirm", confirmTOTP(server.MFAService, server.Validator))
+			stepUp.Post("/users/{userId}/mfa/webauthn/registration", beginWebAuthnRegistration(server.MFAService))
+			stepUp.Post("/users/{userId}/mfa/webauthn/registration:finish", finishWebAuthnRegistration(server.MFAService, server.Validator))
+			router.With(requireLoginOf).Post("/users/{userId}/mfa/webauthn/assertion", beginWebAuthnAssertion(server.MFAService))
+			stepUp.Post("/users/{userId}/mfa/recovery-codes", generateRecoveryCodes(server.MFAService))
+		}
+		if server.SessionService != nil && server.TokenSigner != nil {
+			router.Post("/auth/refresh", refreshSession(server.SessionService, server.Validator))
+			self.Get("/users/{userId}/sessions", getSessions(server.SessionService))
+			self.Delete("/users/{userId}/sessions", revokeAllSessions(server.SessionService))
+			self.Delete("/users/{userId}/sessions/{sessionId}", revokeSession(server.SessionService))
+		}
+		if server.ScheduleService != nil {
+			admin.Post("/users/{userId}/schedules", postSchedule(server.ScheduleService, server.Validator))
+			admin.Get("/users/{userId}/schedules", getSchedules(server.ScheduleService))
+			admin.Delete("/users/{userId}/schedules/{scheduleId}", cancelSchedule(server.ScheduleService))
+		}
+		if server.GroupService != nil {
+			admin.Post("/groups", postGroup(server.GroupService, server.Validator))
+			admin.Get("/groups", getGroups(server.GroupService))
+			admin.Get("/groups/{groupId}", getGroup(server.GroupService))
+			admin.Patch("/groups/{groupId}", patchGroup(server.GroupService, server.Validator))
+			admin.Delete("/groups/{groupId}", deleteGroup(server.GroupService))
+			admin.Get("/groups/{groupId}/members", getGroupMembers(server.GroupService))
+			admin.Put("/groups/{groupId}/members/{userId}", putGroupMember(server.GroupService))
+			admin.Delete("/groups/{groupId}/members/{userId}", deleteGroupMember(server.GroupService))
+			admin.Put("/groups/{groupId}/groups/{memberGroupId}", putSubgroup(server.GroupService))
+			admin.Delete("/groups/{groupId}/groups/{memberGroupId}", deleteSubgroup(server.GroupService))
+			self.Get("/users/{userId}/groups", getUserGroups(server.GroupService))
+		}
+		if server.ContactService != nil {
+			for path, kind := range map[string]domain.ContactKind{"emails": domain.ContactEmail, "phones": domain.ContactPhone} {
+				self.Get("/users/{userId}/"+path, getContactMethods(server.ContactService, kind))
+				self.Post("/users/{userId}/"+path, postContactMethod(server.ContactService, server.Validator, kind))
+				self.Patch("/users/{userId}/"+path+"/{contactId}", patchContactMethod(server.ContactService, server.Validator, kind))
+				self.Delete("/users/{userId}/"+path+"/{contactId}", deleteContactMethod(server.ContactService, kind))
+			}
+			self.Get("/users/{userId}/addresses", getPostalAddresses(server.ContactService))
+			self.Post("/users/{userId}/addresses", postPostalAddress(server.ContactService, server.Validator))
+			self.Patch("/users/{userId}/addresses/{addressId}", patchPostalAddress(server.ContactService, server.Validator))
+			self.Delete("/users/{userId}/addresses/{addressId}", deletePostalAddress(server.ContactService))
+		}
+		if server.AvatarService != nil {
+			self.Put("/users/{userId}/avatar", putAvatar(server.AvatarService, server.AvatarMaxBytes))
+			self.Get("/users/{userId}/avatar", getAvatar(server.AvatarService))
+			self.Delete("/users/{userId}/avatar", deleteAvatar(server.AvatarService))
+		}
+		if server.WebhookService != nil {
+			admin.Post("/webhooks", postWebhook(server.WebhookService, server.Validator))
+			admin.Get("/webhooks", getWebhooks(server.WebhookService))
+			admin.Get("/webhooks/{webhookId}", getWebhook(server.WebhookService))
+			admin.Delete("/webhooks/{webhookId}", deleteWebhook(server.WebhookService))
+			admin.Get("/webhooks/{webhookId}/deliveries", getWebhookDeliveries(server.WebhookService))
+			admin.Post("/webhooks/{webhookId}/deliveries/{deliveryId}:redeliver", redeliverWebhookDelivery(server.WebhookService))
+		}
+		if server.PrivacyService != nil {
+			self.Get("/users/{userId}/data-export", getDataExport(server.PrivacyService))
+			admin.Post("/users/{userId}:erase", eraseUser(server.PrivacyService))
+			admin.Get("/privacy-requests", getPrivacyRequests(server.PrivacyService))
+			admin.Get("/privacy-requests/{requestId}", getPrivacyRequest(server.PrivacyService))
+		}
+	})
+	if server.AdminToken != "" && server.TenantService != nil {
+		server.Router.Route("/admin", func(router chi.Router) {
+			if server.RateLimiter != nil {
+				router.Use(server.RateLimiter.limit)
+			}
+			router.Use(requireAdminToken(server.AdminToken))
+			router.Get("/tenants", getAllTenants(server.TenantService))
+			router.Post("/tenants", postTenant(server.TenantService, server.Validator))
+			router.Get("/tenants/{tenantId}", getTenant(server.TenantService))
+			router.Post("/tenants/{tenantId}:disable", disableTenant(server.TenantService))
+			router.With(tenantFromPath(server.TenantService)).Route("/tenants/{tenantId}/attribute-schema", func(router chi.Router) {
+				router.Get("/", getAttributeSchema(server.TenantService))
+				router.Put("/", putAttributeSchema(server.TenantService))
+				router.Delete("/", deleteAttributeSchema(server.TenantService))
+			})
+			if server.MFAService != nil {
+				router.With(tenantFromPath(server.TenantService)).Route("/tenants/{tenantId}/users/{userId}/mfa/factors", func(router chi.Router) {
+					router.Get("/", getMFAFactors(server.MFAService))
+					router.Delete("/{factorId}", revokeMFAFactor(server.MFAService))
+				})
+			}
+		})
+	}
 	// assign docs.
 	server.Router.Get("/doc", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/doc/index.html", http.StatusMovedPermanently)
@@ -40,79 +233,204 @@ func initServer(server *Server) {
 func NewServer(userService ports.UserService, validator ports.Validator) *Server {
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
-	router.Use(middleware.Logger)
+	router.Use(traceRequests)
+	router.Use(requestLogger(logging.ForPackage("http")))
+	router.Use(problemResponses)
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.StripSlashes)
-	return &Server{UserService: userService, Router: router, Validator: validator}
-}
-
-func (server *Server) Start() error {
-	initServer(server)
-	srv := &http.Server{
+	return &Server{
+		UserService:  userService,
+		Router:       router,
+		Validator:    validator,
+		StepUpMaxAge: 10 * time.Minute,
 		Addr:         ":8080",
-		Handler:      server.Router,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
-	err := srv.ListenAndServe()
+}
+
+// Handler mounts the routes, on the first call, and returns the router. The
+// optional services must be set before.
+func (server *Server) Handler() http.Handler {
+	server.routesOnce.Do(func() { initServer(server) })
+	return server.Router
+}
+
+// Start listens on the Addr and serves in the background. It returns once
+// the server accepts connections; a later failure to serve is passed to fail.
+func (server *Server) Start(fail func(error)) error {
+	handler := server.Handler()
+	listener, err := net.Listen("tcp", server.Addr)
 	if err != nil {
-		slog.Error("could not start the server", err)
-		return err
+		return fmt.Errorf("could not listen on %s: %w", server.Addr, err)
 	}
-	slog.Info("starting the server on port 8080")
-	server.httpServer = srv
+	httpServer := &http.Server{
+		Handler:      handler,
+		ReadTimeout:  server.ReadTimeout,
+		WriteTimeout: server.WriteTimeout,
+		IdleTimeout:  server.IdleTimeout,
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.draining.Load() {
+		_ = listener.Close()
+		return errors.New("the server is stopping")
+	}
+	server.httpServer = httpServer
+	slog.Info("serving", "addr", listener.Addr().String())
+	go func() {
+		if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			fail(fmt.Errorf("could not serve: %w", err))
+		}
+	}()
 	return nil
 }
 
-func (server *Server) Stop() error {
-	slog.Info("stopping server")
-	return server.httpServer.Shutdown(context.Background())
+// Stop fails the readiness probe, keeps serving for the DrainDelay, then
+// waits for the requests in progress to complete. Connections still open
+// when ctx expires are closed. Stopped before Start, the server never serves.
+func (server *Server) Stop(ctx context.Context) error {
+	server.mu.Lock()
+	server.draining.Store(true)
+	httpServer := server.httpServer
+	server.mu.Unlock()
+	if httpServer == nil {
+		return nil
+	}
+	slog.Info("draining", "delay", server.DrainDelay)
+	select {
+	case <-time.After(server.DrainDelay):
+	case <-ctx.Done():
+	}
+	if err := httpServer.Shutdown(ctx); err != nil {
+		_ = httpServer.Close()
+		return fmt.Errorf("could not complete the requests in progress: %w", err)
+	}
+	return nil
 }
 
 // GetAllUsers godoc
 //
 //	@Summary		Get all users
-//	@Description	Retrieves all users from the database.
+//	@Description	Retrieves all users from the database. Requires the admin token.
 //	@Tags users
 //	@Accept			json
 //	@Produce		json
+//	@Param			email	query	string	false	"Only return the user with this email"
+//	@Param			attributes.name	query	string	false	"Only return the users whose attribute name has this value, given as JSON or as a bare string. Repeat for several attributes."
+//	@Param			limit	query	int	false	"Return pages of at most this many users, by user id, up to 1000. Every user is returned when omitted."
+//	@Param			cursor	query	string	false	"Return the users after this user id, as given by the Link header of the previous page"
 //	@Success		200	{array} UserResponse
+//	@Header			200	{string}	Link	"The next page, as <url>; rel=\"next\", when there is one"
+//	@Failure		400	{object}	map[string]string
 //	@Failure		500	{object}	map[string]string
 //	@Router			/users [get]
 func getAllUsers(service ports.UserService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		users, err := service.GetAllUsers(r.Context())
+		limit, err := pageLimit(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		cursor := r.URL.Query().Get("cursor")
+		var users []domain.User
+		if email := r.URL.Query().Get("email"); email != "" {
+			users, err = getUsersByEmail(r.Context(), service, email)
+		} else if limit > 0 {
+			// One more user than the limit tells whether there is a next page.
+			users, err = service.GetUsersPage(r.Context(), attributeFilter(r.URL.Query()), cursor, limit+1)
+		} else if attributes := attributeFilter(r.URL.Query()); len(attributes) > 0 {
+			users, err = service.GetUsersByAttributes(r.Context(), attributes)
+		} else {
+			users, err = service.GetAllUsers(r.Context())
+		}
 		if err != nil {
 			serverErr := fmt.Errorf("error getting all users: %w", err)
-			slog.Error(serverErr.Error())
+			logging.FromContext(r.Context()).Error(serverErr.Error())
 			http.Error(w, errors.New("could not retrieve the users").Error(), http.StatusInternalServerError)
 			return
 		}
+		if limit > 0 && len(users) > limit {
+			users = users[:limit]
+			nextURL := *r.URL
+			query := nextURL.Query()
+			query.Set("cursor", users[limit-1].UserID)
+			nextURL.RawQuery = query.Encode()
+			w.Header().Set("Link", "<"+nextURL.RequestURI()+`>; rel="next"`)
+		}
 		userDTOs := make([]UserResponse, len(users))
 		for i, user := range users {
 			userDTOs[i] = parseUserToUserDTO(user)
 		}
-		blob, err := json.Marshal(users)
-		if err != nil {
-			marshalErr := fmt.Errorf("error marshalling users: %w", err)
-			slog.Error(marshalErr.Error())
-			http.Error(w, errors.New("could not retrieve the users").Error(), http.StatusInternalServerError)
-			return
+		writeJSON(w, http.StatusOK, userDTOs)
+	}
+}
+
+// getUsersByEmail looks a user up by email. A missing user is an empty result.
+func getUsersByEmail(ctx context.Context, service ports.UserService, email string) ([]domain.User, error) {
+	user, err := service.GetUserByEmail(ctx, email)
+	if errors.Is(err, domain.ErrNotFound) {
+		return []domain.User{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []domain.User{user}, nil
+}
+
+// maxPageSize bounds the limit of the user pages.
+const maxPageSize = 1000
+
+// pageLimit returns the page size the limit and cursor query parameters ask
+// for, 0 when every user is asked for.
+func pageLimit(query url.Values) (int, error) {
+	if cursor := query.Get("cursor"); cursor != "" {
+		if _, err := uuid.Parse(cursor); err != nil {
+			return 0, errors.New("cursor must be a user id")
+		}
+	}
+	if query.Get("limit") == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 || limit > maxPageSize {
+		return 0, fmt.Errorf("limit must be a number between 1 and %d", maxPageSize)
+	}
+	return limit, nil
+}
+
+// attributeFilterPrefix marks the query parameters filtering users by attribute.
+const attributeFilterPrefix = "attributes."
+
+// attributeFilter collects the attributes.<name>=<value> query parameters. A
+// value that parses as JSON is taken as such, so attributes.level=3 matches the
+// number 3 and attributes.level="3" the string; anything else is a string.
+func attributeFilter(query url.Values) map[string]any {
+	attributes := map[string]any{}
+	for key, values := range query {
+		name, found := strings.CutPrefix(key, attributeFilterPrefix)
+		if !found || name == "" || len(values) == 0 {
+			continue
+		}
+		var value any
+		if err := json.Unmarshal([]byte(values[0]), &value); err != nil {
+			value = values[0]
 		}
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write(blob)
+		attributes[name] = value
 	}
+	return attributes
 }
 
 // GetUser godoc
 //
-//	@Summary		Get all users
-//	@Description	Retrieves all users from the database.
+//	@Summary		Get a user
+//	@Description	Retrieves a user by user id. Requires a session of the user or the admin token.
 //	@Tags users
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{array} UserResponse
+//	@Success		200	{object} UserResponse
+//	@Failure		404	{object}	map[string]string
 //	@Failure		500	{object}	map[string]string
 //	@Router			/users/{user_id} [get]
 //	@Param user_id  path string true "User ID"
@@ -120,38 +438,30 @@ func getUser(userService ports.UserService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID := chi.URLParam(r, "userId")
 		user, err := userService.GetUserById(r.Context(), userID)
-		if err != nil {
-			serverErr := fmt.Errorf("could not retrieve the user: %w", err)
-			slog.Error(serverErr.Error())
-			notFoundErr := fmt.Errorf("could not retrieve the user %s", userID)
-			w.WriteHeader(http.StatusNotFound)
-			_, _ = w.Write([]byte(notFoundErr.Error()))
+		if errors.Is(err, domain.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("could not find the user %s", userID), http.StatusNotFound)
 			return
 		}
-		userDTO := parseUserToUserDTO(user)
-		blob, err := json.Marshal(userDTO)
 		if err != nil {
-			marshalErr := fmt.Errorf("error marshalling user: %w", err)
-			slog.Error(marshalErr.Error())
+			serverErr := fmt.Errorf("could not retrieve the user: %w", err)
+			logging.FromContext(r.Context()).Error(serverErr.Error())
 			http.Error(w, errors.New("could not retrieve the user").Error(), http.StatusInternalServerError)
 			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-
-		_, _ = w.Write(blob)
+		writeJSON(w, http.StatusOK, parseUserToUserDTO(user))
 	}
 }
 
 // CreateUser godoc
 // @Summary Create a new user
-// @Description Create a user with first name, last name, and email, and other optional data.
+// @Description Create a user with first name, last name, and email, and other optional data. Requires the admin token.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param user body CreateUserRequest true "User payload"
 // @Success 201 {object} UserResponse
-// @Failure 400 {object} UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /users [post]
 func postUser(service ports.UserService, validator ports.Validator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -159,59 +469,51 @@ func postUser(service ports.UserService, validator ports.Validator) http.Handler
 		user := CreateUserRequest{}
 		err := json.NewDecoder(r.Body).Decode(&user)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
 			decodeError := fmt.Errorf("could not decode the request body: %w", err)
-			slog.Error(decodeError.Error())
-			_, _ = w.Write([]byte(decodeError.Error()))
+			logging.FromContext(r.Context()).Error(decodeError.Error())
+			http.Error(w, decodeError.Error(), http.StatusBadRequest)
 			return
 		}
 		validationErr := validator.Struct(user)
 		if validationErr != nil {
-			w.WriteHeader(http.StatusBadRequest)
 			validationErr = fmt.Errorf("could not validate the request: %w", validationErr)
-			slog.Error(validationErr.Error())
-			_, _ = w.Write([]byte(validationErr.Error()))
+			logging.FromContext(r.Context()).Error(validationErr.Error())
+			http.Error(w, validationErr.Error(), http.StatusBadRequest)
 			return
 		}
 		createdUser, err := service.AddUser(r.Context(), user.getUser())
+		if errors.Is(err, domain.ErrInvalidAttributes) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
 			userErr := fmt.Errorf("could not add the user: %w", err)
-			slog.Error(userErr.Error())
-			_, _ = w.Write([]byte(errors.New("could not add the user").Error()))
+			logging.FromContext(r.Context()).Error(userErr.Error())
+			http.Error(w, errors.New("could not add the user").Error(), http.StatusInternalServerError)
 			return
 		}
 		// check the user id.
 		if createdUser.UserID == "" {
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte(errors.New("could not create user").Error()))
-			slog.Error("could not create user, but user service did not return an error")
+			logging.FromContext(r.Context()).Error("could not create user, but user service did not return an error")
+			http.Error(w, errors.New("could not create user").Error(), http.StatusInternalServerError)
 			return
 		}
-		parsedUser := parseUserToUserDTO(createdUser)
-		blob, err := json.Marshal(parsedUser)
-		if err != nil {
-			parsingErr := fmt.Errorf("could not parse the user: %w", err)
-			slog.Error(parsingErr.Error())
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte(errors.New("could not retrieve the user").Error()))
-			return
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		_, _ = w.Write(blob)
+		writeJSON(w, http.StatusCreated, parseUserToUserDTO(createdUser))
 	}
 }
 
 // UpdateUser godoc
 // @Summary Update an existing user
-// @Description Update a user with first name, last name, and email.
+// @Description Update a user with first name, last name, and email. A status change must be allowed by the transition table. Requires the admin token.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param user body UserRequest true "User payload"
-// @Success 201 {object} UserResponse
-// @Failure 400 {object} UserResponse
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /users/{user_id} [patch]
 // @Param user_id  path string true "User ID"
 func patchUser(service ports.UserService, validator ports.Validator) http.HandlerFunc {
@@ -221,46 +523,50 @@ func patchUser(service ports.UserService, validator ports.Validator) http.Handle
 
 		err := json.NewDecoder(r.Body).Decode(&user)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
 			decodeError := fmt.Errorf("could not decode the request body: %w", err)
-			slog.Error(decodeError.Error())
-			_, _ = w.Write([]byte(decodeError.Error()))
+			logging.FromContext(r.Context()).Error(decodeError.Error())
+			http.Error(w, decodeError.Error(), http.StatusBadRequest)
 			return
 		}
 		validationErr := validator.Struct(user)
 		if validationErr != nil {
-			slog.Error(validationErr.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = w.Write([]byte(validationErr.Error()))
+			validationErr = fmt.Errorf("could not validate the request: %w", validationErr)
+			logging.FromContext(r.Context()).Error(validationErr.Error())
+			http.Error(w, validationErr.Error(), http.StatusBadRequest)
 			return
 		}
 		updateUser, err := service.UpdateUserByID(r.Context(), userID, user.getUser())
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			userErr := fmt.Errorf("could not update user: %w", err)
-			slog.Error(userErr.Error())
-			_, _ = w.Write([]byte(errors.New("could not update user").Error()))
+		if errors.Is(err, domain.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("could not find the user %s", userID), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidTransition) || errors.Is(err, domain.ErrAlreadyErased) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidAttributes) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		userDTO := parseUserToUserDTO(updateUser)
-		w.WriteHeader(http.StatusOK)
-		blob, _ := json.Marshal(userDTO)
-		w.Header().Set("Content-Type", "application/json")
-		_, err = w.Write(blob)
 		if err != nil {
+			userErr := fmt.Errorf("could not update user: %w", err)
+			logging.FromContext(r.Context()).Error(userErr.Error())
+			http.Error(w, errors.New("could not update user").Error(), http.StatusInternalServerError)
 			return
 		}
+		writeJSON(w, http.StatusOK, parseUserToUserDTO(updateUser))
 	}
 }
 
 // DeleteUser godoc
 // @Summary Delete an existing user
-// @Description Delete a user by user id
+// @Description Delete a user by user id. Requires the admin token.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Success 200
 // @Failure 400
+// @Failure 404
 // @Failure 500
 // @Router /users/{user_id} [delete]
 // @Param user_id  path string true "User ID"
@@ -268,11 +574,13 @@ func deleteUser(service ports.UserService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID := chi.URLParam(r, "userId")
 		err := service.DeleteUserByID(r.Context(), userID)
+		if errors.Is(err, domain.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("could not find the user %s", userID), http.StatusNotFound)
+			return
+		}
 		if err != nil {
-			deleteErr := fmt.Errorf("could not delete user: %w", err)
-			slog.Error(deleteErr.Error())
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte(errors.New("could not delete user").Error()))
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not delete user: %w", err).Error())
+			http.Error(w, "could not delete user", http.StatusInternalServerError)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -282,3 +590,15 @@ func deleteUser(service ports.UserService) http.HandlerFunc {
 		}
 	}
 }
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	blob, err := json.Marshal(body)
+	if err != nil {
+		slog.Error(fmt.Errorf("error marshalling the response: %w", err).Error())
+		http.Error(w, "could not write the response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(blob)
+}
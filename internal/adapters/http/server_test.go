@@ -0,0 +1,150 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"userapi/app/internal/adapters/service"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	testAdminToken = "test-admin-token"
+	testTenantID   = "8f14e45f-ceea-467f-a0e6-1b2c3d4e5f60"
+)
+
+// newTestServer returns a server of the mock user service, without a tenant
+// service, so the tenant is given by the X-Tenant-ID header.
+func newTestServer() *Server {
+	server := NewServer(service.NewMockUserServiceImpl(), validator.New())
+	server.AdminToken = testAdminToken
+	return server
+}
+
+// serve sends the request as the admin, asking for problem documents.
+func serve(t *testing.T, server *Server, method string, target string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set("Authorization", bearerAuthPrefix+testAdminToken)
+	request.Header.Set(tenantHeader, testTenantID)
+	request.Header.Set("Accept", problemContentType)
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, request)
+	return recorder
+}
+
+// problemOf decodes the problem document of the response.
+func problemOf(t *testing.T, recorder *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	if contentType := recorder.Header().Get("Content-Type"); contentType != problemContentType {
+		t.Fatalf("expected a problem document, got %q: %s", contentType, recorder.Body.String())
+	}
+	var problem Problem
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("could not decode the problem: %v", err)
+	}
+	return problem
+}
+
+func TestUserHandlerProblems(t *testing.T) {
+	server := newTestServer()
+	missing := "/users/5d41402a-bc4b-4a76-b971-9d911017c592"
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"missing user", http.MethodGet, missing, "", http.StatusNotFound},
+		{"malformed creation", http.MethodPost, "/users", "{", http.StatusBadRequest},
+		{"invalid creation", http.MethodPost, "/users", `{"firstname":"A"}`, http.StatusBadRequest},
+		{"malformed update", http.MethodPatch, missing, "{", http.StatusBadRequest},
+		{"invalid update", http.MethodPatch, missing, `{"email":"nope"}`, http.StatusBadRequest},
+		{"update of a missing user", http.MethodPatch, missing, `{"firstname":"Ada"}`, http.StatusNotFound},
+		{"deletion of a missing user", http.MethodDelete, missing, "", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(t, server, test.method, test.target, test.body)
+			if recorder.Code != test.status {
+				t.Fatalf("expected status %d, got %d: %s", test.status, recorder.Code, recorder.Body.String())
+			}
+			problem := problemOf(t, recorder)
+			if problem.Status != test.status || problem.Detail == "" {
+				t.Errorf("unexpected problem %+v", problem)
+			}
+		})
+	}
+}
+
+func TestUserHandlers(t *testing.T) {
+	server := newTestServer()
+	recorder := serve(t, server, http.MethodPost, "/users", `{"firstname":"Ada","lastname":"Lovelace","email":"ada@example.com"}`)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var created UserResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder = serve(t, server, http.MethodPatch, "/users/"+created.UserID, `{"firstname":"Augusta"}`)
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected a 200 JSON response, got %d %q", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+
+	recorder = serve(t, server, http.MethodGet, "/users/"+created.UserID, "")
+	var fetched UserResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &fetched); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Code != http.StatusOK || fetched.FirstName != "Augusta" {
+		t.Errorf("expected the updated user, got %d %+v", recorder.Code, fetched)
+	}
+}
+
+func TestGetAllUsersPages(t *testing.T) {
+	server := newTestServer()
+	for _, name := range []string{"Ada", "Grace", "Edsger"} {
+		recorder := serve(t, server, http.MethodPost, "/users", `{"firstname":"`+name+`","lastname":"Tester","email":"`+strings.ToLower(name)+`@example.com"}`)
+		if recorder.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+	}
+
+	var names []string
+	target := "/users?limit=2"
+	for pages := 0; target != ""; pages++ {
+		if pages == 2 {
+			t.Fatal("expected 2 pages")
+		}
+		recorder := serve(t, server, http.MethodGet, target, "")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+		var page []UserResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		for _, user := range page {
+			names = append(names, user.FirstName)
+		}
+		target = ""
+		if link := recorder.Header().Get("Link"); link != "" {
+			target = strings.TrimPrefix(strings.SplitN(link, ">", 2)[0], "<")
+		}
+	}
+	if len(names) != 3 || slices.Contains(names, "") {
+		t.Errorf("expected the 3 users by name, got %q", names)
+	}
+
+	if recorder := serve(t, server, http.MethodGet, "/users?limit=2&cursor=nope", ""); recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid cursor, got %d", recorder.Code)
+	}
+}
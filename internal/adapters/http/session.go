@@ -0,0 +1,176 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const sessionClaim = "sid"
+
+// clientInfo puts the user agent and the IP address of the caller into the
+// request context, where the session service picks them up.
+func clientInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		ctx := domain.ContextWithClientInfo(r.Context(), domain.ClientInfo{UserAgent: r.UserAgent(), IPAddress: ip})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// checkSession rejects the tokens of users whose session has been revoked or
+// has expired. Tokens without a session id are left to their own expiry.
+func checkSession(service ports.SessionService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller, _ := principalFromContext(r.Context())
+			if caller.sessionID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			_, err := service.CheckSession(r.Context(), caller.sessionID)
+			switch {
+			case errors.Is(err, domain.ErrInvalidToken):
+				unauthorized(w, "the session has ended")
+				return
+			case err != nil:
+				logging.FromContext(r.Context()).Error(fmt.Errorf("could not check the session: %w", err).Error())
+				http.Error(w, "could not check the session", http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RefreshSession godoc
+// @Summary Refresh a session
+// @Description Exchanges a refresh token for a new session token and refresh token. Refresh tokens work once; presenting a used one again revokes the session. The tenant must be named, for instance with the X-Tenant-ID header.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshSessionRequest true "Refresh token"
+// @Success 200 {object} SessionResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/refresh [post]
+func refreshSession(service ports.SessionService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := RefreshSessionRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		session, err := service.RefreshSession(r.Context(), request.RefreshToken)
+		switch {
+		case errors.Is(err, domain.ErrInvalidToken):
+			logging.FromContext(r.Context()).Info(fmt.Errorf("rejected a refresh: %w", err).Error())
+			http.Error(w, domain.ErrInvalidToken.Error(), http.StatusUnauthorized)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not refresh the session: %w", err).Error())
+			http.Error(w, "could not refresh the session", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, parseSessionToSessionDTO(session))
+	}
+}
+
+// GetSessions godoc
+//
+//	@Summary		Get the sessions of a user
+//	@Description	Lists the active sessions of a user, most recently seen first. Requires a session of the user or the admin token.
+//	@Tags sessions
+//	@Produce		json
+//	@Param user_id  path string true "User ID"
+//	@Success		200	{array} UserSessionResponse
+//	@Failure		404	{object}	map[string]string
+//	@Failure		500	{object}	map[string]string
+//	@Router			/users/{user_id}/sessions [get]
+func getSessions(service ports.SessionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		sessions, err := service.GetSessions(r.Context(), userID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the sessions: %w", err).Error())
+			http.Error(w, "could not retrieve the sessions", http.StatusInternalServerError)
+			return
+		}
+		sessionDTOs := make([]UserSessionResponse, len(sessions))
+		for i, session := range sessions {
+			sessionDTOs[i] = parseSessionToUserSessionDTO(session)
+		}
+		writeJSON(w, http.StatusOK, sessionDTOs)
+	}
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Ends a session of a user. Its session and refresh tokens are rejected from then on. Requires a session of the user or the admin token.
+// @Tags sessions
+// @Param user_id  path string true "User ID"
+// @Param session_id  path string true "Session ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/sessions/{session_id} [delete]
+func revokeSession(service ports.SessionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		sessionID := chi.URLParam(r, "sessionId")
+		err := service.RevokeSession(r.Context(), userID, sessionID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not find the session %s", sessionID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not revoke the session: %w", err).Error())
+			http.Error(w, "could not revoke the session", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("revoked a session", "userId", userID, "sessionId", sessionID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RevokeAllSessions godoc
+// @Summary Revoke all the sessions of a user
+// @Description Ends every session of a user, for instance when the account is compromised. Requires a session of the user or the admin token.
+// @Tags sessions
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Success 200 {object} RevokedSessionsResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/sessions [delete]
+func revokeAllSessions(service ports.SessionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		revoked, err := service.RevokeAllSessions(r.Context(), userID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not revoke the sessions: %w", err).Error())
+			http.Error(w, "could not revoke the sessions", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("revoked the sessions of a user", "userId", userID, "revoked", revoked)
+		writeJSON(w, http.StatusOK, RevokedSessionsResponse{Revoked: revoked})
+	}
+}
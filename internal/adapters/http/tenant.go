@@ -0,0 +1,250 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	tenantHeader     = "X-Tenant-ID"
+	tenantClaim      = "tenant_id"
+	bearerAuthPrefix = "Bearer "
+)
+
+// resolveTenant puts the tenant of the request into its context. Users act in
+// the tenant of their token. For the admin and anonymous requests, the tenant
+// is taken from the X-Tenant-ID header, the subdomain of the host, or the
+// default tenant slug, in that order; anonymous requests can only reach the
+// login routes there.
+func resolveTenant(tenantService ports.TenantService, defaultSlug string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID, slug, err := tenantFromRequest(r)
+			if err != nil {
+				logging.FromContext(r.Context()).Error(fmt.Errorf("could not resolve the tenant: %w", err).Error())
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if tenantID == "" && slug == "" {
+				slug = defaultSlug
+			}
+			if tenantID == "" && slug == "" {
+				http.Error(w, domain.ErrTenantRequired.Error(), http.StatusBadRequest)
+				return
+			}
+			if tenantService != nil {
+				var tenant domain.Tenant
+				if tenantID != "" {
+					tenant, err = tenantService.GetTenantByID(r.Context(), tenantID)
+				} else {
+					tenant, err = tenantService.GetTenantBySlug(r.Context(), slug)
+				}
+				if err != nil {
+					logging.FromContext(r.Context()).Error(fmt.Errorf("could not resolve the tenant: %w", err).Error())
+					http.Error(w, "unknown tenant", http.StatusNotFound)
+					return
+				}
+				if tenant.Disabled {
+					http.Error(w, domain.ErrTenantDisabled.Error(), http.StatusForbidden)
+					return
+				}
+				tenantID = tenant.TenantID
+			}
+			if tenantID == "" {
+				http.Error(w, "unknown tenant", http.StatusNotFound)
+				return
+			}
+			ctx := domain.ContextWithTenant(r.Context(), tenantID)
+			ctx = logging.ContextWithLogger(ctx, logging.FromContext(ctx).With("tenantId", tenantID))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// tenantFromRequest returns either a tenant id or a tenant slug found in the request.
+func tenantFromRequest(r *http.Request) (string, string, error) {
+	headerTenant := r.Header.Get(tenantHeader)
+	if headerTenant != "" {
+		if _, err := uuid.Parse(headerTenant); err != nil {
+			return "", "", fmt.Errorf("invalid %s header", tenantHeader)
+		}
+	}
+	caller, _ := principalFromContext(r.Context())
+	switch {
+	case caller.tenantID != "" && headerTenant != "" && headerTenant != caller.tenantID:
+		return "", "", errors.New("tenant header does not match the token")
+	case caller.tenantID != "":
+		return caller.tenantID, "", nil
+	case headerTenant != "":
+		return headerTenant, "", nil
+	}
+	return "", subdomain(r.Host), nil
+}
+
+// subdomain returns the left-most label of hosts like acme.users.example.com.
+func subdomain(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 3 {
+		return ""
+	}
+	return strings.ToLower(labels[0])
+}
+
+// requireAdminToken only lets requests carrying the admin bearer token through.
+func requireAdminToken(adminToken string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			presented := strings.TrimPrefix(r.Header.Get("Authorization"), bearerAuthPrefix)
+			if subtle.ConstantTimeCompare([]byte(presented), []byte(adminToken)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// tenantFromPath puts the tenant named by the tenantId path parameter into the
+// context, for the admin routes acting on the users of a tenant.
+func tenantFromPath(tenantService ports.TenantService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID := chi.URLParam(r, "tenantId")
+			if _, err := tenantService.GetTenantByID(r.Context(), tenantID); err != nil {
+				logging.FromContext(r.Context()).Error(fmt.Errorf("could not resolve the tenant: %w", err).Error())
+				http.Error(w, fmt.Sprintf("could not retrieve the tenant %s", tenantID), http.StatusNotFound)
+				return
+			}
+			ctx := domain.ContextWithTenant(r.Context(), tenantID)
+			ctx = logging.ContextWithLogger(ctx, logging.FromContext(ctx).With("tenantId", tenantID))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetAllTenants godoc
+//
+//	@Summary		Get all tenants
+//	@Description	Retrieves all tenants. Requires the admin token.
+//	@Tags admin
+//	@Produce		json
+//	@Success		200	{array} TenantResponse
+//	@Failure		500	{object}	map[string]string
+//	@Router			/admin/tenants [get]
+func getAllTenants(service ports.TenantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenants, err := service.GetAllTenants(r.Context())
+		if err != nil {
+			logging.FromContext(r.Context()).Error(fmt.Errorf("error getting all tenants: %w", err).Error())
+			http.Error(w, "could not retrieve the tenants", http.StatusInternalServerError)
+			return
+		}
+		tenantDTOs := make([]TenantResponse, len(tenants))
+		for i, tenant := range tenants {
+			tenantDTOs[i] = parseTenantToTenantDTO(tenant)
+		}
+		writeJSON(w, http.StatusOK, tenantDTOs)
+	}
+}
+
+// GetTenant godoc
+//
+//	@Summary		Get a tenant
+//	@Description	Retrieves a tenant by id. Requires the admin token.
+//	@Tags admin
+//	@Produce		json
+//	@Success		200	{object} TenantResponse
+//	@Failure		404	{object}	map[string]string
+//	@Router			/admin/tenants/{tenant_id} [get]
+//	@Param tenant_id  path string true "Tenant ID"
+func getTenant(service ports.TenantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := chi.URLParam(r, "tenantId")
+		tenant, err := service.GetTenantByID(r.Context(), tenantID)
+		if err != nil {
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the tenant: %w", err).Error())
+			http.Error(w, fmt.Sprintf("could not retrieve the tenant %s", tenantID), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, parseTenantToTenantDTO(tenant))
+	}
+}
+
+// CreateTenant godoc
+// @Summary Create a new tenant
+// @Description Create a tenant with a name and a unique slug. Requires the admin token.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param tenant body CreateTenantRequest true "Tenant payload"
+// @Success 201 {object} TenantResponse
+// @Failure 400 {object} map[string]string
+// @Router /admin/tenants [post]
+func postTenant(service ports.TenantService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := CreateTenantRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			decodeError := fmt.Errorf("could not decode the request body: %w", err)
+			logging.FromContext(r.Context()).Error(decodeError.Error())
+			http.Error(w, decodeError.Error(), http.StatusBadRequest)
+			return
+		}
+		if validationErr := validator.Struct(request); validationErr != nil {
+			validationErr = fmt.Errorf("could not validate the request: %w", validationErr)
+			logging.FromContext(r.Context()).Error(validationErr.Error())
+			http.Error(w, validationErr.Error(), http.StatusBadRequest)
+			return
+		}
+		tenant, err := service.AddTenant(r.Context(), request.getTenant())
+		if err != nil {
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not add the tenant: %w", err).Error())
+			http.Error(w, "could not add the tenant", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, parseTenantToTenantDTO(tenant))
+	}
+}
+
+// DisableTenant godoc
+// @Summary Disable a tenant
+// @Description Disable a tenant. Requests resolved to a disabled tenant are rejected. Requires the admin token.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} TenantResponse
+// @Failure 500 {object} map[string]string
+// @Router /admin/tenants/{tenant_id}:disable [post]
+// @Param tenant_id  path string true "Tenant ID"
+func disableTenant(service ports.TenantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := chi.URLParam(r, "tenantId")
+		tenant, err := service.DisableTenantByID(r.Context(), tenantID)
+		if err != nil {
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not disable the tenant: %w", err).Error())
+			if errors.Is(err, domain.ErrNotFound) {
+				http.Error(w, fmt.Sprintf("could not find the tenant %s", tenantID), http.StatusNotFound)
+				return
+			}
+			http.Error(w, "could not disable the tenant", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, parseTenantToTenantDTO(tenant))
+	}
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+
+	"userapi/app/internal/adapters/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceRequests serves each request in a server span, the child of the
+// traceparent sent by the client, if any. The span is named after the route
+// once the request has been routed.
+func traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := tracing.Tracer().Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		))
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		completed := false
+		defer func() {
+			status := ww.Status()
+			switch {
+			case !completed:
+				status = http.StatusInternalServerError
+			case status == 0:
+				status = http.StatusOK
+			}
+			if routeContext := chi.RouteContext(r.Context()); routeContext != nil && routeContext.RoutePattern() != "" {
+				span.SetName(r.Method + " " + routeContext.RoutePattern())
+				span.SetAttributes(attribute.String("http.route", routeContext.RoutePattern()))
+			}
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			span.End()
+		}()
+		next.ServeHTTP(ww, r.WithContext(ctx))
+		completed = true
+	})
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RequestEmailVerification godoc
+// @Summary Send an email verification
+// @Description Sends the user a signed, expiring token that confirms they control their email address. Requires a session of the user or the admin token.
+// @Tags verification
+// @Param user_id  path string true "User ID"
+// @Success 202
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /users/{user_id}/email-verification [post]
+func requestEmailVerification(service ports.VerificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		err := service.RequestEmailVerification(r.Context(), userID)
+		switch {
+		case errors.Is(err, domain.ErrAlreadyVerified):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case errors.Is(err, domain.ErrNotConfigured):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not send the email verification: %w", err).Error())
+			http.Error(w, "could not send the email verification", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("verification sent"))
+	}
+}
+
+// ConfirmEmailVerification godoc
+// @Summary Confirm an email verification
+// @Description Marks the email of the user the token was issued to as verified.
+// @Tags verification
+// @Accept json
+// @Produce json
+// @Param request body ConfirmVerificationRequest true "Verification token"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /email-verification:confirm [post]
+func confirmEmailVerification(service ports.VerificationService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := ConfirmVerificationRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Errorf("could not decode the request body: %w", err).Error(), http.StatusBadRequest)
+			return
+		}
+		if err := validator.Struct(request); err != nil {
+			http.Error(w, fmt.Errorf("could not validate the request: %w", err).Error(), http.StatusBadRequest)
+			return
+		}
+		user, err := service.ConfirmEmailVerification(r.Context(), request.Token)
+		switch {
+		case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrNotFound), errors.Is(err, domain.ErrAlreadyErased):
+			logging.FromContext(r.Context()).Info(fmt.Errorf("rejected an email verification: %w", err).Error())
+			http.Error(w, domain.ErrInvalidToken.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrNotConfigured):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not confirm the email verification: %w", err).Error())
+			http.Error(w, "could not confirm the email verification", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, parseUserToUserDTO(user))
+	}
+}
+
+// RequestPhoneVerification godoc
+// @Summary Send a phone verification code
+// @Description Texts the user a 6-digit code that expires after 10 minutes. Sending a new code replaces the previous one. Requires a session of the user or the admin token.
+// @Tags verification
+// @Param user_id  path string true "User ID"
+// @Success 202
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /users/{user_id}/phone-verification [post]
+func requestPhoneVerification(service ports.VerificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		err := service.RequestPhoneVerification(r.Context(), userID)
+		switch {
+		case errors.Is(err, domain.ErrNoPhone):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrAlreadyVerified):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, domain.ErrLockedOut):
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case errors.Is(err, domain.ErrNotConfigured):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not send the phone verification: %w", err).Error())
+			http.Error(w, "could not send the phone verification", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("verification sent"))
+	}
+}
+
+// ConfirmPhoneVerification godoc
+// @Summary Confirm a phone verification
+// @Description Marks the phone of the user as verified when the code matches. Five wrong codes lock phone verification for 15 minutes. Requires a session of the user or the admin token.
+// @Tags verification
+// @Accept json
+// @Produce json
+// @Param user_id  path string true "User ID"
+// @Param request body ConfirmPhoneVerificationRequest true "Verification code"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/phone-verification:confirm [post]
+func confirmPhoneVerification(service ports.VerificationService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "userId")
+		request := ConfirmPhoneVerificationRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Errorf("could not decode the request body: %w", err).Error(), http.StatusBadRequest)
+			return
+		}
+		if err := validator.Struct(request); err != nil {
+			http.Error(w, fmt.Errorf("could not validate the request: %w", err).Error(), http.StatusBadRequest)
+			return
+		}
+		user, err := service.ConfirmPhoneVerification(r.Context(), userID, request.Code)
+		switch {
+		case errors.Is(err, domain.ErrInvalidCode):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrLockedOut):
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the user %s", userID), http.StatusNotFound)
+			return
+		case errors.Is(err, domain.ErrAlreadyErased):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not confirm the phone verification: %w", err).Error())
+			http.Error(w, "could not confirm the phone verification", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, parseUserToUserDTO(user))
+	}
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// PostWebhook godoc
+// @Summary Subscribe a webhook
+// @Description Posts the user events of the given types to the URL, signed with the secret: the X-Webhook-Signature header is sha256= followed by the hex HMAC-SHA256 of the X-Webhook-Timestamp header, a dot and the body. The URL must resolve to public addresses. The secret is generated when left out, and only returned here. Requires the admin token.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body CreateWebhookRequest true "Webhook"
+// @Success 201 {object} WebhookResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks [post]
+func postWebhook(service ports.WebhookService, validator ports.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := CreateWebhookRequest{}
+		if !decodeRequest(w, r, validator, &request) {
+			return
+		}
+		webhook := domain.Webhook{URL: request.URL, Secret: request.Secret}
+		for _, eventType := range request.EventTypes {
+			webhook.EventTypes = append(webhook.EventTypes, domain.EventType(eventType))
+		}
+		webhook, err := service.CreateWebhook(r.Context(), webhook)
+		switch {
+		case errors.Is(err, domain.ErrInvalidWebhook):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not create the webhook: %w", err).Error())
+			http.Error(w, "could not create the webhook", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("created a webhook", "webhookId", webhook.WebhookID)
+		writeJSON(w, http.StatusCreated, parseWebhookToDTO(webhook))
+	}
+}
+
+// GetWebhooks godoc
+// @Summary Get all webhooks
+// @Description Lists the webhooks of the tenant, without their secret. Requires the admin token.
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} WebhookResponse
+// @Failure 500 {object} map[string]string
+// @Router /webhooks [get]
+func getWebhooks(service ports.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhooks, err := service.GetWebhooks(r.Context())
+		if err != nil {
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the webhooks: %w", err).Error())
+			http.Error(w, "could not retrieve the webhooks", http.StatusInternalServerError)
+			return
+		}
+		webhookDTOs := make([]WebhookResponse, len(webhooks))
+		for i, webhook := range webhooks {
+			webhookDTOs[i] = parseWebhookToDTO(webhook)
+		}
+		writeJSON(w, http.StatusOK, webhookDTOs)
+	}
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook
+// @Description Returns a webhook, without its secret. Requires the admin token.
+// @Tags webhooks
+// @Produce json
+// @Param webhook_id  path string true "Webhook ID"
+// @Success 200 {object} WebhookResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{webhook_id} [get]
+func getWebhook(service ports.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "webhookId")
+		webhook, err := service.GetWebhook(r.Context(), webhookID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the webhook %s", webhookID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the webhook: %w", err).Error())
+			http.Error(w, "could not retrieve the webhook", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, parseWebhookToDTO(webhook))
+	}
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Unsubscribes a webhook and deletes its deliveries. Requires the admin token.
+// @Tags webhooks
+// @Param webhook_id  path string true "Webhook ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{webhook_id} [delete]
+func deleteWebhook(service ports.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "webhookId")
+		err := service.DeleteWebhook(r.Context(), webhookID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the webhook %s", webhookID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not delete the webhook: %w", err).Error())
+			http.Error(w, "could not delete the webhook", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("deleted a webhook", "webhookId", webhookID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetWebhookDeliveries godoc
+// @Summary Get the deliveries of a webhook
+// @Description Lists the latest 100 deliveries of a webhook, newest first, with their attempts and the responses received. Requires the admin token.
+// @Tags webhooks
+// @Produce json
+// @Param webhook_id  path string true "Webhook ID"
+// @Success 200 {array} WebhookDeliveryResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{webhook_id}/deliveries [get]
+func getWebhookDeliveries(service ports.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "webhookId")
+		deliveries, err := service.GetWebhookDeliveries(r.Context(), webhookID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the webhook %s", webhookID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not retrieve the deliveries: %w", err).Error())
+			http.Error(w, "could not retrieve the deliveries", http.StatusInternalServerError)
+			return
+		}
+		deliveryDTOs := make([]WebhookDeliveryResponse, len(deliveries))
+		for i, delivery := range deliveries {
+			deliveryDTOs[i] = parseWebhookDeliveryToDTO(delivery)
+		}
+		writeJSON(w, http.StatusOK, deliveryDTOs)
+	}
+}
+
+// RedeliverWebhookDelivery godoc
+// @Summary Redeliver a webhook delivery
+// @Description Posts a delivery again, dead or not, with a fresh round of retries. Requires the admin token.
+// @Tags webhooks
+// @Produce json
+// @Param webhook_id  path string true "Webhook ID"
+// @Param delivery_id  path string true "Delivery ID"
+// @Success 202 {object} WebhookDeliveryResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{webhook_id}/deliveries/{delivery_id}:redeliver [post]
+func redeliverWebhookDelivery(service ports.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "webhookId")
+		deliveryID := chi.URLParam(r, "deliveryId")
+		delivery, err := service.RedeliverWebhookDelivery(r.Context(), webhookID, deliveryID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, fmt.Sprintf("could not retrieve the delivery %s", deliveryID), http.StatusNotFound)
+			return
+		case err != nil:
+			logging.FromContext(r.Context()).Error(fmt.Errorf("could not redeliver the delivery: %w", err).Error())
+			http.Error(w, "could not redeliver the delivery", http.StatusInternalServerError)
+			return
+		}
+		logging.FromContext(r.Context()).Info("redelivered a webhook delivery", "webhookId", webhookID, "deliveryId", deliveryID)
+		writeJSON(w, http.StatusAccepted, parseWebhookDeliveryToDTO(delivery))
+	}
+}
@@ -0,0 +1,147 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"userapi/app/internal/core/domain"
+)
+
+// DefaultMaxPixels bounds the images that are decoded, so that a small file
+// cannot expand to gigabytes of pixels.
+const DefaultMaxPixels = 40_000_000
+
+const jpegQuality = 85
+
+// Processor prepares uploaded images for storage. JPEG and PNG images are
+// decoded and encoded again, which leaves their metadata behind, and JPEG
+// images are turned upright according to their EXIF orientation first. WebP
+// images cannot be decoded with the standard library: their metadata chunks
+// are removed and no thumbnails are made.
+type Processor struct {
+	// MaxPixels is the largest width times height accepted.
+	MaxPixels int
+}
+
+func NewProcessor() *Processor {
+	return &Processor{MaxPixels: DefaultMaxPixels}
+}
+
+func (p *Processor) Process(data []byte, sizes []int) ([]domain.ImageVariant, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png":
+		return p.processDecodable(data, contentType, sizes)
+	case "image/webp":
+		return p.processWebP(data)
+	}
+	return nil, fmt.Errorf("%w: %s", domain.ErrUnsupportedImage, contentType)
+}
+
+func (p *Processor) checkPixels(width int, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("%w: the image is empty", domain.ErrInvalidImage)
+	}
+	if width*height > p.MaxPixels {
+		return fmt.Errorf("%w: the image has more than %d pixels", domain.ErrInvalidImage, p.MaxPixels)
+	}
+	return nil
+}
+
+func (p *Processor) processDecodable(data []byte, contentType string, sizes []int) ([]domain.ImageVariant, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Join(domain.ErrInvalidImage, err)
+	}
+	if err := p.checkPixels(config.Width, config.Height); err != nil {
+		return nil, err
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Join(domain.ErrInvalidImage, err)
+	}
+	img := toRGBA(decoded)
+	encode := encodePNG
+	if contentType == "image/jpeg" {
+		img = orient(img, exifOrientation(data))
+		encode = encodeJPEG
+	}
+	full, err := encode(img)
+	if err != nil {
+		return nil, err
+	}
+	variants := []domain.ImageVariant{{ContentType: contentType, Data: full}}
+	side := min(img.Bounds().Dx(), img.Bounds().Dy())
+	for _, size := range sizes {
+		// Thumbnails are only ever scaled down.
+		if size <= 0 || size > side {
+			continue
+		}
+		thumbnail, err := encode(thumbnail(img, size))
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, domain.ImageVariant{Size: size, ContentType: contentType, Data: thumbnail})
+	}
+	return variants, nil
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("could not encode the image: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		return nil, fmt.Errorf("could not encode the image: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// thumbnail crops the centered square of the image and scales it down to
+// size by averaging the pixels each target pixel covers.
+func thumbnail(img *image.RGBA, size int) *image.RGBA {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	side := min(width, height)
+	left, top := (width-side)/2, (height-side)/2
+	scaled := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := top+y*side/size, top+(y+1)*side/size
+		for x := 0; x < size; x++ {
+			x0, x1 := left+x*side/size, left+(x+1)*side/size
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				offset := img.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					for channel := range sum {
+						sum[channel] += int(img.Pix[offset+channel])
+					}
+					offset += 4
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			offset := scaled.PixOffset(x, y)
+			for channel := range sum {
+				scaled.Pix[offset+channel] = uint8(sum[channel] / count)
+			}
+		}
+	}
+	return scaled
+}
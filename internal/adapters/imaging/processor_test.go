@@ -0,0 +1,103 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"userapi/app/internal/core/domain"
+)
+
+// withOrientation inserts an EXIF segment holding the orientation after the
+// start of image marker of a JPEG file.
+func withOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry, exifOrientationTag)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	segment := append(append([]byte("Exif\x00\x00"), tiff...), append(entry, 0, 0, 0, 0)...)
+	header := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(segment)+2))
+	return append(append(append([]byte{0xFF, 0xD8}, header...), segment...), data[2:]...)
+}
+
+func TestProcessJPEG(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	upload := withOrientation(buffer.Bytes(), 6)
+	if exifOrientation(upload) != 6 {
+		t.Fatalf("expected orientation 6, got %d", exifOrientation(upload))
+	}
+
+	variants, err := NewProcessor().Process(upload, []int{8, 64})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(variants) != 2 || variants[1].Size != 8 {
+		t.Fatalf("expected the full image and an 8 pixel thumbnail, got %d variants", len(variants))
+	}
+	if bytes.Contains(variants[0].Data, []byte("Exif")) {
+		t.Fatal("expected the EXIF segment to be removed")
+	}
+	config, err := jpeg.DecodeConfig(bytes.NewReader(variants[0].Data))
+	if err != nil || config.Width != 20 || config.Height != 40 {
+		t.Fatalf("expected the image to be turned upright, got %dx%d and %v", config.Width, config.Height, err)
+	}
+}
+
+func TestProcessWebP(t *testing.T) {
+	chunk := func(fourCC string, payload []byte) []byte {
+		header := append([]byte(fourCC), 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(header[4:], uint32(len(payload)))
+		if len(payload)%2 == 1 {
+			payload = append(payload, 0)
+		}
+		return append(header, payload...)
+	}
+	vp8x := []byte{webpMetadataFlags, 0, 0, 0, 99, 0, 0, 49, 0, 0}
+	vp8l := []byte{0x2F, 99, 0x40, 0x0C, 0}
+	body := append(append(append([]byte("WEBP"), chunk("VP8X", vp8x)...), chunk("EXIF", []byte("MM\x00\x2a"))...), chunk("VP8L", vp8l)...)
+	upload := append(append([]byte("RIFF"), 0, 0, 0, 0), body...)
+	binary.LittleEndian.PutUint32(upload[4:], uint32(len(body)))
+
+	variants, err := NewProcessor().Process(upload, []int{8})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(variants) != 1 || variants[0].ContentType != "image/webp" {
+		t.Fatalf("expected the WebP image alone, got %v", variants)
+	}
+	stripped := variants[0].Data
+	if bytes.Contains(stripped, []byte("EXIF")) || stripped[20]&webpMetadataFlags != 0 {
+		t.Fatal("expected the EXIF chunk and flag to be removed")
+	}
+	if int(binary.LittleEndian.Uint32(stripped[4:])) != len(stripped)-8 {
+		t.Fatal("expected the RIFF size to match the stripped file")
+	}
+
+	processor := NewProcessor()
+	processor.MaxPixels = 100*50 - 1
+	if _, err := processor.Process(upload, nil); !errors.Is(err, domain.ErrInvalidImage) {
+		t.Fatalf("expected ErrInvalidImage for a 100x50 canvas, got %v", err)
+	}
+}
+
+func TestProcessUnsupported(t *testing.T) {
+	if _, err := NewProcessor().Process([]byte("GIF89a..."), nil); !errors.Is(err, domain.ErrUnsupportedImage) {
+		t.Fatalf("expected ErrUnsupportedImage, got %v", err)
+	}
+}
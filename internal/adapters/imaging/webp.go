@@ -0,0 +1,66 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"userapi/app/internal/core/domain"
+)
+
+// webpMetadataFlags are the EXIF and XMP bits of the flags of a VP8X chunk.
+const webpMetadataFlags = 0x08 | 0x04
+
+// processWebP removes the EXIF and XMP chunks of a WebP image, after checking
+// its dimensions. The image data itself is kept as it is.
+func (p *Processor) processWebP(data []byte) ([]domain.ImageVariant, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("%w: not a WebP file", domain.ErrInvalidImage)
+	}
+	stripped := append([]byte{}, data[:12]...)
+	width, height := 0, 0
+	for offset := 12; offset < len(data); {
+		if offset+8 > len(data) {
+			return nil, fmt.Errorf("%w: truncated WebP chunk", domain.ErrInvalidImage)
+		}
+		fourCC := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		if offset+8+size > len(data) {
+			return nil, fmt.Errorf("%w: truncated WebP chunk", domain.ErrInvalidImage)
+		}
+		// Chunks are padded to an even size.
+		end := min(offset+8+size+size%2, len(data))
+		chunk := append([]byte{}, data[offset:end]...)
+		payload := chunk[8 : 8+size]
+		switch fourCC {
+		case "EXIF", "XMP ":
+			offset = end
+			continue
+		case "VP8X":
+			if size < 10 {
+				return nil, fmt.Errorf("%w: truncated VP8X chunk", domain.ErrInvalidImage)
+			}
+			payload[0] &^= webpMetadataFlags
+			width, height = 1+int(uint24(payload[4:])), 1+int(uint24(payload[7:]))
+		case "VP8 ":
+			if width == 0 && size >= 10 {
+				width, height = int(binary.LittleEndian.Uint16(payload[6:])&0x3FFF), int(binary.LittleEndian.Uint16(payload[8:])&0x3FFF)
+			}
+		case "VP8L":
+			if width == 0 && size >= 5 {
+				bits := binary.LittleEndian.Uint32(payload[1:])
+				width, height = 1+int(bits&0x3FFF), 1+int(bits>>14&0x3FFF)
+			}
+		}
+		stripped = append(stripped, chunk...)
+		offset = end
+	}
+	if err := p.checkPixels(width, height); err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint32(stripped[4:], uint32(len(stripped)-8))
+	return []domain.ImageVariant{{ContentType: "image/webp", Data: stripped}}, nil
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
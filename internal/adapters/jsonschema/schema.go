@@ -0,0 +1,98 @@
+// Package jsonschema validates JSON documents against JSON Schemas (draft
+// 2020-12 unless they name another draft) with santhosh-tekuri/jsonschema.
+// Formats are asserted, and references can only point inside the schema.
+package jsonschema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// schemaURL names the compiled schema, which is its own only resource.
+const schemaURL = "urn:userapi:attributes"
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	schema *jsonschema.Schema
+}
+
+// noLoader refuses to load the documents schemas refer to, which would
+// otherwise be read from the files of the server.
+type noLoader struct{}
+
+func (noLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("references to other documents are not allowed: %s", url)
+}
+
+// Compile compiles a schema, checking it against its metaschema.
+func Compile(data []byte) (*Schema, error) {
+	document, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("the schema is not JSON: %w", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	compiler.AssertFormat()
+	compiler.UseLoader(noLoader{})
+	if err := compiler.AddResource(schemaURL, document); err != nil {
+		return nil, err
+	}
+	schema, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{schema: schema}, nil
+}
+
+// ValidationError lists every violation found in a document.
+type ValidationError struct {
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Violations, "; ")
+}
+
+// Validate checks a document decoded by encoding/json against the schema.
+func (s *Schema) Validate(document any) error {
+	err := s.schema.Validate(document)
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+	var violations []string
+	for _, unit := range validationErr.BasicOutput().Errors {
+		switch {
+		case unit.Error == nil:
+		case unit.InstanceLocation == "":
+			violations = append(violations, unit.Error.String())
+		default:
+			violations = append(violations, unit.InstanceLocation+": "+unit.Error.String())
+		}
+	}
+	return &ValidationError{Violations: violations}
+}
+
+// Validator adapts Compile and Validate to ports.SchemaValidator.
+type Validator struct{}
+
+func NewValidator() Validator {
+	return Validator{}
+}
+
+func (Validator) CheckSchema(schema []byte) error {
+	_, err := Compile(schema)
+	return err
+}
+
+func (Validator) Validate(schema []byte, document any) error {
+	compiled, err := Compile(schema)
+	if err != nil {
+		return errors.Join(errors.New("the schema is not valid"), err)
+	}
+	return compiled.Validate(document)
+}
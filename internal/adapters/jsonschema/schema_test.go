@@ -0,0 +1,58 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	schema, err := Compile([]byte(`{
+		"type": "object",
+		"properties": {
+			"department": {"enum": ["sales", "support"]},
+			"employeeNumber": {"type": "integer", "minimum": 1},
+			"startDate": {"type": "string", "format": "date"},
+			"skills": {"type": "array", "items": {"type": "string", "maxLength": 20}, "uniqueItems": true}
+		},
+		"required": ["department"],
+		"additionalProperties": false
+	}`))
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	validate := func(document string) error {
+		var value any
+		if err := json.Unmarshal([]byte(document), &value); err != nil {
+			t.Fatal(err)
+		}
+		return schema.Validate(value)
+	}
+	if err := validate(`{"department": "sales", "employeeNumber": 42, "startDate": "2026-01-05", "skills": ["go"]}`); err != nil {
+		t.Fatal("The document should be valid", err)
+	}
+	err = validate(`{"employeeNumber": 4.5, "startDate": "05/01/2026", "skills": ["go", "go"], "team": "a"}`)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("Expected a validation error, got", err)
+	}
+	for _, expected := range []string{"missing property 'department'", "/employeeNumber: got number, want integer", "/startDate: '05/01/2026' is not valid date", "/skills: items at 0 and 1 are equal", "additional properties 'team' not allowed"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %q in %q", expected, err.Error())
+		}
+	}
+	t.Run("References stay inside the schema", func(t *testing.T) {
+		if _, err := Compile([]byte(`{"$defs": {"b": {"type": "string"}}, "properties": {"a": {"$ref": "#/$defs/b"}}}`)); err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		if _, err := Compile([]byte(`{"properties": {"a": {"$ref": "file:///etc/hostname"}}}`)); err == nil || !strings.Contains(err.Error(), "not allowed") {
+			t.Fatal("Expected the reference to a file to be rejected, got", err)
+		}
+	})
+	t.Run("Invalid schemas are rejected", func(t *testing.T) {
+		if _, err := Compile([]byte(`{"type": "strin"}`)); err == nil {
+			t.Fatal("Expected the unknown type to be rejected")
+		}
+	})
+}
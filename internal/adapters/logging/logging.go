@@ -0,0 +1,136 @@
+// Package logging sets up the structured, redacting loggers used by the server.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+type Config struct {
+	// Format is json or text.
+	Format string
+	// Levels is a default level optionally followed by per package levels, e.g. "info,http=debug".
+	Levels       string
+	RedactFields []string
+	RedactUUIDs  bool
+	Output       io.Writer
+}
+
+// Levels holds the default log level and the per package overrides. They can
+// be changed while the server runs.
+type Levels struct {
+	mu       sync.RWMutex
+	fallback slog.Level
+	packages map[string]slog.Level
+}
+
+func (l *Levels) For(pkg string) slog.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if level, ok := l.packages[pkg]; ok {
+		return level
+	}
+	return l.fallback
+}
+
+// Set replaces the levels with a spec like "info,http=debug,db=warn".
+func (l *Levels) Set(spec string) error {
+	fallback := slog.LevelInfo
+	packages := map[string]slog.Level{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		pkg, levelName, found := strings.Cut(part, "=")
+		if !found {
+			levelName, pkg = pkg, ""
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(levelName)); err != nil {
+			return fmt.Errorf("invalid log level %q: %w", part, err)
+		}
+		if pkg == "" {
+			fallback = level
+		} else {
+			packages[strings.TrimSpace(pkg)] = level
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fallback = fallback
+	l.packages = packages
+	return nil
+}
+
+var (
+	root   slog.Handler = slog.Default().Handler()
+	levels              = &Levels{packages: map[string]slog.Level{}}
+)
+
+// Setup installs the redacting handler as the default logger and returns the
+// levels so they can be changed later.
+func Setup(config Config) (*Levels, error) {
+	if err := levels.Set(config.Levels); err != nil {
+		return nil, err
+	}
+	options := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var handler slog.Handler
+	switch config.Format {
+	case "", "json":
+		handler = slog.NewJSONHandler(config.Output, options)
+	case "text":
+		handler = slog.NewTextHandler(config.Output, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", config.Format)
+	}
+	root = NewRedactingHandler(handler, NewRedactor(config.RedactFields, config.RedactUUIDs))
+	slog.SetDefault(slog.New(&packageHandler{inner: root}))
+	return levels, nil
+}
+
+// ForPackage returns a logger whose level is configured under the package name.
+func ForPackage(pkg string) *slog.Logger {
+	return slog.New(&packageHandler{pkg: pkg, inner: root}).With("package", pkg)
+}
+
+type loggerContextKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying the request scoped logger.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// FromContext returns the request scoped logger, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// packageHandler filters records by the level configured for its package.
+type packageHandler struct {
+	pkg   string
+	inner slog.Handler
+}
+
+func (h *packageHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= levels.For(h.pkg) && h.inner.Enabled(ctx, level)
+}
+
+func (h *packageHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.inner.Handle(ctx, record)
+}
+
+func (h *packageHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &packageHandler{pkg: h.pkg, inner: h.inner.WithAttrs(attrs)}
+}
+
+func (h *packageHandler) WithGroup(name string) slog.Handler {
+	return &packageHandler{pkg: h.pkg, inner: h.inner.WithGroup(name)}
+}
@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+[1-9][0-9]{6,14}`)
+	uuidPattern  = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+)
+
+// DefaultRedactedFields are attribute keys whose values are never logged.
+var DefaultRedactedFields = []string{"email", "phone", "password", "token", "secret", "authorization", "body"}
+
+// Redactor masks sensitive attribute values and patterns in log output.
+type Redactor struct {
+	fields   map[string]bool
+	patterns []replacement
+}
+
+type replacement struct {
+	pattern *regexp.Regexp
+	mask    string
+}
+
+// NewRedactor masks the given attribute keys, case-insensitively, along with
+// emails and E.164 phone numbers anywhere in messages and string values. UUIDs
+// are masked as well when redactUUIDs is set.
+func NewRedactor(fields []string, redactUUIDs bool) *Redactor {
+	redactor := &Redactor{fields: make(map[string]bool, len(fields))}
+	for _, field := range fields {
+		redactor.fields[strings.ToLower(strings.TrimSpace(field))] = true
+	}
+	redactor.patterns = []replacement{
+		{emailPattern, "[redacted-email]"},
+		{phonePattern, "[redacted-phone]"},
+	}
+	if redactUUIDs {
+		redactor.patterns = append(redactor.patterns, replacement{uuidPattern, "[redacted-uuid]"})
+	}
+	return redactor
+}
+
+func (r *Redactor) String(value string) string {
+	for _, p := range r.patterns {
+		value = p.pattern.ReplaceAllString(value, p.mask)
+	}
+	return value
+}
+
+func (r *Redactor) Attr(attr slog.Attr) slog.Attr {
+	if r.fields[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, r.String(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		attrs := make([]any, len(group))
+		for i, member := range group {
+			attrs[i] = r.Attr(member)
+		}
+		return slog.Group(attr.Key, attrs...)
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return slog.String(attr.Key, r.String(err.Error()))
+		}
+		return slog.String(attr.Key, r.String(fmt.Sprintf("%+v", value.Any())))
+	default:
+		return slog.Attr{Key: attr.Key, Value: value}
+	}
+}
+
+// RedactingHandler passes records to another handler after redacting them.
+type RedactingHandler struct {
+	inner    slog.Handler
+	redactor *Redactor
+}
+
+func NewRedactingHandler(inner slog.Handler, redactor *Redactor) *RedactingHandler {
+	return &RedactingHandler{inner: inner, redactor: redactor}
+}
+
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *RedactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	clean := slog.NewRecord(record.Time, record.Level, h.redactor.String(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		clean.AddAttrs(h.redactor.Attr(attr))
+		return true
+	})
+	return h.inner.Handle(ctx, clean)
+}
+
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		clean[i] = h.redactor.Attr(attr)
+	}
+	return &RedactingHandler{inner: h.inner.WithAttrs(clean), redactor: h.redactor}
+}
+
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{inner: h.inner.WithGroup(name), redactor: h.redactor}
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedactingHandler(t *testing.T) {
+	newLogger := func(redactUUIDs bool) (*slog.Logger, *bytes.Buffer) {
+		buffer := &bytes.Buffer{}
+		handler := slog.NewJSONHandler(buffer, nil)
+		return slog.New(NewRedactingHandler(handler, NewRedactor(DefaultRedactedFields, redactUUIDs))), buffer
+	}
+
+	t.Run("Masks configured fields", func(t *testing.T) {
+		logger, buffer := newLogger(false)
+		logger.Info("creating user", "email", "john@mail.com", slog.Group("request", "phone", "0712345678"))
+		if strings.Contains(buffer.String(), "john@mail.com") || strings.Contains(buffer.String(), "0712345678") {
+			t.Fatal("Sensitive fields were logged:", buffer.String())
+		}
+	})
+	t.Run("Masks patterns in messages and errors", func(t *testing.T) {
+		logger, buffer := newLogger(false)
+		err := errors.New(`duplicate key (email)=(john@mail.com) for +94712345678`)
+		logger.Error("could not add john@mail.com", "error", err)
+		output := buffer.String()
+		if strings.Contains(output, "john@mail.com") || strings.Contains(output, "+94712345678") {
+			t.Fatal("Sensitive values were logged:", output)
+		}
+		if !strings.Contains(output, "[redacted-email]") || !strings.Contains(output, "[redacted-phone]") {
+			t.Fatal("Masks are missing:", output)
+		}
+	})
+	t.Run("UUIDs are only masked when configured", func(t *testing.T) {
+		id := "0b8f6a52-8a4e-4f7e-9f43-6d2b0f1c8e11"
+		logger, buffer := newLogger(false)
+		logger.Info("user " + id)
+		if !strings.Contains(buffer.String(), id) {
+			t.Fatal("UUID should be kept:", buffer.String())
+		}
+		logger, buffer = newLogger(true)
+		logger.With("userId", id).Info("user " + id)
+		if strings.Contains(buffer.String(), id) {
+			t.Fatal("UUID should be masked:", buffer.String())
+		}
+	})
+}
+
+func TestLevels(t *testing.T) {
+	levels := &Levels{}
+	if err := levels.Set("warn,http=debug"); err != nil {
+		t.Fatal(err)
+	}
+	if levels.For("http") != slog.LevelDebug || levels.For("db") != slog.LevelWarn {
+		t.Fatal("Unexpected levels", levels.For("http"), levels.For("db"))
+	}
+	if err := levels.Set("http=loud"); err == nil {
+		t.Fatal("Expected an invalid level error")
+	}
+}
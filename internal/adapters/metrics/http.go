@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// HTTPMetrics are the rate, errors and duration of the HTTP requests, labeled
+// by route pattern so that ids in paths do not create new series.
+type HTTPMetrics struct {
+	Requests *prometheus.CounterVec
+	Duration *prometheus.HistogramVec
+	InFlight *prometheus.GaugeVec
+}
+
+func NewHTTPMetrics(registerer prometheus.Registerer) *HTTPMetrics {
+	factory := promauto.With(registerer)
+	return &HTTPMetrics{
+		Requests: factory.NewCounterVec(prometheus.CounterOpts{Name: "http_requests_total", Help: "HTTP requests completed."}, []string{"method", "route", "status"}),
+		Duration: factory.NewHistogramVec(prometheus.HistogramOpts{Name: "http_request_duration_seconds", Help: "Time taken to serve HTTP requests.", Buckets: prometheus.DefBuckets}, []string{"method", "route"}),
+		InFlight: factory.NewGaugeVec(prometheus.GaugeOpts{Name: "http_requests_in_flight", Help: "HTTP requests being served."}, []string{"method", "route"}),
+	}
+}
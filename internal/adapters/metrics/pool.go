@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolAcquiredConns     = prometheus.NewDesc("pgxpool_acquired_conns", "Connections currently in use.", nil, nil)
+	poolIdleConns         = prometheus.NewDesc("pgxpool_idle_conns", "Connections currently idle in the pool.", nil, nil)
+	poolConstructingConns = prometheus.NewDesc("pgxpool_constructing_conns", "Connections being established.", nil, nil)
+	poolTotalConns        = prometheus.NewDesc("pgxpool_total_conns", "Connections in the pool, in use, idle or being established.", nil, nil)
+	poolMaxConns          = prometheus.NewDesc("pgxpool_max_conns", "Largest number of connections the pool opens.", nil, nil)
+	poolAcquires          = prometheus.NewDesc("pgxpool_acquires_total", "Connections acquired from the pool.", nil, nil)
+	poolEmptyAcquires     = prometheus.NewDesc("pgxpool_empty_acquires_total", "Acquires that had to wait because no connection was idle.", nil, nil)
+	poolAcquireWait       = prometheus.NewDesc("pgxpool_acquire_wait_seconds_total", "Time spent waiting for a connection when none was idle.", nil, nil)
+	poolCanceledAcquires  = prometheus.NewDesc("pgxpool_canceled_acquires_total", "Acquires canceled before a connection was available.", nil, nil)
+)
+
+// PoolCollector exposes the statistics of a pgx connection pool, read once
+// on each scrape.
+type PoolCollector struct {
+	stat func() *pgxpool.Stat
+}
+
+func NewPoolCollector(stat func() *pgxpool.Stat) *PoolCollector {
+	return &PoolCollector{stat: stat}
+}
+
+func (c *PoolCollector) Describe(descriptions chan<- *prometheus.Desc) {
+	for _, description := range []*prometheus.Desc{
+		poolAcquiredConns, poolIdleConns, poolConstructingConns, poolTotalConns, poolMaxConns,
+		poolAcquires, poolEmptyAcquires, poolAcquireWait, poolCanceledAcquires,
+	} {
+		descriptions <- description
+	}
+}
+
+func (c *PoolCollector) Collect(metrics chan<- prometheus.Metric) {
+	stat := c.stat()
+	metrics <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	metrics <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	metrics <- prometheus.MustNewConstMetric(poolConstructingConns, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	metrics <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	metrics <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	metrics <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	metrics <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	metrics <- prometheus.MustNewConstMetric(poolAcquireWait, prometheus.CounterValue, stat.EmptyAcquireWaitTime().Seconds())
+	metrics <- prometheus.MustNewConstMetric(poolCanceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// errorKinds names the domain errors counted apart. Validation failures are
+// counted as "invalid" and the other errors as "error".
+var errorKinds = []struct {
+	err  error
+	kind string
+}{
+	{domain.ErrNotFound, "not_found"},
+	{domain.ErrTenantRequired, "tenant_required"},
+	{domain.ErrTenantDisabled, "tenant_disabled"},
+	{domain.ErrInvalidTransition, "invalid_transition"},
+	{domain.ErrReasonRequired, "reason_required"},
+	{domain.ErrInvalidAttributes, "invalid_attributes"},
+	{context.Canceled, "canceled"},
+	{context.DeadlineExceeded, "deadline_exceeded"},
+}
+
+func errorKind(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return "invalid"
+	}
+	for _, known := range errorKinds {
+		if errors.Is(err, known.err) {
+			return known.kind
+		}
+	}
+	return "error"
+}
+
+// UserServiceMetrics counts the operations of a user service by outcome.
+type UserServiceMetrics struct {
+	next       ports.UserService
+	operations *prometheus.CounterVec
+}
+
+// InstrumentUserService returns service counting each call in
+// userapi_user_operations_total, labeled with the operation and with "ok" or
+// the kind of error returned.
+func InstrumentUserService(service ports.UserService, registerer prometheus.Registerer) *UserServiceMetrics {
+	return &UserServiceMetrics{
+		next: service,
+		operations: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: "userapi_user_operations_total",
+			Help: "User service operations by result.",
+		}, []string{"operation", "result"}),
+	}
+}
+
+func (m *UserServiceMetrics) count(operation string, err error) {
+	m.operations.WithLabelValues(operation, errorKind(err)).Inc()
+}
+
+func (m *UserServiceMetrics) AddUser(ctx context.Context, user domain.User) (domain.User, error) {
+	created, err := m.next.AddUser(ctx, user)
+	m.count("AddUser", err)
+	return created, err
+}
+
+func (m *UserServiceMetrics) GetUserById(ctx context.Context, userId string) (domain.User, error) {
+	user, err := m.next.GetUserById(ctx, userId)
+	m.count("GetUserById", err)
+	return user, err
+}
+
+func (m *UserServiceMetrics) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	user, err := m.next.GetUserByEmail(ctx, email)
+	m.count("GetUserByEmail", err)
+	return user, err
+}
+
+func (m *UserServiceMetrics) GetAllUsers(ctx context.Context) ([]domain.User, error) {
+	users, err := m.next.GetAllUsers(ctx)
+	m.count("GetAllUsers", err)
+	return users, err
+}
+
+func (m *UserServiceMetrics) GetUsersPage(ctx context.Context, attributes map[string]any, after string, limit int) ([]domain.User, error) {
+	users, err := m.next.GetUsersPage(ctx, attributes, after, limit)
+	m.count("GetUsersPage", err)
+	return users, err
+}
+
+func (m *UserServiceMetrics) GetUsersByAttributes(ctx context.Context, attributes map[string]any) ([]domain.User, error) {
+	users, err := m.next.GetUsersByAttributes(ctx, attributes)
+	m.count("GetUsersByAttributes", err)
+	return users, err
+}
+
+func (m *UserServiceMetrics) UpdateUserByID(ctx context.Context, userId string, user domain.User) (domain.User, error) {
+	updated, err := m.next.UpdateUserByID(ctx, userId, user)
+	m.count("UpdateUserByID", err)
+	return updated, err
+}
+
+func (m *UserServiceMetrics) DeleteUserByID(ctx context.Context, userId string) error {
+	err := m.next.DeleteUserByID(ctx, userId)
+	m.count("DeleteUserByID", err)
+	return err
+}
+
+func (m *UserServiceMetrics) ActivateUser(ctx context.Context, userId string, reason string) (domain.User, error) {
+	user, err := m.next.ActivateUser(ctx, userId, reason)
+	m.count("ActivateUser", err)
+	return user, err
+}
+
+func (m *UserServiceMetrics) SuspendUser(ctx context.Context, userId string, reason string, until time.Time) (domain.User, error) {
+	user, err := m.next.SuspendUser(ctx, userId, reason, until)
+	m.count("SuspendUser", err)
+	return user, err
+}
+
+func (m *UserServiceMetrics) ReactivateUser(ctx context.Context, userId string, reason string) (domain.User, error) {
+	user, err := m.next.ReactivateUser(ctx, userId, reason)
+	m.count("ReactivateUser", err)
+	return user, err
+}
+
+func (m *UserServiceMetrics) GetStatusTransitions(ctx context.Context, userId string) ([]domain.StatusTransition, error) {
+	transitions, err := m.next.GetStatusTransitions(ctx, userId)
+	m.count("GetStatusTransitions", err)
+	return transitions, err
+}
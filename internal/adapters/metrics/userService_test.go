@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"userapi/app/internal/adapters/service"
+	"userapi/app/internal/core/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestErrorKind(t *testing.T) {
+	for err, kind := range map[error]string{
+		nil:                                     "ok",
+		fmt.Errorf("x: %w", domain.ErrNotFound): "not_found",
+		fmt.Errorf("could not connect"):         "error",
+	} {
+		if actual := errorKind(err); actual != kind {
+			t.Errorf("expected %s for %v, got %s", kind, err, actual)
+		}
+	}
+}
+
+func TestInstrumentUserService(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	instrumented := InstrumentUserService(service.MockUserServiceImpl{}, registry)
+	_, _ = instrumented.GetUserById(context.Background(), "42")
+	if count := testutil.ToFloat64(instrumented.operations.WithLabelValues("GetUserById", "not_found")); count != 1 {
+		t.Fatalf("expected 1 failed GetUserById call, got %v", count)
+	}
+	// The pool connects lazily, so it has statistics without a database.
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:1/userapi")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pool.Close()
+	registry.MustRegister(NewPoolCollector(pool.Stat))
+	if count := testutil.CollectAndCount(registry, "pgxpool_max_conns"); count != 1 {
+		t.Fatalf("expected the pool metrics, got %d pgxpool_max_conns series", count)
+	}
+	if problems, err := testutil.GatherAndLint(registry); err != nil || len(problems) > 0 {
+		t.Fatalf("unexpected lint problems %v: %v", problems, err)
+	}
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"userapi/app/internal/core/domain"
+)
+
+// FileNotifier appends notifications to a JSON lines file instead of
+// delivering them. It stands in for a real channel during local testing.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+type outboxEntry struct {
+	SentAt  time.Time `json:"sentAt"`
+	Channel string    `json:"channel"`
+	To      string    `json:"to"`
+	Subject string    `json:"subject,omitempty"`
+	Body    string    `json:"body"`
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Send(ctx context.Context, notification domain.Notification) error {
+	_ = ctx
+	line, err := json.Marshal(outboxEntry{
+		SentAt:  time.Now().UTC(),
+		Channel: string(notification.Channel),
+		To:      notification.To,
+		Subject: notification.Subject,
+		Body:    notification.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("could not encode the notification: %w", err)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	file, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not open the outbox: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("could not write to the outbox: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"userapi/app/internal/core/domain"
+)
+
+// SMTPNotifier sends email notifications through an SMTP relay.
+type SMTPNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPNotifier returns a notifier for the relay at addr (host:port). PLAIN
+// authentication is used when a username is given.
+func NewSMTPNotifier(addr string, from string, username string, password string) (*SMTPNotifier, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp address %q: %w", addr, err)
+	}
+	notifier := &SMTPNotifier{addr: addr, from: from}
+	if username != "" {
+		notifier.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return notifier, nil
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, notification domain.Notification) error {
+	if notification.Channel != domain.NotificationEmail {
+		return fmt.Errorf("smtp cannot deliver %s notifications", notification.Channel)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(notification.To+notification.Subject, "\r\n") {
+		return fmt.Errorf("notification headers must not contain line breaks")
+	}
+	message := strings.Join([]string{
+		"From: " + n.from,
+		"To: " + notification.To,
+		"Subject: " + notification.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		notification.Body,
+	}, "\r\n")
+	if err := smtp.SendMail(n.addr, n.auth, n.from, []string{notification.To}, []byte(message)); err != nil {
+		return fmt.Errorf("could not send the email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArgon2Hasher(t *testing.T) {
+	hasher := &Argon2Hasher{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16}
+	hash, err := hasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatal("Unexpected error while hashing", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatal("Unexpected hash format", hash)
+	}
+	t.Run("Right password verifies", func(t *testing.T) {
+		if ok, err := NewArgon2Hasher().Verify("correct horse battery staple", hash); err != nil || !ok {
+			t.Fatal("The password should verify with the parameters of the hash", err)
+		}
+	})
+	t.Run("Wrong password does not verify", func(t *testing.T) {
+		if ok, _ := hasher.Verify("Tr0ub4dor&3", hash); ok {
+			t.Fatal("A wrong password should not verify")
+		}
+	})
+	t.Run("Other hashes are rejected", func(t *testing.T) {
+		if _, err := hasher.Verify("password", "$2a$10$abcdefghijklmnopqrstuv"); err != ErrUnsupportedHash {
+			t.Fatal("Expected an unsupported hash error, got", err)
+		}
+	})
+}
+
+func TestLoadBreachList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	content := "# top passwords\npassword123\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\n\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := LoadBreachList(path)
+	if err != nil {
+		t.Fatal("Unexpected error while loading the list", err)
+	}
+	for _, breached := range []string{"password123", "password"} {
+		if !list.Contains(breached) {
+			t.Fatalf("%q should be breached", breached)
+		}
+	}
+	if list.Contains("correct horse battery staple") {
+		t.Fatal("The password should not be breached")
+	}
+}
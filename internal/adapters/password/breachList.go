@@ -0,0 +1,66 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1" //nolint:gosec // breach lists are published as SHA-1 digests.
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// BreachList holds the SHA-1 digests of breached passwords.
+type BreachList struct {
+	digests map[string]struct{}
+}
+
+// LoadBreachList reads a file with one breached password per line. Lines may
+// hold the password itself or its upper case SHA-1 digest, optionally followed
+// by ":count" as in the Have I Been Pwned downloads. Blank lines and lines
+// starting with # are skipped.
+func LoadBreachList(path string) (*BreachList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the breach list: %w", err)
+	}
+	defer file.Close()
+	list := &BreachList{digests: make(map[string]struct{})}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if digest, _, _ := strings.Cut(line, ":"); isSHA1(digest) {
+			list.digests[strings.ToUpper(digest)] = struct{}{}
+			continue
+		}
+		list.digests[sha1Hex(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read the breach list: %w", err)
+	}
+	return list, nil
+}
+
+func (l *BreachList) Contains(password string) bool {
+	_, ok := l.digests[sha1Hex(password)]
+	return ok
+}
+
+func (l *BreachList) Len() int {
+	return len(l.digests)
+}
+
+func sha1Hex(value string) string {
+	sum := sha1.Sum([]byte(value)) //nolint:gosec
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isSHA1(value string) bool {
+	if len(value) != 2*sha1.Size {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
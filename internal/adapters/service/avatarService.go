@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+// defaultAvatarSizes are the widths of the thumbnails made from avatars.
+var defaultAvatarSizes = []int{64, 128, 256}
+
+// AvatarServiceImpl keeps the avatars of users in a blob store, under
+// avatars/{tenantId}/{userId}/. Each upload is stored without its metadata as
+// "original", along with a square thumbnail for each of the Sizes; a new
+// upload replaces all of them.
+type AvatarServiceImpl struct {
+	UserService    ports.UserService
+	BlobStore      ports.BlobStore
+	ImageProcessor ports.ImageProcessor
+	Sizes          []int
+}
+
+func NewAvatarService(userService ports.UserService, blobStore ports.BlobStore, imageProcessor ports.ImageProcessor) *AvatarServiceImpl {
+	return &AvatarServiceImpl{UserService: userService, BlobStore: blobStore, ImageProcessor: imageProcessor, Sizes: defaultAvatarSizes}
+}
+
+func avatarKey(tenantId string, userId string, size int) string {
+	name := "original"
+	if size > 0 {
+		name = strconv.Itoa(size)
+	}
+	return "avatars/" + tenantId + "/" + userId + "/" + name
+}
+
+func (s *AvatarServiceImpl) SetAvatar(ctx context.Context, userId string, data []byte) (domain.Avatar, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.Avatar{}, err
+	}
+	if _, err := s.UserService.GetUserById(ctx, userId); err != nil {
+		return domain.Avatar{}, err
+	}
+	variants, err := s.ImageProcessor.Process(data, s.Sizes)
+	if err != nil {
+		return domain.Avatar{}, err
+	}
+	avatar := domain.Avatar{ContentType: variants[0].ContentType, Sizes: []int{}}
+	for _, variant := range variants {
+		if err := s.BlobStore.Put(ctx, avatarKey(tenantId, userId, variant.Size), variant.ContentType, variant.Data); err != nil {
+			return domain.Avatar{}, fmt.Errorf("could not store the avatar of user %s: %w", userId, err)
+		}
+		if variant.Size > 0 {
+			avatar.Sizes = append(avatar.Sizes, variant.Size)
+		}
+	}
+	// Thumbnails of the previous avatar would otherwise outlive it.
+	for _, size := range s.Sizes {
+		if slices.Contains(avatar.Sizes, size) {
+			continue
+		}
+		if err := s.BlobStore.Delete(ctx, avatarKey(tenantId, userId, size)); err != nil {
+			return domain.Avatar{}, fmt.Errorf("could not remove a previous thumbnail of user %s: %w", userId, err)
+		}
+	}
+	return avatar, nil
+}
+
+func (s *AvatarServiceImpl) GetAvatar(ctx context.Context, userId string, size int) (domain.Blob, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.Blob{}, err
+	}
+	if _, err := s.UserService.GetUserById(ctx, userId); err != nil {
+		return domain.Blob{}, err
+	}
+	original := avatarKey(tenantId, userId, 0)
+	key := original
+	if size > 0 {
+		for _, thumbnail := range slices.Sorted(slices.Values(s.Sizes)) {
+			if thumbnail >= size {
+				key = avatarKey(tenantId, userId, thumbnail)
+				break
+			}
+		}
+	}
+	blob, err := s.BlobStore.Get(ctx, key)
+	if errors.Is(err, domain.ErrNotFound) && key != original {
+		// Images too small for the thumbnail, and WebP images, have none.
+		blob, err = s.BlobStore.Get(ctx, original)
+	}
+	if err != nil {
+		return domain.Blob{}, fmt.Errorf("could not retrieve the avatar of user %s: %w", userId, err)
+	}
+	return blob, nil
+}
+
+func (s *AvatarServiceImpl) DeleteAvatar(ctx context.Context, userId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := s.UserService.GetUserById(ctx, userId); err != nil {
+		return err
+	}
+	for _, size := range append([]int{0}, s.Sizes...) {
+		if err := s.BlobStore.Delete(ctx, avatarKey(tenantId, userId, size)); err != nil {
+			return fmt.Errorf("could not delete the avatar of user %s: %w", userId, err)
+		}
+	}
+	return nil
+}
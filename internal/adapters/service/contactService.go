@@ -0,0 +1,320 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+// ContactServiceImpl manages the contact methods and postal addresses of
+// users. User.Email and User.Phone stay the source of the primary email and
+// phone: the contact methods are brought in line with them before each
+// operation, which also fills the collections of users created before they
+// existed. Making another contact method primary updates the user.
+type ContactServiceImpl struct {
+	UserService       ports.UserService
+	ContactRepository ports.ContactRepository
+	Validator         ports.Validator
+}
+
+func NewContactService(userService ports.UserService, contactRepository ports.ContactRepository, validator ports.Validator) *ContactServiceImpl {
+	return &ContactServiceImpl{UserService: userService, ContactRepository: contactRepository, Validator: validator}
+}
+
+// defaultContactLabel labels the contact methods taken over from the user.
+const defaultContactLabel = "other"
+
+// contactValueTags validates the value of each kind of contact method.
+var contactValueTags = map[domain.ContactKind]string{
+	domain.ContactEmail: "email",
+	domain.ContactPhone: "e164",
+}
+
+func (s *ContactServiceImpl) GetContactMethods(ctx context.Context, userId string, kind domain.ContactKind) ([]domain.ContactMethod, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.contactMethods(ctx, tenantId, userId, kind)
+}
+
+func (s *ContactServiceImpl) AddContactMethod(ctx context.Context, userId string, contact domain.ContactMethod) (domain.ContactMethod, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.ContactMethod{}, err
+	}
+	if err := s.validateContactMethod(contact); err != nil {
+		return domain.ContactMethod{}, err
+	}
+	contacts, err := s.contactMethods(ctx, tenantId, userId, contact.Kind)
+	if err != nil {
+		return domain.ContactMethod{}, err
+	}
+	contact.UserID = userId
+	contact.Primary = contact.Primary || len(contacts) == 0
+	if contact.Primary {
+		if err := s.setUserValue(ctx, userId, contact.Kind, contact.Value); err != nil {
+			return domain.ContactMethod{}, err
+		}
+	}
+	created, err := s.ContactRepository.CreateContactMethod(ctx, tenantId, contact)
+	if err != nil {
+		return domain.ContactMethod{}, fmt.Errorf("could not add the contact method: %w", err)
+	}
+	return created, nil
+}
+
+func (s *ContactServiceImpl) UpdateContactMethod(ctx context.Context, userId string, update domain.ContactMethod) (domain.ContactMethod, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.ContactMethod{}, err
+	}
+	contacts, err := s.contactMethods(ctx, tenantId, userId, update.Kind)
+	if err != nil {
+		return domain.ContactMethod{}, err
+	}
+	contact, err := findContactMethod(contacts, update.ContactID)
+	if err != nil {
+		return domain.ContactMethod{}, err
+	}
+	changed := contact
+	changed.Label = cmp.Or(update.Label, contact.Label)
+	changed.Value = cmp.Or(update.Value, contact.Value)
+	if err := s.validateContactMethod(changed); err != nil {
+		return domain.ContactMethod{}, err
+	}
+	if (contact.Primary && changed.Value != contact.Value) || (!contact.Primary && update.Primary) {
+		if err := s.setUserValue(ctx, userId, changed.Kind, changed.Value); err != nil {
+			return domain.ContactMethod{}, err
+		}
+	}
+	if changed != contact {
+		if changed, err = s.ContactRepository.UpdateContactMethod(ctx, tenantId, changed); err != nil {
+			return domain.ContactMethod{}, fmt.Errorf("could not update the contact method %s: %w", contact.ContactID, err)
+		}
+	}
+	if !contact.Primary && update.Primary {
+		if changed, err = s.ContactRepository.SetPrimaryContactMethod(ctx, tenantId, userId, changed.Kind, changed.ContactID); err != nil {
+			return domain.ContactMethod{}, fmt.Errorf("could not make the contact method %s primary: %w", contact.ContactID, err)
+		}
+	}
+	return changed, nil
+}
+
+func (s *ContactServiceImpl) DeleteContactMethod(ctx context.Context, userId string, kind domain.ContactKind, contactId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	contacts, err := s.contactMethods(ctx, tenantId, userId, kind)
+	if err != nil {
+		return err
+	}
+	contact, err := findContactMethod(contacts, contactId)
+	if err != nil {
+		return err
+	}
+	if contact.Primary {
+		return domain.ErrPrimaryContact
+	}
+	if err := s.ContactRepository.DeleteContactMethod(ctx, tenantId, userId, kind, contactId); err != nil {
+		return fmt.Errorf("could not delete the contact method %s: %w", contactId, err)
+	}
+	return nil
+}
+
+func (s *ContactServiceImpl) GetPostalAddresses(ctx context.Context, userId string) ([]domain.PostalAddress, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.postalAddresses(ctx, tenantId, userId)
+}
+
+func (s *ContactServiceImpl) AddPostalAddress(ctx context.Context, userId string, address domain.PostalAddress) (domain.PostalAddress, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.PostalAddress{}, err
+	}
+	if err := s.validatePostalAddress(address); err != nil {
+		return domain.PostalAddress{}, err
+	}
+	addresses, err := s.postalAddresses(ctx, tenantId, userId)
+	if err != nil {
+		return domain.PostalAddress{}, err
+	}
+	address.UserID = userId
+	address.Primary = address.Primary || len(addresses) == 0
+	created, err := s.ContactRepository.CreatePostalAddress(ctx, tenantId, address)
+	if err != nil {
+		return domain.PostalAddress{}, fmt.Errorf("could not add the postal address: %w", err)
+	}
+	return created, nil
+}
+
+func (s *ContactServiceImpl) UpdatePostalAddress(ctx context.Context, userId string, update domain.PostalAddress) (domain.PostalAddress, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.PostalAddress{}, err
+	}
+	addresses, err := s.postalAddresses(ctx, tenantId, userId)
+	if err != nil {
+		return domain.PostalAddress{}, err
+	}
+	index := findPostalAddress(addresses, update.AddressID)
+	if index < 0 {
+		return domain.PostalAddress{}, domain.ErrNotFound
+	}
+	address := addresses[index]
+	changed := address
+	changed.Label = cmp.Or(update.Label, address.Label)
+	changed.Line1 = cmp.Or(update.Line1, address.Line1)
+	changed.Line2 = cmp.Or(update.Line2, address.Line2)
+	changed.City = cmp.Or(update.City, address.City)
+	changed.Region = cmp.Or(update.Region, address.Region)
+	changed.PostalCode = cmp.Or(update.PostalCode, address.PostalCode)
+	changed.Country = cmp.Or(update.Country, address.Country)
+	if err := s.validatePostalAddress(changed); err != nil {
+		return domain.PostalAddress{}, err
+	}
+	if changed != address {
+		if changed, err = s.ContactRepository.UpdatePostalAddress(ctx, tenantId, changed); err != nil {
+			return domain.PostalAddress{}, fmt.Errorf("could not update the postal address %s: %w", address.AddressID, err)
+		}
+	}
+	if !address.Primary && update.Primary {
+		if changed, err = s.ContactRepository.SetPrimaryPostalAddress(ctx, tenantId, userId, address.AddressID); err != nil {
+			return domain.PostalAddress{}, fmt.Errorf("could not make the postal address %s primary: %w", address.AddressID, err)
+		}
+	}
+	return changed, nil
+}
+
+func (s *ContactServiceImpl) DeletePostalAddress(ctx context.Context, userId string, addressId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	addresses, err := s.postalAddresses(ctx, tenantId, userId)
+	if err != nil {
+		return err
+	}
+	index := findPostalAddress(addresses, addressId)
+	if index < 0 {
+		return domain.ErrNotFound
+	}
+	if err := s.ContactRepository.DeletePostalAddress(ctx, tenantId, userId, addressId); err != nil {
+		return fmt.Errorf("could not delete the postal address %s: %w", addressId, err)
+	}
+	if !addresses[index].Primary || len(addresses) == 1 {
+		return nil
+	}
+	// the primary address comes first, then the others oldest first.
+	next := addresses[1].AddressID
+	if _, err := s.ContactRepository.SetPrimaryPostalAddress(ctx, tenantId, userId, next); err != nil {
+		return fmt.Errorf("could not make the postal address %s primary: %w", next, err)
+	}
+	return nil
+}
+
+// contactMethods returns the contact methods of a kind after bringing the
+// primary one in line with the value held by the user.
+func (s *ContactServiceImpl) contactMethods(ctx context.Context, tenantId string, userId string, kind domain.ContactKind) ([]domain.ContactMethod, error) {
+	if _, ok := contactValueTags[kind]; !ok {
+		return nil, fmt.Errorf("%w: unknown kind %q", domain.ErrInvalidContact, kind)
+	}
+	user, err := s.UserService.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	contacts, err := s.ContactRepository.RetrieveContactMethods(ctx, tenantId, userId, kind)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve the contact methods: %w", err)
+	}
+	value := user.Email
+	if kind == domain.ContactPhone {
+		value = user.Phone
+	}
+	switch {
+	case value == "":
+		return contacts, nil
+	case len(contacts) == 0 || !contacts[0].Primary:
+		primary, err := s.ContactRepository.CreateContactMethod(ctx, tenantId, domain.ContactMethod{UserID: userId, Kind: kind, Label: defaultContactLabel, Value: value, Primary: true})
+		if err != nil {
+			return nil, fmt.Errorf("could not add the primary contact method: %w", err)
+		}
+		return append([]domain.ContactMethod{primary}, contacts...), nil
+	case contacts[0].Value != value:
+		contacts[0].Value = value
+		if contacts[0], err = s.ContactRepository.UpdateContactMethod(ctx, tenantId, contacts[0]); err != nil {
+			return nil, fmt.Errorf("could not update the primary contact method: %w", err)
+		}
+	}
+	return contacts, nil
+}
+
+func (s *ContactServiceImpl) postalAddresses(ctx context.Context, tenantId string, userId string) ([]domain.PostalAddress, error) {
+	if _, err := s.UserService.GetUserById(ctx, userId); err != nil {
+		return nil, err
+	}
+	addresses, err := s.ContactRepository.RetrievePostalAddresses(ctx, tenantId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve the postal addresses: %w", err)
+	}
+	return addresses, nil
+}
+
+// setUserValue makes value the email or the phone of the user.
+func (s *ContactServiceImpl) setUserValue(ctx context.Context, userId string, kind domain.ContactKind, value string) error {
+	update := domain.User{Email: value}
+	if kind == domain.ContactPhone {
+		update = domain.User{Phone: value}
+	}
+	if _, err := s.UserService.UpdateUserByID(ctx, userId, update); err != nil {
+		return fmt.Errorf("could not update the user %s: %w", userId, err)
+	}
+	return nil
+}
+
+func (s *ContactServiceImpl) validateContactMethod(contact domain.ContactMethod) error {
+	tag, ok := contactValueTags[contact.Kind]
+	if !ok {
+		return fmt.Errorf("%w: unknown kind %q", domain.ErrInvalidContact, contact.Kind)
+	}
+	if err := s.Validator.Struct(contact); err != nil {
+		return errors.Join(domain.ErrInvalidContact, err)
+	}
+	if err := s.Validator.Var(contact.Value, tag); err != nil {
+		return errors.Join(domain.ErrInvalidContact, err)
+	}
+	return nil
+}
+
+func (s *ContactServiceImpl) validatePostalAddress(address domain.PostalAddress) error {
+	if err := s.Validator.Struct(address); err != nil {
+		return errors.Join(domain.ErrInvalidAddress, err)
+	}
+	return address.CheckCountryRules()
+}
+
+func findContactMethod(contacts []domain.ContactMethod, contactId string) (domain.ContactMethod, error) {
+	for _, contact := range contacts {
+		if contact.ContactID == contactId {
+			return contact, nil
+		}
+	}
+	return domain.ContactMethod{}, domain.ErrNotFound
+}
+
+func findPostalAddress(addresses []domain.PostalAddress, addressId string) int {
+	for i, address := range addresses {
+		if address.AddressID == addressId {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"userapi/app/internal/core/domain"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type MockContactRepository struct {
+	contacts  []domain.ContactMethod
+	addresses []domain.PostalAddress
+}
+
+func (m *MockContactRepository) CreateContactMethod(ctx context.Context, tenantId string, contact domain.ContactMethod) (domain.ContactMethod, error) {
+	contact.ContactID = uuid.New().String()
+	if contact.Primary {
+		m.clearPrimaryContact(contact.UserID, contact.Kind)
+	}
+	m.contacts = append(m.contacts, contact)
+	return contact, nil
+}
+
+func (m *MockContactRepository) RetrieveContactMethods(ctx context.Context, tenantId string, userId string, kind domain.ContactKind) ([]domain.ContactMethod, error) {
+	var contacts []domain.ContactMethod
+	for _, contact := range m.contacts {
+		if contact.UserID == userId && contact.Kind == kind {
+			contacts = append(contacts, contact)
+		}
+	}
+	slices.SortStableFunc(contacts, func(a, b domain.ContactMethod) int {
+		if a.Primary == b.Primary {
+			return 0
+		}
+		if a.Primary {
+			return -1
+		}
+		return 1
+	})
+	return contacts, nil
+}
+
+func (m *MockContactRepository) UpdateContactMethod(ctx context.Context, tenantId string, contact domain.ContactMethod) (domain.ContactMethod, error) {
+	for i := range m.contacts {
+		if m.contacts[i].ContactID == contact.ContactID {
+			m.contacts[i].Label = contact.Label
+			m.contacts[i].Value = contact.Value
+			return m.contacts[i], nil
+		}
+	}
+	return domain.ContactMethod{}, domain.ErrNotFound
+}
+
+func (m *MockContactRepository) SetPrimaryContactMethod(ctx context.Context, tenantId string, userId string, kind domain.ContactKind, contactId string) (domain.ContactMethod, error) {
+	m.clearPrimaryContact(userId, kind)
+	for i := range m.contacts {
+		if m.contacts[i].ContactID == contactId {
+			m.contacts[i].Primary = true
+			return m.contacts[i], nil
+		}
+	}
+	return domain.ContactMethod{}, domain.ErrNotFound
+}
+
+func (m *MockContactRepository) clearPrimaryContact(userId string, kind domain.ContactKind) {
+	for i := range m.contacts {
+		if m.contacts[i].UserID == userId && m.contacts[i].Kind == kind {
+			m.contacts[i].Primary = false
+		}
+	}
+}
+
+func (m *MockContactRepository) DeleteContactMethod(ctx context.Context, tenantId string, userId string, kind domain.ContactKind, contactId string) error {
+	m.contacts = slices.DeleteFunc(m.contacts, func(contact domain.ContactMethod) bool { return contact.ContactID == contactId })
+	return nil
+}
+
+func (m *MockContactRepository) CreatePostalAddress(ctx context.Context, tenantId string, address domain.PostalAddress) (domain.PostalAddress, error) {
+	address.AddressID = uuid.New().String()
+	m.addresses = append(m.addresses, address)
+	return address, nil
+}
+
+func (m *MockContactRepository) RetrievePostalAddresses(ctx context.Context, tenantId string, userId string) ([]domain.PostalAddress, error) {
+	return m.addresses, nil
+}
+
+func (m *MockContactRepository) UpdatePostalAddress(ctx context.Context, tenantId string, address domain.PostalAddress) (domain.PostalAddress, error) {
+	return address, nil
+}
+
+func (m *MockContactRepository) SetPrimaryPostalAddress(ctx context.Context, tenantId string, userId string, addressId string) (domain.PostalAddress, error) {
+	return domain.PostalAddress{}, domain.ErrNotFound
+}
+
+func (m *MockContactRepository) DeletePostalAddress(ctx context.Context, tenantId string, userId string, addressId string) error {
+	return nil
+}
+
+func TestContactServiceImpl(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), uuid.New().String())
+	userService := NewMockUserServiceImpl()
+	user, _ := userService.AddUser(ctx, domain.User{Email: "ada@example.com"})
+	service := NewContactService(userService, &MockContactRepository{}, validator.New())
+
+	t.Run("the email of the user is the primary email", func(t *testing.T) {
+		emails, err := service.GetContactMethods(ctx, user.UserID, domain.ContactEmail)
+		if err != nil || len(emails) != 1 || !emails[0].Primary || emails[0].Value != user.Email {
+			t.Fatalf("expected the primary email to be %s, got %v and %v", user.Email, emails, err)
+		}
+	})
+
+	t.Run("making an email primary changes the email of the user", func(t *testing.T) {
+		work, err := service.AddContactMethod(ctx, user.UserID, domain.ContactMethod{Kind: domain.ContactEmail, Label: "work", Value: "ada@work.example"})
+		if err != nil || work.Primary {
+			t.Fatalf("expected a secondary email, got %v and %v", work, err)
+		}
+		if _, err := service.UpdateContactMethod(ctx, user.UserID, domain.ContactMethod{ContactID: work.ContactID, Kind: domain.ContactEmail, Primary: true}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		updated, _ := userService.GetUserById(ctx, user.UserID)
+		if updated.Email != work.Value {
+			t.Fatalf("expected the email of the user to be %s, got %s", work.Value, updated.Email)
+		}
+		if err := service.DeleteContactMethod(ctx, user.UserID, domain.ContactEmail, work.ContactID); !errors.Is(err, domain.ErrPrimaryContact) {
+			t.Fatalf("expected ErrPrimaryContact, got %v", err)
+		}
+	})
+
+	t.Run("addresses follow the rules of their country", func(t *testing.T) {
+		address := domain.PostalAddress{Label: "home", Line1: "1600 Pennsylvania Ave NW", City: "Washington", PostalCode: "20500", Country: "US"}
+		if _, err := service.AddPostalAddress(ctx, user.UserID, address); !errors.Is(err, domain.ErrInvalidAddress) {
+			t.Fatalf("expected ErrInvalidAddress without a state, got %v", err)
+		}
+		address.PostalCode = "SW1A 1AA"
+		address.Region = "DC"
+		if _, err := service.AddPostalAddress(ctx, user.UserID, address); !errors.Is(err, domain.ErrInvalidAddress) {
+			t.Fatalf("expected ErrInvalidAddress for a postal code of another country, got %v", err)
+		}
+		address.PostalCode = "20500"
+		created, err := service.AddPostalAddress(ctx, user.UserID, address)
+		if err != nil || !created.Primary {
+			t.Fatalf("expected the first address to be primary, got %v and %v", created, err)
+		}
+	})
+}
@@ -0,0 +1,371 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+const (
+	passwordResetPurpose = "password_reset"
+	sessionPurpose       = "session"
+	mfaLoginPurpose      = "mfa_login"
+	mfaLoginTTL          = 5 * time.Minute
+	defaultSessionTTL    = time.Hour
+	passwordResetTTL     = time.Hour
+	// maxLoginAttempts failed logins lock the credential for loginLockout.
+	maxLoginAttempts = 5
+	loginLockout     = 15 * time.Minute
+)
+
+// PasswordPolicy is checked whenever a password is set.
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	// Breached rejects known breached passwords when set.
+	Breached ports.BreachedPasswords
+}
+
+// DefaultPasswordPolicy follows NIST SP 800-63B: length over composition rules.
+var DefaultPasswordPolicy = PasswordPolicy{MinLength: 12, MaxLength: 128}
+
+func (p PasswordPolicy) Check(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("%w: it must be at least %d characters", domain.ErrWeakPassword, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("%w: it must be at most %d characters", domain.ErrWeakPassword, p.MaxLength)
+	}
+	if p.Breached != nil && p.Breached.Contains(password) {
+		return fmt.Errorf("%w: it appears in a known data breach", domain.ErrWeakPassword)
+	}
+	return nil
+}
+
+type CredentialServiceImpl struct {
+	UserService          ports.UserService
+	CredentialRepository ports.CredentialRepository
+	Hasher               ports.PasswordHasher
+	TokenSigner          ports.TokenSigner
+	Policy               PasswordPolicy
+	SessionTTL           time.Duration
+	// Notifier sends password reset emails. Resets are not available without it.
+	Notifier ports.Notifier
+	// PasswordResetURL is prepended to the token in the reset email.
+	PasswordResetURL string
+	// MFAService makes users with an active second factor complete their
+	// logins with it when set.
+	MFAService ports.MFAService
+	// SessionService, when set, stores the sessions so they can be listed,
+	// refreshed and revoked. Logins only get a signed token without it.
+	SessionService ports.SessionService
+	now            func() time.Time
+}
+
+func NewCredentialService(userService ports.UserService, credentialRepository ports.CredentialRepository, hasher ports.PasswordHasher, tokenSigner ports.TokenSigner) *CredentialServiceImpl {
+	return &CredentialServiceImpl{
+		UserService:          userService,
+		CredentialRepository: credentialRepository,
+		Hasher:               hasher,
+		TokenSigner:          tokenSigner,
+		Policy:               DefaultPasswordPolicy,
+		SessionTTL:           defaultSessionTTL,
+		now:                  time.Now,
+	}
+}
+
+func (c *CredentialServiceImpl) SetPassword(ctx context.Context, userId string, password string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := c.UserService.GetUserById(ctx, userId); err != nil {
+		return err
+	}
+	return c.savePassword(ctx, tenantId, userId, password)
+}
+
+// ChangePassword sets the password of a user who gives their current one,
+// when they have one. Wrong current passwords count as failed logins, so they
+// lock the account too.
+func (c *CredentialServiceImpl) ChangePassword(ctx context.Context, userId string, currentPassword string, password string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := c.UserService.GetUserById(ctx, userId); err != nil {
+		return err
+	}
+	credential, err := c.CredentialRepository.RetrieveCredential(ctx, tenantId, userId)
+	if errors.Is(err, domain.ErrNotFound) {
+		return c.savePassword(ctx, tenantId, userId, password)
+	}
+	if err != nil {
+		return fmt.Errorf("could not retrieve the credential: %w", err)
+	}
+	now := c.now()
+	if credential.LockedUntil.After(now) {
+		return domain.ErrLockedOut
+	}
+	ok, err := c.Hasher.Verify(currentPassword, credential.PasswordHash)
+	if err != nil {
+		return fmt.Errorf("could not verify the password of user %s : %w", userId, err)
+	}
+	if !ok {
+		return c.countFailure(ctx, tenantId, userId, now)
+	}
+	return c.savePassword(ctx, tenantId, userId, password)
+}
+
+func (c *CredentialServiceImpl) savePassword(ctx context.Context, tenantId string, userId string, password string) error {
+	if err := c.Policy.Check(password); err != nil {
+		return err
+	}
+	hash, err := c.Hasher.Hash(password)
+	if err != nil {
+		return fmt.Errorf("could not hash the password: %w", err)
+	}
+	_, err = c.CredentialRepository.SaveCredential(ctx, tenantId, domain.Credential{UserID: userId, PasswordHash: hash})
+	if err != nil {
+		return fmt.Errorf("could not save the password of user %s : %w", userId, err)
+	}
+	return nil
+}
+
+// Login returns a signed session token for the user with the email and
+// password. Unknown emails, missing passwords, inactive users and wrong
+// passwords all fail with domain.ErrInvalidCredentials. Users with an active
+// second factor get an MFA token instead, see CompleteLogin.
+func (c *CredentialServiceImpl) Login(ctx context.Context, email string, password string) (domain.Session, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	user, err := c.UserService.GetUserByEmail(ctx, email)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Session{}, c.rejectUnknown(password)
+	}
+	if err != nil {
+		return domain.Session{}, err
+	}
+	credential, err := c.CredentialRepository.RetrieveCredential(ctx, tenantId, user.UserID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Session{}, c.rejectUnknown(password)
+	}
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("could not retrieve the credential: %w", err)
+	}
+	now := c.now()
+	if credential.LockedUntil.After(now) {
+		return domain.Session{}, domain.ErrLockedOut
+	}
+	ok, err := c.Hasher.Verify(password, credential.PasswordHash)
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("could not verify the password of user %s : %w", user.UserID, err)
+	}
+	if !ok {
+		return domain.Session{}, c.countFailure(ctx, tenantId, user.UserID, now)
+	}
+	if user.Status.Blocked() {
+		return domain.Session{}, domain.ErrInvalidCredentials
+	}
+	if c.MFAService != nil {
+		factors, err := c.MFAService.GetMFAFactors(ctx, user.UserID)
+		if err != nil {
+			return domain.Session{}, fmt.Errorf("could not retrieve the factors: %w", err)
+		}
+		if hasActiveFactor(factors) {
+			// Failed attempts are kept until the second factor is verified, so
+			// they also limit guesses of the second factor.
+			return c.issueMFAToken(tenantId, user.UserID, now)
+		}
+	}
+	return c.issueSession(ctx, tenantId, user.UserID, credential, now)
+}
+
+func (c *CredentialServiceImpl) issueMFAToken(tenantId string, userId string, now time.Time) (domain.Session, error) {
+	expiresAt := now.Add(mfaLoginTTL)
+	token, err := c.TokenSigner.Sign(map[string]any{
+		"purpose":   mfaLoginPurpose,
+		"sub":       userId,
+		"tenant_id": tenantId,
+		"exp":       expiresAt.Unix(),
+	})
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("could not sign the mfa token: %w", err)
+	}
+	return domain.Session{UserID: userId, Token: token, ExpiresAt: expiresAt, MFARequired: true}, nil
+}
+
+func (c *CredentialServiceImpl) issueSession(ctx context.Context, tenantId string, userId string, credential domain.Credential, now time.Time) (domain.Session, error) {
+	if credential.FailedAttempts > 0 {
+		if err := c.CredentialRepository.ResetLoginFailures(ctx, tenantId, userId); err != nil {
+			return domain.Session{}, fmt.Errorf("could not reset the failed logins: %w", err)
+		}
+	}
+	if c.SessionService != nil {
+		return c.SessionService.StartSession(ctx, userId)
+	}
+	expiresAt := now.Add(c.SessionTTL)
+	token, err := c.TokenSigner.Sign(map[string]any{
+		"purpose":   sessionPurpose,
+		"sub":       userId,
+		"tenant_id": tenantId,
+		"auth_time": now.Unix(),
+		"iat":       now.Unix(),
+		"exp":       expiresAt.Unix(),
+	})
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("could not sign the session token: %w", err)
+	}
+	return domain.Session{UserID: userId, Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// CompleteLogin issues the session of a login that needs a second factor.
+// Failed verifications count as failed logins, so they lock the account too.
+func (c *CredentialServiceImpl) CompleteLogin(ctx context.Context, mfaToken string, verification domain.MFAVerification) (domain.Session, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	if c.MFAService == nil {
+		return domain.Session{}, fmt.Errorf("mfa is %w", domain.ErrNotConfigured)
+	}
+	claims, err := c.TokenSigner.Verify(mfaToken)
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("%w: %w", domain.ErrInvalidToken, err)
+	}
+	userId, _ := claims["sub"].(string)
+	if claims["purpose"] != mfaLoginPurpose || claims["tenant_id"] != tenantId || userId == "" {
+		return domain.Session{}, domain.ErrInvalidToken
+	}
+	credential, err := c.CredentialRepository.RetrieveCredential(ctx, tenantId, userId)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Session{}, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("could not retrieve the credential: %w", err)
+	}
+	now := c.now()
+	if credential.LockedUntil.After(now) {
+		return domain.Session{}, domain.ErrLockedOut
+	}
+	user, err := c.UserService.GetUserById(ctx, userId)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	if user.Status.Blocked() {
+		return domain.Session{}, domain.ErrInvalidToken
+	}
+	err = c.MFAService.VerifyMFA(ctx, userId, verification)
+	if errors.Is(err, domain.ErrMFAFailed) {
+		if countErr := c.countFailure(ctx, tenantId, userId, now); !errors.Is(countErr, domain.ErrInvalidCredentials) {
+			return domain.Session{}, countErr
+		}
+		return domain.Session{}, err
+	}
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return c.issueSession(ctx, tenantId, userId, credential, now)
+}
+
+// rejectUnknown spends the time of a password check, so the response does not
+// reveal whether the email has a password.
+func (c *CredentialServiceImpl) rejectUnknown(password string) error {
+	_, _ = c.Hasher.Hash(password)
+	return domain.ErrInvalidCredentials
+}
+
+func (c *CredentialServiceImpl) countFailure(ctx context.Context, tenantId string, userId string, now time.Time) error {
+	credential, err := c.CredentialRepository.CountLoginFailure(ctx, tenantId, userId)
+	if err != nil {
+		return fmt.Errorf("could not count the failed login: %w", err)
+	}
+	if credential.FailedAttempts < maxLoginAttempts {
+		return domain.ErrInvalidCredentials
+	}
+	if err := c.CredentialRepository.LockCredential(ctx, tenantId, userId, now.Add(loginLockout)); err != nil {
+		return fmt.Errorf("could not lock the credential: %w", err)
+	}
+	return domain.ErrLockedOut
+}
+
+// RequestPasswordReset emails a reset link to the user with the email, if
+// there is one. The token is bound to the current password hash, so it stops
+// working once the password changes.
+func (c *CredentialServiceImpl) RequestPasswordReset(ctx context.Context, email string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if c.Notifier == nil {
+		return fmt.Errorf("password reset is %w", domain.ErrNotConfigured)
+	}
+	user, err := c.UserService.GetUserByEmail(ctx, email)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	credential, err := c.CredentialRepository.RetrieveCredential(ctx, tenantId, user.UserID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("could not retrieve the credential: %w", err)
+	}
+	token, err := c.TokenSigner.Sign(map[string]any{
+		"purpose":   passwordResetPurpose,
+		"sub":       user.UserID,
+		"tenant_id": tenantId,
+		"pwd":       digest(credential.PasswordHash),
+		"exp":       c.now().Add(passwordResetTTL).Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("could not sign the reset token: %w", err)
+	}
+	err = c.Notifier.Send(ctx, domain.Notification{
+		Channel: domain.NotificationEmail,
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nReset your password with the link below. It expires in %s. If you did not ask for it, ignore this email.\n\n%s%s\n",
+			user.FirstName, passwordResetTTL, c.PasswordResetURL, token),
+	})
+	if err != nil {
+		return fmt.Errorf("could not send the reset email: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a token from RequestPasswordReset.
+// It also lifts any lockout.
+func (c *CredentialServiceImpl) ResetPassword(ctx context.Context, token string, password string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	claims, err := c.TokenSigner.Verify(token)
+	if err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrInvalidToken, err)
+	}
+	userId, _ := claims["sub"].(string)
+	if claims["purpose"] != passwordResetPurpose || claims["tenant_id"] != tenantId || userId == "" {
+		return domain.ErrInvalidToken
+	}
+	credential, err := c.CredentialRepository.RetrieveCredential(ctx, tenantId, userId)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("could not retrieve the credential: %w", err)
+	}
+	if claims["pwd"] != digest(credential.PasswordHash) {
+		return fmt.Errorf("the token has already been used: %w", domain.ErrInvalidToken)
+	}
+	if _, err := c.UserService.GetUserById(ctx, userId); err != nil {
+		return err
+	}
+	return c.savePassword(ctx, tenantId, userId, password)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"userapi/app/internal/adapters/token"
+	"userapi/app/internal/core/domain"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type MockCredentialRepository struct {
+	credentials map[string]domain.Credential
+}
+
+func NewMockCredentialRepository() *MockCredentialRepository {
+	return &MockCredentialRepository{credentials: make(map[string]domain.Credential)}
+}
+
+func (m *MockCredentialRepository) SaveCredential(ctx context.Context, tenantId string, credential domain.Credential) (domain.Credential, error) {
+	credential.PasswordChangedAt = time.Now()
+	m.credentials[credential.UserID] = credential
+	return credential, nil
+}
+
+func (m *MockCredentialRepository) RetrieveCredential(ctx context.Context, tenantId string, userId string) (domain.Credential, error) {
+	credential, ok := m.credentials[userId]
+	if !ok {
+		return domain.Credential{}, domain.ErrNotFound
+	}
+	return credential, nil
+}
+
+func (m *MockCredentialRepository) CountLoginFailure(ctx context.Context, tenantId string, userId string) (domain.Credential, error) {
+	credential := m.credentials[userId]
+	credential.FailedAttempts++
+	m.credentials[userId] = credential
+	return credential, nil
+}
+
+func (m *MockCredentialRepository) LockCredential(ctx context.Context, tenantId string, userId string, until time.Time) error {
+	credential := m.credentials[userId]
+	credential.LockedUntil = until
+	credential.FailedAttempts = 0
+	m.credentials[userId] = credential
+	return nil
+}
+
+func (m *MockCredentialRepository) ResetLoginFailures(ctx context.Context, tenantId string, userId string) error {
+	credential := m.credentials[userId]
+	credential.LockedUntil = time.Time{}
+	credential.FailedAttempts = 0
+	m.credentials[userId] = credential
+	return nil
+}
+
+// plainHasher keeps the tests fast. Never use it outside tests.
+type plainHasher struct{}
+
+func (plainHasher) Hash(password string) (string, error) {
+	return "plain$" + password, nil
+}
+
+func (plainHasher) Verify(password string, hash string) (bool, error) {
+	return hash == "plain$"+password, nil
+}
+
+type breachedSet map[string]bool
+
+func (b breachedSet) Contains(password string) bool {
+	return b[password]
+}
+
+func TestCredentialServiceImpl(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), uuid.New().String())
+	userId := uuid.New().String()
+	stored := domain.User{UserID: userId, FirstName: "John", LastName: "Doe", Email: "john.doe@mail.com", Status: domain.ACTIVE}
+	repo := MockUserRepository{}
+	repo.RetrieveUserFn = func(ctx context.Context, id string) (domain.User, error) {
+		return stored, nil
+	}
+	repo.RetrieveByEmailFn = func(ctx context.Context, email string) (domain.User, error) {
+		if email != stored.Email {
+			return domain.User{}, domain.ErrNotFound
+		}
+		return stored, nil
+	}
+	signer := token.NewHMACSigner([]byte("secret"))
+	notifier := &recordingNotifier{}
+	credentialService := NewCredentialService(NewUserService(repo, validator.New()), NewMockCredentialRepository(), plainHasher{}, signer)
+	credentialService.Policy.Breached = breachedSet{"password1234": true}
+	credentialService.Notifier = notifier
+	const password = "correct horse battery staple"
+
+	t.Run("Weak passwords are rejected", func(t *testing.T) {
+		for _, weak := range []string{"short", "password1234"} {
+			if err := credentialService.SetPassword(ctx, userId, weak); !errors.Is(err, domain.ErrWeakPassword) {
+				t.Fatalf("Expected a weak password error for %q, got %v", weak, err)
+			}
+		}
+	})
+	t.Run("Login issues a session token", func(t *testing.T) {
+		if err := credentialService.SetPassword(ctx, userId, password); err != nil {
+			t.Fatal("Unexpected error while setting the password", err)
+		}
+		session, err := credentialService.Login(ctx, stored.Email, password)
+		if err != nil {
+			t.Fatal("Unexpected error while logging in", err)
+		}
+		claims, err := signer.Verify(session.Token)
+		if err != nil || claims["sub"] != userId {
+			t.Fatal("The session token should name the user", claims, err)
+		}
+		if _, err := credentialService.Login(ctx, "jane.doe@mail.com", password); !errors.Is(err, domain.ErrInvalidCredentials) {
+			t.Fatal("Expected an invalid credentials error for an unknown email, got", err)
+		}
+	})
+	t.Run("Changing the password needs the current one", func(t *testing.T) {
+		if err := credentialService.ChangePassword(ctx, userId, "wrong password", "a changed passphrase"); !errors.Is(err, domain.ErrInvalidCredentials) {
+			t.Fatal("Expected an invalid credentials error for a wrong current password, got", err)
+		}
+		if err := credentialService.ChangePassword(ctx, userId, password, "a changed passphrase"); err != nil {
+			t.Fatal("Unexpected error while changing the password", err)
+		}
+		if err := credentialService.SetPassword(ctx, userId, password); err != nil {
+			t.Fatal("Unexpected error while setting the password", err)
+		}
+	})
+	t.Run("Failed logins lock the credential", func(t *testing.T) {
+		var err error
+		for range maxLoginAttempts {
+			_, err = credentialService.Login(ctx, stored.Email, "wrong password")
+		}
+		if !errors.Is(err, domain.ErrLockedOut) {
+			t.Fatal("Expected a locked out error, got", err)
+		}
+		if _, err := credentialService.Login(ctx, stored.Email, password); !errors.Is(err, domain.ErrLockedOut) {
+			t.Fatal("The right password should be rejected while locked, got", err)
+		}
+	})
+	t.Run("Reset sets a new password once", func(t *testing.T) {
+		if err := credentialService.RequestPasswordReset(ctx, stored.Email); err != nil {
+			t.Fatal("Unexpected error while requesting the reset", err)
+		}
+		body := notifier.sent[len(notifier.sent)-1].Body
+		resetToken := strings.TrimSpace(body[strings.LastIndex(body, "\n\n"):])
+		if err := credentialService.ResetPassword(ctx, resetToken, "a brand new passphrase"); err != nil {
+			t.Fatal("Unexpected error while resetting the password", err)
+		}
+		if _, err := credentialService.Login(ctx, stored.Email, "a brand new passphrase"); err != nil {
+			t.Fatal("The reset should lift the lockout and set the password", err)
+		}
+		if err := credentialService.ResetPassword(ctx, resetToken, "another new passphrase"); !errors.Is(err, domain.ErrInvalidToken) {
+			t.Fatal("Expected an invalid token error when reusing the token, got", err)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+const (
+	defaultDispatchInterval = time.Second
+	defaultDispatchBatch    = 100
+	defaultEventRetention   = 7 * 24 * time.Hour
+	defaultEventMaxAttempts = 20
+	// The wait after a failed publication doubles from minEventBackoff up to
+	// maxEventBackoff.
+	minEventBackoff = time.Second
+	maxEventBackoff = time.Hour
+)
+
+// EventDispatcher relays the events stored in the outbox to the sinks. An
+// event is marked published once every sink took it, so a failing sink has it
+// sent again to all of them: delivery is at least once. The events of a user
+// are published in order, an event waiting for a retry holding back the later
+// events of its user, not those of the others. An event that failed
+// MaxAttempts times is dead: it is given up and no longer holds them back.
+type EventDispatcher struct {
+	Outbox ports.OutboxRepository
+	Sinks  []ports.EventSink
+	// LeaderLock makes sure a single replica publishes the events, which
+	// keeps them in order. Without it every replica publishes them.
+	LeaderLock ports.LeaderLock
+	Interval   time.Duration
+	// BatchSize bounds the events read from the outbox on each tick.
+	BatchSize int
+	// Retention is how long published and dead events stay in the outbox.
+	Retention   time.Duration
+	MaxAttempts int
+	now         func() time.Time
+}
+
+func NewEventDispatcher(outbox ports.OutboxRepository, sinks ...ports.EventSink) *EventDispatcher {
+	return &EventDispatcher{
+		Outbox:      outbox,
+		Sinks:       sinks,
+		Interval:    defaultDispatchInterval,
+		BatchSize:   defaultDispatchBatch,
+		Retention:   defaultEventRetention,
+		MaxAttempts: defaultEventMaxAttempts,
+		now:         time.Now,
+	}
+}
+
+// Run publishes the pending events every Interval while this replica is the
+// leader, until ctx is done.
+func (d *EventDispatcher) Run(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	leader := false
+	for {
+		locked := true
+		if d.LeaderLock != nil {
+			var err error
+			if locked, err = d.LeaderLock.TryLock(ctx); err != nil {
+				logger.Error(fmt.Errorf("could not take the outbox lock: %w", err).Error())
+			}
+		}
+		if locked != leader {
+			logger.Info("changed the outbox leadership", "leader", locked)
+			leader = locked
+		}
+		if locked {
+			if err := d.Dispatch(ctx); err != nil {
+				logger.Error(fmt.Errorf("could not publish the events: %w", err).Error())
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if leader && d.LeaderLock != nil {
+				if err := d.LeaderLock.Unlock(context.WithoutCancel(ctx)); err != nil {
+					logger.Error(fmt.Errorf("could not release the outbox lock: %w", err).Error())
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch publishes the pending events that are due, in the order they were
+// stored, and deletes the events published or dead before the retention. A
+// failed publication is retried with an exponential backoff.
+func (d *EventDispatcher) Dispatch(ctx context.Context) error {
+	now := d.now()
+	events, err := d.Outbox.RetrieveDueEvents(ctx, now, d.BatchSize)
+	if err != nil {
+		return fmt.Errorf("could not retrieve the pending events: %w", err)
+	}
+	// held are the users with an event failing in this batch, whose later
+	// events wait too.
+	held := map[string]bool{}
+	var errs []error
+	for _, event := range events {
+		if held[event.UserID] {
+			continue
+		}
+		if err := d.publish(ctx, event); err != nil {
+			if event.Attempts+1 >= d.MaxAttempts {
+				if err := d.Outbox.MarkEventDead(ctx, event.Sequence, err.Error()); err != nil {
+					held[event.UserID] = true
+					errs = append(errs, fmt.Errorf("could not mark event %s as dead: %w", event.EventID, err))
+				}
+				logging.FromContext(ctx).Error("gave up publishing an event", "eventId", event.EventID, "type", string(event.Type), "attempts", event.Attempts+1, "error", err.Error())
+				continue
+			}
+			held[event.UserID] = true
+			next := now.Add(eventBackoff(event.Attempts))
+			if err := d.Outbox.MarkEventFailed(ctx, event.Sequence, err.Error(), next); err != nil {
+				errs = append(errs, fmt.Errorf("could not mark event %s as failed: %w", event.EventID, err))
+			}
+			logging.FromContext(ctx).Warn("could not publish an event", "eventId", event.EventID, "type", string(event.Type), "attempts", event.Attempts+1, "error", err.Error())
+			continue
+		}
+		if err := d.Outbox.MarkEventPublished(ctx, event.Sequence); err != nil {
+			held[event.UserID] = true
+			errs = append(errs, fmt.Errorf("could not mark event %s as published: %w", event.EventID, err))
+		}
+	}
+	if d.Retention > 0 {
+		if _, err := d.Outbox.DeleteFinishedEvents(ctx, now.Add(-d.Retention)); err != nil {
+			errs = append(errs, fmt.Errorf("could not delete the finished events: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *EventDispatcher) publish(ctx context.Context, event domain.Event) error {
+	var errs []error
+	for _, sink := range d.Sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.EventSinkName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// eventBackoff returns the wait before retrying an event that failed attempts
+// times before.
+func eventBackoff(attempts int) time.Duration {
+	if attempts >= 12 {
+		return maxEventBackoff
+	}
+	return min(minEventBackoff<<attempts, maxEventBackoff)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"userapi/app/internal/adapters/db"
+	"userapi/app/internal/core/domain"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// recordingSink records the events it takes, and fails those fail returns
+// true for.
+type recordingSink struct {
+	events []domain.Event
+	fail   func(domain.Event) bool
+}
+
+func (s *recordingSink) EventSinkName() string {
+	return "recording"
+}
+
+func (s *recordingSink) Publish(ctx context.Context, event domain.Event) error {
+	if s.fail != nil && s.fail(event) {
+		return errors.New("unavailable")
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *recordingSink) published() []string {
+	var published []string
+	for _, event := range s.events {
+		published = append(published, string(event.Type)+" "+event.UserID)
+	}
+	return published
+}
+
+func TestEventDispatcher(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), uuid.New().String())
+	repository := db.NewMockUserRepository()
+	users := NewUserService(repository, validator.New())
+	sink := &recordingSink{}
+	dispatcher := NewEventDispatcher(repository, sink)
+	now := time.Now()
+	dispatcher.now = func() time.Time { return now }
+
+	ada, err := users.AddUser(ctx, domain.User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Status: domain.ACTIVE})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := users.UpdateUserByID(ctx, ada.UserID, domain.User{LastName: "King"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := users.UpdateUserByID(ctx, ada.UserID, domain.User{LastName: "King"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	alan, err := users.AddUser(ctx, domain.User{FirstName: "Alan", LastName: "Turing", Email: "alan@example.com", Status: domain.ACTIVE})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := users.DeleteUserByID(ctx, ada.UserID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sink.fail = func(event domain.Event) bool { return event.Type == domain.UserUpdated }
+	if err := dispatcher.Dispatch(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"user.created " + ada.UserID, "user.created " + alan.UserID}
+	if got := sink.published(); !slices.Equal(got, want) {
+		t.Fatalf("expected the events of the failing user to wait, published %v", got)
+	}
+
+	sink.fail = nil
+	if err := dispatcher.Dispatch(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := sink.published(); len(got) != 2 {
+		t.Fatalf("expected the failed event to wait for its backoff, published %v", got)
+	}
+	now = now.Add(time.Second)
+	if err := dispatcher.Dispatch(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = append(want, "user.updated "+ada.UserID, "user.deleted "+ada.UserID)
+	if got := sink.published(); !slices.Equal(got, want) {
+		t.Fatalf("expected the events of each user in order, once, published %v", got)
+	}
+	var updated domain.UserUpdatedData
+	if err := json.Unmarshal(sink.events[2].Data, &updated); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(updated.ChangedFields, []string{"lastName"}) || updated.User.LastName != "King" {
+		t.Fatalf("unexpected update data %+v", updated)
+	}
+	if sink.events[0].EventID == "" || sink.events[0].TenantID == "" || sink.events[0].Sequence >= sink.events[1].Sequence {
+		t.Fatalf("unexpected event %+v", sink.events[0])
+	}
+
+	dispatcher.MaxAttempts = 2
+	sink.fail = func(event domain.Event) bool { return event.Type == domain.UserUpdated }
+	if _, err := users.UpdateUserByID(ctx, alan.UserID, domain.User{LastName: "Mathison"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := users.DeleteUserByID(ctx, alan.UserID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range 2 {
+		now = now.Add(time.Minute)
+		if err := dispatcher.Dispatch(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	want = append(want, "user.deleted "+alan.UserID)
+	if got := sink.published(); !slices.Equal(got, want) {
+		t.Fatalf("expected the dead event to stop holding back the later ones, published %v", got)
+	}
+}
+
+func TestEventBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{0: time.Second, 3: 8 * time.Second, 20: time.Hour, 100: time.Hour} {
+		if got := eventBackoff(attempts); got != want {
+			t.Errorf("eventBackoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
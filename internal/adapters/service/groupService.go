@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+// GroupServiceImpl manages groups of users. Groups nest: a group can be a
+// member of other groups, and its users are then members of those as well.
+type GroupServiceImpl struct {
+	UserService     ports.UserService
+	GroupRepository ports.GroupRepository
+}
+
+func NewGroupService(userService ports.UserService, groupRepository ports.GroupRepository) *GroupServiceImpl {
+	return &GroupServiceImpl{UserService: userService, GroupRepository: groupRepository}
+}
+
+func (s *GroupServiceImpl) CreateGroup(ctx context.Context, group domain.Group) (domain.Group, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.Group{}, err
+	}
+	created, err := s.GroupRepository.CreateGroup(ctx, tenantId, group)
+	if err != nil {
+		return domain.Group{}, fmt.Errorf("could not create the group %s: %w", group.Name, err)
+	}
+	return created, nil
+}
+
+func (s *GroupServiceImpl) GetGroup(ctx context.Context, groupId string) (domain.Group, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.Group{}, err
+	}
+	group, err := s.GroupRepository.RetrieveGroup(ctx, tenantId, groupId)
+	if err != nil {
+		return domain.Group{}, fmt.Errorf("could not retrieve the group %s: %w", groupId, err)
+	}
+	return group, nil
+}
+
+func (s *GroupServiceImpl) GetGroups(ctx context.Context) ([]domain.Group, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.GroupRepository.RetrieveAllGroups(ctx, tenantId)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve the groups: %w", err)
+	}
+	return groups, nil
+}
+
+func (s *GroupServiceImpl) UpdateGroup(ctx context.Context, groupId string, group domain.Group) (domain.Group, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.Group{}, err
+	}
+	updated, err := s.GroupRepository.UpdateGroup(ctx, tenantId, groupId, group)
+	if err != nil {
+		return domain.Group{}, fmt.Errorf("could not update the group %s: %w", groupId, err)
+	}
+	return updated, nil
+}
+
+func (s *GroupServiceImpl) DeleteGroup(ctx context.Context, groupId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.GroupRepository.DeleteGroup(ctx, tenantId, groupId); err != nil {
+		return fmt.Errorf("could not delete the group %s: %w", groupId, err)
+	}
+	return nil
+}
+
+func (s *GroupServiceImpl) AddGroupUser(ctx context.Context, groupId string, userId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.GroupRepository.AddGroupUser(ctx, tenantId, groupId, userId); err != nil {
+		return fmt.Errorf("could not add the user %s to the group %s: %w", userId, groupId, err)
+	}
+	return nil
+}
+
+func (s *GroupServiceImpl) RemoveGroupUser(ctx context.Context, groupId string, userId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.GroupRepository.RemoveGroupUser(ctx, tenantId, groupId, userId); err != nil {
+		return fmt.Errorf("could not remove the user %s from the group %s: %w", userId, groupId, err)
+	}
+	return nil
+}
+
+func (s *GroupServiceImpl) AddSubgroup(ctx context.Context, groupId string, memberGroupId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if groupId == memberGroupId {
+		return domain.ErrGroupCycle
+	}
+	if err := s.GroupRepository.AddSubgroup(ctx, tenantId, groupId, memberGroupId); err != nil {
+		return fmt.Errorf("could not add the group %s to the group %s: %w", memberGroupId, groupId, err)
+	}
+	return nil
+}
+
+func (s *GroupServiceImpl) RemoveSubgroup(ctx context.Context, groupId string, memberGroupId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.GroupRepository.RemoveSubgroup(ctx, tenantId, groupId, memberGroupId); err != nil {
+		return fmt.Errorf("could not remove the group %s from the group %s: %w", memberGroupId, groupId, err)
+	}
+	return nil
+}
+
+func (s *GroupServiceImpl) GetGroupMembers(ctx context.Context, groupId string) ([]domain.User, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.GroupRepository.RetrieveEffectiveGroupUsers(ctx, tenantId, groupId)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve the members of the group %s: %w", groupId, err)
+	}
+	return users, nil
+}
+
+func (s *GroupServiceImpl) GetUserGroups(ctx context.Context, userId string) ([]domain.GroupMembership, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.UserService.GetUserById(ctx, userId); err != nil {
+		return nil, err
+	}
+	memberships, err := s.GroupRepository.RetrieveUserGroups(ctx, tenantId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve the groups of the user %s: %w", userId, err)
+	}
+	return memberships, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"userapi/app/internal/core/domain"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type MockGroupRepository struct {
+	groups    map[string]domain.Group
+	subgroups map[string][]string
+	users     map[string][]string
+}
+
+func NewMockGroupRepository() *MockGroupRepository {
+	return &MockGroupRepository{groups: make(map[string]domain.Group), subgroups: make(map[string][]string), users: make(map[string][]string)}
+}
+
+func (m *MockGroupRepository) CreateGroup(ctx context.Context, tenantId string, group domain.Group) (domain.Group, error) {
+	group.GroupID = uuid.New().String()
+	m.groups[group.GroupID] = group
+	return group, nil
+}
+
+func (m *MockGroupRepository) RetrieveGroup(ctx context.Context, tenantId string, groupId string) (domain.Group, error) {
+	group, ok := m.groups[groupId]
+	if !ok {
+		return domain.Group{}, domain.ErrNotFound
+	}
+	return group, nil
+}
+
+func (m *MockGroupRepository) RetrieveAllGroups(ctx context.Context, tenantId string) ([]domain.Group, error) {
+	groups := make([]domain.Group, 0, len(m.groups))
+	for _, group := range m.groups {
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+func (m *MockGroupRepository) UpdateGroup(ctx context.Context, tenantId string, groupId string, group domain.Group) (domain.Group, error) {
+	return m.RetrieveGroup(ctx, tenantId, groupId)
+}
+
+func (m *MockGroupRepository) DeleteGroup(ctx context.Context, tenantId string, groupId string) error {
+	delete(m.groups, groupId)
+	return nil
+}
+
+func (m *MockGroupRepository) AddGroupUser(ctx context.Context, tenantId string, groupId string, userId string) error {
+	m.users[groupId] = append(m.users[groupId], userId)
+	return nil
+}
+
+func (m *MockGroupRepository) RemoveGroupUser(ctx context.Context, tenantId string, groupId string, userId string) error {
+	return domain.ErrNotFound
+}
+
+func (m *MockGroupRepository) AddSubgroup(ctx context.Context, tenantId string, groupId string, memberGroupId string) error {
+	if m.contains(memberGroupId, groupId) {
+		return domain.ErrGroupCycle
+	}
+	m.subgroups[groupId] = append(m.subgroups[groupId], memberGroupId)
+	return nil
+}
+
+func (m *MockGroupRepository) contains(groupId string, descendantId string) bool {
+	for _, subgroupId := range m.subgroups[groupId] {
+		if subgroupId == descendantId || m.contains(subgroupId, descendantId) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MockGroupRepository) RemoveSubgroup(ctx context.Context, tenantId string, groupId string, memberGroupId string) error {
+	return domain.ErrNotFound
+}
+
+func (m *MockGroupRepository) RetrieveEffectiveGroupUsers(ctx context.Context, tenantId string, groupId string) ([]domain.User, error) {
+	var users []domain.User
+	for _, userId := range m.users[groupId] {
+		users = append(users, domain.User{UserID: userId})
+	}
+	for _, subgroupId := range m.subgroups[groupId] {
+		members, _ := m.RetrieveEffectiveGroupUsers(ctx, tenantId, subgroupId)
+		users = append(users, members...)
+	}
+	return users, nil
+}
+
+func (m *MockGroupRepository) RetrieveUserGroups(ctx context.Context, tenantId string, userId string) ([]domain.GroupMembership, error) {
+	return nil, nil
+}
+
+func TestGroupServiceImpl(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), uuid.New().String())
+	userId := uuid.New().String()
+	repo := MockUserRepository{}
+	repo.RetrieveUserFn = func(ctx context.Context, id string) (domain.User, error) {
+		if id != userId {
+			return domain.User{}, domain.ErrNotFound
+		}
+		return domain.User{UserID: userId}, nil
+	}
+	service := NewGroupService(NewUserService(repo, validator.New()), NewMockGroupRepository())
+
+	engineering, _ := service.CreateGroup(ctx, domain.Group{Name: "engineering"})
+	backend, _ := service.CreateGroup(ctx, domain.Group{Name: "backend"})
+	if err := service.AddSubgroup(ctx, engineering.GroupID, backend.GroupID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.AddGroupUser(ctx, backend.GroupID, userId); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	members, err := service.GetGroupMembers(ctx, engineering.GroupID)
+	if err != nil || len(members) != 1 || members[0].UserID != userId {
+		t.Fatalf("expected the user to be an effective member, got %v and %v", members, err)
+	}
+
+	if err := service.AddSubgroup(ctx, backend.GroupID, backend.GroupID); !errors.Is(err, domain.ErrGroupCycle) {
+		t.Fatalf("expected ErrGroupCycle for a group nested in itself, got %v", err)
+	}
+	if err := service.AddSubgroup(ctx, backend.GroupID, engineering.GroupID); !errors.Is(err, domain.ErrGroupCycle) {
+		t.Fatalf("expected ErrGroupCycle, got %v", err)
+	}
+	if _, err := service.GetUserGroups(ctx, uuid.New().String()); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an unknown user, got %v", err)
+	}
+}
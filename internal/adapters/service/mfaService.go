@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+const (
+	webAuthnRegistrationPurpose = "webauthn.create"
+	webAuthnAssertionPurpose    = "webauthn.get"
+	webAuthnChallengeTTL        = 5 * time.Minute
+	recoveryCodeCount           = 10
+	defaultTOTPName             = "Authenticator app"
+	defaultSecurityKeyName      = "Security key"
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// MFAServiceImpl enrolls and verifies TOTP and WebAuthn second factors and
+// recovery codes. The WebAuthn ceremonies return domain.ErrNotConfigured until
+// a relying party is set.
+type MFAServiceImpl struct {
+	UserService   ports.UserService
+	MFARepository ports.MFARepository
+	TOTP          ports.TOTPAuthenticator
+	WebAuthn      ports.WebAuthnRelyingParty
+	now           func() time.Time
+}
+
+func NewMFAService(userService ports.UserService, mfaRepository ports.MFARepository, totp ports.TOTPAuthenticator) *MFAServiceImpl {
+	return &MFAServiceImpl{
+		UserService:   userService,
+		MFARepository: mfaRepository,
+		TOTP:          totp,
+		now:           time.Now,
+	}
+}
+
+// EnrollTOTP creates a pending TOTP factor. It becomes active once ConfirmTOTP
+// accepts a first code, which proves the secret was added to an app.
+func (m *MFAServiceImpl) EnrollTOTP(ctx context.Context, userId string, name string) (domain.TOTPEnrollment, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	user, err := m.UserService.GetUserById(ctx, userId)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	secret, err := m.TOTP.NewSecret()
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	if name == "" {
+		name = defaultTOTPName
+	}
+	factor, err := m.MFARepository.CreateMFAFactor(ctx, tenantId, domain.MFAFactor{
+		UserID:     userId,
+		Kind:       domain.MFAFactorTOTP,
+		Name:       name,
+		Status:     domain.MFAFactorPending,
+		TOTPSecret: secret,
+	})
+	if err != nil {
+		return domain.TOTPEnrollment{}, fmt.Errorf("could not save the factor: %w", err)
+	}
+	return domain.TOTPEnrollment{Factor: factor, Secret: secret, URI: m.TOTP.URI(user.Email, secret)}, nil
+}
+
+func (m *MFAServiceImpl) ConfirmTOTP(ctx context.Context, userId string, factorId string, code string) (domain.MFAFactor, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	factor, err := m.MFARepository.RetrieveMFAFactor(ctx, tenantId, userId, factorId)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	if factor.Kind != domain.MFAFactorTOTP {
+		return domain.MFAFactor{}, fmt.Errorf("factor %s is not a TOTP factor: %w", factorId, domain.ErrNotFound)
+	}
+	if factor.Status == domain.MFAFactorActive {
+		return domain.MFAFactor{}, fmt.Errorf("factor %s is %w", factorId, domain.ErrAlreadyVerified)
+	}
+	step, ok := m.TOTP.Match(factor.TOTPSecret, code, m.now())
+	if !ok {
+		return domain.MFAFactor{}, domain.ErrInvalidCode
+	}
+	return m.MFARepository.UseTOTPFactor(ctx, tenantId, userId, factorId, step)
+}
+
+func (m *MFAServiceImpl) BeginWebAuthnRegistration(ctx context.Context, userId string) (domain.WebAuthnOptions, error) {
+	if m.WebAuthn == nil {
+		return domain.WebAuthnOptions{}, fmt.Errorf("webauthn is %w", domain.ErrNotConfigured)
+	}
+	user, err := m.UserService.GetUserById(ctx, userId)
+	if err != nil {
+		return domain.WebAuthnOptions{}, err
+	}
+	// Registered credentials are excluded, so an authenticator is not registered twice.
+	factors, err := m.webAuthnFactors(ctx, userId)
+	if err != nil {
+		return domain.WebAuthnOptions{}, err
+	}
+	return m.beginCeremony(ctx, user, webAuthnRegistrationPurpose, factors)
+}
+
+func (m *MFAServiceImpl) FinishWebAuthnRegistration(ctx context.Context, userId string, registration domain.WebAuthnRegistration) (domain.MFAFactor, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	if m.WebAuthn == nil {
+		return domain.MFAFactor{}, fmt.Errorf("webauthn is %w", domain.ErrNotConfigured)
+	}
+	challenge, err := m.consumeChallenge(ctx, tenantId, userId, registration.ChallengeID, webAuthnRegistrationPurpose)
+	if err != nil {
+		return domain.MFAFactor{}, err
+	}
+	credential, err := m.WebAuthn.VerifyRegistration(challenge.Challenge, registration.ClientDataJSON, registration.AttestationObject)
+	if err != nil {
+		return domain.MFAFactor{}, fmt.Errorf("%w: %w", domain.ErrMFAFailed, err)
+	}
+	name := registration.Name
+	if name == "" {
+		name = defaultSecurityKeyName
+	}
+	factor, err := m.MFARepository.CreateMFAFactor(ctx, tenantId, domain.MFAFactor{
+		UserID:   userId,
+		Kind:     domain.MFAFactorWebAuthn,
+		Name:     name,
+		Status:   domain.MFAFactorActive,
+		WebAuthn: credential,
+	})
+	if err != nil {
+		return domain.MFAFactor{}, fmt.Errorf("could not save the factor: %w", err)
+	}
+	return factor, nil
+}
+
+func (m *MFAServiceImpl) BeginWebAuthnAssertion(ctx context.Context, userId string) (domain.WebAuthnOptions, error) {
+	if m.WebAuthn == nil {
+		return domain.WebAuthnOptions{}, fmt.Errorf("webauthn is %w", domain.ErrNotConfigured)
+	}
+	user, err := m.UserService.GetUserById(ctx, userId)
+	if err != nil {
+		return domain.WebAuthnOptions{}, err
+	}
+	factors, err := m.webAuthnFactors(ctx, userId)
+	if err != nil {
+		return domain.WebAuthnOptions{}, err
+	}
+	if len(factors) == 0 {
+		return domain.WebAuthnOptions{}, fmt.Errorf("user %s has no security key: %w", userId, domain.ErrNotFound)
+	}
+	return m.beginCeremony(ctx, user, webAuthnAssertionPurpose, factors)
+}
+
+func (m *MFAServiceImpl) beginCeremony(ctx context.Context, user domain.User, purpose string, factors []domain.MFAFactor) (domain.WebAuthnOptions, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.WebAuthnOptions{}, err
+	}
+	random, err := m.WebAuthn.NewChallenge()
+	if err != nil {
+		return domain.WebAuthnOptions{}, err
+	}
+	challenge, err := m.MFARepository.CreateMFAChallenge(ctx, tenantId, domain.MFAChallenge{
+		UserID:    user.UserID,
+		Purpose:   purpose,
+		Challenge: random,
+		ExpiresAt: m.now().Add(webAuthnChallengeTTL),
+	})
+	if err != nil {
+		return domain.WebAuthnOptions{}, fmt.Errorf("could not save the challenge: %w", err)
+	}
+	credentialIds := make([][]byte, len(factors))
+	for i, factor := range factors {
+		credentialIds[i] = factor.WebAuthn.CredentialID
+	}
+	return domain.WebAuthnOptions{
+		ChallengeID:      challenge.ChallengeID,
+		Challenge:        challenge.Challenge,
+		RelyingPartyID:   m.WebAuthn.RelyingPartyID(),
+		RelyingPartyName: m.WebAuthn.RelyingPartyName(),
+		UserID:           user.UserID,
+		UserName:         user.Email,
+		Algorithms:       m.WebAuthn.Algorithms(),
+		CredentialIDs:    credentialIds,
+		Timeout:          webAuthnChallengeTTL,
+	}, nil
+}
+
+// consumeChallenge returns the challenge of a ceremony once. Unknown and
+// expired challenges fail with domain.ErrMFAFailed.
+func (m *MFAServiceImpl) consumeChallenge(ctx context.Context, tenantId string, userId string, challengeId string, purpose string) (domain.MFAChallenge, error) {
+	challenge, err := m.MFARepository.ConsumeMFAChallenge(ctx, tenantId, userId, challengeId, purpose)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.MFAChallenge{}, fmt.Errorf("%w: unknown challenge", domain.ErrMFAFailed)
+	}
+	if err != nil {
+		return domain.MFAChallenge{}, fmt.Errorf("could not retrieve the challenge: %w", err)
+	}
+	if !challenge.ExpiresAt.After(m.now()) {
+		return domain.MFAChallenge{}, fmt.Errorf("%w: the challenge has expired", domain.ErrMFAFailed)
+	}
+	return challenge, nil
+}
+
+// VerifyMFA checks a TOTP code, a recovery code or a WebAuthn assertion
+// against the active factors of the user. Failures are domain.ErrMFAFailed.
+// It does not limit attempts, callers must.
+func (m *MFAServiceImpl) VerifyMFA(ctx context.Context, userId string, verification domain.MFAVerification) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case verification.Code != "":
+		return m.verifyTOTP(ctx, tenantId, userId, verification.Code)
+	case verification.RecoveryCode != "":
+		err := m.MFARepository.UseRecoveryCode(ctx, tenantId, userId, recoveryCodeHash(verification.RecoveryCode))
+		if errors.Is(err, domain.ErrInvalidCode) {
+			return fmt.Errorf("%w: invalid recovery code", domain.ErrMFAFailed)
+		}
+		return err
+	case verification.Assertion != nil:
+		return m.verifyAssertion(ctx, tenantId, userId, *verification.Assertion)
+	}
+	return fmt.Errorf("%w: no second factor was given", domain.ErrMFAFailed)
+}
+
+func (m *MFAServiceImpl) verifyTOTP(ctx context.Context, tenantId string, userId string, code string) error {
+	factors, err := m.MFARepository.RetrieveMFAFactors(ctx, tenantId, userId)
+	if err != nil {
+		return fmt.Errorf("could not retrieve the factors: %w", err)
+	}
+	for _, factor := range factors {
+		if factor.Kind != domain.MFAFactorTOTP || factor.Status != domain.MFAFactorActive {
+			continue
+		}
+		step, ok := m.TOTP.Match(factor.TOTPSecret, code, m.now())
+		if !ok {
+			continue
+		}
+		_, err := m.MFARepository.UseTOTPFactor(ctx, tenantId, userId, factor.FactorID, step)
+		if errors.Is(err, domain.ErrInvalidCode) {
+			return fmt.Errorf("%w: the code has already been used", domain.ErrMFAFailed)
+		}
+		return err
+	}
+	return fmt.Errorf("%w: invalid code", domain.ErrMFAFailed)
+}
+
+func (m *MFAServiceImpl) verifyAssertion(ctx context.Context, tenantId string, userId string, assertion domain.WebAuthnAssertion) error {
+	if m.WebAuthn == nil {
+		return fmt.Errorf("webauthn is %w", domain.ErrNotConfigured)
+	}
+	challenge, err := m.consumeChallenge(ctx, tenantId, userId, assertion.ChallengeID, webAuthnAssertionPurpose)
+	if err != nil {
+		return err
+	}
+	factor, err := m.MFARepository.RetrieveMFAFactorByCredentialID(ctx, tenantId, userId, assertion.CredentialID)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && factor.Status != domain.MFAFactorActive) {
+		return fmt.Errorf("%w: unknown credential", domain.ErrMFAFailed)
+	}
+	if err != nil {
+		return fmt.Errorf("could not retrieve the factor: %w", err)
+	}
+	signCount, err := m.WebAuthn.VerifyAssertion(challenge.Challenge, factor.WebAuthn, assertion.ClientDataJSON, assertion.AuthenticatorData, assertion.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrMFAFailed, err)
+	}
+	_, err = m.MFARepository.UseWebAuthnFactor(ctx, tenantId, userId, factor.FactorID, signCount)
+	return err
+}
+
+// GenerateRecoveryCodes replaces the recovery codes of the user. The codes are
+// only returned here, they are stored hashed.
+func (m *MFAServiceImpl) GenerateRecoveryCodes(ctx context.Context, userId string) ([]string, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.UserService.GetUserById(ctx, userId); err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([][]byte, recoveryCodeCount)
+	for i := range codes {
+		random := make([]byte, 10)
+		if _, err := rand.Read(random); err != nil {
+			return nil, fmt.Errorf("could not generate the recovery codes: %w", err)
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(random))
+		codes[i] = code[:8] + "-" + code[8:]
+		hashes[i] = recoveryCodeHash(codes[i])
+	}
+	if err := m.MFARepository.ReplaceRecoveryCodes(ctx, tenantId, userId, hashes); err != nil {
+		return nil, fmt.Errorf("could not save the recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// recoveryCodeHash ignores case, spaces and dashes, which are easily mistyped.
+func recoveryCodeHash(code string) []byte {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return sum[:]
+}
+
+func (m *MFAServiceImpl) GetMFAFactors(ctx context.Context, userId string) ([]domain.MFAFactor, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.UserService.GetUserById(ctx, userId); err != nil {
+		return nil, err
+	}
+	factors, err := m.MFARepository.RetrieveMFAFactors(ctx, tenantId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve the factors: %w", err)
+	}
+	return factors, nil
+}
+
+func (m *MFAServiceImpl) RevokeMFAFactor(ctx context.Context, userId string, factorId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return m.MFARepository.DeleteMFAFactor(ctx, tenantId, userId, factorId)
+}
+
+func (m *MFAServiceImpl) webAuthnFactors(ctx context.Context, userId string) ([]domain.MFAFactor, error) {
+	factors, err := m.GetMFAFactors(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	var webAuthn []domain.MFAFactor
+	for _, factor := range factors {
+		if factor.Kind == domain.MFAFactorWebAuthn && factor.Status == domain.MFAFactorActive {
+			webAuthn = append(webAuthn, factor)
+		}
+	}
+	return webAuthn, nil
+}
+
+// hasActiveFactor reports whether logins of the user need a second factor.
+func hasActiveFactor(factors []domain.MFAFactor) bool {
+	for _, factor := range factors {
+		if factor.Status == domain.MFAFactorActive {
+			return true
+		}
+	}
+	return false
+}
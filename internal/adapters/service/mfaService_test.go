@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"userapi/app/internal/adapters/token"
+	"userapi/app/internal/adapters/totp"
+	"userapi/app/internal/core/domain"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type MockMFARepository struct {
+	factors       map[string]domain.MFAFactor
+	recoveryCodes map[string][][]byte
+	challenges    map[string]domain.MFAChallenge
+}
+
+func NewMockMFARepository() *MockMFARepository {
+	return &MockMFARepository{
+		factors:       make(map[string]domain.MFAFactor),
+		recoveryCodes: make(map[string][][]byte),
+		challenges:    make(map[string]domain.MFAChallenge),
+	}
+}
+
+func (m *MockMFARepository) CreateMFAFactor(ctx context.Context, tenantId string, factor domain.MFAFactor) (domain.MFAFactor, error) {
+	factor.FactorID = uuid.New().String()
+	factor.CreatedAt = time.Now()
+	m.factors[factor.FactorID] = factor
+	return factor, nil
+}
+
+func (m *MockMFARepository) RetrieveMFAFactor(ctx context.Context, tenantId string, userId string, factorId string) (domain.MFAFactor, error) {
+	factor, ok := m.factors[factorId]
+	if !ok || factor.UserID != userId {
+		return domain.MFAFactor{}, domain.ErrNotFound
+	}
+	return factor, nil
+}
+
+func (m *MockMFARepository) RetrieveMFAFactorByCredentialID(ctx context.Context, tenantId string, userId string, credentialId []byte) (domain.MFAFactor, error) {
+	for _, factor := range m.factors {
+		if factor.UserID == userId && bytes.Equal(factor.WebAuthn.CredentialID, credentialId) {
+			return factor, nil
+		}
+	}
+	return domain.MFAFactor{}, domain.ErrNotFound
+}
+
+func (m *MockMFARepository) RetrieveMFAFactors(ctx context.Context, tenantId string, userId string) ([]domain.MFAFactor, error) {
+	var factors []domain.MFAFactor
+	for _, factor := range m.factors {
+		if factor.UserID == userId {
+			factors = append(factors, factor)
+		}
+	}
+	return factors, nil
+}
+
+func (m *MockMFARepository) UseTOTPFactor(ctx context.Context, tenantId string, userId string, factorId string, step int64) (domain.MFAFactor, error) {
+	factor, ok := m.factors[factorId]
+	if !ok || factor.UserID != userId || step <= factor.LastUsedStep {
+		return domain.MFAFactor{}, domain.ErrInvalidCode
+	}
+	factor.Status = domain.MFAFactorActive
+	factor.LastUsedStep = step
+	m.factors[factorId] = factor
+	return factor, nil
+}
+
+func (m *MockMFARepository) UseWebAuthnFactor(ctx context.Context, tenantId string, userId string, factorId string, signCount uint32) (domain.MFAFactor, error) {
+	factor, ok := m.factors[factorId]
+	if !ok || factor.UserID != userId {
+		return domain.MFAFactor{}, domain.ErrMFAFailed
+	}
+	factor.WebAuthn.SignCount = signCount
+	m.factors[factorId] = factor
+	return factor, nil
+}
+
+func (m *MockMFARepository) DeleteMFAFactor(ctx context.Context, tenantId string, userId string, factorId string) error {
+	if _, ok := m.factors[factorId]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(m.factors, factorId)
+	return nil
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(ctx context.Context, tenantId string, userId string, codeHashes [][]byte) error {
+	m.recoveryCodes[userId] = codeHashes
+	return nil
+}
+
+func (m *MockMFARepository) UseRecoveryCode(ctx context.Context, tenantId string, userId string, codeHash []byte) error {
+	codes := m.recoveryCodes[userId]
+	for i, code := range codes {
+		if bytes.Equal(code, codeHash) {
+			m.recoveryCodes[userId] = append(codes[:i], codes[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrInvalidCode
+}
+
+func (m *MockMFARepository) CreateMFAChallenge(ctx context.Context, tenantId string, challenge domain.MFAChallenge) (domain.MFAChallenge, error) {
+	challenge.ChallengeID = uuid.New().String()
+	m.challenges[challenge.ChallengeID] = challenge
+	return challenge, nil
+}
+
+func (m *MockMFARepository) ConsumeMFAChallenge(ctx context.Context, tenantId string, userId string, challengeId string, purpose string) (domain.MFAChallenge, error) {
+	challenge, ok := m.challenges[challengeId]
+	if !ok || challenge.UserID != userId || challenge.Purpose != purpose {
+		return domain.MFAChallenge{}, domain.ErrNotFound
+	}
+	delete(m.challenges, challengeId)
+	return challenge, nil
+}
+
+func TestMFAServiceImpl(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), uuid.New().String())
+	userId := uuid.New().String()
+	stored := domain.User{UserID: userId, FirstName: "John", LastName: "Doe", Email: "john.doe@mail.com", Status: domain.ACTIVE}
+	repo := MockUserRepository{}
+	repo.RetrieveUserFn = func(ctx context.Context, id string) (domain.User, error) {
+		return stored, nil
+	}
+	repo.RetrieveByEmailFn = func(ctx context.Context, email string) (domain.User, error) {
+		return stored, nil
+	}
+	userService := NewUserService(repo, validator.New())
+	authenticator := totp.NewAuthenticator("UserAPI")
+	now := time.Unix(1_800_000_000, 0)
+	mfaService := NewMFAService(userService, NewMockMFARepository(), authenticator)
+	mfaService.now = func() time.Time { return now }
+
+	enrollment, err := mfaService.EnrollTOTP(ctx, userId, "")
+	if err != nil {
+		t.Fatal("Unexpected error while enrolling", err)
+	}
+	if enrollment.Factor.Status != domain.MFAFactorPending || enrollment.URI == "" {
+		t.Fatal("Unexpected enrollment", enrollment)
+	}
+	codeAt := func(t time.Time) string {
+		code, _ := authenticator.Code(enrollment.Secret, authenticator.Step(t))
+		return code
+	}
+
+	t.Run("Pending factors are not accepted", func(t *testing.T) {
+		err := mfaService.VerifyMFA(ctx, userId, domain.MFAVerification{Code: codeAt(now)})
+		if !errors.Is(err, domain.ErrMFAFailed) {
+			t.Fatal("Expected an mfa failure, got", err)
+		}
+	})
+	t.Run("Confirming activates the factor", func(t *testing.T) {
+		factor, err := mfaService.ConfirmTOTP(ctx, userId, enrollment.Factor.FactorID, codeAt(now))
+		if err != nil || factor.Status != domain.MFAFactorActive {
+			t.Fatal("Unexpected result", factor, err)
+		}
+	})
+	t.Run("Codes cannot be replayed", func(t *testing.T) {
+		err := mfaService.VerifyMFA(ctx, userId, domain.MFAVerification{Code: codeAt(now)})
+		if !errors.Is(err, domain.ErrMFAFailed) {
+			t.Fatal("Expected the used code to be rejected, got", err)
+		}
+		// The next period is accepted early to allow for clock drift.
+		if err := mfaService.VerifyMFA(ctx, userId, domain.MFAVerification{Code: codeAt(now.Add(30 * time.Second))}); err != nil {
+			t.Fatal("Unexpected error for the code of the next period", err)
+		}
+	})
+	t.Run("Recovery codes can be used once", func(t *testing.T) {
+		codes, err := mfaService.GenerateRecoveryCodes(ctx, userId)
+		if err != nil || len(codes) != recoveryCodeCount {
+			t.Fatal("Unexpected result", codes, err)
+		}
+		if err := mfaService.VerifyMFA(ctx, userId, domain.MFAVerification{RecoveryCode: codes[0]}); err != nil {
+			t.Fatal("Unexpected error for a recovery code", err)
+		}
+		if err := mfaService.VerifyMFA(ctx, userId, domain.MFAVerification{RecoveryCode: codes[0]}); !errors.Is(err, domain.ErrMFAFailed) {
+			t.Fatal("Expected the used recovery code to be rejected, got", err)
+		}
+	})
+	t.Run("Logins need the second factor", func(t *testing.T) {
+		signer := token.NewHMACSigner([]byte("secret"))
+		credentialService := NewCredentialService(userService, NewMockCredentialRepository(), plainHasher{}, signer)
+		credentialService.MFAService = mfaService
+		const password = "correct horse battery staple"
+		if err := credentialService.SetPassword(ctx, userId, password); err != nil {
+			t.Fatal("Unexpected error while setting the password", err)
+		}
+		session, err := credentialService.Login(ctx, stored.Email, password)
+		if err != nil || !session.MFARequired {
+			t.Fatal("Expected an mfa token", session, err)
+		}
+		if _, err := credentialService.CompleteLogin(ctx, session.Token, domain.MFAVerification{Code: "000000"}); !errors.Is(err, domain.ErrMFAFailed) {
+			t.Fatal("Expected an mfa failure, got", err)
+		}
+		now = now.Add(time.Minute)
+		completed, err := credentialService.CompleteLogin(ctx, session.Token, domain.MFAVerification{Code: codeAt(now)})
+		if err != nil || completed.MFARequired {
+			t.Fatal("Expected a session, got", completed, err)
+		}
+		if claims, err := signer.Verify(completed.Token); err != nil || claims["purpose"] != sessionPurpose {
+			t.Fatal("Unexpected session token", claims, err)
+		}
+	})
+}
@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"userapi/app/internal/core/domain"
 
@@ -33,16 +36,26 @@ func (m MockUserServiceImpl) GetUserById(ctx context.Context, s string) (domain.
 	_ = ctx
 	user, ok := m.users[s]
 	if !ok {
-		return user, errors.New("user not found")
+		return user, fmt.Errorf("user %w", domain.ErrNotFound)
 	}
 	return user, nil
 }
 
+func (m MockUserServiceImpl) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	_ = ctx
+	for _, user := range m.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return domain.User{}, fmt.Errorf("user %w", domain.ErrNotFound)
+}
+
 func (m MockUserServiceImpl) UpdateUserByID(ctx context.Context, s string, user domain.User) (domain.User, error) {
 	_ = ctx
 	currUser, ok := m.users[s]
 	if !ok {
-		return user, errors.New("user not found")
+		return user, fmt.Errorf("user %w", domain.ErrNotFound)
 	}
 	if user.FirstName != "" {
 		currUser.FirstName = user.FirstName
@@ -59,8 +72,11 @@ func (m MockUserServiceImpl) UpdateUserByID(ctx context.Context, s string, user
 	if user.Age != 0 {
 		currUser.Age = user.Age
 	}
-	if user.Status == domain.INACTIVE {
-		currUser.Status = domain.INACTIVE
+	if user.Status != 0 {
+		currUser.Status = user.Status
+	}
+	if user.Attributes != nil {
+		currUser.Attributes = user.Attributes
 	}
 	m.users[s] = currUser
 	return currUser, nil
@@ -68,6 +84,9 @@ func (m MockUserServiceImpl) UpdateUserByID(ctx context.Context, s string, user
 
 func (m MockUserServiceImpl) DeleteUserByID(ctx context.Context, s string) error {
 	_ = ctx
+	if _, ok := m.users[s]; !ok {
+		return fmt.Errorf("user %w", domain.ErrNotFound)
+	}
 	delete(m.users, s)
 	return nil
 }
@@ -80,3 +99,48 @@ func (m MockUserServiceImpl) GetAllUsers(ctx context.Context) ([]domain.User, er
 	}
 	return users, nil
 }
+
+func (m MockUserServiceImpl) GetUsersByAttributes(ctx context.Context, attributes map[string]any) ([]domain.User, error) {
+	_ = ctx
+	users := make([]domain.User, 0)
+	for _, user := range m.users {
+		if user.HasAttributes(attributes) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (m MockUserServiceImpl) GetUsersPage(ctx context.Context, attributes map[string]any, after string, limit int) ([]domain.User, error) {
+	users, _ := m.GetUsersByAttributes(ctx, attributes)
+	users = slices.DeleteFunc(users, func(user domain.User) bool { return user.UserID <= after })
+	slices.SortFunc(users, func(a, b domain.User) int { return strings.Compare(a.UserID, b.UserID) })
+	return users[:min(limit, len(users))], nil
+}
+
+func (m MockUserServiceImpl) setStatus(s string, status domain.UserStatus, reason string) (domain.User, error) {
+	user, ok := m.users[s]
+	if !ok {
+		return user, fmt.Errorf("user %w", domain.ErrNotFound)
+	}
+	user.Status = status
+	user.StatusReason = reason
+	m.users[s] = user
+	return user, nil
+}
+
+func (m MockUserServiceImpl) ActivateUser(ctx context.Context, s string, reason string) (domain.User, error) {
+	return m.setStatus(s, domain.ACTIVE, reason)
+}
+
+func (m MockUserServiceImpl) SuspendUser(ctx context.Context, s string, reason string, until time.Time) (domain.User, error) {
+	return m.setStatus(s, domain.SUSPENDED, reason)
+}
+
+func (m MockUserServiceImpl) ReactivateUser(ctx context.Context, s string, reason string) (domain.User, error) {
+	return m.setStatus(s, domain.ACTIVE, reason)
+}
+
+func (m MockUserServiceImpl) GetStatusTransitions(ctx context.Context, s string) ([]domain.StatusTransition, error) {
+	return []domain.StatusTransition{}, nil
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+// privacyDeadline is the time allowed to fulfil a data subject request.
+const privacyDeadline = 30 * 24 * time.Hour
+
+type PrivacyServiceImpl struct {
+	UserService       ports.UserService
+	PrivacyRepository ports.PrivacyRepository
+	Validator         ports.Validator
+	// AvatarService, when set, has the avatar of a user exported, and deleted
+	// with the erasure.
+	AvatarService ports.AvatarService
+	// VerificationRepository, when set, has the pending phone verification of
+	// a user exported.
+	VerificationRepository ports.VerificationRepository
+	// CredentialRepository, when set, has the password metadata of a user
+	// exported. The hash is left out.
+	CredentialRepository ports.CredentialRepository
+	// MFAService, when set, has the second factors of a user exported. The
+	// TOTP secrets are left out.
+	MFAService ports.MFAService
+	// SessionService, when set, has the active sessions of a user exported.
+	// The tokens are left out.
+	SessionService ports.SessionService
+	// ScheduleService, when set, has the scheduled actions of a user exported.
+	ScheduleService ports.ScheduleService
+	// GroupService, when set, has the groups of a user exported.
+	GroupService ports.GroupService
+	// ContactRepository, when set, has the contact methods and the postal
+	// addresses of a user exported.
+	ContactRepository ports.ContactRepository
+	// WebhookRepository, when set, has the webhook deliveries of the events
+	// of a user exported.
+	WebhookRepository ports.WebhookRepository
+	now               func() time.Time
+}
+
+func NewPrivacyService(userService ports.UserService, privacyRepository ports.PrivacyRepository, validator ports.Validator) *PrivacyServiceImpl {
+	return &PrivacyServiceImpl{UserService: userService, PrivacyRepository: privacyRepository, Validator: validator, now: time.Now}
+}
+
+func (p *PrivacyServiceImpl) newRequest(ctx context.Context, tenantId string, userId string, kind domain.PrivacyRequestKind) (domain.PrivacyRequest, error) {
+	request, err := p.PrivacyRepository.CreatePrivacyRequest(ctx, tenantId, domain.PrivacyRequest{
+		UserID: userId,
+		Kind:   kind,
+		Status: domain.PrivacyPending,
+		DueAt:  p.now().Add(privacyDeadline),
+	})
+	if err != nil {
+		return domain.PrivacyRequest{}, fmt.Errorf("could not record the %s request: %w", kind, err)
+	}
+	return request, nil
+}
+
+// fail marks the request as failed. The original error is returned.
+func (p *PrivacyServiceImpl) fail(ctx context.Context, tenantId string, request domain.PrivacyRequest, cause error) (domain.PrivacyRequest, error) {
+	failed, err := p.PrivacyRepository.CompletePrivacyRequest(ctx, tenantId, request.RequestID, domain.PrivacyFailed, cause.Error())
+	if err != nil {
+		return request, errors.Join(cause, fmt.Errorf("could not mark request %s as failed: %w", request.RequestID, err))
+	}
+	return failed, cause
+}
+
+func (p *PrivacyServiceImpl) ExportUserData(ctx context.Context, userId string) (domain.UserDataExport, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.UserDataExport{}, err
+	}
+	user, err := p.UserService.GetUserById(ctx, userId)
+	if err != nil {
+		return domain.UserDataExport{}, err
+	}
+	request, err := p.newRequest(ctx, tenantId, userId, domain.PrivacyExport)
+	if err != nil {
+		return domain.UserDataExport{}, err
+	}
+	export := domain.UserDataExport{User: user}
+	if err := p.collect(ctx, tenantId, userId, &export); err != nil {
+		_, err = p.fail(ctx, tenantId, request, err)
+		return domain.UserDataExport{}, fmt.Errorf("could not export the data of user %s: %w", userId, err)
+	}
+	completed, err := p.PrivacyRepository.CompletePrivacyRequest(ctx, tenantId, request.RequestID, domain.PrivacyCompleted, "")
+	if err != nil {
+		return domain.UserDataExport{}, fmt.Errorf("could not complete request %s: %w", request.RequestID, err)
+	}
+	for index := range export.PrivacyRequests {
+		if export.PrivacyRequests[index].RequestID == completed.RequestID {
+			export.PrivacyRequests[index] = completed
+		}
+	}
+	export.ExportedAt = p.now()
+	return export, nil
+}
+
+// collect fills export with the data held about the user besides the profile.
+func (p *PrivacyServiceImpl) collect(ctx context.Context, tenantId string, userId string, export *domain.UserDataExport) error {
+	var err error
+	export.PrivacyRequests, err = p.PrivacyRepository.RetrievePrivacyRequestsByUser(ctx, tenantId, userId)
+	if err != nil {
+		return err
+	}
+	export.ErasureCertificates, err = p.PrivacyRepository.RetrieveErasureCertificatesByUser(ctx, tenantId, userId)
+	if err != nil {
+		return err
+	}
+	export.StatusTransitions, err = p.UserService.GetStatusTransitions(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if p.VerificationRepository != nil {
+		pending, err := p.VerificationRepository.RetrievePhoneVerification(ctx, tenantId, userId)
+		switch {
+		case err == nil:
+			export.PhoneVerification = &pending
+		case !errors.Is(err, domain.ErrNotFound):
+			return fmt.Errorf("could not retrieve the phone verification: %w", err)
+		}
+	}
+	if p.CredentialRepository != nil {
+		credential, err := p.CredentialRepository.RetrieveCredential(ctx, tenantId, userId)
+		switch {
+		case err == nil:
+			credential.PasswordHash = ""
+			export.Credential = &credential
+		case !errors.Is(err, domain.ErrNotFound):
+			return fmt.Errorf("could not retrieve the credential: %w", err)
+		}
+	}
+	if p.MFAService != nil {
+		factors, err := p.MFAService.GetMFAFactors(ctx, userId)
+		if err != nil {
+			return err
+		}
+		for index := range factors {
+			factors[index].TOTPSecret = ""
+		}
+		export.MFAFactors = factors
+	}
+	if p.SessionService != nil {
+		sessions, err := p.SessionService.GetSessions(ctx, userId)
+		if err != nil {
+			return err
+		}
+		for index := range sessions {
+			sessions[index].Token = ""
+			sessions[index].RefreshToken = ""
+			sessions[index].RefreshTokenHash = nil
+		}
+		export.Sessions = sessions
+	}
+	if p.ScheduleService != nil {
+		export.ScheduledActions, err = p.ScheduleService.GetScheduledActions(ctx, userId)
+		if err != nil {
+			return err
+		}
+	}
+	if p.GroupService != nil {
+		export.Groups, err = p.GroupService.GetUserGroups(ctx, userId)
+		if err != nil {
+			return err
+		}
+	}
+	if p.ContactRepository != nil {
+		for _, kind := range []domain.ContactKind{domain.ContactEmail, domain.ContactPhone} {
+			contacts, err := p.ContactRepository.RetrieveContactMethods(ctx, tenantId, userId, kind)
+			if err != nil {
+				return fmt.Errorf("could not retrieve the contact methods: %w", err)
+			}
+			export.ContactMethods = append(export.ContactMethods, contacts...)
+		}
+		export.PostalAddresses, err = p.ContactRepository.RetrievePostalAddresses(ctx, tenantId, userId)
+		if err != nil {
+			return fmt.Errorf("could not retrieve the postal addresses: %w", err)
+		}
+	}
+	if p.WebhookRepository != nil {
+		export.WebhookDeliveries, err = p.WebhookRepository.RetrieveWebhookDeliveriesByUser(ctx, tenantId, userId)
+		if err != nil {
+			return fmt.Errorf("could not retrieve the webhook deliveries: %w", err)
+		}
+	}
+	if p.AvatarService != nil {
+		avatar, err := p.AvatarService.GetAvatar(ctx, userId, 0)
+		switch {
+		case err == nil:
+			export.Avatar = &avatar
+		case !errors.Is(err, domain.ErrNotFound):
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *PrivacyServiceImpl) EraseUser(ctx context.Context, userId string) (domain.PrivacyRequest, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.PrivacyRequest{}, err
+	}
+	if _, err := p.UserService.GetUserById(ctx, userId); err != nil {
+		return domain.PrivacyRequest{}, err
+	}
+	certificates, err := p.PrivacyRepository.RetrieveErasureCertificatesByUser(ctx, tenantId, userId)
+	if err != nil {
+		return domain.PrivacyRequest{}, fmt.Errorf("could not check the erasures of user %s: %w", userId, err)
+	}
+	if len(certificates) > 0 {
+		return domain.PrivacyRequest{}, domain.ErrAlreadyErased
+	}
+	request, err := p.newRequest(ctx, tenantId, userId, domain.PrivacyErasure)
+	if err != nil {
+		return domain.PrivacyRequest{}, err
+	}
+	if p.AvatarService != nil {
+		if err := p.AvatarService.DeleteAvatar(ctx, userId); err != nil {
+			return p.fail(ctx, tenantId, request, fmt.Errorf("could not erase user %s: %w", userId, err))
+		}
+	}
+	if _, err := p.PrivacyRepository.EraseUser(ctx, tenantId, userId, request.RequestID); err != nil {
+		return p.fail(ctx, tenantId, request, fmt.Errorf("could not erase user %s: %w", userId, err))
+	}
+	return p.PrivacyRepository.RetrievePrivacyRequest(ctx, tenantId, request.RequestID)
+}
+
+func (p *PrivacyServiceImpl) GetPrivacyRequest(ctx context.Context, requestId string) (domain.PrivacyRequest, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.PrivacyRequest{}, err
+	}
+	if uuidErr := p.Validator.Var(requestId, "required,uuid"); uuidErr != nil {
+		return domain.PrivacyRequest{}, errors.New("request id is not valid")
+	}
+	request, err := p.PrivacyRepository.RetrievePrivacyRequest(ctx, tenantId, requestId)
+	if err != nil {
+		return domain.PrivacyRequest{}, fmt.Errorf("privacy request with id %s not found : %w", requestId, err)
+	}
+	return request, nil
+}
+
+func (p *PrivacyServiceImpl) GetPrivacyRequests(ctx context.Context) ([]domain.PrivacyRequest, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return make([]domain.PrivacyRequest, 0), err
+	}
+	requests, err := p.PrivacyRepository.RetrievePrivacyRequests(ctx, tenantId)
+	if err != nil {
+		return make([]domain.PrivacyRequest, 0), fmt.Errorf("could not retrieve the privacy requests: %w", err)
+	}
+	return requests, nil
+}
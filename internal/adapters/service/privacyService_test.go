@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"userapi/app/internal/adapters/db"
+	"userapi/app/internal/core/domain"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type MockPrivacyRepository struct {
+	requests     map[string]domain.PrivacyRequest
+	certificates []domain.ErasureCertificate
+	EraseUserFn  func(ctx context.Context, userId string) error
+}
+
+func NewMockPrivacyRepository() *MockPrivacyRepository {
+	return &MockPrivacyRepository{requests: make(map[string]domain.PrivacyRequest)}
+}
+
+func (m *MockPrivacyRepository) CreatePrivacyRequest(ctx context.Context, tenantId string, request domain.PrivacyRequest) (domain.PrivacyRequest, error) {
+	request.RequestID = uuid.New().String()
+	m.requests[request.RequestID] = request
+	return request, nil
+}
+
+func (m *MockPrivacyRepository) CompletePrivacyRequest(ctx context.Context, tenantId string, requestId string, status domain.PrivacyRequestStatus, message string) (domain.PrivacyRequest, error) {
+	request := m.requests[requestId]
+	request.Status = status
+	request.Error = message
+	m.requests[requestId] = request
+	return request, nil
+}
+
+func (m *MockPrivacyRepository) RetrievePrivacyRequest(ctx context.Context, tenantId string, requestId string) (domain.PrivacyRequest, error) {
+	request, ok := m.requests[requestId]
+	if !ok {
+		return domain.PrivacyRequest{}, domain.ErrNotFound
+	}
+	return request, nil
+}
+
+func (m *MockPrivacyRepository) RetrievePrivacyRequests(ctx context.Context, tenantId string) ([]domain.PrivacyRequest, error) {
+	requests := make([]domain.PrivacyRequest, 0, len(m.requests))
+	for _, request := range m.requests {
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
+
+func (m *MockPrivacyRepository) RetrievePrivacyRequestsByUser(ctx context.Context, tenantId string, userId string) ([]domain.PrivacyRequest, error) {
+	return m.RetrievePrivacyRequests(ctx, tenantId)
+}
+
+func (m *MockPrivacyRepository) RetrieveErasureCertificatesByUser(ctx context.Context, tenantId string, userId string) ([]domain.ErasureCertificate, error) {
+	return m.certificates, nil
+}
+
+func (m *MockPrivacyRepository) EraseUser(ctx context.Context, tenantId string, userId string, requestId string) (domain.ErasureCertificate, error) {
+	if err := m.EraseUserFn(ctx, userId); err != nil {
+		return domain.ErasureCertificate{}, err
+	}
+	certificate := domain.ErasureCertificate{RequestID: requestId, UserID: userId}
+	m.certificates = append(m.certificates, certificate)
+	_, _ = m.CompletePrivacyRequest(ctx, tenantId, requestId, domain.PrivacyCompleted, "")
+	return certificate, nil
+}
+
+func TestPrivacyServiceImpl(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), uuid.New().String())
+	entityValidator := validator.New()
+	userId := uuid.New().String()
+	repo := MockUserRepository{}
+	repo.RetrieveUserFn = func(ctx context.Context, id string) (domain.User, error) {
+		return domain.User{UserID: userId, FirstName: "John", LastName: "Doe", Email: "john.doe@mail.com", EmailVerified: true, Attributes: map[string]any{"department": "R&D"}}, nil
+	}
+	userService := NewUserService(repo, entityValidator)
+
+	t.Run("Export completes the request", func(t *testing.T) {
+		privacyService := NewPrivacyService(userService, NewMockPrivacyRepository(), entityValidator)
+		export, err := privacyService.ExportUserData(ctx, userId)
+		if err != nil {
+			t.Fatal("Unexpected error while exporting the user data", err)
+		}
+		if export.User.UserID != userId {
+			t.Fatal("Exported user does not match")
+		}
+		if !export.User.EmailVerified {
+			t.Fatal("The export should carry the email verification of the user")
+		}
+		if export.User.Attributes["department"] != "R&D" {
+			t.Fatal("The export should carry the custom attributes of the user")
+		}
+		if len(export.PrivacyRequests) != 1 || export.PrivacyRequests[0].Status != domain.PrivacyCompleted {
+			t.Fatal("The export should be recorded as a completed request", export.PrivacyRequests)
+		}
+	})
+	t.Run("Export has the pending phone verification", func(t *testing.T) {
+		privacyService := NewPrivacyService(userService, NewMockPrivacyRepository(), entityValidator)
+		privacyService.VerificationRepository = NewMockVerificationRepository()
+		export, err := privacyService.ExportUserData(ctx, userId)
+		if err != nil || export.PhoneVerification != nil {
+			t.Fatal("Expected no phone verification to be exported", err, export.PhoneVerification)
+		}
+		_, _ = privacyService.VerificationRepository.SavePhoneVerification(ctx, "", domain.PhoneVerification{UserID: userId, Attempts: 2})
+		export, err = privacyService.ExportUserData(ctx, userId)
+		if err != nil || export.PhoneVerification == nil || export.PhoneVerification.Attempts != 2 {
+			t.Fatal("Expected the pending phone verification to be exported", err, export.PhoneVerification)
+		}
+	})
+	t.Run("Export leaves the TOTP secrets out", func(t *testing.T) {
+		privacyService := NewPrivacyService(userService, NewMockPrivacyRepository(), entityValidator)
+		mfaRepository := NewMockMFARepository()
+		_, _ = mfaRepository.CreateMFAFactor(ctx, "", domain.MFAFactor{UserID: userId, Kind: domain.MFAFactorTOTP, TOTPSecret: "secret"})
+		privacyService.MFAService = NewMFAService(userService, mfaRepository, nil)
+		export, err := privacyService.ExportUserData(ctx, userId)
+		if err != nil || len(export.MFAFactors) != 1 {
+			t.Fatal("Expected the factor to be exported", err, export.MFAFactors)
+		}
+		if export.MFAFactors[0].TOTPSecret != "" {
+			t.Fatal("The TOTP secret should not be exported")
+		}
+	})
+	t.Run("Export leaves the session tokens out", func(t *testing.T) {
+		privacyService := NewPrivacyService(userService, NewMockPrivacyRepository(), entityValidator)
+		sessionRepository := db.NewMemorySessionRepository()
+		tenantId, _ := domain.TenantFromContext(ctx)
+		_, _ = sessionRepository.CreateSession(ctx, tenantId, domain.Session{UserID: userId, Token: "token", RefreshToken: "refresh", RefreshExpiresAt: time.Now().Add(time.Hour)})
+		privacyService.SessionService = NewSessionService(userService, sessionRepository, nil)
+		export, err := privacyService.ExportUserData(ctx, userId)
+		if err != nil || len(export.Sessions) != 1 {
+			t.Fatal("Expected the session to be exported", err, export.Sessions)
+		}
+		if export.Sessions[0].Token != "" || export.Sessions[0].RefreshToken != "" {
+			t.Fatal("The session tokens should not be exported")
+		}
+	})
+	t.Run("Export has the contact methods and addresses", func(t *testing.T) {
+		privacyService := NewPrivacyService(userService, NewMockPrivacyRepository(), entityValidator)
+		contactRepository := &MockContactRepository{}
+		_, _ = contactRepository.CreateContactMethod(ctx, "", domain.ContactMethod{UserID: userId, Kind: domain.ContactEmail, Label: "work", Value: "john@work.example"})
+		_, _ = contactRepository.CreateContactMethod(ctx, "", domain.ContactMethod{UserID: userId, Kind: domain.ContactPhone, Label: "mobile", Value: "+94771234567"})
+		_, _ = contactRepository.CreatePostalAddress(ctx, "", domain.PostalAddress{UserID: userId, Label: "home", City: "Colombo"})
+		privacyService.ContactRepository = contactRepository
+		export, err := privacyService.ExportUserData(ctx, userId)
+		if err != nil || len(export.ContactMethods) != 2 || len(export.PostalAddresses) != 1 {
+			t.Fatal("Expected the contacts to be exported", err, export.ContactMethods, export.PostalAddresses)
+		}
+	})
+	t.Run("Export has the webhook deliveries of the user", func(t *testing.T) {
+		privacyService := NewPrivacyService(userService, NewMockPrivacyRepository(), entityValidator)
+		webhookRepository := NewMockWebhookRepository()
+		webhookRepository.deliveries["mine"] = domain.WebhookDelivery{DeliveryID: "mine", UserID: userId}
+		webhookRepository.deliveries["other"] = domain.WebhookDelivery{DeliveryID: "other", UserID: uuid.New().String()}
+		privacyService.WebhookRepository = webhookRepository
+		export, err := privacyService.ExportUserData(ctx, userId)
+		if err != nil || len(export.WebhookDeliveries) != 1 || export.WebhookDeliveries[0].DeliveryID != "mine" {
+			t.Fatal("Expected only the deliveries of the user to be exported", err, export.WebhookDeliveries)
+		}
+	})
+	t.Run("Export leaves the password hash out", func(t *testing.T) {
+		privacyService := NewPrivacyService(userService, NewMockPrivacyRepository(), entityValidator)
+		privacyService.CredentialRepository = NewMockCredentialRepository()
+		_, _ = privacyService.CredentialRepository.SaveCredential(ctx, "", domain.Credential{UserID: userId, PasswordHash: "hash"})
+		export, err := privacyService.ExportUserData(ctx, userId)
+		if err != nil || export.Credential == nil || export.Credential.PasswordChangedAt.IsZero() {
+			t.Fatal("Expected the credential to be exported", err, export.Credential)
+		}
+		if export.Credential.PasswordHash != "" {
+			t.Fatal("The password hash should not be exported")
+		}
+	})
+	t.Run("Failed erasure is recorded", func(t *testing.T) {
+		privacyRepository := NewMockPrivacyRepository()
+		privacyRepository.EraseUserFn = func(ctx context.Context, userId string) error {
+			return errors.New("mock db error")
+		}
+		privacyService := NewPrivacyService(userService, privacyRepository, entityValidator)
+		request, err := privacyService.EraseUser(ctx, userId)
+		if err == nil {
+			t.Fatal("Error expected. Should return the database error")
+		}
+		if request.Status != domain.PrivacyFailed || request.Error == "" {
+			t.Fatal("The erasure request should be marked as failed", request)
+		}
+	})
+	t.Run("User is only erased once", func(t *testing.T) {
+		privacyRepository := NewMockPrivacyRepository()
+		privacyRepository.EraseUserFn = func(ctx context.Context, userId string) error {
+			return nil
+		}
+		privacyService := NewPrivacyService(userService, privacyRepository, entityValidator)
+		request, err := privacyService.EraseUser(ctx, userId)
+		if err != nil || request.Status != domain.PrivacyCompleted {
+			t.Fatal("Unexpected error while erasing the user", err)
+		}
+		if _, err := privacyService.EraseUser(ctx, userId); !errors.Is(err, domain.ErrAlreadyErased) {
+			t.Fatal("Expected an already erased error, got", err)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+const (
+	defaultScheduleInterval = time.Minute
+	// scheduleBatchSize bounds the due actions run per tenant on each tick.
+	scheduleBatchSize = 100
+	// suspensionEndedReason is recorded when the scheduler lifts an expired suspension.
+	suspensionEndedReason = "the suspension ended"
+)
+
+// ScheduleServiceImpl keeps the status changes scheduled for later and runs
+// them once due. It also reactivates users whose suspension has ended. The
+// changes go through UserService.UpdateUserByID, which leaves users already in
+// the target status alone, so running an action twice does no harm.
+type ScheduleServiceImpl struct {
+	UserService        ports.UserService
+	ScheduleRepository ports.ScheduleRepository
+	TenantRepository   ports.TenantRepository
+	// LeaderLock makes sure a single replica runs the due actions. Without it
+	// every replica runs them.
+	LeaderLock ports.LeaderLock
+	Interval   time.Duration
+	now        func() time.Time
+}
+
+func NewScheduleService(userService ports.UserService, scheduleRepository ports.ScheduleRepository, tenantRepository ports.TenantRepository) *ScheduleServiceImpl {
+	return &ScheduleServiceImpl{
+		UserService:        userService,
+		ScheduleRepository: scheduleRepository,
+		TenantRepository:   tenantRepository,
+		Interval:           defaultScheduleInterval,
+		now:                time.Now,
+	}
+}
+
+func (s *ScheduleServiceImpl) ScheduleAction(ctx context.Context, userId string, action domain.ScheduledAction) (domain.ScheduledAction, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.ScheduledAction{}, err
+	}
+	if _, ok := action.Kind.TargetStatus(); !ok {
+		return domain.ScheduledAction{}, fmt.Errorf("%w: unknown action %q", domain.ErrInvalidSchedule, action.Kind)
+	}
+	if !action.RunAt.After(s.now()) {
+		return domain.ScheduledAction{}, fmt.Errorf("%w: the time must be in the future", domain.ErrInvalidSchedule)
+	}
+	if _, err := s.UserService.GetUserById(ctx, userId); err != nil {
+		return domain.ScheduledAction{}, err
+	}
+	action.UserID = userId
+	scheduled, err := s.ScheduleRepository.CreateScheduledAction(ctx, tenantId, action)
+	if err != nil {
+		return domain.ScheduledAction{}, fmt.Errorf("could not schedule the action: %w", err)
+	}
+	return scheduled, nil
+}
+
+func (s *ScheduleServiceImpl) GetScheduledActions(ctx context.Context, userId string) ([]domain.ScheduledAction, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.UserService.GetUserById(ctx, userId); err != nil {
+		return nil, err
+	}
+	actions, err := s.ScheduleRepository.RetrieveScheduledActions(ctx, tenantId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve the scheduled actions: %w", err)
+	}
+	return actions, nil
+}
+
+func (s *ScheduleServiceImpl) CancelScheduledAction(ctx context.Context, userId string, actionId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.ScheduleRepository.CancelScheduledAction(ctx, tenantId, userId, actionId); err != nil {
+		return fmt.Errorf("could not cancel the scheduled action %s: %w", actionId, err)
+	}
+	return nil
+}
+
+// Run runs the due actions every Interval while this replica is the leader,
+// until ctx is done.
+func (s *ScheduleServiceImpl) Run(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	leader := false
+	for {
+		locked := true
+		if s.LeaderLock != nil {
+			var err error
+			if locked, err = s.LeaderLock.TryLock(ctx); err != nil {
+				logger.Error(fmt.Errorf("could not take the scheduler lock: %w", err).Error())
+			}
+		}
+		if locked != leader {
+			logger.Info("changed the scheduler leadership", "leader", locked)
+			leader = locked
+		}
+		if locked {
+			if err := s.RunDueActions(ctx); err != nil {
+				logger.Error(fmt.Errorf("could not run the scheduled actions: %w", err).Error())
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if leader && s.LeaderLock != nil {
+				if err := s.LeaderLock.Unlock(context.WithoutCancel(ctx)); err != nil {
+					logger.Error(fmt.Errorf("could not release the scheduler lock: %w", err).Error())
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDueActions runs the actions due in every enabled tenant and lifts the
+// suspensions that have ended. An action that fails for good, because the
+// user is gone or the transition is not allowed, is marked as failed; other
+// errors leave it pending for the next run.
+func (s *ScheduleServiceImpl) RunDueActions(ctx context.Context) error {
+	tenants, err := s.TenantRepository.RetrieveAllTenants(ctx)
+	if err != nil {
+		return fmt.Errorf("could not retrieve the tenants: %w", err)
+	}
+	var errs []error
+	for _, tenant := range tenants {
+		if tenant.Disabled {
+			continue
+		}
+		if err := s.runTenant(domain.ContextWithTenant(ctx, tenant.TenantID), tenant.TenantID); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.TenantID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *ScheduleServiceImpl) runTenant(ctx context.Context, tenantId string) error {
+	now := s.now()
+	actions, err := s.ScheduleRepository.RetrieveDueScheduledActions(ctx, tenantId, now, scheduleBatchSize)
+	if err != nil {
+		return fmt.Errorf("could not retrieve the due actions: %w", err)
+	}
+	var errs []error
+	for _, action := range actions {
+		if err := s.execute(ctx, tenantId, action); err != nil {
+			errs = append(errs, fmt.Errorf("action %s: %w", action.ActionID, err))
+		}
+	}
+	userIds, err := s.ScheduleRepository.RetrieveExpiredSuspensions(ctx, tenantId, now)
+	if err != nil {
+		return errors.Join(append(errs, fmt.Errorf("could not retrieve the expired suspensions: %w", err))...)
+	}
+	for _, userId := range userIds {
+		_, err := s.UserService.UpdateUserByID(ctx, userId, domain.User{Status: domain.ACTIVE, StatusReason: suspensionEndedReason})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not end the suspension of user %s: %w", userId, err))
+			continue
+		}
+		logging.FromContext(ctx).Info("ended a suspension", "userId", userId)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *ScheduleServiceImpl) execute(ctx context.Context, tenantId string, action domain.ScheduledAction) error {
+	to, _ := action.Kind.TargetStatus()
+	reason := action.Reason
+	if reason == "" {
+		reason = "scheduled " + strings.ToLower(string(action.Kind))
+	}
+	status, message := domain.ScheduleDone, ""
+	_, err := s.UserService.UpdateUserByID(ctx, action.UserID, domain.User{Status: to, StatusReason: reason})
+	switch {
+	case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, domain.ErrNotFound):
+		status, message = domain.ScheduleFailed, err.Error()
+	case err != nil:
+		return err
+	}
+	err = s.ScheduleRepository.CompleteScheduledAction(ctx, tenantId, action.ActionID, status, message)
+	if errors.Is(err, domain.ErrNotFound) {
+		// cancelled or completed meanwhile.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not complete the action: %w", err)
+	}
+	logging.FromContext(ctx).Info("ran a scheduled action", "userId", action.UserID, "actionId", action.ActionID, "kind", string(action.Kind), "status", string(status))
+	return nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"userapi/app/internal/core/domain"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type MockScheduleRepository struct {
+	actions   map[string]domain.ScheduledAction
+	suspended []string
+}
+
+func NewMockScheduleRepository() *MockScheduleRepository {
+	return &MockScheduleRepository{actions: make(map[string]domain.ScheduledAction)}
+}
+
+func (m *MockScheduleRepository) CreateScheduledAction(ctx context.Context, tenantId string, action domain.ScheduledAction) (domain.ScheduledAction, error) {
+	action.ActionID = uuid.New().String()
+	action.Status = domain.SchedulePending
+	m.actions[action.ActionID] = action
+	return action, nil
+}
+
+func (m *MockScheduleRepository) RetrieveScheduledActions(ctx context.Context, tenantId string, userId string) ([]domain.ScheduledAction, error) {
+	actions := make([]domain.ScheduledAction, 0, len(m.actions))
+	for _, action := range m.actions {
+		if action.UserID == userId {
+			actions = append(actions, action)
+		}
+	}
+	return actions, nil
+}
+
+func (m *MockScheduleRepository) CancelScheduledAction(ctx context.Context, tenantId string, userId string, actionId string) error {
+	return m.CompleteScheduledAction(ctx, tenantId, actionId, domain.ScheduleCancelled, "")
+}
+
+func (m *MockScheduleRepository) RetrieveDueScheduledActions(ctx context.Context, tenantId string, now time.Time, limit int) ([]domain.ScheduledAction, error) {
+	var actions []domain.ScheduledAction
+	for _, action := range m.actions {
+		if action.Status == domain.SchedulePending && !action.RunAt.After(now) && len(actions) < limit {
+			actions = append(actions, action)
+		}
+	}
+	return actions, nil
+}
+
+func (m *MockScheduleRepository) CompleteScheduledAction(ctx context.Context, tenantId string, actionId string, status domain.ScheduledActionStatus, message string) error {
+	action, ok := m.actions[actionId]
+	if !ok || action.Status != domain.SchedulePending {
+		return domain.ErrNotFound
+	}
+	action.Status, action.Error = status, message
+	m.actions[actionId] = action
+	return nil
+}
+
+func (m *MockScheduleRepository) RetrieveExpiredSuspensions(ctx context.Context, tenantId string, now time.Time) ([]string, error) {
+	return m.suspended, nil
+}
+
+type MockTenantRepository struct {
+	tenants []domain.Tenant
+}
+
+func (m MockTenantRepository) CreateTenant(ctx context.Context, tenant domain.Tenant) (domain.Tenant, error) {
+	return tenant, nil
+}
+
+func (m MockTenantRepository) RetrieveTenant(ctx context.Context, id string) (domain.Tenant, error) {
+	return domain.Tenant{}, domain.ErrNotFound
+}
+
+func (m MockTenantRepository) RetrieveTenantBySlug(ctx context.Context, slug string) (domain.Tenant, error) {
+	return domain.Tenant{}, domain.ErrNotFound
+}
+
+func (m MockTenantRepository) RetrieveAllTenants(ctx context.Context) ([]domain.Tenant, error) {
+	return m.tenants, nil
+}
+
+func (m MockTenantRepository) DisableTenant(ctx context.Context, id string) (domain.Tenant, error) {
+	return domain.Tenant{}, domain.ErrNotFound
+}
+
+func TestScheduleServiceImpl(t *testing.T) {
+	tenantId := uuid.New().String()
+	ctx := domain.ContextWithTenant(context.Background(), tenantId)
+	userId := uuid.New().String()
+	user := domain.User{UserID: userId, FirstName: "John", LastName: "Doe", Email: "john.doe@mail.com", Status: domain.ACTIVE}
+	transitions := 0
+	repo := MockUserRepository{}
+	repo.RetrieveUserFn = func(ctx context.Context, id string) (domain.User, error) {
+		return user, nil
+	}
+	repo.UpdateUserFn = func(ctx context.Context, _ domain.User, id string) (domain.User, error) {
+		return user, nil
+	}
+	repo.TransitionFn = func(ctx context.Context, id string, transition domain.StatusTransition) (domain.User, error) {
+		transitions++
+		user.Status, user.StatusReason = transition.To, transition.Reason
+		return user, nil
+	}
+	scheduleRepository := NewMockScheduleRepository()
+	service := NewScheduleService(NewUserService(repo, validator.New()), scheduleRepository, MockTenantRepository{tenants: []domain.Tenant{{TenantID: tenantId}}})
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	if _, err := service.ScheduleAction(ctx, userId, domain.ScheduledAction{Kind: domain.ScheduleDeactivate, RunAt: now.Add(-time.Minute)}); !errors.Is(err, domain.ErrInvalidSchedule) {
+		t.Fatalf("expected ErrInvalidSchedule for a past time, got %v", err)
+	}
+	deactivation, err := service.ScheduleAction(ctx, userId, domain.ScheduledAction{Kind: domain.ScheduleDeactivate, RunAt: now.Add(time.Hour), Reason: "contract ended"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cancelled, _ := service.ScheduleAction(ctx, userId, domain.ScheduledAction{Kind: domain.ScheduleReactivate, RunAt: now.Add(time.Hour)})
+	if err := service.CancelScheduledAction(ctx, userId, cancelled.ActionID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := service.RunDueActions(context.Background()); err != nil || transitions != 0 {
+		t.Fatalf("expected nothing to run before the time, got %d transitions and %v", transitions, err)
+	}
+	now = now.Add(2 * time.Hour)
+	if err := service.RunDueActions(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Status != domain.DEACTIVATED || user.StatusReason != "contract ended" {
+		t.Fatalf("expected the user to be deactivated, got %s (%q)", user.Status.String(), user.StatusReason)
+	}
+	if got := scheduleRepository.actions[deactivation.ActionID].Status; got != domain.ScheduleDone {
+		t.Fatalf("expected the action to be done, got %s", got)
+	}
+	if got := scheduleRepository.actions[cancelled.ActionID].Status; got != domain.ScheduleCancelled {
+		t.Fatalf("expected the cancelled action to stay cancelled, got %s", got)
+	}
+
+	// running an action again leaves a user already in the target status alone.
+	if err := service.execute(ctx, tenantId, deactivation); err != nil || transitions != 1 {
+		t.Fatalf("expected a second run to be a no-op, got %d transitions and %v", transitions, err)
+	}
+
+	user.Status = domain.SUSPENDED
+	scheduleRepository.suspended = []string{userId}
+	if err := service.RunDueActions(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Status != domain.ACTIVE || user.StatusReason != suspensionEndedReason {
+		t.Fatalf("expected the expired suspension to end, got %s (%q)", user.Status.String(), user.StatusReason)
+	}
+}
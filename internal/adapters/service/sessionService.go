@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+const (
+	defaultRefreshTTL = 30 * 24 * time.Hour
+	// sessionTouchInterval limits how often CheckSession writes the last seen time.
+	sessionTouchInterval = time.Minute
+	refreshSecretBytes   = 32
+)
+
+// SessionServiceImpl tracks the sessions issued on login. Access tokens carry
+// the session id in their sid claim, so revoking the session rejects them
+// before they expire, and the login time in their auth_time claim, kept
+// across refreshes. Refresh tokens are <session id>.<secret> and rotate on
+// every use. Presenting a rotated refresh token again revokes the session, as
+// it means the token leaked.
+type SessionServiceImpl struct {
+	UserService       ports.UserService
+	SessionRepository ports.SessionRepository
+	TokenSigner       ports.TokenSigner
+	AccessTTL         time.Duration
+	RefreshTTL        time.Duration
+	now               func() time.Time
+}
+
+func NewSessionService(userService ports.UserService, sessionRepository ports.SessionRepository, tokenSigner ports.TokenSigner) *SessionServiceImpl {
+	return &SessionServiceImpl{
+		UserService:       userService,
+		SessionRepository: sessionRepository,
+		TokenSigner:       tokenSigner,
+		AccessTTL:         defaultSessionTTL,
+		RefreshTTL:        defaultRefreshTTL,
+		now:               time.Now,
+	}
+}
+
+func refreshTokenHash(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+func newRefreshSecret() (string, error) {
+	secret := make([]byte, refreshSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// StartSession stores a new session for the user, from the client in the
+// context, and issues its tokens.
+func (s *SessionServiceImpl) StartSession(ctx context.Context, userId string) (domain.Session, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	secret, err := newRefreshSecret()
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("could not generate the refresh token: %w", err)
+	}
+	client := domain.ClientInfoFromContext(ctx)
+	session, err := s.SessionRepository.CreateSession(ctx, tenantId, domain.Session{
+		UserID:           userId,
+		RefreshTokenHash: refreshTokenHash(secret),
+		RefreshExpiresAt: s.now().Add(s.RefreshTTL),
+		UserAgent:        client.UserAgent,
+		IPAddress:        client.IPAddress,
+	})
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("could not save the session: %w", err)
+	}
+	return s.issueTokens(tenantId, session, secret)
+}
+
+func (s *SessionServiceImpl) issueTokens(tenantId string, session domain.Session, secret string) (domain.Session, error) {
+	now := s.now()
+	session.ExpiresAt = now.Add(s.AccessTTL)
+	if session.ExpiresAt.After(session.RefreshExpiresAt) {
+		session.ExpiresAt = session.RefreshExpiresAt
+	}
+	token, err := s.TokenSigner.Sign(map[string]any{
+		"purpose":   sessionPurpose,
+		"sub":       session.UserID,
+		"tenant_id": tenantId,
+		"sid":       session.SessionID,
+		"auth_time": session.CreatedAt.Unix(),
+		"iat":       now.Unix(),
+		"exp":       session.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("could not sign the session token: %w", err)
+	}
+	session.Token = token
+	session.RefreshToken = session.SessionID + "." + secret
+	return session, nil
+}
+
+// RefreshSession exchanges a refresh token for new tokens.
+func (s *SessionServiceImpl) RefreshSession(ctx context.Context, refreshToken string) (domain.Session, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	sessionId, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || sessionId == "" || secret == "" {
+		return domain.Session{}, domain.ErrInvalidToken
+	}
+	session, err := s.SessionRepository.RetrieveSession(ctx, tenantId, sessionId)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Session{}, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("could not retrieve the session: %w", err)
+	}
+	if !s.active(session) {
+		return domain.Session{}, fmt.Errorf("the session has ended: %w", domain.ErrInvalidToken)
+	}
+	hash := refreshTokenHash(secret)
+	if subtle.ConstantTimeCompare(hash, session.RefreshTokenHash) != 1 {
+		if err := s.SessionRepository.RevokeSession(ctx, tenantId, session.UserID, sessionId); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return domain.Session{}, fmt.Errorf("could not revoke the session: %w", err)
+		}
+		return domain.Session{}, fmt.Errorf("a rotated refresh token was reused, the session is revoked: %w", domain.ErrInvalidToken)
+	}
+	newSecret, err := newRefreshSecret()
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("could not generate the refresh token: %w", err)
+	}
+	session, err = s.SessionRepository.RotateRefreshToken(ctx, tenantId, sessionId, hash, refreshTokenHash(newSecret), s.now().Add(s.RefreshTTL))
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return s.issueTokens(tenantId, session, newSecret)
+}
+
+func (s *SessionServiceImpl) active(session domain.Session) bool {
+	return session.RevokedAt.IsZero() && session.RefreshExpiresAt.After(s.now())
+}
+
+// CheckSession returns domain.ErrInvalidToken once the session is revoked or expired.
+func (s *SessionServiceImpl) CheckSession(ctx context.Context, sessionId string) (domain.Session, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	session, err := s.SessionRepository.RetrieveSession(ctx, tenantId, sessionId)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Session{}, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("could not retrieve the session: %w", err)
+	}
+	if !s.active(session) {
+		return domain.Session{}, fmt.Errorf("the session has ended: %w", domain.ErrInvalidToken)
+	}
+	if s.now().Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := s.SessionRepository.TouchSession(ctx, tenantId, sessionId, domain.ClientInfoFromContext(ctx).IPAddress); err != nil {
+			return domain.Session{}, fmt.Errorf("could not update the session: %w", err)
+		}
+	}
+	return session, nil
+}
+
+func (s *SessionServiceImpl) GetSessions(ctx context.Context, userId string) ([]domain.Session, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.UserService.GetUserById(ctx, userId); err != nil {
+		return nil, err
+	}
+	sessions, err := s.SessionRepository.RetrieveSessions(ctx, tenantId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve the sessions of user %s : %w", userId, err)
+	}
+	return sessions, nil
+}
+
+func (s *SessionServiceImpl) RevokeSession(ctx context.Context, userId string, sessionId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.SessionRepository.RevokeSession(ctx, tenantId, userId, sessionId); err != nil {
+		return fmt.Errorf("could not revoke the session %s : %w", sessionId, err)
+	}
+	return nil
+}
+
+// RevokeAllSessions ends every session of the user and returns how many were active.
+func (s *SessionServiceImpl) RevokeAllSessions(ctx context.Context, userId string) (int, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.UserService.GetUserById(ctx, userId); err != nil {
+		return 0, err
+	}
+	revoked, err := s.SessionRepository.RevokeSessions(ctx, tenantId, userId)
+	if err != nil {
+		return 0, fmt.Errorf("could not revoke the sessions of user %s : %w", userId, err)
+	}
+	return revoked, nil
+}
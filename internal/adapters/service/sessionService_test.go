@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"userapi/app/internal/adapters/db"
+	"userapi/app/internal/adapters/token"
+	"userapi/app/internal/core/domain"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+func TestSessionServiceImpl(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), uuid.New().String())
+	ctx = domain.ContextWithClientInfo(ctx, domain.ClientInfo{UserAgent: "curl/8.5.0", IPAddress: "192.0.2.10"})
+	userId := uuid.New().String()
+	stored := domain.User{UserID: userId, FirstName: "John", LastName: "Doe", Email: "john.doe@mail.com", Status: domain.ACTIVE}
+	repo := MockUserRepository{}
+	repo.RetrieveUserFn = func(ctx context.Context, id string) (domain.User, error) {
+		return stored, nil
+	}
+	repo.UpdateUserFn = func(ctx context.Context, user domain.User, id string) (domain.User, error) {
+		return stored, nil
+	}
+	repo.TransitionFn = func(ctx context.Context, id string, transition domain.StatusTransition) (domain.User, error) {
+		stored.Status = transition.To
+		return stored, nil
+	}
+	sessions := db.NewMemorySessionRepository()
+	userService := NewUserService(repo, validator.New())
+	userService.SessionRepository = sessions
+	signer := token.NewHMACSigner([]byte("secret"))
+	sessionService := NewSessionService(userService, sessions, signer)
+
+	t.Run("Sessions are listed with their client", func(t *testing.T) {
+		session, err := sessionService.StartSession(ctx, userId)
+		if err != nil {
+			t.Fatal("Unexpected error while starting the session", err)
+		}
+		claims, err := signer.Verify(session.Token)
+		if err != nil || claims["sid"] != session.SessionID {
+			t.Fatal("The session token should name the session", claims, err)
+		}
+		listed, err := sessionService.GetSessions(ctx, userId)
+		if err != nil || len(listed) != 1 || listed[0].UserAgent != "curl/8.5.0" || listed[0].IPAddress != "192.0.2.10" {
+			t.Fatal("Expected the session with its client", listed, err)
+		}
+	})
+	t.Run("Refresh tokens rotate and reuse revokes the session", func(t *testing.T) {
+		session, err := sessionService.StartSession(ctx, userId)
+		if err != nil {
+			t.Fatal("Unexpected error while starting the session", err)
+		}
+		refreshed, err := sessionService.RefreshSession(ctx, session.RefreshToken)
+		if err != nil || refreshed.RefreshToken == session.RefreshToken {
+			t.Fatal("Expected a new refresh token", err)
+		}
+		claims, _ := signer.Verify(session.Token)
+		refreshedClaims, _ := signer.Verify(refreshed.Token)
+		if refreshedClaims["auth_time"] == nil || refreshedClaims["auth_time"] != claims["auth_time"] {
+			t.Fatal("The refreshed token should keep the login time", claims, refreshedClaims)
+		}
+		if _, err := sessionService.RefreshSession(ctx, session.RefreshToken); !errors.Is(err, domain.ErrInvalidToken) {
+			t.Fatal("Expected an invalid token error when reusing the refresh token, got", err)
+		}
+		if _, err := sessionService.CheckSession(ctx, session.SessionID); !errors.Is(err, domain.ErrInvalidToken) {
+			t.Fatal("The reuse should revoke the session, got", err)
+		}
+	})
+	t.Run("Deactivating the user revokes every session", func(t *testing.T) {
+		session, err := sessionService.StartSession(ctx, userId)
+		if err != nil {
+			t.Fatal("Unexpected error while starting the session", err)
+		}
+		if _, err := userService.UpdateUserByID(ctx, userId, domain.User{Status: domain.DEACTIVATED}); err != nil {
+			t.Fatal("Unexpected error while updating the user", err)
+		}
+		if _, err := sessionService.CheckSession(ctx, session.SessionID); !errors.Is(err, domain.ErrInvalidToken) {
+			t.Fatal("Expected the session to be revoked, got", err)
+		}
+		if listed, _ := sessionService.GetSessions(ctx, userId); len(listed) != 0 {
+			t.Fatal("Expected no active session, got", listed)
+		}
+	})
+}
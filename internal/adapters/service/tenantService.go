@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+type TenantServiceImpl struct {
+	TenantRepository ports.TenantRepository
+	Validator        ports.Validator
+	// AttributeSchemas and SchemaValidator serve the attribute schemas of the
+	// tenants. Both are needed; ErrNotConfigured is returned otherwise.
+	AttributeSchemas ports.AttributeSchemaRepository
+	SchemaValidator  ports.SchemaValidator
+}
+
+func NewTenantService(tenantRepository ports.TenantRepository, validator ports.Validator) *TenantServiceImpl {
+	return &TenantServiceImpl{TenantRepository: tenantRepository, Validator: validator}
+}
+
+func (t *TenantServiceImpl) AddTenant(ctx context.Context, tenant domain.Tenant) (domain.Tenant, error) {
+	if tenant.Name == "" || tenant.Slug == "" {
+		return domain.Tenant{}, errors.New("name or slug is empty")
+	}
+	if validationErr := t.Validator.Struct(tenant); validationErr != nil {
+		return domain.Tenant{}, fmt.Errorf("could not add the tenant. %w", validationErr)
+	}
+	newTenant, err := t.TenantRepository.CreateTenant(ctx, tenant)
+	if err != nil {
+		return domain.Tenant{}, fmt.Errorf("could not add the tenant %s: %w", tenant.Slug, err)
+	}
+	return newTenant, nil
+}
+
+func (t *TenantServiceImpl) GetTenantByID(ctx context.Context, tenantId string) (domain.Tenant, error) {
+	if uuidErr := t.Validator.Var(tenantId, "required,uuid"); uuidErr != nil {
+		return domain.Tenant{}, errors.New("tenant id is not valid")
+	}
+	tenant, err := t.TenantRepository.RetrieveTenant(ctx, tenantId)
+	if err != nil {
+		return domain.Tenant{}, fmt.Errorf("tenant with id %s not found : %w", tenantId, err)
+	}
+	return tenant, nil
+}
+
+func (t *TenantServiceImpl) GetTenantBySlug(ctx context.Context, slug string) (domain.Tenant, error) {
+	if slug == "" {
+		return domain.Tenant{}, errors.New("tenant slug is empty")
+	}
+	tenant, err := t.TenantRepository.RetrieveTenantBySlug(ctx, slug)
+	if err != nil {
+		return domain.Tenant{}, fmt.Errorf("tenant %s not found : %w", slug, err)
+	}
+	return tenant, nil
+}
+
+func (t *TenantServiceImpl) GetAllTenants(ctx context.Context) ([]domain.Tenant, error) {
+	tenants, err := t.TenantRepository.RetrieveAllTenants(ctx)
+	if err != nil {
+		return make([]domain.Tenant, 0), fmt.Errorf("could not retrieve the tenants: %w", err)
+	}
+	return tenants, nil
+}
+
+func (t *TenantServiceImpl) DisableTenantByID(ctx context.Context, tenantId string) (domain.Tenant, error) {
+	if uuidErr := t.Validator.Var(tenantId, "required,uuid"); uuidErr != nil {
+		return domain.Tenant{}, errors.New("tenant id is not valid")
+	}
+	tenant, err := t.TenantRepository.DisableTenant(ctx, tenantId)
+	if err != nil {
+		return domain.Tenant{}, fmt.Errorf("could not disable the tenant with id %s : %w", tenantId, err)
+	}
+	return tenant, nil
+}
+
+func (t *TenantServiceImpl) GetAttributeSchema(ctx context.Context, tenantId string) (domain.AttributeSchema, error) {
+	if t.AttributeSchemas == nil || t.SchemaValidator == nil {
+		return domain.AttributeSchema{}, domain.ErrNotConfigured
+	}
+	schema, err := t.AttributeSchemas.RetrieveAttributeSchema(ctx, tenantId)
+	if err != nil {
+		return domain.AttributeSchema{}, fmt.Errorf("could not retrieve the attribute schema of tenant %s : %w", tenantId, err)
+	}
+	return schema, nil
+}
+
+// SetAttributeSchema replaces the attribute schema of the tenant. Users saved
+// before are only checked against it when their attributes next change.
+func (t *TenantServiceImpl) SetAttributeSchema(ctx context.Context, tenantId string, schema []byte) (domain.AttributeSchema, error) {
+	if t.AttributeSchemas == nil || t.SchemaValidator == nil {
+		return domain.AttributeSchema{}, domain.ErrNotConfigured
+	}
+	if err := t.SchemaValidator.CheckSchema(schema); err != nil {
+		return domain.AttributeSchema{}, fmt.Errorf("%w: %w", domain.ErrInvalidSchema, err)
+	}
+	saved, err := t.AttributeSchemas.SaveAttributeSchema(ctx, tenantId, schema)
+	if err != nil {
+		return domain.AttributeSchema{}, fmt.Errorf("could not save the attribute schema of tenant %s : %w", tenantId, err)
+	}
+	return saved, nil
+}
+
+func (t *TenantServiceImpl) DeleteAttributeSchema(ctx context.Context, tenantId string) error {
+	if t.AttributeSchemas == nil || t.SchemaValidator == nil {
+		return domain.ErrNotConfigured
+	}
+	if err := t.AttributeSchemas.DeleteAttributeSchema(ctx, tenantId); err != nil {
+		return fmt.Errorf("could not delete the attribute schema of tenant %s : %w", tenantId, err)
+	}
+	return nil
+}
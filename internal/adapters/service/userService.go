@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"userapi/app/internal/core/domain"
 	"userapi/app/internal/core/ports"
@@ -12,13 +14,37 @@ import (
 type UserServiceImpl struct {
 	UserRepository ports.UserRepository
 	Validator      ports.Validator
+	// SessionRepository, when set, has the sessions of users moved to a blocked
+	// status revoked.
+	SessionRepository ports.SessionRepository
+	// AttributeSchemas and SchemaValidator, when both set, have the attributes of
+	// users validated against the attribute schema of their tenant.
+	AttributeSchemas ports.AttributeSchemaRepository
+	SchemaValidator  ports.SchemaValidator
+	// AvatarService, when set, has the avatars of deleted users removed.
+	AvatarService ports.AvatarService
+	now           func() time.Time
 }
 
 func NewUserService(userRepository ports.UserRepository, validator ports.Validator) *UserServiceImpl {
-	return &UserServiceImpl{UserRepository: userRepository, Validator: validator}
+	return &UserServiceImpl{UserRepository: userRepository, Validator: validator, now: time.Now}
+}
+
+// tenantFromContext returns the tenant the caller is scoped to. Every user
+// operation requires one.
+func tenantFromContext(ctx context.Context) (string, error) {
+	tenantId, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		return "", domain.ErrTenantRequired
+	}
+	return tenantId, nil
 }
 
 func (u *UserServiceImpl) AddUser(ctx context.Context, user domain.User) (domain.User, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
 	validationErr := u.Validator.Struct(user)
 	if validationErr != nil {
 		return domain.User{}, fmt.Errorf("could not add the user. %w", validationErr)
@@ -26,8 +52,14 @@ func (u *UserServiceImpl) AddUser(ctx context.Context, user domain.User) (domain
 	if user.FirstName == "" || user.LastName == "" || user.Email == "" {
 		return user, errors.New("firstName or lastName or email is empty")
 	}
+	if user.Status != 0 && user.Status != domain.ACTIVE && user.Status != domain.PENDING {
+		return user, fmt.Errorf("%w: users are created pending or active", domain.ErrInvalidTransition)
+	}
+	if err := u.validateAttributes(ctx, tenantId, user.Attributes); err != nil {
+		return user, err
+	}
 	repository := u.UserRepository
-	newUser, err := repository.CreateUser(ctx, user)
+	newUser, err := repository.CreateUser(domain.ContextWithEvents(ctx, userCreatedEvents), tenantId, user)
 	if err != nil {
 		return newUser, err
 	}
@@ -39,13 +71,17 @@ func (u *UserServiceImpl) AddUser(ctx context.Context, user domain.User) (domain
 }
 
 func (u *UserServiceImpl) GetUserById(ctx context.Context, userId string) (domain.User, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
 	if userId == "" {
 		return domain.User{}, errors.New("user id is empty")
 	}
 	if uuidErr := u.Validator.Var(userId, "uuid"); uuidErr != nil {
 		return domain.User{}, errors.New("user id is not valid")
 	}
-	user, err := u.UserRepository.RetrieveUser(ctx, userId)
+	user, err := u.UserRepository.RetrieveUser(ctx, tenantId, userId)
 	if err != nil {
 		return domain.User{}, fmt.Errorf("user with id %s not found : %w", userId, err)
 	}
@@ -59,7 +95,30 @@ func (u *UserServiceImpl) GetUserById(ctx context.Context, userId string) (domai
 	return user, nil
 }
 
+func (u *UserServiceImpl) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if emailErr := u.Validator.Var(email, "required,email"); emailErr != nil {
+		return domain.User{}, errors.New("email is not valid")
+	}
+	user, err := u.UserRepository.RetrieveUserByEmail(ctx, tenantId, email)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("user with email not found : %w", err)
+	}
+	validationErr := u.Validator.Struct(user)
+	if validationErr != nil {
+		return domain.User{}, fmt.Errorf("could not validate the retrieved user. %w", validationErr)
+	}
+	return user, nil
+}
+
 func (u *UserServiceImpl) UpdateUserByID(ctx context.Context, userId string, user domain.User) (domain.User, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
 	uuidErr := u.Validator.Var(userId, "uuid")
 	if uuidErr != nil {
 		return domain.User{}, errors.New("user id is not valid")
@@ -71,14 +130,175 @@ func (u *UserServiceImpl) UpdateUserByID(ctx context.Context, userId string, use
 	if userId == "" {
 		return domain.User{}, errors.New("user id is empty")
 	}
-	user, err := u.UserRepository.UpdateUser(ctx, userId, user)
+	if user.Attributes != nil {
+		if err := u.validateAttributes(ctx, tenantId, user.Attributes); err != nil {
+			return domain.User{}, err
+		}
+	}
+	// status changes go through the transition table, in the transaction of
+	// the update.
+	status, reason := user.Status, user.StatusReason
+	user.Status, user.StatusReason, user.SuspendedUntil = 0, "", time.Time{}
+	if status != 0 {
+		current, err := u.UserRepository.RetrieveUser(ctx, tenantId, userId)
+		if err != nil {
+			return domain.User{}, fmt.Errorf("could not update the user with id %s : %w", userId, err)
+		}
+		if current.Status != status {
+			return u.updateWithTransition(ctx, tenantId, current, user, status, reason)
+		}
+	}
+	user, err = u.UserRepository.UpdateUser(domain.ContextWithEvents(ctx, userUpdatedEvents), tenantId, userId, user)
 	if err != nil {
 		return domain.User{}, fmt.Errorf("could not update the user with id %s : %w", userId, err)
 	}
 	return user, nil
 }
 
+// updateWithTransition updates the user and moves it to the status when the
+// transition table allows it, all or nothing, and revokes the sessions of
+// users it blocks.
+func (u *UserServiceImpl) updateWithTransition(ctx context.Context, tenantId string, current domain.User, user domain.User, to domain.UserStatus, reason string) (domain.User, error) {
+	if !current.Status.CanTransitionTo(to) {
+		return domain.User{}, fmt.Errorf("%w: from %s to %s", domain.ErrInvalidTransition, current.Status.String(), to.String())
+	}
+	transition := domain.StatusTransition{UserID: current.UserID, From: current.Status, To: to, Reason: reason}
+	ctx = domain.ContextWithEvents(ctx, func(before domain.User, after domain.User) []domain.Event {
+		return append([]domain.Event{domain.NewUserStatusChanged(transition)}, userUpdatedEvents(before, after)...)
+	})
+	updated, err := u.UserRepository.UpdateUserWithTransition(ctx, tenantId, current.UserID, user, transition)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("could not update the user with id %s : %w", current.UserID, err)
+	}
+	if to.Blocked() && u.SessionRepository != nil {
+		if _, err := u.SessionRepository.RevokeSessions(ctx, tenantId, current.UserID); err != nil {
+			return domain.User{}, fmt.Errorf("could not revoke the sessions of the user with id %s : %w", current.UserID, err)
+		}
+	}
+	return updated, nil
+}
+
+// userCreatedEvents, userUpdatedEvents and userDeletedEvents raise the events
+// of the writes, stored by the repository along with them.
+func userCreatedEvents(_ domain.User, after domain.User) []domain.Event {
+	return []domain.Event{domain.NewUserCreated(after)}
+}
+
+func userUpdatedEvents(before domain.User, after domain.User) []domain.Event {
+	if event, ok := domain.NewUserUpdated(before, after); ok {
+		return []domain.Event{event}
+	}
+	return nil
+}
+
+func userDeletedEvents(before domain.User, _ domain.User) []domain.Event {
+	return []domain.Event{domain.NewUserDeleted(before.UserID)}
+}
+
+// validateAttributes checks the attributes against the attribute schema of the
+// tenant. Any attributes are accepted when the tenant has no schema.
+func (u *UserServiceImpl) validateAttributes(ctx context.Context, tenantId string, attributes map[string]any) error {
+	if u.AttributeSchemas == nil || u.SchemaValidator == nil {
+		return nil
+	}
+	schema, err := u.AttributeSchemas.RetrieveAttributeSchema(ctx, tenantId)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not retrieve the attribute schema: %w", err)
+	}
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	if err := u.SchemaValidator.Validate(schema.Schema, attributes); err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrInvalidAttributes, err)
+	}
+	return nil
+}
+
+// transition moves the user to the status when the transition table allows
+// it, and revokes the sessions of users it blocks.
+func (u *UserServiceImpl) transition(ctx context.Context, tenantId string, user domain.User, to domain.UserStatus, reason string, until time.Time) (domain.User, error) {
+	if !user.Status.CanTransitionTo(to) {
+		return domain.User{}, fmt.Errorf("%w: from %s to %s", domain.ErrInvalidTransition, user.Status.String(), to.String())
+	}
+	transition := domain.StatusTransition{
+		UserID:         user.UserID,
+		From:           user.Status,
+		To:             to,
+		Reason:         reason,
+		SuspendedUntil: until,
+	}
+	ctx = domain.ContextWithEvents(ctx, func(domain.User, domain.User) []domain.Event {
+		return []domain.Event{domain.NewUserStatusChanged(transition)}
+	})
+	updated, err := u.UserRepository.TransitionUserStatus(ctx, tenantId, user.UserID, transition)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("could not change the status of the user with id %s : %w", user.UserID, err)
+	}
+	if to.Blocked() && u.SessionRepository != nil {
+		if _, err := u.SessionRepository.RevokeSessions(ctx, tenantId, user.UserID); err != nil {
+			return domain.User{}, fmt.Errorf("could not revoke the sessions of the user with id %s : %w", user.UserID, err)
+		}
+	}
+	return updated, nil
+}
+
+// changeStatus applies an action moving users in one of the from statuses to the to status.
+func (u *UserServiceImpl) changeStatus(ctx context.Context, userId string, from []domain.UserStatus, to domain.UserStatus, reason string, until time.Time) (domain.User, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
+	user, err := u.GetUserById(ctx, userId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if !slices.Contains(from, user.Status) {
+		return domain.User{}, fmt.Errorf("%w: the user is %s", domain.ErrInvalidTransition, user.Status.String())
+	}
+	return u.transition(ctx, tenantId, user, to, reason, until)
+}
+
+func (u *UserServiceImpl) ActivateUser(ctx context.Context, userId string, reason string) (domain.User, error) {
+	return u.changeStatus(ctx, userId, []domain.UserStatus{domain.PENDING, domain.LOCKED}, domain.ACTIVE, reason, time.Time{})
+}
+
+func (u *UserServiceImpl) SuspendUser(ctx context.Context, userId string, reason string, until time.Time) (domain.User, error) {
+	if reason == "" {
+		return domain.User{}, fmt.Errorf("%w to suspend a user", domain.ErrReasonRequired)
+	}
+	if !until.IsZero() && !until.After(u.now()) {
+		return domain.User{}, fmt.Errorf("%w: the suspension must end in the future", domain.ErrInvalidTransition)
+	}
+	return u.changeStatus(ctx, userId, []domain.UserStatus{domain.ACTIVE}, domain.SUSPENDED, reason, until)
+}
+
+func (u *UserServiceImpl) ReactivateUser(ctx context.Context, userId string, reason string) (domain.User, error) {
+	return u.changeStatus(ctx, userId, []domain.UserStatus{domain.SUSPENDED, domain.DEACTIVATED}, domain.ACTIVE, reason, time.Time{})
+}
+
+func (u *UserServiceImpl) GetStatusTransitions(ctx context.Context, userId string) ([]domain.StatusTransition, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.GetUserById(ctx, userId); err != nil {
+		return nil, err
+	}
+	transitions, err := u.UserRepository.RetrieveStatusTransitions(ctx, tenantId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve the status history of the user with id %s : %w", userId, err)
+	}
+	return transitions, nil
+}
+
 func (u *UserServiceImpl) DeleteUserByID(ctx context.Context, userId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
 	if userId == "" {
 		return errors.New("user id is empty")
 	}
@@ -86,15 +306,59 @@ func (u *UserServiceImpl) DeleteUserByID(ctx context.Context, userId string) err
 	if uuidErr != nil {
 		return errors.New("user id is not valid")
 	}
-	err := u.UserRepository.DeleteUser(ctx, userId)
+	if u.AvatarService != nil {
+		if err := u.AvatarService.DeleteAvatar(ctx, userId); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("could not delete the avatar of the user with id %s : %w", userId, err)
+		}
+	}
+	err = u.UserRepository.DeleteUser(domain.ContextWithEvents(ctx, userDeletedEvents), tenantId, userId)
 	if err != nil {
 		return fmt.Errorf("could not delete the user with id %s : %w", userId, err)
 	}
 	return nil
 }
 
+func (u *UserServiceImpl) GetUsersByAttributes(ctx context.Context, attributes map[string]any) ([]domain.User, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return make([]domain.User, 0), err
+	}
+	users, err := u.UserRepository.RetrieveUsersByAttributes(ctx, tenantId, attributes)
+	if err != nil {
+		return make([]domain.User, 0), fmt.Errorf("could not retrieve the users:  %w", err)
+	}
+	validationErr := u.Validator.Var(users, "omitempty,dive")
+	if validationErr != nil {
+		return make([]domain.User, 0), fmt.Errorf("could not utils the retrieved users. %w", validationErr)
+	}
+	return users, nil
+}
+
+func (u *UserServiceImpl) GetUsersPage(ctx context.Context, attributes map[string]any, after string, limit int) ([]domain.User, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return make([]domain.User, 0), err
+	}
+	if uuidErr := u.Validator.Var(after, "omitempty,uuid"); uuidErr != nil {
+		return make([]domain.User, 0), errors.New("cursor is not a valid user id")
+	}
+	users, err := u.UserRepository.RetrieveUsersPage(ctx, tenantId, attributes, after, limit)
+	if err != nil {
+		return make([]domain.User, 0), fmt.Errorf("could not retrieve the users:  %w", err)
+	}
+	validationErr := u.Validator.Var(users, "omitempty,dive")
+	if validationErr != nil {
+		return make([]domain.User, 0), fmt.Errorf("could not utils the retrieved users. %w", validationErr)
+	}
+	return users, nil
+}
+
 func (u *UserServiceImpl) GetAllUsers(ctx context.Context) ([]domain.User, error) {
-	users, err := u.UserRepository.RetrieveAllUsers(ctx)
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return make([]domain.User, 0), err
+	}
+	users, err := u.UserRepository.RetrieveAllUsers(ctx, tenantId)
 	if err != nil {
 		return make([]domain.User, 0), fmt.Errorf("could not retrieve the users:  %w", err)
 	}
@@ -12,7 +12,9 @@ import (
 	"errors"
 	"log"
 	"testing"
+	"time"
 
+	"userapi/app/internal/adapters/jsonschema"
 	"userapi/app/internal/core/domain"
 
 	"github.com/go-playground/validator/v10"
@@ -22,37 +24,91 @@ import (
 type MockUserRepository struct {
 	CreateUserFn       func(ctx context.Context, user domain.User) (domain.User, error)
 	RetrieveUserFn     func(ctx context.Context, id string) (domain.User, error)
+	RetrieveByEmailFn  func(ctx context.Context, email string) (domain.User, error)
 	RetrieveAllUsersFn func(ctx context.Context) ([]domain.User, error)
 	UpdateUserFn       func(ctx context.Context, user domain.User, id string) (domain.User, error)
 	DeleteUserFn       func(ctx context.Context, id string) error
+	SetVerifiedFn      func(ctx context.Context, id string, verified bool) (domain.User, error)
+	TransitionFn       func(ctx context.Context, id string, transition domain.StatusTransition) (domain.User, error)
 }
 
-func (m MockUserRepository) CreateUser(ctx context.Context, user domain.User) (domain.User, error) {
+func (m MockUserRepository) CreateUser(ctx context.Context, tenantId string, user domain.User) (domain.User, error) {
 	return m.CreateUserFn(ctx, user)
 }
 
-func (m MockUserRepository) RetrieveUser(ctx context.Context, s string) (domain.User, error) {
+func (m MockUserRepository) RetrieveUser(ctx context.Context, tenantId string, s string) (domain.User, error) {
 	return m.RetrieveUserFn(ctx, s)
 }
 
-func (m MockUserRepository) RetrieveAllUsers(ctx context.Context) ([]domain.User, error) {
+func (m MockUserRepository) RetrieveUserByEmail(ctx context.Context, tenantId string, email string) (domain.User, error) {
+	return m.RetrieveByEmailFn(ctx, email)
+}
+
+func (m MockUserRepository) RetrieveAllUsers(ctx context.Context, tenantId string) ([]domain.User, error) {
 	return m.RetrieveAllUsersFn(ctx)
 }
 
-func (m MockUserRepository) UpdateUser(ctx context.Context, s string, user domain.User) (domain.User, error) {
+func (m MockUserRepository) RetrieveUsersByAttributes(ctx context.Context, tenantId string, attributes map[string]any) ([]domain.User, error) {
+	users, err := m.RetrieveAllUsersFn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]domain.User, 0, len(users))
+	for _, user := range users {
+		if user.HasAttributes(attributes) {
+			matching = append(matching, user)
+		}
+	}
+	return matching, nil
+}
+
+func (m MockUserRepository) RetrieveUsersPage(ctx context.Context, tenantId string, attributes map[string]any, after string, limit int) ([]domain.User, error) {
+	users, err := m.RetrieveUsersByAttributes(ctx, tenantId, attributes)
+	if err != nil {
+		return nil, err
+	}
+	page := make([]domain.User, 0, limit)
+	for _, user := range users {
+		if user.UserID > after && len(page) < limit {
+			page = append(page, user)
+		}
+	}
+	return page, nil
+}
+
+func (m MockUserRepository) UpdateUser(ctx context.Context, tenantId string, s string, user domain.User) (domain.User, error) {
 	return m.UpdateUserFn(ctx, user, s)
 }
 
-func (m MockUserRepository) DeleteUser(ctx context.Context, s string) error {
+func (m MockUserRepository) DeleteUser(ctx context.Context, tenantId string, s string) error {
 	return m.DeleteUserFn(ctx, s)
 }
 
+func (m MockUserRepository) SetEmailVerified(ctx context.Context, tenantId string, s string, verified bool) (domain.User, error) {
+	return m.SetVerifiedFn(ctx, s, verified)
+}
+
+func (m MockUserRepository) TransitionUserStatus(ctx context.Context, tenantId string, s string, transition domain.StatusTransition) (domain.User, error) {
+	return m.TransitionFn(ctx, s, transition)
+}
+
+func (m MockUserRepository) UpdateUserWithTransition(ctx context.Context, tenantId string, s string, user domain.User, transition domain.StatusTransition) (domain.User, error) {
+	if _, err := m.TransitionFn(ctx, s, transition); err != nil {
+		return domain.User{}, err
+	}
+	return m.UpdateUserFn(ctx, user, s)
+}
+
+func (m MockUserRepository) RetrieveStatusTransitions(ctx context.Context, tenantId string, s string) ([]domain.StatusTransition, error) {
+	return []domain.StatusTransition{}, nil
+}
+
 func (m MockUserRepository) Close() error {
 	return nil
 }
 
 func TestUserServiceImpl_AddUser(t *testing.T) {
-	ctx := context.Background()
+	ctx := domain.ContextWithTenant(context.Background(), uuid.New().String())
 	entityValidator := validator.New()
 
 	// Add User
@@ -296,6 +352,34 @@ func TestUserServiceImpl_AddUser(t *testing.T) {
 		}
 	})
 
+	// Get User By Email
+	t.Run("Get user with invalid email", func(t *testing.T) {
+		userService := NewUserService(MockUserRepository{}, entityValidator)
+		_, err := userService.GetUserByEmail(ctx, "john.com")
+		if err == nil {
+			t.Fatal("Error expected. Should validate the email")
+		}
+	})
+	t.Run("Get user by email", func(t *testing.T) {
+		repo := MockUserRepository{}
+		repo.RetrieveByEmailFn = func(ctx context.Context, email string) (domain.User, error) {
+			return domain.User{
+				UserID:    uuid.New().String(),
+				FirstName: "John",
+				LastName:  "Smith",
+				Email:     email,
+			}, nil
+		}
+		userService := NewUserService(repo, entityValidator)
+		user, err := userService.GetUserByEmail(ctx, "john.smith@mail.com")
+		if err != nil {
+			t.Fatal("Unexpected error while get user by email", err)
+		}
+		if user.Email != "john.smith@mail.com" {
+			t.Fatalf("Email does not match")
+		}
+	})
+
 	// Get All Users
 	t.Run("Get users database error", func(t *testing.T) {
 		repo := MockUserRepository{}
@@ -329,7 +413,7 @@ func TestUserServiceImpl_AddUser(t *testing.T) {
 			return users, nil
 		}
 		userService := NewUserService(repo, entityValidator)
-		usrList, err := userService.GetAllUsers(context.Background())
+		usrList, err := userService.GetAllUsers(ctx)
 		if err == nil {
 			log.Fatal("User service should not return invalid data", usrList)
 		}
@@ -354,7 +438,7 @@ func TestUserServiceImpl_AddUser(t *testing.T) {
 			return users, nil
 		}
 		userService := NewUserService(repo, entityValidator)
-		usrList, err := userService.GetAllUsers(context.Background())
+		usrList, err := userService.GetAllUsers(ctx)
 		if err != nil {
 			log.Fatal("User service should not return an error for valid data", usrList)
 		}
@@ -441,6 +525,16 @@ func TestUserServiceImpl_AddUser(t *testing.T) {
 		}
 	})
 
+	t.Run("Delete missing user", func(t *testing.T) {
+		repo := MockUserRepository{}
+		repo.DeleteUserFn = func(ctx context.Context, userId string) error {
+			return domain.ErrNotFound
+		}
+		userService := NewUserService(repo, entityValidator)
+		if err := userService.DeleteUserByID(ctx, uuid.New().String()); !errors.Is(err, domain.ErrNotFound) {
+			t.Fatal("Expected a not found error, got", err)
+		}
+	})
 	t.Run("Delete user with valid uuid", func(t *testing.T) {
 		repo := MockUserRepository{}
 		repo.DeleteUserFn = func(ctx context.Context, userId string) error {
@@ -454,3 +548,150 @@ func TestUserServiceImpl_AddUser(t *testing.T) {
 		}
 	})
 }
+
+func TestUserServiceImpl_TenantScope(t *testing.T) {
+	entityValidator := validator.New()
+	tenantId := uuid.New().String()
+
+	t.Run("Missing tenant is rejected", func(t *testing.T) {
+		userService := NewUserService(MockUserRepository{}, entityValidator)
+		_, err := userService.GetUserById(context.Background(), uuid.New().String())
+		if !errors.Is(err, domain.ErrTenantRequired) {
+			t.Fatal("Expected a tenant required error, got", err)
+		}
+		_, err = userService.GetAllUsers(context.Background())
+		if !errors.Is(err, domain.ErrTenantRequired) {
+			t.Fatal("Expected a tenant required error, got", err)
+		}
+	})
+	t.Run("Tenant is passed to the repository", func(t *testing.T) {
+		repo := &tenantRecordingRepository{}
+		userService := NewUserService(repo, entityValidator)
+		ctx := domain.ContextWithTenant(context.Background(), tenantId)
+		_ = userService.DeleteUserByID(ctx, uuid.New().String())
+		if repo.tenantId != tenantId {
+			t.Fatalf("Expected tenant %s, repository received %s", tenantId, repo.tenantId)
+		}
+	})
+}
+
+type tenantRecordingRepository struct {
+	MockUserRepository
+	tenantId string
+}
+
+func (m *tenantRecordingRepository) DeleteUser(ctx context.Context, tenantId string, s string) error {
+	m.tenantId = tenantId
+	return nil
+}
+
+func TestUserServiceImpl_Lifecycle(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), uuid.New().String())
+	userId := uuid.New().String()
+	stored := domain.User{UserID: userId, FirstName: "John", LastName: "Doe", Email: "john.doe@mail.com", Status: domain.PENDING}
+	var transitions []domain.StatusTransition
+	repo := MockUserRepository{}
+	repo.RetrieveUserFn = func(ctx context.Context, id string) (domain.User, error) {
+		return stored, nil
+	}
+	repo.UpdateUserFn = func(ctx context.Context, user domain.User, id string) (domain.User, error) {
+		return stored, nil
+	}
+	repo.TransitionFn = func(ctx context.Context, id string, transition domain.StatusTransition) (domain.User, error) {
+		transitions = append(transitions, transition)
+		stored.Status, stored.StatusReason, stored.SuspendedUntil = transition.To, transition.Reason, transition.SuspendedUntil
+		return stored, nil
+	}
+	userService := NewUserService(repo, validator.New())
+
+	t.Run("Pending users cannot be suspended", func(t *testing.T) {
+		if _, err := userService.SuspendUser(ctx, userId, "chargeback", time.Time{}); !errors.Is(err, domain.ErrInvalidTransition) {
+			t.Fatal("Expected an invalid transition error, got", err)
+		}
+	})
+	t.Run("Suspensions need a reason", func(t *testing.T) {
+		if _, err := userService.ActivateUser(ctx, userId, "onboarded"); err != nil {
+			t.Fatal("Unexpected error while activating the user", err)
+		}
+		if _, err := userService.SuspendUser(ctx, userId, "", time.Time{}); !errors.Is(err, domain.ErrReasonRequired) {
+			t.Fatal("Expected a reason required error, got", err)
+		}
+		until := time.Now().Add(24 * time.Hour)
+		user, err := userService.SuspendUser(ctx, userId, "chargeback", until)
+		if err != nil || user.Status != domain.SUSPENDED || !user.SuspendedUntil.Equal(until) {
+			t.Fatal("Expected the user to be suspended until the given time", user, err)
+		}
+	})
+	t.Run("Status updates follow the transition table", func(t *testing.T) {
+		if _, err := userService.UpdateUserByID(ctx, userId, domain.User{Status: domain.LOCKED}); !errors.Is(err, domain.ErrInvalidTransition) {
+			t.Fatal("Expected an invalid transition error from suspended to locked, got", err)
+		}
+		if _, err := userService.ReactivateUser(ctx, userId, "chargeback withdrawn"); err != nil {
+			t.Fatal("Unexpected error while reactivating the user", err)
+		}
+		last := transitions[len(transitions)-1]
+		if len(transitions) != 3 || last.From != domain.SUSPENDED || last.To != domain.ACTIVE || last.Reason != "chargeback withdrawn" {
+			t.Fatal("Expected each transition to be recorded with its reason", transitions)
+		}
+	})
+}
+
+type MockAttributeSchemaRepository struct {
+	schema []byte
+}
+
+func (m *MockAttributeSchemaRepository) RetrieveAttributeSchema(ctx context.Context, tenantId string) (domain.AttributeSchema, error) {
+	if m.schema == nil {
+		return domain.AttributeSchema{}, domain.ErrNotFound
+	}
+	return domain.AttributeSchema{TenantID: tenantId, Schema: m.schema}, nil
+}
+
+func (m *MockAttributeSchemaRepository) SaveAttributeSchema(ctx context.Context, tenantId string, schema []byte) (domain.AttributeSchema, error) {
+	m.schema = schema
+	return domain.AttributeSchema{TenantID: tenantId, Schema: schema}, nil
+}
+
+func (m *MockAttributeSchemaRepository) DeleteAttributeSchema(ctx context.Context, tenantId string) error {
+	m.schema = nil
+	return nil
+}
+
+func TestUserServiceImpl_Attributes(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), uuid.New().String())
+	repo := MockUserRepository{}
+	repo.CreateUserFn = func(ctx context.Context, user domain.User) (domain.User, error) {
+		user.UserID = uuid.New().String()
+		return user, nil
+	}
+	repo.RetrieveUserFn = func(ctx context.Context, id string) (domain.User, error) {
+		return domain.User{UserID: id}, nil
+	}
+	repo.UpdateUserFn = func(ctx context.Context, user domain.User, id string) (domain.User, error) {
+		return user, nil
+	}
+	schemas := &MockAttributeSchemaRepository{}
+	userService := NewUserService(repo, validator.New())
+	userService.AttributeSchemas = schemas
+	userService.SchemaValidator = jsonschema.NewValidator()
+	user := domain.User{FirstName: "John", LastName: "Doe", Email: "john.doe@mail.com", Attributes: map[string]any{"costCenter": 4.2}}
+
+	if _, err := userService.AddUser(ctx, user); err != nil {
+		t.Fatal("Any attributes should be accepted without a schema", err)
+	}
+	_, _ = schemas.SaveAttributeSchema(ctx, "", []byte(`{"type": "object", "properties": {"department": {"enum": ["sales", "support"]}}, "required": ["department"], "additionalProperties": false}`))
+	if _, err := userService.AddUser(ctx, user); !errors.Is(err, domain.ErrInvalidAttributes) {
+		t.Fatal("Expected an invalid attributes error, got", err)
+	}
+	user.Attributes = map[string]any{"department": "sales"}
+	created, err := userService.AddUser(ctx, user)
+	if err != nil {
+		t.Fatal("Unexpected error while adding the user", err)
+	}
+	if _, err := userService.UpdateUserByID(ctx, created.UserID, domain.User{Attributes: map[string]any{"department": "legal"}}); !errors.Is(err, domain.ErrInvalidAttributes) {
+		t.Fatal("Expected an invalid attributes error on update, got", err)
+	}
+	if _, err := userService.UpdateUserByID(ctx, created.UserID, domain.User{FirstName: "Jane"}); err != nil {
+		t.Fatal("Updates leaving the attributes alone should not be validated against the schema", err)
+	}
+}
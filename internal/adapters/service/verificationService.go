@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+const (
+	emailVerificationPurpose = "email_verification"
+	defaultVerificationTTL   = 24 * time.Hour
+	phoneCodeTTL             = 10 * time.Minute
+	// maxPhoneAttempts wrong codes lock phone verification for phoneLockout.
+	maxPhoneAttempts = 5
+	phoneLockout     = 15 * time.Minute
+)
+
+// VerificationServiceImpl verifies emails with signed tokens and phones with
+// one-time codes. Each flow returns domain.ErrNotConfigured until its
+// notifier, and for emails the token signer, is set.
+type VerificationServiceImpl struct {
+	UserService            ports.UserService
+	UserRepository         ports.UserRepository
+	VerificationRepository ports.VerificationRepository
+	TokenSigner            ports.TokenSigner
+	EmailNotifier          ports.Notifier
+	SMSNotifier            ports.Notifier
+	// EmailVerificationURL is prepended to the token in the email. The bare token
+	// is sent when it is empty.
+	EmailVerificationURL string
+	EmailVerificationTTL time.Duration
+	now                  func() time.Time
+}
+
+func NewVerificationService(userService ports.UserService, userRepository ports.UserRepository, verificationRepository ports.VerificationRepository) *VerificationServiceImpl {
+	return &VerificationServiceImpl{
+		UserService:            userService,
+		UserRepository:         userRepository,
+		VerificationRepository: verificationRepository,
+		EmailVerificationTTL:   defaultVerificationTTL,
+		now:                    time.Now,
+	}
+}
+
+// emailDigest binds a token to the address it was sent to without putting the
+// address in the token.
+func emailDigest(email string) string {
+	return digest(strings.ToLower(email))
+}
+
+func digest(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (v *VerificationServiceImpl) RequestEmailVerification(ctx context.Context, userId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if v.TokenSigner == nil || v.EmailNotifier == nil {
+		return fmt.Errorf("email verification is %w", domain.ErrNotConfigured)
+	}
+	user, err := v.UserService.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return fmt.Errorf("the email of user %s is %w", userId, domain.ErrAlreadyVerified)
+	}
+	token, err := v.TokenSigner.Sign(map[string]any{
+		"purpose":   emailVerificationPurpose,
+		"sub":       user.UserID,
+		"tenant_id": tenantId,
+		"email":     emailDigest(user.Email),
+		"exp":       v.now().Add(v.EmailVerificationTTL).Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("could not sign the verification token: %w", err)
+	}
+	err = v.EmailNotifier.Send(ctx, domain.Notification{
+		Channel: domain.NotificationEmail,
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address with the link below. It expires in %s.\n\n%s%s\n",
+			user.FirstName, v.EmailVerificationTTL, v.EmailVerificationURL, token),
+	})
+	if err != nil {
+		return fmt.Errorf("could not send the verification email: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailVerification marks the email as verified. The token must have
+// been issued in the current tenant for the address the user still has.
+func (v *VerificationServiceImpl) ConfirmEmailVerification(ctx context.Context, token string) (domain.User, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if v.TokenSigner == nil {
+		return domain.User{}, fmt.Errorf("email verification is %w", domain.ErrNotConfigured)
+	}
+	claims, err := v.TokenSigner.Verify(token)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("%w: %w", domain.ErrInvalidToken, err)
+	}
+	userId, _ := claims["sub"].(string)
+	if claims["purpose"] != emailVerificationPurpose || claims["tenant_id"] != tenantId || userId == "" {
+		return domain.User{}, domain.ErrInvalidToken
+	}
+	user, err := v.UserRepository.RetrieveUser(ctx, tenantId, userId)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("user with id %s not found : %w", userId, err)
+	}
+	if claims["email"] != emailDigest(user.Email) {
+		return domain.User{}, fmt.Errorf("the email has changed since the token was issued: %w", domain.ErrInvalidToken)
+	}
+	user, err = v.UserRepository.SetEmailVerified(ctx, tenantId, userId, true)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("could not verify the email of user %s : %w", userId, err)
+	}
+	return user, nil
+}
+
+// phoneCodeHash binds a code to the user and the phone it was sent to.
+func phoneCodeHash(userId string, phone string, code string) []byte {
+	sum := sha256.Sum256([]byte(userId + "\x00" + phone + "\x00" + code))
+	return sum[:]
+}
+
+func newPhoneCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func (v *VerificationServiceImpl) RequestPhoneVerification(ctx context.Context, userId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if v.SMSNotifier == nil {
+		return fmt.Errorf("phone verification is %w", domain.ErrNotConfigured)
+	}
+	user, err := v.UserService.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.Phone == "" {
+		return domain.ErrNoPhone
+	}
+	if !user.PhoneVerifiedAt.IsZero() {
+		return fmt.Errorf("the phone of user %s is %w", userId, domain.ErrAlreadyVerified)
+	}
+	pending, err := v.VerificationRepository.RetrievePhoneVerification(ctx, tenantId, userId)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("could not retrieve the pending verification: %w", err)
+	}
+	if pending.LockedUntil.After(v.now()) {
+		return domain.ErrLockedOut
+	}
+	code, err := newPhoneCode()
+	if err != nil {
+		return fmt.Errorf("could not generate the code: %w", err)
+	}
+	_, err = v.VerificationRepository.SavePhoneVerification(ctx, tenantId, domain.PhoneVerification{
+		UserID:    userId,
+		CodeHash:  phoneCodeHash(userId, user.Phone, code),
+		ExpiresAt: v.now().Add(phoneCodeTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("could not save the verification code: %w", err)
+	}
+	err = v.SMSNotifier.Send(ctx, domain.Notification{
+		Channel: domain.NotificationSMS,
+		To:      user.Phone,
+		Body:    fmt.Sprintf("Your verification code is %s. It expires in %s.", code, phoneCodeTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("could not send the verification code: %w", err)
+	}
+	return nil
+}
+
+// ConfirmPhoneVerification marks the phone as verified when the code matches.
+// Every attempt is counted, and the last allowed failure locks verification
+// for the user.
+func (v *VerificationServiceImpl) ConfirmPhoneVerification(ctx context.Context, userId string, code string) (domain.User, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
+	user, err := v.UserService.GetUserById(ctx, userId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	pending, err := v.VerificationRepository.CountPhoneVerificationAttempt(ctx, tenantId, userId)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.User{}, domain.ErrInvalidCode
+	}
+	if err != nil {
+		return domain.User{}, fmt.Errorf("could not retrieve the pending verification: %w", err)
+	}
+	now := v.now()
+	if pending.LockedUntil.After(now) {
+		return domain.User{}, domain.ErrLockedOut
+	}
+	if pending.Attempts > maxPhoneAttempts || now.After(pending.ExpiresAt) {
+		return domain.User{}, domain.ErrInvalidCode
+	}
+	if subtle.ConstantTimeCompare(phoneCodeHash(userId, user.Phone, code), pending.CodeHash) != 1 {
+		if pending.Attempts < maxPhoneAttempts {
+			return domain.User{}, domain.ErrInvalidCode
+		}
+		if err := v.VerificationRepository.LockPhoneVerification(ctx, tenantId, userId, now.Add(phoneLockout)); err != nil {
+			return domain.User{}, fmt.Errorf("could not lock the verification: %w", err)
+		}
+		return domain.User{}, domain.ErrLockedOut
+	}
+	user, err = v.VerificationRepository.ConfirmPhoneVerification(ctx, tenantId, userId)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("could not verify the phone of user %s : %w", userId, err)
+	}
+	return user, nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"userapi/app/internal/adapters/token"
+	"userapi/app/internal/core/domain"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type recordingNotifier struct {
+	sent []domain.Notification
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, notification domain.Notification) error {
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+type MockVerificationRepository struct {
+	pending   map[string]domain.PhoneVerification
+	confirmFn func(ctx context.Context, userId string) (domain.User, error)
+}
+
+func NewMockVerificationRepository() *MockVerificationRepository {
+	return &MockVerificationRepository{pending: make(map[string]domain.PhoneVerification)}
+}
+
+func (m *MockVerificationRepository) SavePhoneVerification(ctx context.Context, tenantId string, verification domain.PhoneVerification) (domain.PhoneVerification, error) {
+	m.pending[verification.UserID] = verification
+	return verification, nil
+}
+
+func (m *MockVerificationRepository) RetrievePhoneVerification(ctx context.Context, tenantId string, userId string) (domain.PhoneVerification, error) {
+	verification, ok := m.pending[userId]
+	if !ok {
+		return domain.PhoneVerification{}, domain.ErrNotFound
+	}
+	return verification, nil
+}
+
+func (m *MockVerificationRepository) CountPhoneVerificationAttempt(ctx context.Context, tenantId string, userId string) (domain.PhoneVerification, error) {
+	verification, ok := m.pending[userId]
+	if !ok {
+		return domain.PhoneVerification{}, domain.ErrNotFound
+	}
+	verification.Attempts++
+	m.pending[userId] = verification
+	return verification, nil
+}
+
+func (m *MockVerificationRepository) LockPhoneVerification(ctx context.Context, tenantId string, userId string, until time.Time) error {
+	verification := m.pending[userId]
+	verification.LockedUntil = until
+	m.pending[userId] = verification
+	return nil
+}
+
+func (m *MockVerificationRepository) ConfirmPhoneVerification(ctx context.Context, tenantId string, userId string) (domain.User, error) {
+	delete(m.pending, userId)
+	return m.confirmFn(ctx, userId)
+}
+
+func TestVerificationServiceImpl_Email(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), uuid.New().String())
+	userId := uuid.New().String()
+	stored := domain.User{UserID: userId, FirstName: "John", LastName: "Doe", Email: "john.doe@mail.com"}
+	repo := MockUserRepository{}
+	repo.RetrieveUserFn = func(ctx context.Context, id string) (domain.User, error) {
+		return stored, nil
+	}
+	repo.SetVerifiedFn = func(ctx context.Context, id string, verified bool) (domain.User, error) {
+		stored.EmailVerified = verified
+		return stored, nil
+	}
+	notifier := &recordingNotifier{}
+	verificationService := NewVerificationService(NewUserService(repo, validator.New()), repo, NewMockVerificationRepository())
+	verificationService.TokenSigner = token.NewHMACSigner([]byte("secret"))
+	verificationService.EmailNotifier = notifier
+	verificationService.EmailVerificationURL = "https://example.test/verify?token="
+	issueToken := func(t *testing.T) string {
+		if err := verificationService.RequestEmailVerification(ctx, userId); err != nil {
+			t.Fatal("Unexpected error while requesting the verification", err)
+		}
+		last := notifier.sent[len(notifier.sent)-1]
+		if last.To != stored.Email {
+			t.Fatal("The verification should be sent to the user's email", last.To)
+		}
+		_, issued, _ := strings.Cut(last.Body, verificationService.EmailVerificationURL)
+		return strings.TrimSpace(issued)
+	}
+
+	t.Run("Token for another tenant is rejected", func(t *testing.T) {
+		issued := issueToken(t)
+		otherTenant := domain.ContextWithTenant(context.Background(), uuid.New().String())
+		if _, err := verificationService.ConfirmEmailVerification(otherTenant, issued); !errors.Is(err, domain.ErrInvalidToken) {
+			t.Fatal("Expected an invalid token error, got", err)
+		}
+	})
+	t.Run("Token for a previous email is rejected", func(t *testing.T) {
+		issued := issueToken(t)
+		stored.Email = "john.new@mail.com"
+		defer func() { stored.Email = "john.doe@mail.com" }()
+		if _, err := verificationService.ConfirmEmailVerification(ctx, issued); !errors.Is(err, domain.ErrInvalidToken) {
+			t.Fatal("Expected an invalid token error, got", err)
+		}
+	})
+	t.Run("Confirm verifies the email", func(t *testing.T) {
+		user, err := verificationService.ConfirmEmailVerification(ctx, issueToken(t))
+		if err != nil {
+			t.Fatal("Unexpected error while confirming the verification", err)
+		}
+		if !user.EmailVerified {
+			t.Fatal("The email should be verified")
+		}
+		if err := verificationService.RequestEmailVerification(ctx, userId); !errors.Is(err, domain.ErrAlreadyVerified) {
+			t.Fatal("Expected an already verified error, got", err)
+		}
+	})
+}
+
+func TestVerificationServiceImpl_Phone(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), uuid.New().String())
+	userId := uuid.New().String()
+	stored := domain.User{UserID: userId, FirstName: "John", LastName: "Doe", Email: "john.doe@mail.com", Phone: "+94771234567"}
+	repo := MockUserRepository{}
+	repo.RetrieveUserFn = func(ctx context.Context, id string) (domain.User, error) {
+		return stored, nil
+	}
+	verificationRepository := NewMockVerificationRepository()
+	verificationRepository.confirmFn = func(ctx context.Context, id string) (domain.User, error) {
+		stored.PhoneVerifiedAt = time.Now()
+		return stored, nil
+	}
+	notifier := &recordingNotifier{}
+	verificationService := NewVerificationService(NewUserService(repo, validator.New()), repo, verificationRepository)
+	verificationService.SMSNotifier = notifier
+	sendCode := func(t *testing.T) string {
+		if err := verificationService.RequestPhoneVerification(ctx, userId); err != nil {
+			t.Fatal("Unexpected error while requesting the verification", err)
+		}
+		last := notifier.sent[len(notifier.sent)-1]
+		if last.Channel != domain.NotificationSMS || last.To != stored.Phone {
+			t.Fatal("The code should be sent to the user's phone", last)
+		}
+		return strings.Fields(last.Body)[4][:6]
+	}
+
+	t.Run("Wrong codes lock verification", func(t *testing.T) {
+		code := sendCode(t)
+		var err error
+		for range maxPhoneAttempts {
+			_, err = verificationService.ConfirmPhoneVerification(ctx, userId, "not-it")
+		}
+		if !errors.Is(err, domain.ErrLockedOut) {
+			t.Fatal("Expected a locked out error, got", err)
+		}
+		if _, err := verificationService.ConfirmPhoneVerification(ctx, userId, code); !errors.Is(err, domain.ErrLockedOut) {
+			t.Fatal("The right code should be rejected while locked, got", err)
+		}
+		if err := verificationService.RequestPhoneVerification(ctx, userId); !errors.Is(err, domain.ErrLockedOut) {
+			t.Fatal("A new code should not be sent while locked, got", err)
+		}
+		verificationService.now = func() time.Time { return time.Now().Add(phoneLockout) }
+	})
+	t.Run("Expired code is rejected", func(t *testing.T) {
+		code := sendCode(t)
+		now := verificationService.now
+		verificationService.now = func() time.Time { return now().Add(phoneCodeTTL + time.Second) }
+		defer func() { verificationService.now = now }()
+		if _, err := verificationService.ConfirmPhoneVerification(ctx, userId, code); !errors.Is(err, domain.ErrInvalidCode) {
+			t.Fatal("Expected an invalid code error, got", err)
+		}
+	})
+	t.Run("Confirm verifies the phone", func(t *testing.T) {
+		user, err := verificationService.ConfirmPhoneVerification(ctx, userId, sendCode(t))
+		if err != nil {
+			t.Fatal("Unexpected error while confirming the verification", err)
+		}
+		if user.PhoneVerifiedAt.IsZero() {
+			t.Fatal("The phone should be verified")
+		}
+	})
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"time"
+
+	"userapi/app/internal/adapters/logging"
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+)
+
+const (
+	defaultWebhookInterval    = 5 * time.Second
+	defaultWebhookMaxFailures = 10
+	defaultWebhookRetention   = 30 * 24 * time.Hour
+	// webhookBatchSize bounds the due deliveries sent per tenant on each tick.
+	webhookBatchSize = 100
+	// webhookDeliveriesListed is how many of the latest deliveries are listed.
+	webhookDeliveriesListed = 100
+	// minWebhookSecret is the shortest secret accepted.
+	minWebhookSecret = 16
+	// The wait after a failed attempt doubles from minWebhookBackoff up to
+	// maxWebhookBackoff.
+	minWebhookBackoff = 30 * time.Second
+	maxWebhookBackoff = 6 * time.Hour
+)
+
+// WebhookServiceImpl manages the webhook subscriptions and delivers the user
+// events to them. As an event sink of the EventDispatcher it queues a delivery
+// of each event for every webhook of the tenant subscribed to its type; Run
+// then posts the due deliveries, retrying the failed ones with an exponential
+// backoff until MaxFailures attempts in a row have failed, when the delivery
+// is dead. The finished deliveries are deleted after Retention.
+type WebhookServiceImpl struct {
+	WebhookRepository ports.WebhookRepository
+	TenantRepository  ports.TenantRepository
+	Sender            ports.WebhookSender
+	// LeaderLock makes sure a single replica posts the deliveries. Without it
+	// every replica posts them.
+	LeaderLock  ports.LeaderLock
+	Interval    time.Duration
+	MaxFailures int
+	// Retention is how long succeeded and dead deliveries are kept.
+	Retention time.Duration
+	now       func() time.Time
+	// lookupHost resolves the host of the webhooks created.
+	lookupHost func(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+func NewWebhookService(webhookRepository ports.WebhookRepository, tenantRepository ports.TenantRepository, sender ports.WebhookSender) *WebhookServiceImpl {
+	return &WebhookServiceImpl{
+		WebhookRepository: webhookRepository,
+		TenantRepository:  tenantRepository,
+		Sender:            sender,
+		Interval:          defaultWebhookInterval,
+		MaxFailures:       defaultWebhookMaxFailures,
+		Retention:         defaultWebhookRetention,
+		now:               time.Now,
+		lookupHost: func(ctx context.Context, host string) ([]netip.Addr, error) {
+			return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		},
+	}
+}
+
+func (s *WebhookServiceImpl) CreateWebhook(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+		return domain.Webhook{}, fmt.Errorf("%w: the url must be an absolute http or https url", domain.ErrInvalidWebhook)
+	}
+	if err := s.checkPublicHost(ctx, target.Hostname()); err != nil {
+		return domain.Webhook{}, err
+	}
+	if len(webhook.EventTypes) == 0 {
+		return domain.Webhook{}, fmt.Errorf("%w: at least one event type is required", domain.ErrInvalidWebhook)
+	}
+	for _, eventType := range webhook.EventTypes {
+		if !slices.Contains(domain.EventTypes, eventType) {
+			return domain.Webhook{}, fmt.Errorf("%w: unknown event type %q", domain.ErrInvalidWebhook, eventType)
+		}
+	}
+	webhook.EventTypes = slices.Compact(slices.Sorted(slices.Values(webhook.EventTypes)))
+	switch {
+	case webhook.Secret == "":
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return domain.Webhook{}, err
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	case len(webhook.Secret) < minWebhookSecret:
+		return domain.Webhook{}, fmt.Errorf("%w: the secret must be at least %d characters long", domain.ErrInvalidWebhook, minWebhookSecret)
+	}
+	created, err := s.WebhookRepository.CreateWebhook(ctx, tenantId, webhook)
+	if err != nil {
+		return domain.Webhook{}, fmt.Errorf("could not create the webhook: %w", err)
+	}
+	return created, nil
+}
+
+// checkPublicHost makes sure the host only resolves to public addresses. The
+// sender checks the addresses again when it connects, as they may change.
+func (s *WebhookServiceImpl) checkPublicHost(ctx context.Context, host string) error {
+	addrs := []netip.Addr{}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else if addrs, err = s.lookupHost(ctx, host); err != nil {
+		return fmt.Errorf("%w: could not resolve the host %s: %w", domain.ErrInvalidWebhook, host, err)
+	}
+	for _, addr := range addrs {
+		if !domain.IsPublicAddress(addr) {
+			return fmt.Errorf("%w: the host %s resolves to %s, which is not a public address", domain.ErrInvalidWebhook, host, addr)
+		}
+	}
+	return nil
+}
+
+func (s *WebhookServiceImpl) GetWebhook(ctx context.Context, webhookId string) (domain.Webhook, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	webhook, err := s.WebhookRepository.RetrieveWebhook(ctx, tenantId, webhookId)
+	if err != nil {
+		return domain.Webhook{}, fmt.Errorf("could not retrieve the webhook %s: %w", webhookId, err)
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+func (s *WebhookServiceImpl) GetWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := s.WebhookRepository.RetrieveWebhooks(ctx, tenantId)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve the webhooks: %w", err)
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+func (s *WebhookServiceImpl) DeleteWebhook(ctx context.Context, webhookId string) error {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.WebhookRepository.DeleteWebhook(ctx, tenantId, webhookId); err != nil {
+		return fmt.Errorf("could not delete the webhook %s: %w", webhookId, err)
+	}
+	return nil
+}
+
+func (s *WebhookServiceImpl) GetWebhookDeliveries(ctx context.Context, webhookId string) ([]domain.WebhookDelivery, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.GetWebhook(ctx, webhookId); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.WebhookRepository.RetrieveWebhookDeliveries(ctx, tenantId, webhookId, webhookDeliveriesListed)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve the deliveries of the webhook %s: %w", webhookId, err)
+	}
+	return deliveries, nil
+}
+
+func (s *WebhookServiceImpl) RedeliverWebhookDelivery(ctx context.Context, webhookId string, deliveryId string) (domain.WebhookDelivery, error) {
+	tenantId, err := tenantFromContext(ctx)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	delivery, err := s.WebhookRepository.RedeliverWebhookDelivery(ctx, tenantId, webhookId, deliveryId)
+	if err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("could not redeliver the delivery %s: %w", deliveryId, err)
+	}
+	return delivery, nil
+}
+
+func (s *WebhookServiceImpl) EventSinkName() string {
+	return "webhooks"
+}
+
+// Publish queues the event for the webhooks of its tenant subscribed to it.
+func (s *WebhookServiceImpl) Publish(ctx context.Context, event domain.Event) error {
+	return s.WebhookRepository.CreateWebhookDeliveries(ctx, event.TenantID, event)
+}
+
+// Run posts the due deliveries every Interval while this replica is the
+// leader, until ctx is done.
+func (s *WebhookServiceImpl) Run(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	leader := false
+	for {
+		locked := true
+		if s.LeaderLock != nil {
+			var err error
+			if locked, err = s.LeaderLock.TryLock(ctx); err != nil {
+				logger.Error(fmt.Errorf("could not take the webhook lock: %w", err).Error())
+			}
+		}
+		if locked != leader {
+			logger.Info("changed the webhook leadership", "leader", locked)
+			leader = locked
+		}
+		if locked {
+			if err := s.DeliverDue(ctx); err != nil {
+				logger.Error(fmt.Errorf("could not deliver the webhooks: %w", err).Error())
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if leader && s.LeaderLock != nil {
+				if err := s.LeaderLock.Unlock(context.WithoutCancel(ctx)); err != nil {
+					logger.Error(fmt.Errorf("could not release the webhook lock: %w", err).Error())
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue posts the due deliveries of every enabled tenant and records the
+// attempts, then deletes the finished deliveries older than Retention.
+func (s *WebhookServiceImpl) DeliverDue(ctx context.Context) error {
+	tenants, err := s.TenantRepository.RetrieveAllTenants(ctx)
+	if err != nil {
+		return fmt.Errorf("could not retrieve the tenants: %w", err)
+	}
+	var errs []error
+	for _, tenant := range tenants {
+		if tenant.Disabled {
+			continue
+		}
+		if err := s.deliverTenant(domain.ContextWithTenant(ctx, tenant.TenantID), tenant.TenantID); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.TenantID, err))
+		}
+	}
+	if s.Retention > 0 {
+		for _, tenant := range tenants {
+			if _, err := s.WebhookRepository.DeleteFinishedWebhookDeliveries(ctx, tenant.TenantID, s.now().Add(-s.Retention)); err != nil {
+				errs = append(errs, fmt.Errorf("tenant %s: could not delete the finished deliveries: %w", tenant.TenantID, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *WebhookServiceImpl) deliverTenant(ctx context.Context, tenantId string) error {
+	deliveries, err := s.WebhookRepository.RetrieveDueWebhookDeliveries(ctx, tenantId, s.now(), webhookBatchSize)
+	if err != nil || len(deliveries) == 0 {
+		return err
+	}
+	webhooks, err := s.WebhookRepository.RetrieveWebhooks(ctx, tenantId)
+	if err != nil {
+		return fmt.Errorf("could not retrieve the webhooks: %w", err)
+	}
+	var errs []error
+	for _, delivery := range deliveries {
+		index := slices.IndexFunc(webhooks, func(webhook domain.Webhook) bool { return webhook.WebhookID == delivery.WebhookID })
+		if index < 0 {
+			// deleted meanwhile, with its deliveries.
+			continue
+		}
+		if err := s.deliver(ctx, tenantId, webhooks[index], delivery); err != nil {
+			errs = append(errs, fmt.Errorf("delivery %s: %w", delivery.DeliveryID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *WebhookServiceImpl) deliver(ctx context.Context, tenantId string, webhook domain.Webhook, delivery domain.WebhookDelivery) error {
+	attempt := s.Sender.SendWebhook(ctx, webhook, delivery)
+	status, next := domain.DeliverySucceeded, s.now()
+	switch {
+	case attempt.Succeeded():
+	case delivery.Failures+1 >= s.MaxFailures:
+		status = domain.DeliveryDead
+	default:
+		status, next = domain.DeliveryPending, next.Add(webhookBackoff(delivery.Failures))
+	}
+	err := s.WebhookRepository.RecordWebhookAttempt(ctx, tenantId, delivery.DeliveryID, attempt, status, next)
+	if errors.Is(err, domain.ErrNotFound) {
+		// redelivered or deleted meanwhile.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not record the attempt: %w", err)
+	}
+	logger := logging.FromContext(ctx)
+	switch status {
+	case domain.DeliverySucceeded:
+		logger.Info("delivered a webhook", "webhookId", webhook.WebhookID, "deliveryId", delivery.DeliveryID, "eventType", string(delivery.EventType))
+	case domain.DeliveryDead:
+		logger.Warn("gave up a webhook delivery", "webhookId", webhook.WebhookID, "deliveryId", delivery.DeliveryID, "statusCode", attempt.StatusCode, "error", attempt.Error)
+	default:
+		logger.Info("could not deliver a webhook", "webhookId", webhook.WebhookID, "deliveryId", delivery.DeliveryID, "statusCode", attempt.StatusCode, "error", attempt.Error, "nextAttemptAt", next)
+	}
+	return nil
+}
+
+// webhookBackoff returns the wait before retrying a delivery that failed
+// failures times in a row before.
+func webhookBackoff(failures int) time.Duration {
+	if failures >= 20 {
+		return maxWebhookBackoff
+	}
+	return min(minWebhookBackoff<<failures, maxWebhookBackoff)
+}
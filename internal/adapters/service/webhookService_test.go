@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/netip"
+	"testing"
+	"time"
+
+	"userapi/app/internal/core/domain"
+
+	"github.com/google/uuid"
+)
+
+type MockWebhookRepository struct {
+	webhooks   map[string]domain.Webhook
+	deliveries map[string]domain.WebhookDelivery
+}
+
+func NewMockWebhookRepository() *MockWebhookRepository {
+	return &MockWebhookRepository{webhooks: make(map[string]domain.Webhook), deliveries: make(map[string]domain.WebhookDelivery)}
+}
+
+func (m *MockWebhookRepository) CreateWebhook(ctx context.Context, tenantId string, webhook domain.Webhook) (domain.Webhook, error) {
+	webhook.WebhookID = uuid.New().String()
+	m.webhooks[webhook.WebhookID] = webhook
+	return webhook, nil
+}
+
+func (m *MockWebhookRepository) RetrieveWebhook(ctx context.Context, tenantId string, webhookId string) (domain.Webhook, error) {
+	webhook, ok := m.webhooks[webhookId]
+	if !ok {
+		return domain.Webhook{}, domain.ErrNotFound
+	}
+	return webhook, nil
+}
+
+func (m *MockWebhookRepository) RetrieveWebhooks(ctx context.Context, tenantId string) ([]domain.Webhook, error) {
+	webhooks := make([]domain.Webhook, 0, len(m.webhooks))
+	for _, webhook := range m.webhooks {
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+func (m *MockWebhookRepository) DeleteWebhook(ctx context.Context, tenantId string, webhookId string) error {
+	if _, ok := m.webhooks[webhookId]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(m.webhooks, webhookId)
+	return nil
+}
+
+func (m *MockWebhookRepository) CreateWebhookDeliveries(ctx context.Context, tenantId string, event domain.Event) error {
+	for _, webhook := range m.webhooks {
+		if webhook.Subscribes(event.Type) {
+			deliveryId := uuid.New().String()
+			m.deliveries[deliveryId] = domain.WebhookDelivery{DeliveryID: deliveryId, WebhookID: webhook.WebhookID, EventID: event.EventID, EventType: event.Type, Status: domain.DeliveryPending, CreatedAt: time.Now()}
+		}
+	}
+	return nil
+}
+
+func (m *MockWebhookRepository) RetrieveWebhookDeliveries(ctx context.Context, tenantId string, webhookId string, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.WebhookID == webhookId && len(deliveries) < limit {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (m *MockWebhookRepository) RetrieveWebhookDeliveriesByUser(ctx context.Context, tenantId string, userId string) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.UserID == userId {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (m *MockWebhookRepository) RetrieveDueWebhookDeliveries(ctx context.Context, tenantId string, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.Status == domain.DeliveryPending && !delivery.NextAttemptAt.After(now) && len(deliveries) < limit {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (m *MockWebhookRepository) RecordWebhookAttempt(ctx context.Context, tenantId string, deliveryId string, attempt domain.WebhookAttempt, status domain.WebhookDeliveryStatus, next time.Time) error {
+	delivery, ok := m.deliveries[deliveryId]
+	if !ok || delivery.Status != domain.DeliveryPending {
+		return domain.ErrNotFound
+	}
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	delivery.Status, delivery.NextAttemptAt = status, next
+	if !attempt.Succeeded() {
+		delivery.Failures++
+	}
+	m.deliveries[deliveryId] = delivery
+	return nil
+}
+
+func (m *MockWebhookRepository) RedeliverWebhookDelivery(ctx context.Context, tenantId string, webhookId string, deliveryId string) (domain.WebhookDelivery, error) {
+	delivery, ok := m.deliveries[deliveryId]
+	if !ok || delivery.WebhookID != webhookId {
+		return domain.WebhookDelivery{}, domain.ErrNotFound
+	}
+	delivery.Status, delivery.Failures, delivery.NextAttemptAt = domain.DeliveryPending, 0, time.Time{}
+	m.deliveries[deliveryId] = delivery
+	return delivery, nil
+}
+
+func (m *MockWebhookRepository) DeleteFinishedWebhookDeliveries(ctx context.Context, tenantId string, before time.Time) (int64, error) {
+	var deleted int64
+	for deliveryId, delivery := range m.deliveries {
+		if delivery.Status != domain.DeliveryPending && delivery.CreatedAt.Before(before) {
+			delete(m.deliveries, deliveryId)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// statusSender answers every delivery with its status code.
+type statusSender struct {
+	status int
+	sent   int
+}
+
+func (s *statusSender) SendWebhook(ctx context.Context, webhook domain.Webhook, delivery domain.WebhookDelivery) domain.WebhookAttempt {
+	s.sent++
+	return domain.WebhookAttempt{StatusCode: s.status}
+}
+
+func TestWebhookServiceImpl(t *testing.T) {
+	tenantId := uuid.New().String()
+	ctx := domain.ContextWithTenant(context.Background(), tenantId)
+	repository := NewMockWebhookRepository()
+	sender := &statusSender{status: http.StatusServiceUnavailable}
+	service := NewWebhookService(repository, MockTenantRepository{tenants: []domain.Tenant{{TenantID: tenantId}}}, sender)
+	service.MaxFailures = 3
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	service.lookupHost = func(ctx context.Context, host string) ([]netip.Addr, error) {
+		if host == "internal.example.com" {
+			return []netip.Addr{netip.MustParseAddr("203.0.114.7"), netip.MustParseAddr("10.0.0.7")}, nil
+		}
+		return []netip.Addr{netip.MustParseAddr("203.0.114.7")}, nil
+	}
+
+	for _, invalid := range []domain.Webhook{
+		{URL: "ftp://partner.example.com", EventTypes: []domain.EventType{domain.UserCreated}},
+		{URL: "http://169.254.169.254/latest/meta-data", EventTypes: []domain.EventType{domain.UserCreated}},
+		{URL: "http://[::ffff:127.0.0.1]:8080", EventTypes: []domain.EventType{domain.UserCreated}},
+		{URL: "https://internal.example.com", EventTypes: []domain.EventType{domain.UserCreated}},
+		{URL: "https://partner.example.com", EventTypes: []domain.EventType{"user.renamed"}},
+		{URL: "https://partner.example.com", EventTypes: []domain.EventType{domain.UserCreated}, Secret: "short"},
+	} {
+		if _, err := service.CreateWebhook(ctx, invalid); !errors.Is(err, domain.ErrInvalidWebhook) {
+			t.Fatalf("expected ErrInvalidWebhook for %+v, got %v", invalid, err)
+		}
+	}
+	webhook, err := service.CreateWebhook(ctx, domain.Webhook{URL: "https://partner.example.com/hooks", EventTypes: []domain.EventType{domain.UserDeleted, domain.UserCreated, domain.UserDeleted}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(webhook.Secret) != 64 || len(webhook.EventTypes) != 2 {
+		t.Fatalf("expected a generated secret and the event types deduplicated, got %+v", webhook)
+	}
+	if got, _ := service.GetWebhook(ctx, webhook.WebhookID); got.Secret != "" {
+		t.Fatalf("expected the secret to be hidden, got %q", got.Secret)
+	}
+
+	for _, eventType := range []domain.EventType{domain.UserCreated, domain.UserUpdated} {
+		if err := service.Publish(ctx, domain.Event{EventID: uuid.New().String(), TenantID: tenantId, Type: eventType}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	deliveries, err := service.GetWebhookDeliveries(ctx, webhook.WebhookID)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("expected a delivery of the subscribed event only, got %d and %v", len(deliveries), err)
+	}
+	deliveryId := deliveries[0].DeliveryID
+
+	if err := service.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := repository.deliveries[deliveryId]; got.Status != domain.DeliveryPending || !got.NextAttemptAt.Equal(now.Add(minWebhookBackoff)) {
+		t.Fatalf("expected the failed delivery to be retried after the backoff, got %+v", got)
+	}
+	if err := service.DeliverDue(context.Background()); err != nil || sender.sent != 1 {
+		t.Fatalf("expected no attempt during the backoff, got %d and %v", sender.sent, err)
+	}
+	for range 2 {
+		now = now.Add(maxWebhookBackoff)
+		if err := service.DeliverDue(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := repository.deliveries[deliveryId]; got.Status != domain.DeliveryDead || len(got.Attempts) != 3 {
+		t.Fatalf("expected the delivery to be dead after 3 failures, got %+v", got)
+	}
+
+	sender.status = http.StatusNoContent
+	if _, err := service.RedeliverWebhookDelivery(ctx, webhook.WebhookID, deliveryId); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := repository.deliveries[deliveryId]; got.Status != domain.DeliverySucceeded || len(got.Attempts) != 4 {
+		t.Fatalf("expected the redelivery to succeed, got %+v", got)
+	}
+	if _, err := service.RedeliverWebhookDelivery(ctx, uuid.New().String(), deliveryId); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for another webhook, got %v", err)
+	}
+
+	now = now.Add(service.Retention)
+	if err := service.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := repository.deliveries[deliveryId]; ok {
+		t.Fatal("expected the delivery to be deleted after the retention")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	for failures, want := range map[int]time.Duration{0: 30 * time.Second, 2: 2 * time.Minute, 10: 6 * time.Hour, 100: 6 * time.Hour} {
+		if got := webhookBackoff(failures); got != want {
+			t.Errorf("webhookBackoff(%d) = %s, want %s", failures, got, want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package token
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHMACSigner(t *testing.T) {
+	signer := NewHMACSigner([]byte("secret"))
+
+	t.Run("Round trip", func(t *testing.T) {
+		signed, err := signer.Sign(map[string]any{"tenant_id": "tenant"})
+		if err != nil {
+			t.Fatal("Unexpected error while signing", err)
+		}
+		claims, err := signer.Verify(signed)
+		if err != nil {
+			t.Fatal("Unexpected error while verifying", err)
+		}
+		if claims["tenant_id"] != "tenant" {
+			t.Fatalf("Claims do not match: %v", claims)
+		}
+	})
+	t.Run("Different secret", func(t *testing.T) {
+		signed, _ := NewHMACSigner([]byte("other")).Sign(map[string]any{"sub": "user"})
+		if _, err := signer.Verify(signed); !errors.Is(err, ErrInvalidToken) {
+			t.Fatal("Expected an invalid token error, got", err)
+		}
+	})
+	t.Run("Expired token", func(t *testing.T) {
+		signed, _ := signer.Sign(map[string]any{"exp": time.Now().Add(-time.Minute).Unix()})
+		if _, err := signer.Verify(signed); !errors.Is(err, ErrExpiredToken) {
+			t.Fatal("Expected an expired token error, got", err)
+		}
+	})
+	t.Run("Malformed token", func(t *testing.T) {
+		if _, err := signer.Verify("not-a-token"); !errors.Is(err, ErrMalformedToken) {
+			t.Fatal("Expected a malformed token error, got", err)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthenticator(t *testing.T) {
+	authenticator := NewAuthenticator("UserAPI")
+	// The SHA1 test vectors of RFC 6238, truncated to six digits.
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924", 2000000000: "279037"}
+	for unix, expected := range vectors {
+		code, err := authenticator.Code(secret, authenticator.Step(time.Unix(unix, 0)))
+		if err != nil || code != expected {
+			t.Fatalf("Unexpected code at %d: %s %v", unix, code, err)
+		}
+	}
+	t.Run("Codes of the next period are accepted", func(t *testing.T) {
+		now := time.Unix(1111111109, 0)
+		next, _ := authenticator.Code(secret, authenticator.Step(now)+1)
+		step, ok := authenticator.Match(secret, next, now)
+		if !ok || step != authenticator.Step(now)+1 {
+			t.Fatal("The code of the next period should match")
+		}
+		later, _ := authenticator.Code(secret, authenticator.Step(now)+2)
+		if _, ok := authenticator.Match(secret, later, now); ok {
+			t.Fatal("Codes outside the skew should not match")
+		}
+	})
+	t.Run("URI", func(t *testing.T) {
+		uri := authenticator.URI("john.doe@mail.com", "JBSWY3DPEHPK3PXP")
+		if !strings.HasPrefix(uri, "otpauth://totp/UserAPI:john.doe@mail.com?") || !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") {
+			t.Fatal("Unexpected URI", uri)
+		}
+	})
+}
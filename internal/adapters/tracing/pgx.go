@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer traces the queries of a pgx connection as client spans, named
+// after the sqlc query when the statement starts with its "-- name:" comment.
+type QueryTracer struct{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	name, operation := queryName(data.SQL)
+	ctx, _ = Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", operation),
+		attribute.String("db.query.text", data.SQL),
+	))
+	return ctx
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		recordError(span, data.Err)
+	}
+	span.SetAttributes(attribute.Int64("db.response.returned_rows", data.CommandTag.RowsAffected()))
+	span.End()
+}
+
+// redactError returns the error to record on a span. Postgres errors are cut
+// down to their SQLSTATE and constraint: their messages and details can quote
+// the values of a row, such as the email of a duplicate user.
+func redactError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	message := "SQLSTATE " + pgErr.Code
+	if pgErr.ConstraintName != "" {
+		message += " (" + pgErr.ConstraintName + ")"
+	}
+	return errors.New(message)
+}
+
+// queryName returns the sqlc name of the query, or its first keyword, along
+// with the operation, the first keyword of the statement.
+func queryName(sql string) (string, string) {
+	name := ""
+	rest := strings.TrimSpace(sql)
+	if strings.HasPrefix(rest, "-- name:") {
+		line, after, _ := strings.Cut(rest, "\n")
+		if fields := strings.Fields(strings.TrimPrefix(line, "-- name:")); len(fields) > 0 {
+			name = fields[0]
+		}
+		rest = strings.TrimSpace(after)
+	}
+	operation, _, _ := strings.Cut(rest, " ")
+	operation = strings.ToUpper(strings.TrimSpace(operation))
+	if name == "" {
+		name = operation
+	}
+	return name, operation
+}
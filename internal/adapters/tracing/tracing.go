@@ -0,0 +1,81 @@
+// Package tracing sets up OpenTelemetry tracing, propagated with W3C trace
+// context headers, and traces the HTTP clients, the user service and the
+// database queries.
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "userapi/app"
+
+// propagator reads and writes the traceparent header, whatever propagator is
+// set globally.
+var propagator = propagation.TraceContext{}
+
+// NewTracerProvider returns a provider exporting the spans of the
+// sampleRatio share of new traces in batches. Traces started elsewhere follow
+// the decision of their parent. Without an exporter, spans are only
+// propagated.
+func NewTracerProvider(serviceName string, exporter sdktrace.SpanExporter, sampleRatio float64) *sdktrace.TracerProvider {
+	sampler := sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))
+	if exporter == nil {
+		sampler = sdktrace.ParentBased(sdktrace.NeverSample())
+	}
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	}
+	if exporter != nil {
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+	return sdktrace.NewTracerProvider(options...)
+}
+
+// SetDefault makes provider the one spans are started with.
+func SetDefault(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+}
+
+// Tracer returns the tracer of the service, from the provider set with
+// SetDefault.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Extract returns a copy of ctx carrying the remote parent named by the
+// traceparent header, when there is a valid one.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// NewTransport returns base tracing the requests sent through it as client
+// spans and propagating their context to the servers. A nil base is
+// http.DefaultTransport.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base,
+		otelhttp.WithPropagators(propagator),
+		otelhttp.WithSpanNameFormatter(func(_ string, request *http.Request) string { return request.Method }),
+	)
+}
+
+// recordError marks the span failed with the error, redacted.
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	err = redactError(err)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
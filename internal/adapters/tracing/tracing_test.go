@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestProviderRecordsChildrenOfRemoteParents(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewTracerProvider("userapi", exporter, 0)
+	tracer := provider.Tracer(instrumentationName)
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, server := tracer.Start(Extract(context.Background(), header), "GET /users/{userId}", trace.WithSpanKind(trace.SpanKindServer))
+	_, child := tracer.Start(ctx, "CreateUser", trace.WithSpanKind(trace.SpanKindClient))
+	recordError(child, &pgconn.PgError{Code: "23505", Detail: "Key (email)=(ada@example.com) already exists."})
+	child.End()
+	server.End()
+	// Traces started here are not sampled at a ratio of 0.
+	_, unsampled := tracer.Start(context.Background(), "unsampled")
+	unsampled.End()
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || spans[0].Parent.SpanID() != server.SpanContext().SpanID() {
+		t.Fatalf("expected the child of the server span, got %+v", spans[0])
+	}
+	if spans[0].Status.Description != "SQLSTATE 23505" || len(spans[0].Events) != 1 {
+		t.Fatalf("expected the redacted error to be recorded, got %+v", spans[0].Status)
+	}
+	if spans[1].Parent.SpanID().String() != "00f067aa0ba902b7" || !spans[1].Parent.IsRemote() {
+		t.Fatalf("expected the child of the remote parent, got %+v", spans[1])
+	}
+}
+
+func TestProviderWithoutExporterPropagates(t *testing.T) {
+	provider := NewTracerProvider("userapi", nil, 1)
+	_, span := provider.Tracer(instrumentationName).Start(context.Background(), "GET /users")
+	if !span.SpanContext().IsValid() || span.IsRecording() {
+		t.Fatalf("expected a valid span that records nothing, got %+v", span.SpanContext())
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	err := fmt.Errorf("could not create the user: %w", &pgconn.PgError{
+		Code:           "23505",
+		Message:        `duplicate key value violates unique constraint "users_email_key"`,
+		Detail:         "Key (email)=(ada@example.com) already exists.",
+		ConstraintName: "users_email_key",
+	})
+	if redacted := redactError(err).Error(); redacted != "SQLSTATE 23505 (users_email_key)" {
+		t.Fatalf("expected the SQLSTATE and the constraint only, got %q", redacted)
+	}
+	if other := errors.New("not found"); redactError(other) != other {
+		t.Fatal("expected other errors to be kept")
+	}
+}
+
+func TestQueryName(t *testing.T) {
+	name, operation := queryName("-- name: GetUser :one\nSELECT * FROM users WHERE id = $1")
+	if name != "GetUser" || operation != "SELECT" {
+		t.Fatalf("expected GetUser and SELECT, got %s and %s", name, operation)
+	}
+	if name, _ := queryName("begin"); name != "BEGIN" {
+		t.Fatalf("expected BEGIN, got %s", name)
+	}
+}
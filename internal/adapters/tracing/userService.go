@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"userapi/app/internal/core/domain"
+	"userapi/app/internal/core/ports"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// UserServiceTracing traces the operations of a user service.
+type UserServiceTracing struct {
+	next ports.UserService
+}
+
+// TraceUserService returns service recording each call as a span named
+// "UserService.<operation>", failed when the call returns an error.
+func TraceUserService(service ports.UserService) *UserServiceTracing {
+	return &UserServiceTracing{next: service}
+}
+
+func (t *UserServiceTracing) start(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, "UserService."+operation)
+}
+
+func end(span trace.Span, err error) {
+	recordError(span, err)
+	span.End()
+}
+
+func (t *UserServiceTracing) AddUser(ctx context.Context, user domain.User) (domain.User, error) {
+	ctx, span := t.start(ctx, "AddUser")
+	created, err := t.next.AddUser(ctx, user)
+	end(span, err)
+	return created, err
+}
+
+func (t *UserServiceTracing) GetUserById(ctx context.Context, userId string) (domain.User, error) {
+	ctx, span := t.start(ctx, "GetUserById")
+	user, err := t.next.GetUserById(ctx, userId)
+	end(span, err)
+	return user, err
+}
+
+func (t *UserServiceTracing) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	ctx, span := t.start(ctx, "GetUserByEmail")
+	user, err := t.next.GetUserByEmail(ctx, email)
+	end(span, err)
+	return user, err
+}
+
+func (t *UserServiceTracing) GetAllUsers(ctx context.Context) ([]domain.User, error) {
+	ctx, span := t.start(ctx, "GetAllUsers")
+	users, err := t.next.GetAllUsers(ctx)
+	end(span, err)
+	return users, err
+}
+
+func (t *UserServiceTracing) GetUsersByAttributes(ctx context.Context, attributes map[string]any) ([]domain.User, error) {
+	ctx, span := t.start(ctx, "GetUsersByAttributes")
+	users, err := t.next.GetUsersByAttributes(ctx, attributes)
+	end(span, err)
+	return users, err
+}
+
+func (t *UserServiceTracing) GetUsersPage(ctx context.Context, attributes map[string]any, after string, limit int) ([]domain.User, error) {
+	ctx, span := t.start(ctx, "GetUsersPage")
+	users, err := t.next.GetUsersPage(ctx, attributes, after, limit)
+	end(span, err)
+	return users, err
+}
+
+func (t *UserServiceTracing) UpdateUserByID(ctx context.Context, userId string, user domain.User) (domain.User, error) {
+	ctx, span := t.start(ctx, "UpdateUserByID")
+	updated, err := t.next.UpdateUserByID(ctx, userId, user)
+	end(span, err)
+	return updated, err
+}
+
+func (t *UserServiceTracing) DeleteUserByID(ctx context.Context, userId string) error {
+	ctx, span := t.start(ctx, "DeleteUserByID")
+	err := t.next.DeleteUserByID(ctx, userId)
+	end(span, err)
+	return err
+}
+
+func (t *UserServiceTracing) ActivateUser(ctx context.Context, userId string, reason string) (domain.User, error) {
+	ctx, span := t.start(ctx, "ActivateUser")
+	user, err := t.next.ActivateUser(ctx, userId, reason)
+	end(span, err)
+	return user, err
+}
+
+func (t *UserServiceTracing) SuspendUser(ctx context.Context, userId string, reason string, until time.Time) (domain.User, error) {
+	ctx, span := t.start(ctx, "SuspendUser")
+	user, err := t.next.SuspendUser(ctx, userId, reason, until)
+	end(span, err)
+	return user, err
+}
+
+func (t *UserServiceTracing) ReactivateUser(ctx context.Context, userId string, reason string) (domain.User, error) {
+	ctx, span := t.start(ctx, "ReactivateUser")
+	user, err := t.next.ReactivateUser(ctx, userId, reason)
+	end(span, err)
+	return user, err
+}
+
+func (t *UserServiceTracing) GetStatusTransitions(ctx context.Context, userId string) ([]domain.StatusTransition, error) {
+	ctx, span := t.start(ctx, "GetStatusTransitions")
+	transitions, err := t.next.GetStatusTransitions(ctx, userId)
+	end(span, err)
+	return transitions, err
+}
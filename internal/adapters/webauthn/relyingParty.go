@@ -0,0 +1,154 @@
+// Package webauthn verifies WebAuthn registration and assertion responses for
+// a relying party with go-webauthn. Registration options ask for "none"
+// attestation, so authenticators are trusted on first use, but the attestation
+// statements they send anyway are verified by their format.
+package webauthn
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"userapi/app/internal/core/domain"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+var ErrVerification = errors.New("webauthn verification failed")
+
+// SupportedAlgorithms lists the COSE algorithms accepted for credentials, in
+// order of preference.
+var SupportedAlgorithms = []int{int(webauthncose.AlgES256), int(webauthncose.AlgEdDSA), int(webauthncose.AlgRS256)}
+
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+func NewRelyingParty(id string, name string, origins []string) *RelyingParty {
+	return &RelyingParty{ID: id, Name: name, Origins: origins}
+}
+
+func (rp *RelyingParty) RelyingPartyID() string {
+	return rp.ID
+}
+
+func (rp *RelyingParty) RelyingPartyName() string {
+	return rp.Name
+}
+
+func (rp *RelyingParty) Algorithms() []int {
+	return SupportedAlgorithms
+}
+
+func (rp *RelyingParty) NewChallenge() ([]byte, error) {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, fmt.Errorf("could not generate the challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+func verificationError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrVerification, fmt.Sprintf(format, args...))
+}
+
+// libraryError wraps an error of go-webauthn, whose details say what failed.
+func libraryError(err error) error {
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) && protocolErr.Details != "" {
+		return verificationError("%s", protocolErr.Details)
+	}
+	return fmt.Errorf("%w: %w", ErrVerification, err)
+}
+
+// checkClientData verifies the type, the challenge and the origin of the
+// client data.
+func (rp *RelyingParty) checkClientData(clientDataJSON []byte, ceremony protocol.CeremonyType, challenge []byte) error {
+	var data protocol.CollectedClientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return verificationError("malformed client data")
+	}
+	err := data.Verify(base64.RawURLEncoding.EncodeToString(challenge), ceremony, rp.Origins, nil, protocol.TopOriginIgnoreVerificationMode)
+	if err != nil {
+		return libraryError(err)
+	}
+	return nil
+}
+
+// parameters are the credential parameters of the registration options.
+func (rp *RelyingParty) parameters() []protocol.CredentialParameter {
+	parameters := make([]protocol.CredentialParameter, 0, len(SupportedAlgorithms))
+	for _, algorithm := range SupportedAlgorithms {
+		parameters = append(parameters, protocol.CredentialParameter{
+			Type:      protocol.PublicKeyCredentialType,
+			Algorithm: webauthncose.COSEAlgorithmIdentifier(algorithm),
+		})
+	}
+	return parameters
+}
+
+// VerifyRegistration checks the response of navigator.credentials.create and
+// returns the new credential.
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, clientDataJSON []byte, attestationObject []byte) (domain.WebAuthnCredential, error) {
+	if err := rp.checkClientData(clientDataJSON, protocol.CreateCeremony, challenge); err != nil {
+		return domain.WebAuthnCredential{}, err
+	}
+	response := protocol.AuthenticatorAttestationResponse{
+		AuthenticatorResponse: protocol.AuthenticatorResponse{ClientDataJSON: clientDataJSON},
+		AttestationObject:     attestationObject,
+	}
+	parsed, err := response.Parse()
+	if err != nil {
+		return domain.WebAuthnCredential{}, libraryError(err)
+	}
+	object := parsed.AttestationObject
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if err := object.Verify(rp.ID, clientDataHash[:], false, true, nil, rp.parameters()); err != nil {
+		return domain.WebAuthnCredential{}, libraryError(err)
+	}
+	if _, err := webauthncose.ParsePublicKey(object.AuthData.AttData.CredentialPublicKey); err != nil {
+		return domain.WebAuthnCredential{}, libraryError(err)
+	}
+	return domain.WebAuthnCredential{
+		CredentialID: object.AuthData.AttData.CredentialID,
+		PublicKey:    object.AuthData.AttData.CredentialPublicKey,
+		SignCount:    object.AuthData.Counter,
+	}, nil
+}
+
+// VerifyAssertion checks the response of navigator.credentials.get against the
+// stored credential and returns the new signature counter.
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, credential domain.WebAuthnCredential, clientDataJSON []byte, authData []byte, signature []byte) (uint32, error) {
+	if err := rp.checkClientData(clientDataJSON, protocol.AssertCeremony, challenge); err != nil {
+		return 0, err
+	}
+	var parsed protocol.AuthenticatorData
+	if err := parsed.Unmarshal(authData); err != nil {
+		return 0, libraryError(err)
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if err := parsed.Verify(rpIDHash[:], nil, false, true); err != nil {
+		return 0, libraryError(err)
+	}
+	key, err := webauthncose.ParsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, libraryError(err)
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	valid, err := webauthncose.VerifySignature(key, append(append([]byte(nil), authData...), clientDataHash[:]...), signature)
+	if err != nil || !valid {
+		return 0, verificationError("invalid signature")
+	}
+	// A counter that does not increase suggests a cloned authenticator.
+	// Authenticators without a counter always report zero.
+	if (parsed.Counter != 0 || credential.SignCount != 0) && parsed.Counter <= credential.SignCount {
+		return 0, verificationError("signature counter did not increase")
+	}
+	return parsed.Counter, nil
+}
@@ -0,0 +1,141 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+// encodeCBOR encodes the small subset of CBOR the synthetic authenticator needs.
+func encodeCBOR(value any) []byte {
+	head := func(major byte, argument int) []byte {
+		switch {
+		case argument < 24:
+			return []byte{major<<5 | byte(argument)}
+		case argument < 256:
+			return []byte{major<<5 | 24, byte(argument)}
+		}
+		return []byte{major<<5 | 25, byte(argument >> 8), byte(argument)}
+	}
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return head(1, -1-v)
+		}
+		return head(0, v)
+	case []byte:
+		return append(head(2, len(v)), v...)
+	case string:
+		return append(head(3, len(v)), v...)
+	case [][2]any:
+		encoded := head(5, len(v))
+		for _, entry := range v {
+			encoded = append(encoded, encodeCBOR(entry[0])...)
+			encoded = append(encoded, encodeCBOR(entry[1])...)
+		}
+		return encoded
+	}
+	panic(fmt.Sprintf("unsupported value %T", value))
+}
+
+type authenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	counter      uint32
+}
+
+func (a *authenticator) authData(rpID string, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte(nil), rpIDHash[:]...)
+	flags := byte(protocol.FlagUserPresent | protocol.FlagUserVerified)
+	if attested {
+		flags |= byte(protocol.FlagAttestedCredentialData)
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.counter)
+	if !attested {
+		return data
+	}
+	data = append(data, make([]byte, 16)...)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+	data = append(data, a.credentialID...)
+	x, y := make([]byte, 32), make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+	return append(data, encodeCBOR([][2]any{{1, 2}, {3, int(webauthncose.AlgES256)}, {-1, 1}, {-2, x}, {-3, y}})...)
+}
+
+func clientDataJSON(ceremony string, challenge []byte, origin string) []byte {
+	return fmt.Appendf(nil, `{"type":%q,"challenge":%q,"origin":%q}`,
+		ceremony, base64.RawURLEncoding.EncodeToString(challenge), origin)
+}
+
+func TestRelyingParty(t *testing.T) {
+	rp := NewRelyingParty("example.com", "Example", []string{"https://example.com"})
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	device := &authenticator{key: key, credentialID: []byte("credential-1")}
+	challenge, _ := rp.NewChallenge()
+
+	attestation := encodeCBOR([][2]any{{"fmt", "none"}, {"attStmt", [][2]any{}}, {"authData", device.authData(rp.ID, true)}})
+	credential, err := rp.VerifyRegistration(challenge, clientDataJSON("webauthn.create", challenge, "https://example.com"), attestation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(credential.CredentialID) != "credential-1" {
+		t.Fatal("Unexpected credential id", credential.CredentialID)
+	}
+
+	t.Run("Registration from another origin is rejected", func(t *testing.T) {
+		_, err := rp.VerifyRegistration(challenge, clientDataJSON("webauthn.create", challenge, "https://evil.com"), attestation)
+		if !errors.Is(err, ErrVerification) {
+			t.Fatal("Expected a verification error, got", err)
+		}
+	})
+
+	t.Run("Registration with an unverifiable attestation is rejected", func(t *testing.T) {
+		packed := encodeCBOR([][2]any{{"fmt", "packed"}, {"attStmt", [][2]any{}}, {"authData", device.authData(rp.ID, true)}})
+		_, err := rp.VerifyRegistration(challenge, clientDataJSON("webauthn.create", challenge, "https://example.com"), packed)
+		if !errors.Is(err, ErrVerification) {
+			t.Fatal("Expected a verification error, got", err)
+		}
+	})
+
+	assert := func(challenge []byte) ([]byte, []byte, []byte) {
+		device.counter++
+		authData := device.authData(rp.ID, false)
+		clientData := clientDataJSON("webauthn.get", challenge, "https://example.com")
+		clientDataHash := sha256.Sum256(clientData)
+		digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+		signature, _ := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		return clientData, authData, signature
+	}
+	t.Run("Assertion", func(t *testing.T) {
+		clientData, authData, signature := assert(challenge)
+		counter, err := rp.VerifyAssertion(challenge, credential, clientData, authData, signature)
+		if err != nil || counter != 1 {
+			t.Fatal("Unexpected result", counter, err)
+		}
+		credential.SignCount = counter
+		if _, err := rp.VerifyAssertion(challenge, credential, clientData, authData, signature); !errors.Is(err, ErrVerification) {
+			t.Fatal("A replayed counter should be rejected")
+		}
+		other, _ := rp.NewChallenge()
+		clientData, authData, signature = assert(challenge)
+		if _, err := rp.VerifyAssertion(other, credential, clientData, authData, signature); !errors.Is(err, ErrVerification) {
+			t.Fatal("A different challenge should be rejected")
+		}
+		signature[len(signature)-1] ^= 1
+		if _, err := rp.VerifyAssertion(challenge, credential, clientData, authData, signature); !errors.Is(err, ErrVerification) {
+			t.Fatal("A tampered signature should be rejected")
+		}
+	})
+}
@@ -0,0 +1,216 @@
+// Package config loads the settings of the server from, in increasing
+// precedence, their defaults, a YAML or TOML file, the environment and the
+// command line.
+//
+// Each setting is a field of Config. Its tags give its key in files and, with
+// the keys of the sections above it joined by dots, its flag; its environment
+// variables, the first one set winning; its default; and its help. Secret
+// settings can also be read from a file, named by the variable suffixed with
+// _FILE, the key suffixed with File or the flag suffixed with -file, as
+// docker and Kubernetes secrets are mounted. Settings tagged reload can be
+// changed without a restart.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	Server       ServerConfig       `yaml:"server"`
+	Database     DatabaseConfig     `yaml:"database"`
+	Log          LogConfig          `yaml:"log"`
+	Tracing      TracingConfig      `yaml:"tracing"`
+	Auth         AuthConfig         `yaml:"auth"`
+	Sessions     SessionsConfig     `yaml:"sessions"`
+	Verification VerificationConfig `yaml:"verification"`
+	Notify       NotifyConfig       `yaml:"notify"`
+	MFA          MFAConfig          `yaml:"mfa"`
+	Scheduler    SchedulerConfig    `yaml:"scheduler"`
+	Events       EventsConfig       `yaml:"events"`
+	Webhooks     WebhooksConfig     `yaml:"webhooks"`
+	Avatars      AvatarsConfig      `yaml:"avatars"`
+}
+
+type ServerConfig struct {
+	Addr            string          `yaml:"addr" env:"HTTP_ADDR" default:":8080" help:"address the server listens on"`
+	ReadTimeout     time.Duration   `yaml:"readTimeout" env:"HTTP_READ_TIMEOUT" default:"10s" help:"longest time to read a request"`
+	WriteTimeout    time.Duration   `yaml:"writeTimeout" env:"HTTP_WRITE_TIMEOUT" default:"10s" help:"longest time to write a response"`
+	IdleTimeout     time.Duration   `yaml:"idleTimeout" env:"HTTP_IDLE_TIMEOUT" default:"120s" help:"how long idle keep-alive connections are kept"`
+	DrainDelay      time.Duration   `yaml:"drainDelay" env:"SHUTDOWN_DRAIN_DELAY" default:"5s" help:"how long the server keeps serving once /readyz fails on shutdown"`
+	ShutdownTimeout time.Duration   `yaml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT" default:"25s" help:"longest time the shutdown takes, drain delay included, before connections are closed"`
+	DefaultTenant   string          `yaml:"defaultTenant" env:"DEFAULT_TENANT" help:"tenant slug used when a request names none"`
+	AdminToken      string          `yaml:"adminToken" env:"ADMIN_API_TOKEN" secret:"true" help:"bearer token of the /admin routes, which are disabled without one"`
+	RateLimit       RateLimitConfig `yaml:"rateLimit"`
+}
+
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `yaml:"requestsPerSecond" env:"RATE_LIMIT_RPS" default:"0" reload:"true" help:"requests per second allowed to each client address, 0 for no limit"`
+	Burst             int     `yaml:"burst" env:"RATE_LIMIT_BURST" default:"20" reload:"true" help:"requests a client can send at once above the rate"`
+}
+
+type DatabaseConfig struct {
+	Host              string   `yaml:"host" env:"PG_HOST" default:"localhost" help:"PostgreSQL host"`
+	Port              int      `yaml:"port" env:"PG_PORT" default:"5432" help:"PostgreSQL port"`
+	User              string   `yaml:"user" env:"PG_USER" default:"postgres" help:"PostgreSQL user, a member of userapi_app that is neither a superuser nor BYPASSRLS"`
+	Password          string   `yaml:"password" env:"PG_PASSWORD" secret:"true" help:"PostgreSQL password"`
+	MigrationUser     string   `yaml:"migrationUser" env:"PG_MIGRATION_USER" help:"PostgreSQL user owning the schema, the migrations run as it, the user when empty"`
+	MigrationPassword string   `yaml:"migrationPassword" env:"PG_MIGRATION_PASSWORD" secret:"true" help:"password of the migration user"`
+	Name              string   `yaml:"name" env:"PG_DATABASE" default:"userapi" help:"PostgreSQL database"`
+	SSLMode           string   `yaml:"sslMode" env:"PG_SSLMODE" default:"disable" help:"PostgreSQL sslmode"`
+	KeyringFile       string   `yaml:"keyringFile" env:"PII_KEYRING_FILE" help:"keyring encrypting PII columns, which are stored in clear without one"`
+	EncryptedFields   []string `yaml:"encryptedFields" env:"PII_ENCRYPTED_FIELDS" default:"email,phone,address" help:"PII columns encrypted with the keyring"`
+	MigrateOnStart    bool     `yaml:"migrateOnStart" env:"MIGRATE_ON_START" help:"apply the pending schema migrations at startup"`
+}
+
+type LogConfig struct {
+	Format       string   `yaml:"format" env:"LOG_FORMAT" default:"json" help:"json or text"`
+	Level        string   `yaml:"level" env:"LOG_LEVEL" default:"info" reload:"true" help:"default level optionally followed by per package levels, e.g. info,http=debug"`
+	RedactFields []string `yaml:"redactFields" env:"LOG_REDACT_FIELDS" help:"attributes redacted on top of the built-in ones"`
+	RedactUUIDs  bool     `yaml:"redactUUIDs" env:"LOG_REDACT_UUIDS" help:"redact UUIDs in log messages"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" default:"none" help:"otlp, console, file or none"`
+	Endpoint    string  `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" default:"http://localhost:4318" help:"OTLP/HTTP collector spans are posted to"`
+	Headers     string  `yaml:"headers" env:"OTEL_EXPORTER_OTLP_HEADERS" secret:"true" help:"comma separated name=value headers sent to the collector"`
+	ServiceName string  `yaml:"serviceName" env:"OTEL_SERVICE_NAME" default:"userapi" help:"service.name of the exported spans"`
+	SampleRatio float64 `yaml:"sampleRatio" env:"OTEL_TRACES_SAMPLER_ARG" default:"1" help:"share of new traces recorded"`
+	File        string  `yaml:"file" env:"TRACES_FILE" help:"file the file exporter appends spans to"`
+}
+
+type AuthConfig struct {
+	TokenSecret       string        `yaml:"tokenSecret" env:"TOKEN_SECRET" secret:"true" help:"key signing the tokens, passwords and sessions are disabled without one"`
+	PasswordMinLength int           `yaml:"passwordMinLength" env:"PASSWORD_MIN_LENGTH" default:"12" help:"shortest password accepted"`
+	BreachListFile    string        `yaml:"breachListFile" env:"PASSWORD_BREACH_LIST_FILE" help:"file of breached passwords that are refused"`
+	PasswordResetURL  string        `yaml:"passwordResetURL" env:"PASSWORD_RESET_URL" help:"page the password reset links point to"`
+	StepUpMaxAge      time.Duration `yaml:"stepUpMaxAge" env:"STEP_UP_MAX_AGE" default:"10m" help:"how recent the login of users must be for them to change their second factors"`
+}
+
+type SessionsConfig struct {
+	Store      string        `yaml:"store" env:"SESSION_STORE" default:"postgres" help:"postgres or memory"`
+	TTL        time.Duration `yaml:"ttl" env:"SESSION_TTL" default:"1h" help:"lifetime of the session tokens"`
+	RefreshTTL time.Duration `yaml:"refreshTTL" env:"SESSION_REFRESH_TTL" default:"720h" help:"lifetime of the refresh tokens"`
+}
+
+type VerificationConfig struct {
+	EmailURL string        `yaml:"emailURL" env:"EMAIL_VERIFICATION_URL" help:"page the email verification links point to"`
+	EmailTTL time.Duration `yaml:"emailTTL" env:"EMAIL_VERIFICATION_TTL" default:"24h" help:"lifetime of the email verification links"`
+}
+
+type NotifyConfig struct {
+	SMTPAddr      string `yaml:"smtpAddr" env:"NOTIFY_SMTP_ADDR" help:"SMTP server emails are sent through"`
+	SMTPFrom      string `yaml:"smtpFrom" env:"NOTIFY_SMTP_FROM" help:"sender of the emails"`
+	SMTPUsername  string `yaml:"smtpUsername" env:"NOTIFY_SMTP_USERNAME" help:"SMTP user"`
+	SMTPPassword  string `yaml:"smtpPassword" env:"NOTIFY_SMTP_PASSWORD" secret:"true" help:"SMTP password"`
+	OutboxFile    string `yaml:"outboxFile" env:"NOTIFY_OUTBOX_FILE" help:"file emails are appended to when no SMTP server is set"`
+	SMSOutboxFile string `yaml:"smsOutboxFile" env:"NOTIFY_SMS_OUTBOX_FILE" help:"file text messages are appended to"`
+}
+
+type MFAConfig struct {
+	TOTPIssuer      string   `yaml:"totpIssuer" env:"TOTP_ISSUER" default:"UserAPI" help:"issuer shown by authenticator apps"`
+	WebAuthnRPID    string   `yaml:"webAuthnRPID" env:"WEBAUTHN_RP_ID" help:"WebAuthn relying party id, security keys are disabled without one"`
+	WebAuthnRPName  string   `yaml:"webAuthnRPName" env:"WEBAUTHN_RP_NAME" help:"relying party name shown by browsers, the TOTP issuer when empty"`
+	WebAuthnOrigins []string `yaml:"webAuthnOrigins" env:"WEBAUTHN_ORIGINS" help:"origins allowed in WebAuthn responses, https://<rp id> when empty"`
+}
+
+type SchedulerConfig struct {
+	Interval time.Duration `yaml:"interval" env:"SCHEDULER_INTERVAL" default:"1m" help:"how often scheduled status changes are applied"`
+}
+
+type EventsConfig struct {
+	WebhookURL  string        `yaml:"webhookURL" env:"EVENTS_WEBHOOK_URL" help:"URL the user events are posted to"`
+	NATSURL     string        `yaml:"natsURL" env:"EVENTS_NATS_URL" secret:"true" help:"NATS server the user events are published to, nats://[user:password@]host:port"`
+	NATSSubject string        `yaml:"natsSubject" env:"EVENTS_NATS_SUBJECT" default:"userapi" help:"prefix of the NATS subjects, followed by the event type"`
+	File        string        `yaml:"file" env:"EVENTS_FILE" help:"file the user events are appended to"`
+	Interval    time.Duration `yaml:"interval" env:"EVENTS_INTERVAL" default:"1s" help:"how often the outbox is checked for events to publish"`
+	BatchSize   int           `yaml:"batchSize" env:"EVENTS_BATCH_SIZE" default:"100" help:"events read from the outbox at once"`
+	Retention   time.Duration `yaml:"retention" env:"EVENTS_RETENTION" default:"168h" help:"how long published and dead events are kept in the outbox"`
+	MaxAttempts int           `yaml:"maxAttempts" env:"EVENTS_MAX_ATTEMPTS" default:"20" help:"failed publications after which an event is dead"`
+}
+
+type WebhooksConfig struct {
+	Interval    time.Duration `yaml:"interval" env:"WEBHOOK_INTERVAL" default:"5s" help:"how often due webhook deliveries are posted"`
+	MaxFailures int           `yaml:"maxFailures" env:"WEBHOOK_MAX_FAILURES" default:"10" help:"failed attempts in a row after which a webhook delivery is dead"`
+	Retention   time.Duration `yaml:"retention" env:"WEBHOOK_RETENTION" default:"720h" help:"how long succeeded and dead webhook deliveries are kept"`
+}
+
+type AvatarsConfig struct {
+	Dir      string   `yaml:"dir" env:"AVATAR_DIR" help:"directory avatars are stored in when no bucket is set"`
+	MaxBytes int64    `yaml:"maxBytes" env:"AVATAR_MAX_BYTES" default:"5242880" help:"largest avatar upload accepted"`
+	S3       S3Config `yaml:"s3"`
+}
+
+type S3Config struct {
+	Bucket          string `yaml:"bucket" env:"AVATAR_S3_BUCKET" help:"S3 bucket avatars are stored in"`
+	Endpoint        string `yaml:"endpoint" env:"AVATAR_S3_ENDPOINT" help:"S3 compatible endpoint, https://s3.<region>.amazonaws.com when empty"`
+	Region          string `yaml:"region" env:"AVATAR_S3_REGION" default:"us-east-1" help:"region used to sign S3 requests"`
+	AccessKeyID     string `yaml:"accessKeyID" env:"AVATAR_S3_ACCESS_KEY_ID,AWS_ACCESS_KEY_ID" help:"S3 access key"`
+	SecretAccessKey string `yaml:"secretAccessKey" env:"AVATAR_S3_SECRET_ACCESS_KEY,AWS_SECRET_ACCESS_KEY" secret:"true" help:"S3 secret key"`
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var problems []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+	check(c.Server.Addr != "", "server.addr is required")
+	check(c.Server.ReadTimeout >= 0 && c.Server.WriteTimeout >= 0 && c.Server.IdleTimeout >= 0 && c.Server.DrainDelay >= 0, "server timeouts cannot be negative")
+	check(c.Server.ShutdownTimeout > c.Server.DrainDelay, "server.shutdownTimeout must be longer than server.drainDelay")
+	check(c.Server.RateLimit.RequestsPerSecond >= 0, "server.rateLimit.requestsPerSecond cannot be negative")
+	check(c.Server.RateLimit.Burst >= 1, "server.rateLimit.burst must be at least 1")
+	check(c.Database.Password != "", "database.password is required, set PG_PASSWORD or PG_PASSWORD_FILE")
+	check(c.Database.Port > 0 && c.Database.Port < 1<<16, "database.port %d is not a port", c.Database.Port)
+	check(oneOf(c.Log.Format, "json", "text"), "log.format must be json or text, not %q", c.Log.Format)
+	check(validLevels(c.Log.Level), "log.level %q is invalid", c.Log.Level)
+	check(oneOf(c.Tracing.Exporter, "otlp", "console", "file", "none"), "tracing.exporter must be otlp, console, file or none, not %q", c.Tracing.Exporter)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sampleRatio must be between 0 and 1")
+	check(c.Tracing.Exporter != "file" || c.Tracing.File != "", "tracing.file is required by the file exporter")
+	check(c.Auth.PasswordMinLength > 0, "auth.passwordMinLength must be positive")
+	check(c.Auth.StepUpMaxAge > 0, "auth.stepUpMaxAge must be positive")
+	check(oneOf(c.Sessions.Store, "postgres", "memory"), "sessions.store must be postgres or memory, not %q", c.Sessions.Store)
+	check(c.Sessions.TTL > 0 && c.Sessions.RefreshTTL > 0, "session lifetimes must be positive")
+	check(c.Verification.EmailTTL > 0, "verification.emailTTL must be positive")
+	check(c.Scheduler.Interval > 0, "scheduler.interval must be positive")
+	check(c.Events.Interval > 0, "events.interval must be positive")
+	check(c.Events.BatchSize > 0, "events.batchSize must be positive")
+	check(c.Events.MaxAttempts > 0, "events.maxAttempts must be positive")
+	check(c.Webhooks.Interval > 0, "webhooks.interval must be positive")
+	check(c.Webhooks.MaxFailures > 0, "webhooks.maxFailures must be positive")
+	check(c.Avatars.MaxBytes > 0, "avatars.maxBytes must be positive")
+	return errors.Join(problems...)
+}
+
+// validLevels tells whether the spec would be accepted by logging.Levels.Set.
+func validLevels(spec string) bool {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		_, levelName, found := strings.Cut(part, "=")
+		if !found {
+			levelName = part
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(levelName)); err != nil {
+			return false
+		}
+	}
+	return true
+}
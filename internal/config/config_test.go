@@ -0,0 +1,121 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	passwordFile := writeFile(t, "password.txt", "from-file\n")
+	file := writeFile(t, "config.yaml", `
+server:
+  addr: ":9000"
+  readTimeout: 30s
+database:
+  host: db
+  passwordFile: `+passwordFile+`
+log:
+  level: debug
+  redactFields: [ssn, iban]
+`)
+	t.Setenv("PG_HOST", "")
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("AWS_ACCESS_KEY_ID", "aws-key")
+	config, _, err := Load([]string{"--config", file, "--server.addr", ":9100"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Server.Addr != ":9100" || config.Server.ReadTimeout != 30*time.Second || config.Server.WriteTimeout != 10*time.Second {
+		t.Fatalf("expected the flag over the file over the defaults, got %+v", config.Server)
+	}
+	if config.Database.Host != "db" || config.Database.Password != "from-file" {
+		t.Fatalf("expected the file settings, got %+v", config.Database)
+	}
+	if config.Log.Level != "warn" || strings.Join(config.Log.RedactFields, ",") != "ssn,iban" {
+		t.Fatalf("expected the environment over the file, got %+v", config.Log)
+	}
+	if config.Avatars.S3.AccessKeyID != "aws-key" {
+		t.Fatalf("expected the fallback variable, got %q", config.Avatars.S3.AccessKeyID)
+	}
+
+	var printed bytes.Buffer
+	if err := config.Print(&printed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(printed.String(), "from-file") || !strings.Contains(printed.String(), "password: REDACTED") {
+		t.Fatalf("expected the password to be redacted, got\n%s", printed.String())
+	}
+	reprinted := writeFile(t, "printed.yaml", printed.String())
+	if _, _, err := Load([]string{"--config", reprinted, "--database.password", "secret"}); err != nil {
+		t.Fatalf("expected the printed configuration to load, got %v", err)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	file := writeFile(t, "config.toml", `
+# The database of the staging cluster.
+[database]
+host = "staging-db" # inline comment
+port = 6_432
+password = 'p"w#d'
+
+[server.rateLimit]
+requestsPerSecond = 2.5
+
+[mfa]
+webAuthnOrigins = [
+  "https://example.com",
+  "https://app.example.com",
+]
+`)
+	config, _, err := Load([]string{"--config", file})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Database.Host != "staging-db" || config.Database.Port != 6432 || config.Database.Password != `p"w#d` {
+		t.Fatalf("unexpected database settings %+v", config.Database)
+	}
+	if config.Server.RateLimit.RequestsPerSecond != 2.5 || len(config.MFA.WebAuthnOrigins) != 2 {
+		t.Fatalf("unexpected settings %+v %+v", config.Server.RateLimit, config.MFA)
+	}
+}
+
+func TestLoadRejectsInvalidSettings(t *testing.T) {
+	unknown := writeFile(t, "config.yaml", "database:\n  password: x\n  hots: db\n")
+	if _, _, err := Load([]string{"--config", unknown}); err == nil || !strings.Contains(err.Error(), `"database.hots"`) {
+		t.Fatalf("expected the unknown setting to be reported, got %v", err)
+	}
+	t.Setenv("PG_PASSWORD", "x")
+	t.Setenv("SESSION_TTL", "forever")
+	if _, _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "SESSION_TTL") {
+		t.Fatalf("expected the invalid duration to be reported, got %v", err)
+	}
+	t.Setenv("SESSION_TTL", "")
+	if _, _, err := Load([]string{"--log.format", "xml", "--tracing.sampleRatio", "2"}); err == nil ||
+		!strings.Contains(err.Error(), "log.format") || !strings.Contains(err.Error(), "tracing.sampleRatio") {
+		t.Fatalf("expected every invalid setting to be reported, got %v", err)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	t.Setenv("PG_PASSWORD", "x")
+	previous, _, _ := Load(nil)
+	next, _, _ := Load([]string{"--log.level", "debug", "--server.addr", ":9000"})
+	reloadable, restart := Changes(previous, next)
+	if strings.Join(reloadable, ",") != "log.level" || strings.Join(restart, ",") != "server.addr" {
+		t.Fatalf("unexpected changes %v and %v", reloadable, restart)
+	}
+}
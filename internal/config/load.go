@@ -0,0 +1,307 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// setting is a leaf field of Config along with its tags.
+type setting struct {
+	path   string
+	value  reflect.Value
+	env    []string
+	def    string
+	help   string
+	secret bool
+	reload bool
+}
+
+func settingsOf(config *Config) []setting {
+	var settings []setting
+	var walk func(prefix string, value reflect.Value)
+	walk = func(prefix string, value reflect.Value) {
+		for index := range value.NumField() {
+			field := value.Type().Field(index)
+			path := prefix + field.Tag.Get("yaml")
+			if field.Type.Kind() == reflect.Struct {
+				walk(path+".", value.Field(index))
+				continue
+			}
+			settings = append(settings, setting{
+				path:   path,
+				value:  value.Field(index),
+				env:    strings.Split(field.Tag.Get("env"), ","),
+				def:    field.Tag.Get("default"),
+				help:   field.Tag.Get("help"),
+				secret: field.Tag.Get("secret") == "true",
+				reload: field.Tag.Get("reload") == "true",
+			})
+		}
+	}
+	walk("", reflect.ValueOf(config).Elem())
+	return settings
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// set parses a value given as text, lists being comma separated.
+func (s setting) set(text string) error {
+	switch s.value.Kind() {
+	case reflect.String:
+		s.value.SetString(text)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", text)
+		}
+		s.value.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		if s.value.Type() == durationType {
+			parsed, err := time.ParseDuration(text)
+			if err != nil {
+				return fmt.Errorf("%q is not a duration", text)
+			}
+			s.value.SetInt(int64(parsed))
+			return nil
+		}
+		parsed, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", text)
+		}
+		s.value.SetInt(parsed)
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", text)
+		}
+		s.value.SetFloat(parsed)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(text, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		s.value.Set(reflect.ValueOf(items))
+	default:
+		panic("config: unsupported setting type " + s.value.Type().String())
+	}
+	return nil
+}
+
+// setFromFile sets a value decoded from a YAML or TOML file.
+func (s setting) setFromFile(value any) error {
+	switch typed := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		if s.value.Kind() != reflect.Slice {
+			return fmt.Errorf("a list is not allowed here")
+		}
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			items = append(items, fmt.Sprint(item))
+		}
+		s.value.Set(reflect.ValueOf(items))
+		return nil
+	case map[string]any:
+		return fmt.Errorf("a table is not allowed here")
+	case float64:
+		return s.set(strconv.FormatFloat(typed, 'g', -1, 64))
+	}
+	return s.set(fmt.Sprint(value))
+}
+
+// readSecret reads a secret from the file at path, without the trailing
+// newline most editors add.
+func readSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// flagValue collects the flags given on the command line.
+type flagValue struct {
+	name   string
+	values map[string]string
+	isBool bool
+}
+
+func (f *flagValue) String() string { return "" }
+
+func (f *flagValue) Set(value string) error {
+	f.values[f.name] = value
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
+
+// Options are the flags that are not settings.
+type Options struct {
+	// File is the configuration file, given with --config or CONFIG_FILE.
+	File string
+	// PrintConfig asks for the configuration to be printed, redacted,
+	// instead of running the server.
+	PrintConfig bool
+}
+
+// Load returns the configuration given by the command line arguments,
+// without the program name, and the environment. The help of the flags is
+// returned as flag.ErrHelp.
+func Load(args []string) (*Config, Options, error) {
+	var options Options
+	config := &Config{}
+	settings := settingsOf(config)
+
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	flags.StringVar(&options.File, "config", os.Getenv("CONFIG_FILE"), "YAML or TOML configuration `file`")
+	flags.BoolVar(&options.PrintConfig, "print-config", false, "print the configuration, secrets redacted, and exit")
+	given := map[string]string{}
+	for _, s := range settings {
+		var notes []string
+		if env := strings.Join(s.env, ", "); env != "" {
+			notes = append(notes, env)
+		}
+		if s.def != "" {
+			notes = append(notes, "default "+s.def)
+		}
+		usage := s.help + " (" + strings.Join(notes, ", ") + ")"
+		flags.Var(&flagValue{name: s.path, values: given, isBool: s.value.Kind() == reflect.Bool}, s.path, usage)
+		if s.secret {
+			flags.Var(&flagValue{name: s.path + "-file", values: given}, s.path+"-file", "file to read "+s.path+" from")
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, options, err
+	}
+	if flags.NArg() > 0 {
+		return nil, options, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	for _, s := range settings {
+		if s.def != "" {
+			if err := s.set(s.def); err != nil {
+				panic("config: invalid default of " + s.path + ": " + err.Error())
+			}
+		}
+	}
+	if options.File != "" {
+		if err := loadFile(options.File, settings); err != nil {
+			return nil, options, err
+		}
+	}
+	for _, s := range settings {
+		if err := loadEnv(s); err != nil {
+			return nil, options, err
+		}
+	}
+	for _, s := range settings {
+		if path, ok := given[s.path+"-file"]; ok {
+			secret, err := readSecret(path)
+			if err != nil {
+				return nil, options, fmt.Errorf("could not read --%s-file: %w", s.path, err)
+			}
+			given[s.path] = secret
+		}
+		if value, ok := given[s.path]; ok {
+			if err := s.set(value); err != nil {
+				return nil, options, fmt.Errorf("invalid --%s: %w", s.path, err)
+			}
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, options, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return config, options, nil
+}
+
+func loadEnv(s setting) error {
+	for _, name := range s.env {
+		if name == "" {
+			continue
+		}
+		if s.secret {
+			if path := os.Getenv(name + "_FILE"); path != "" {
+				secret, err := readSecret(path)
+				if err != nil {
+					return fmt.Errorf("could not read %s_FILE: %w", name, err)
+				}
+				return s.set(secret)
+			}
+		}
+		if value := os.Getenv(name); value != "" {
+			if err := s.set(value); err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func loadFile(path string, settings []setting) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read the configuration file: %w", err)
+	}
+	document := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		err = yaml.Unmarshal(data, &document)
+	case ".toml":
+		err = toml.Unmarshal(data, &document)
+	default:
+		return fmt.Errorf("unknown configuration file format %q, use .yaml or .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	values := map[string]any{}
+	flatten("", document, values)
+	for _, s := range settings {
+		if s.secret {
+			if secretPath, ok := values[s.path+"File"]; ok {
+				delete(values, s.path+"File")
+				secret, err := readSecret(fmt.Sprint(secretPath))
+				if err != nil {
+					return fmt.Errorf("could not read %sFile: %w", s.path, err)
+				}
+				values[s.path] = secret
+			}
+		}
+		if value, ok := values[s.path]; ok {
+			delete(values, s.path)
+			if err := s.setFromFile(value); err != nil {
+				return fmt.Errorf("invalid %s in %s: %w", s.path, path, err)
+			}
+		}
+	}
+	var unknown []error
+	for key := range values {
+		unknown = append(unknown, fmt.Errorf("unknown setting %q in %s", key, path))
+	}
+	return errors.Join(unknown...)
+}
+
+// flatten indexes the values of nested tables by their dotted path.
+func flatten(prefix string, table map[string]any, values map[string]any) {
+	for key, value := range table {
+		if nested, ok := value.(map[string]any); ok {
+			flatten(prefix+key+".", nested, values)
+			continue
+		}
+		values[prefix+key] = value
+	}
+}
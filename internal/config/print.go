@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const redacted = "REDACTED"
+
+// Print writes the configuration as YAML, in the layout of the configuration
+// files, with the secrets that are set replaced by REDACTED.
+func (c *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range settingsOf(c) {
+		parent := root
+		keys := strings.Split(s.path, ".")
+		for _, key := range keys[:len(keys)-1] {
+			parent = child(parent, key)
+		}
+		node := valueNode(s)
+		if s.help != "" {
+			node.LineComment = s.help
+		}
+		parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: keys[len(keys)-1]}, node)
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return fmt.Errorf("could not print the configuration: %w", err)
+	}
+	return encoder.Close()
+}
+
+// child returns the mapping under key, adding it after the existing ones.
+func child(parent *yaml.Node, key string) *yaml.Node {
+	for index := 0; index < len(parent.Content); index += 2 {
+		if parent.Content[index].Value == key {
+			return parent.Content[index+1]
+		}
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, node)
+	return node
+}
+
+func valueNode(s setting) *yaml.Node {
+	scalar := func(value string, tag string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: value, Tag: tag}
+	}
+	switch value := s.value.Interface().(type) {
+	case string:
+		if s.secret && value != "" {
+			return scalar(redacted, "!!str")
+		}
+		return scalar(value, "!!str")
+	case bool:
+		return scalar(strconv.FormatBool(value), "")
+	case int:
+		return scalar(strconv.Itoa(value), "")
+	case int64:
+		return scalar(strconv.FormatInt(value, 10), "")
+	case float64:
+		return scalar(strconv.FormatFloat(value, 'g', -1, 64), "")
+	case time.Duration:
+		return scalar(value.String(), "!!str")
+	case []string:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, item := range value {
+			node.Content = append(node.Content, scalar(item, "!!str"))
+		}
+		return node
+	}
+	panic("config: unsupported setting type " + s.value.Type().String())
+}
+
+// Changes lists the settings that differ between two configurations, split
+// into those that apply without a restart and those that do not.
+func Changes(previous *Config, next *Config) (reloadable []string, restart []string) {
+	previousSettings := settingsOf(previous)
+	for index, s := range settingsOf(next) {
+		if reflect.DeepEqual(s.value.Interface(), previousSettings[index].value.Interface()) {
+			continue
+		}
+		if s.reload {
+			reloadable = append(reloadable, s.path)
+		} else {
+			restart = append(restart, s.path)
+		}
+	}
+	return reloadable, restart
+}
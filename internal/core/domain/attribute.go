@@ -0,0 +1,14 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AttributeSchema is the JSON Schema the custom attributes of the users of a
+// tenant must satisfy.
+type AttributeSchema struct {
+	TenantID  string
+	Schema    json.RawMessage
+	UpdatedAt time.Time
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// Blob is an object kept in a blob store.
+type Blob struct {
+	ContentType string
+	Data        []byte
+	ModTime     time.Time
+}
+
+// ImageVariant is an image ready to be stored: an upload without its metadata,
+// or a thumbnail made from it.
+type ImageVariant struct {
+	// Size is the width and height of a square thumbnail, 0 for the full image.
+	Size        int
+	ContentType string
+	Data        []byte
+}
+
+// Avatar describes the profile picture of a user.
+type Avatar struct {
+	ContentType string
+	// Sizes are the thumbnails that were made from the upload.
+	Sizes []int
+}
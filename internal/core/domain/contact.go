@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+type ContactKind string
+
+const (
+	ContactEmail ContactKind = "EMAIL"
+	ContactPhone ContactKind = "PHONE"
+)
+
+// ContactMethod is an email address or a phone number of a user. The primary
+// one of each kind is the value of User.Email or User.Phone.
+type ContactMethod struct {
+	ContactID string
+	UserID    string
+	Kind      ContactKind
+	Label     string `validate:"required,oneof=home work mobile other"`
+	Value     string `validate:"required"`
+	Primary   bool
+	CreatedAt time.Time
+}
+
+// PostalAddress is a postal address of a user. The postal code is checked
+// against the format used in the country.
+type PostalAddress struct {
+	AddressID  string
+	UserID     string
+	Label      string `validate:"required,oneof=home work other"`
+	Line1      string `validate:"required,max=200"`
+	Line2      string `validate:"max=200"`
+	City       string `validate:"required,max=100"`
+	Region     string `validate:"max=100"`
+	PostalCode string `validate:"omitempty,postcode_iso3166_alpha2_field=Country"`
+	Country    string `validate:"required,iso3166_1_alpha2"`
+	Primary    bool
+	CreatedAt  time.Time
+}
+
+// regionCountries need a state, province or territory in their addresses.
+var regionCountries = []string{"AU", "BR", "CA", "CN", "IN", "MX", "US"}
+
+// postalCodeOptionalCountries have no postal codes, or do not require them.
+var postalCodeOptionalCountries = []string{"AE", "AO", "BS", "BZ", "FJ", "GH", "HK", "IE", "JM", "QA", "ZW"}
+
+// CheckCountryRules reports the fields the country of the address requires
+// but are missing.
+func (a PostalAddress) CheckCountryRules() error {
+	if a.Region == "" && slices.Contains(regionCountries, a.Country) {
+		return fmt.Errorf("%w: addresses in %s need a region", ErrInvalidAddress, a.Country)
+	}
+	if a.PostalCode == "" && !slices.Contains(postalCodeOptionalCountries, a.Country) {
+		return fmt.Errorf("%w: addresses in %s need a postal code", ErrInvalidAddress, a.Country)
+	}
+	return nil
+}
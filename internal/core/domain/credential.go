@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// Credential is the password of a user that can log in.
+type Credential struct {
+	UserID            string
+	PasswordHash      string
+	FailedAttempts    int
+	LockedUntil       time.Time
+	PasswordChangedAt time.Time
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "errors"
+
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrTenantRequired     = errors.New("tenant is required")
+	ErrTenantDisabled     = errors.New("tenant is disabled")
+	ErrAlreadyErased      = errors.New("user has already been erased")
+	ErrInvalidToken       = errors.New("token is invalid or has expired")
+	ErrAlreadyVerified    = errors.New("already verified")
+	ErrInvalidCode        = errors.New("code is invalid or has expired")
+	ErrLockedOut          = errors.New("too many failed attempts, try again later")
+	ErrNoPhone            = errors.New("user has no phone number")
+	ErrNotConfigured      = errors.New("not configured")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrWeakPassword       = errors.New("password does not meet the policy")
+	ErrMFAFailed          = errors.New("second factor could not be verified")
+	ErrInvalidTransition  = errors.New("status transition is not allowed")
+	ErrReasonRequired     = errors.New("a reason is required")
+	ErrInvalidSchedule    = errors.New("scheduled action is not valid")
+	ErrInvalidAttributes  = errors.New("attributes do not match the attribute schema")
+	ErrInvalidSchema      = errors.New("attribute schema is not valid")
+	ErrGroupCycle         = errors.New("a group cannot contain itself")
+	ErrInvalidContact     = errors.New("contact method is not valid")
+	ErrInvalidAddress     = errors.New("postal address is not valid")
+	ErrPrimaryContact     = errors.New("the primary contact method cannot be removed, make another one primary first")
+	ErrUnsupportedImage   = errors.New("the image format is not supported")
+	ErrInvalidImage       = errors.New("the image could not be read")
+	ErrInvalidWebhook     = errors.New("webhook is not valid")
+)
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// EventType names a change of a user published to other systems.
+type EventType string
+
+const (
+	UserCreated       EventType = "user.created"
+	UserUpdated       EventType = "user.updated"
+	UserDeleted       EventType = "user.deleted"
+	UserStatusChanged EventType = "user.status_changed"
+)
+
+// EventTypes are the types of the events raised.
+var EventTypes = []EventType{UserCreated, UserUpdated, UserDeleted, UserStatusChanged}
+
+// Event is a change of a user, stored in the outbox with the change and
+// published from there. Consumers may receive an event more than once, and
+// tell the copies apart by EventID; the events of a user are published in the
+// order of Sequence.
+type Event struct {
+	EventID    string    `json:"eventId"`
+	Sequence   int64     `json:"sequence"`
+	Type       EventType `json:"type"`
+	TenantID   string    `json:"tenantId"`
+	UserID     string    `json:"userId"`
+	OccurredAt time.Time `json:"occurredAt"`
+	// Data is the UserCreatedData, UserUpdatedData or UserStatusChangedData
+	// of the event, and an empty object for UserDeleted.
+	Data json.RawMessage `json:"data"`
+	// Attempts counts the failed publications, the next one being due at
+	// NextAttemptAt.
+	Attempts      int       `json:"-"`
+	NextAttemptAt time.Time `json:"-"`
+}
+
+type UserCreatedData struct {
+	User User `json:"user"`
+}
+
+type UserUpdatedData struct {
+	// ChangedFields are the JSON names of the fields the update changed.
+	ChangedFields []string `json:"changedFields"`
+	User          User     `json:"user"`
+}
+
+type UserStatusChangedData struct {
+	From           UserStatus `json:"from"`
+	To             UserStatus `json:"to"`
+	Reason         string     `json:"reason,omitempty"`
+	SuspendedUntil time.Time  `json:"suspendedUntil,omitzero"`
+}
+
+func newEvent(eventType EventType, userID string, data any) Event {
+	// data is a pointer, so that the statuses it holds marshal as strings.
+	blob, _ := json.Marshal(data)
+	return Event{Type: eventType, UserID: userID, Data: blob}
+}
+
+func NewUserCreated(user User) Event {
+	return newEvent(UserCreated, user.UserID, &UserCreatedData{User: user})
+}
+
+// NewUserUpdated returns the event of the update from before to after, and
+// false when the update changed nothing.
+func NewUserUpdated(before User, after User) (Event, bool) {
+	changed := ChangedFields(before, after)
+	if len(changed) == 0 {
+		return Event{}, false
+	}
+	return newEvent(UserUpdated, after.UserID, &UserUpdatedData{ChangedFields: changed, User: after}), true
+}
+
+func NewUserDeleted(userID string) Event {
+	return newEvent(UserDeleted, userID, &struct{}{})
+}
+
+func NewUserStatusChanged(transition StatusTransition) Event {
+	return newEvent(UserStatusChanged, transition.UserID, &UserStatusChangedData{
+		From:           transition.From,
+		To:             transition.To,
+		Reason:         transition.Reason,
+		SuspendedUntil: transition.SuspendedUntil,
+	})
+}
+
+// ChangedFields returns the JSON names of the profile fields that differ
+// between before and after. Status changes have events of their own.
+func ChangedFields(before User, after User) []string {
+	var changed []string
+	add := func(name string, equal bool) {
+		if !equal {
+			changed = append(changed, name)
+		}
+	}
+	add("firstName", before.FirstName == after.FirstName)
+	add("lastName", before.LastName == after.LastName)
+	add("email", before.Email == after.Email)
+	add("phone", before.Phone == after.Phone)
+	add("age", before.Age == after.Age)
+	add("emailVerified", before.EmailVerified == after.EmailVerified)
+	add("phoneVerifiedAt", before.PhoneVerifiedAt.Equal(after.PhoneVerifiedAt))
+	add("attributes", len(before.Attributes) == 0 && len(after.Attributes) == 0 || reflect.DeepEqual(before.Attributes, after.Attributes))
+	return changed
+}
+
+// RaiseEvents returns the events of a write from the user before and after
+// it. The user repositories call it in the transaction of the write, so the
+// events are stored if and only if the change is. before is zero for a created
+// user, after is zero for a deleted one, and both are the written user for a
+// status transition.
+type RaiseEvents func(before User, after User) []Event
+
+type eventsContextKey struct{}
+
+// ContextWithEvents returns a copy of ctx whose user writes raise events.
+func ContextWithEvents(ctx context.Context, raise RaiseEvents) context.Context {
+	return context.WithValue(ctx, eventsContextKey{}, raise)
+}
+
+// EventsFromContext returns how the writes done with ctx raise events, nil
+// when they raise none.
+func EventsFromContext(ctx context.Context) RaiseEvents {
+	raise, _ := ctx.Value(eventsContextKey{}).(RaiseEvents)
+	return raise
+}
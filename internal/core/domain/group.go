@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+type GroupMemberKind string
+
+const (
+	MemberUser  GroupMemberKind = "USER"
+	MemberGroup GroupMemberKind = "GROUP"
+)
+
+// GroupMember is a direct member of a group, either a user or another group.
+type GroupMember struct {
+	MemberID string
+	Kind     GroupMemberKind
+	AddedAt  time.Time
+}
+
+// Group is a named set of users and other groups. The members of a nested
+// group are members of the enclosing groups as well.
+type Group struct {
+	GroupID     string
+	Name        string
+	Description string
+	Members     []GroupMember
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// GroupMembership is a group a user belongs to, directly or through nested
+// groups.
+type GroupMembership struct {
+	GroupID string
+	Name    string
+	Direct  bool
+}
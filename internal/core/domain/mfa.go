@@ -0,0 +1,98 @@
+package domain
+
+import "time"
+
+type MFAFactorKind string
+
+const (
+	MFAFactorTOTP     MFAFactorKind = "TOTP"
+	MFAFactorWebAuthn MFAFactorKind = "WEBAUTHN"
+)
+
+type MFAFactorStatus string
+
+const (
+	// MFAFactorPending factors are enrolled but not yet confirmed by the user.
+	MFAFactorPending MFAFactorStatus = "PENDING"
+	MFAFactorActive  MFAFactorStatus = "ACTIVE"
+)
+
+// WebAuthnCredential is a public key credential registered by an authenticator.
+type WebAuthnCredential struct {
+	CredentialID []byte
+	// PublicKey is the COSE encoded public key.
+	PublicKey []byte
+	SignCount uint32
+}
+
+// MFAFactor is a second factor of a user: a TOTP secret or a WebAuthn credential.
+type MFAFactor struct {
+	FactorID   string
+	UserID     string
+	Kind       MFAFactorKind
+	Name       string
+	Status     MFAFactorStatus
+	TOTPSecret string
+	// LastUsedStep is the last TOTP time step accepted, codes of earlier or
+	// equal steps are replays.
+	LastUsedStep int64
+	WebAuthn     WebAuthnCredential
+	CreatedAt    time.Time
+	LastUsedAt   time.Time
+}
+
+// MFAChallenge is a pending WebAuthn ceremony. It can be consumed once.
+type MFAChallenge struct {
+	ChallengeID string
+	UserID      string
+	Purpose     string
+	Challenge   []byte
+	ExpiresAt   time.Time
+}
+
+// MFAVerification carries one of the proofs accepted as a second factor.
+type MFAVerification struct {
+	Code         string
+	RecoveryCode string
+	Assertion    *WebAuthnAssertion
+}
+
+// WebAuthnAssertion is the response of navigator.credentials.get.
+type WebAuthnAssertion struct {
+	ChallengeID       string
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+}
+
+// WebAuthnRegistration is the response of navigator.credentials.create.
+type WebAuthnRegistration struct {
+	ChallengeID       string
+	Name              string
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// TOTPEnrollment is returned once when a TOTP factor is enrolled, so the
+// secret can be added to an authenticator app.
+type TOTPEnrollment struct {
+	Factor MFAFactor
+	Secret string
+	URI    string
+}
+
+// WebAuthnOptions are the options of a WebAuthn ceremony for the browser.
+type WebAuthnOptions struct {
+	ChallengeID      string
+	Challenge        []byte
+	RelyingPartyID   string
+	RelyingPartyName string
+	UserID           string
+	UserName         string
+	Algorithms       []int
+	// CredentialIDs are the credentials already registered, excluded on
+	// registration and allowed on assertion.
+	CredentialIDs [][]byte
+	Timeout       time.Duration
+}
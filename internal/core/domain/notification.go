@@ -0,0 +1,16 @@
+package domain
+
+type NotificationChannel string
+
+const (
+	NotificationEmail NotificationChannel = "email"
+	NotificationSMS   NotificationChannel = "sms"
+)
+
+// Notification is a message addressed to a user over a single channel.
+type Notification struct {
+	Channel NotificationChannel
+	To      string
+	Subject string
+	Body    string
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+type PrivacyRequestKind string
+
+const (
+	PrivacyExport  PrivacyRequestKind = "EXPORT"
+	PrivacyErasure PrivacyRequestKind = "ERASURE"
+)
+
+type PrivacyRequestStatus string
+
+const (
+	PrivacyPending   PrivacyRequestStatus = "PENDING"
+	PrivacyCompleted PrivacyRequestStatus = "COMPLETED"
+	PrivacyFailed    PrivacyRequestStatus = "FAILED"
+)
+
+// PrivacyRequest tracks a data subject request until it is fulfilled.
+type PrivacyRequest struct {
+	RequestID   string
+	UserID      string
+	Kind        PrivacyRequestKind
+	Status      PrivacyRequestStatus
+	RequestedAt time.Time
+	DueAt       time.Time
+	CompletedAt time.Time
+	Error       string
+}
+
+// ErasureCertificate records that the personal data of a user was erased.
+type ErasureCertificate struct {
+	CertificateID string
+	RequestID     string
+	UserID        string
+	ErasedFields  []string
+	ErasedAt      time.Time
+}
+
+// UserDataExport is everything held about a user.
+type UserDataExport struct {
+	User                User
+	PrivacyRequests     []PrivacyRequest
+	ErasureCertificates []ErasureCertificate
+	StatusTransitions   []StatusTransition
+	// PhoneVerification is the code pending for the phone, if any.
+	PhoneVerification *PhoneVerification
+	// Credential is the password metadata of the user, if any, without the hash.
+	Credential *Credential
+	// MFAFactors are the second factors of the user, without their secrets.
+	MFAFactors []MFAFactor
+	// Sessions are the active sessions of the user, without their tokens.
+	Sessions         []Session
+	ScheduledActions []ScheduledAction
+	Groups           []GroupMembership
+	ContactMethods   []ContactMethod
+	PostalAddresses  []PostalAddress
+	// WebhookDeliveries are the deliveries of the events of the user.
+	WebhookDeliveries []WebhookDelivery
+	// Avatar is the uploaded image of the user, if any.
+	Avatar     *Blob
+	ExportedAt time.Time
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+type ScheduledActionKind string
+
+const (
+	ScheduleDeactivate ScheduledActionKind = "DEACTIVATE"
+	ScheduleReactivate ScheduledActionKind = "REACTIVATE"
+)
+
+// TargetStatus is the status the action moves the user to.
+func (k ScheduledActionKind) TargetStatus() (UserStatus, bool) {
+	switch k {
+	case ScheduleDeactivate:
+		return DEACTIVATED, true
+	case ScheduleReactivate:
+		return ACTIVE, true
+	}
+	return 0, false
+}
+
+type ScheduledActionStatus string
+
+const (
+	SchedulePending   ScheduledActionStatus = "PENDING"
+	ScheduleDone      ScheduledActionStatus = "DONE"
+	ScheduleCancelled ScheduledActionStatus = "CANCELLED"
+	ScheduleFailed    ScheduledActionStatus = "FAILED"
+)
+
+// ScheduledAction is a status change of a user to be made at RunAt, for
+// instance deactivating a contractor on their end date.
+type ScheduledAction struct {
+	ActionID   string
+	UserID     string
+	Kind       ScheduledActionKind
+	Status     ScheduledActionStatus
+	RunAt      time.Time
+	Reason     string
+	CreatedAt  time.Time
+	ExecutedAt time.Time
+	Error      string
+}
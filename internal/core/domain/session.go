@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Session is issued on a successful login and tracked until it expires or is
+// revoked. Token and RefreshToken are only set when the session is issued or
+// refreshed, the store keeps a hash of the refresh token. When MFARequired is
+// set, Token is a short lived token that completes the login together with a
+// second factor, and no session is stored yet.
+type Session struct {
+	SessionID string
+	UserID    string
+	Token     string
+	// ExpiresAt is the expiry of Token.
+	ExpiresAt        time.Time
+	RefreshToken     string
+	RefreshTokenHash []byte
+	// RefreshExpiresAt is the expiry of the session itself.
+	RefreshExpiresAt time.Time
+	MFARequired      bool
+	UserAgent        string
+	IPAddress        string
+	CreatedAt        time.Time
+	LastSeenAt       time.Time
+	RevokedAt        time.Time
+}
+
+// ClientInfo describes the device a request came from.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+type clientInfoContextKey struct{}
+
+// ContextWithClientInfo returns a copy of ctx carrying the client of the request.
+func ContextWithClientInfo(ctx context.Context, client ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoContextKey{}, client)
+}
+
+// ClientInfoFromContext returns the client of the request, or a zero ClientInfo.
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	client, _ := ctx.Value(clientInfoContextKey{}).(ClientInfo)
+	return client
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "context"
+
+type Tenant struct {
+	TenantID string `json:"tenantId,omitempty" validate:"omitempty,uuid"`
+	Name     string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Slug     string `json:"slug,omitempty" validate:"omitempty,hostname_rfc1123,max=63"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+type tenantContextKey struct{}
+
+// ContextWithTenant returns a copy of ctx scoped to the given tenant.
+func ContextWithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant the request was resolved to, if any.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
@@ -1,23 +1,74 @@
 package domain
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
 
 type UserStatus int
 
 const (
 	_ UserStatus = iota // We don't want this one.
 	ACTIVE
-	INACTIVE
+	// DEACTIVATED was called INACTIVE, "inactive" is still accepted.
+	DEACTIVATED
+	// PENDING users have been created but not activated yet.
+	PENDING
+	// SUSPENDED users are blocked by an admin, with a reason and possibly an end date.
+	SUSPENDED
+	// LOCKED users are blocked for security reasons until they are activated again.
+	LOCKED
 )
 
 var statusMap = map[UserStatus]string{
-	ACTIVE:   "active",
-	INACTIVE: "inactive",
+	ACTIVE:      "active",
+	DEACTIVATED: "deactivated",
+	PENDING:     "pending",
+	SUSPENDED:   "suspended",
+	LOCKED:      "locked",
 }
 
 var stringToStatusMap = map[string]UserStatus{
-	"active":   ACTIVE,
-	"inactive": INACTIVE,
+	"active":      ACTIVE,
+	"deactivated": DEACTIVATED,
+	"inactive":    DEACTIVATED,
+	"pending":     PENDING,
+	"suspended":   SUSPENDED,
+	"locked":      LOCKED,
+}
+
+// userStatusTransitions lists the statuses a user can move to from each status.
+var userStatusTransitions = map[UserStatus][]UserStatus{
+	PENDING:     {ACTIVE, DEACTIVATED},
+	ACTIVE:      {SUSPENDED, LOCKED, DEACTIVATED},
+	SUSPENDED:   {ACTIVE, DEACTIVATED},
+	LOCKED:      {ACTIVE, DEACTIVATED},
+	DEACTIVATED: {ACTIVE},
+}
+
+// ParseUserStatus returns the status named by its JSON value, or 0.
+func ParseUserStatus(s string) UserStatus {
+	return stringToStatusMap[s]
+}
+
+// CanTransitionTo reports whether the transition table allows moving to next.
+func (u UserStatus) CanTransitionTo(next UserStatus) bool {
+	for _, allowed := range userStatusTransitions[u] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Blocked reports whether users in the status are refused logins and sessions.
+func (u UserStatus) Blocked() bool {
+	switch u {
+	case PENDING, SUSPENDED, LOCKED, DEACTIVATED:
+		return true
+	}
+	return false
 }
 
 func (u *UserStatus) UnmarshalJSON(bytes []byte) error {
@@ -46,5 +97,38 @@ type User struct {
 	Email     string     `json:"email,omitempty" validate:"omitempty,email"`
 	Phone     string     `json:"phone,omitempty" validate:"omitempty,e164"`
 	Age       int        `json:"age,omitempty" validate:"omitempty,gte=0,lte=150"`
-	Status    UserStatus `json:"status,omitempty" validate:"omitempty,oneof=1 2"`
+	Status    UserStatus `json:"status,omitempty" validate:"omitempty,oneof=1 2 3 4 5"`
+	// StatusReason explains the last status change. It is recorded with the transition.
+	StatusReason string `json:"statusReason,omitempty" validate:"omitempty,max=500"`
+	// SuspendedUntil is when a suspension is meant to end, zero when open ended.
+	SuspendedUntil time.Time `json:"suspendedUntil,omitzero"`
+	// EmailVerified is reset whenever the email changes.
+	EmailVerified bool `json:"emailVerified,omitempty"`
+	// PhoneVerifiedAt is zero until the phone is verified, and again after it changes.
+	PhoneVerifiedAt time.Time `json:"phoneVerifiedAt,omitzero"`
+	// Attributes are the custom profile attributes, validated against the
+	// attribute schema of the tenant. An update replaces them as a whole.
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// HasAttributes reports whether every given attribute is set on the user to an equal value.
+func (u User) HasAttributes(attributes map[string]any) bool {
+	for name, value := range attributes {
+		actual, ok := u.Attributes[name]
+		if !ok || !reflect.DeepEqual(actual, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// StatusTransition records a change of the status of a user.
+type StatusTransition struct {
+	TransitionID   string
+	UserID         string
+	From           UserStatus
+	To             UserStatus
+	Reason         string
+	SuspendedUntil time.Time
+	CreatedAt      time.Time
 }
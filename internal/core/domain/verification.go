@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// PhoneVerification is the one-time code pending for a user's phone.
+type PhoneVerification struct {
+	UserID      string
+	CodeHash    []byte
+	ExpiresAt   time.Time
+	Attempts    int
+	LockedUntil time.Time
+}
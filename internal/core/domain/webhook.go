@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/netip"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Webhook is a URL of a partner the user events of the tenant are posted to,
+// for the EventTypes it subscribes to.
+type Webhook struct {
+	WebhookID  string
+	URL        string
+	EventTypes []EventType
+	// Secret signs the deliveries. It is only returned when the webhook is
+	// created.
+	Secret    string
+	CreatedAt time.Time
+}
+
+// Subscribes tells whether the webhook receives the events of the type.
+func (w Webhook) Subscribes(eventType EventType) bool {
+	return slices.Contains(w.EventTypes, eventType)
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "PENDING"
+	DeliverySucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	// DeliveryDead is a delivery that failed too many times in a row. It is
+	// no longer retried, unless redelivered.
+	DeliveryDead WebhookDeliveryStatus = "DEAD"
+)
+
+// WebhookDelivery is an event to post to a webhook, with the attempts made.
+type WebhookDelivery struct {
+	DeliveryID string
+	WebhookID  string
+	// UserID is the user of the event.
+	UserID    string
+	EventID   string
+	EventType EventType
+	// Payload is the event as posted, the JSON of Event.
+	Payload json.RawMessage
+	Status  WebhookDeliveryStatus
+	// Failures counts the failed attempts since the delivery was queued or
+	// redelivered.
+	Failures      int
+	Attempts      []WebhookAttempt
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	DeliveredAt   time.Time
+}
+
+// WebhookAttempt is a post of a delivery, with the response or the error
+// that prevented one.
+type WebhookAttempt struct {
+	AttemptedAt time.Time     `json:"attemptedAt"`
+	Duration    time.Duration `json:"duration"`
+	StatusCode  int           `json:"statusCode,omitempty"`
+	// Response is the beginning of the response body.
+	Response string `json:"response,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Succeeded tells whether the webhook accepted the delivery with a 2xx.
+func (a WebhookAttempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode <= 299
+}
+
+// SignWebhook returns the signature of a delivery posted at timestamp: the
+// hex HMAC-SHA256, keyed with the secret, of the Unix timestamp in seconds,
+// a dot and the body.
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// reservedPrefixes are the ranges, besides the loopback, private, link local,
+// multicast and unspecified addresses, that webhooks are not posted to: shared,
+// documentation, benchmarking and future use addresses, and the IPv6
+// translation prefixes that could reach IPv4 addresses of the first kind.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fec0::/10"),
+}
+
+// IsPublicAddress tells whether webhooks may be posted to the address, which
+// keeps them away from the internal services and the cloud metadata endpoint
+// at 169.254.169.254.
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,26 @@
+package ports
+
+import (
+	"context"
+
+	"userapi/app/internal/core/domain"
+)
+
+// BlobStore keeps binary objects by key. Keys are slash separated paths.
+type BlobStore interface {
+	// Put creates or replaces the object at key.
+	Put(ctx context.Context, key string, contentType string, data []byte) error
+	// Get returns domain.ErrNotFound when there is no object at key.
+	Get(ctx context.Context, key string) (domain.Blob, error)
+	// Delete succeeds when there is no object at key.
+	Delete(ctx context.Context, key string) error
+}
+
+// ImageProcessor prepares uploaded images for storage.
+type ImageProcessor interface {
+	// Process returns the image without its metadata, followed by a square
+	// thumbnail for each of the sizes it can be scaled down to. Images in
+	// unknown formats are rejected with domain.ErrUnsupportedImage, and images
+	// that cannot be decoded with domain.ErrInvalidImage.
+	Process(data []byte, sizes []int) ([]domain.ImageVariant, error)
+}
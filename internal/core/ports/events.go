@@ -0,0 +1,21 @@
+package ports
+
+import (
+	"context"
+
+	"userapi/app/internal/core/domain"
+)
+
+// EventSink publishes the user events to another system. An event is
+// published again after an error, so sinks deliver at least once.
+type EventSink interface {
+	// EventSinkName names the sink in the logs.
+	EventSinkName() string
+	Publish(context.Context, domain.Event) error
+}
+
+// WebhookSender posts the deliveries of the webhooks. A delivery failed
+// unless the attempt returned Succeeded.
+type WebhookSender interface {
+	SendWebhook(context.Context, domain.Webhook, domain.WebhookDelivery) domain.WebhookAttempt
+}
@@ -0,0 +1,13 @@
+package ports
+
+import "context"
+
+// HealthChecker is implemented by the adapters whose dependencies the server
+// cannot serve without, such as the database. The server is not ready while
+// one of its checks fails.
+type HealthChecker interface {
+	// HealthCheckName names the check in the health reports.
+	HealthCheckName() string
+	// CheckHealth returns why the dependency cannot be used, or nil.
+	CheckHealth(context.Context) error
+}
@@ -0,0 +1,11 @@
+package ports
+
+import "context"
+
+// LeaderLock elects a single replica to run the background jobs. Once TryLock
+// succeeds the replica keeps the lock until Unlock, or until it loses the
+// connection holding it, which the next TryLock reports.
+type LeaderLock interface {
+	TryLock(context.Context) (bool, error)
+	Unlock(context.Context) error
+}
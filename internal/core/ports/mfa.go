@@ -0,0 +1,27 @@
+package ports
+
+import (
+	"time"
+
+	"userapi/app/internal/core/domain"
+)
+
+// TOTPAuthenticator generates and checks RFC 6238 time-based one-time passwords.
+type TOTPAuthenticator interface {
+	NewSecret() (string, error)
+	URI(account string, secret string) string
+	// Match returns the time step of the code when it is valid at t, allowing
+	// for clock drift.
+	Match(secret string, code string, t time.Time) (int64, bool)
+}
+
+// WebAuthnRelyingParty runs the WebAuthn registration and assertion ceremonies.
+type WebAuthnRelyingParty interface {
+	RelyingPartyID() string
+	RelyingPartyName() string
+	Algorithms() []int
+	NewChallenge() ([]byte, error)
+	VerifyRegistration(challenge []byte, clientDataJSON []byte, attestationObject []byte) (domain.WebAuthnCredential, error)
+	// VerifyAssertion returns the signature counter reported by the authenticator.
+	VerifyAssertion(challenge []byte, credential domain.WebAuthnCredential, clientDataJSON []byte, authenticatorData []byte, signature []byte) (uint32, error)
+}
@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"context"
+
+	"userapi/app/internal/core/domain"
+)
+
+// Notifier delivers notifications to users.
+type Notifier interface {
+	Send(context.Context, domain.Notification) error
+}
@@ -0,0 +1,13 @@
+package ports
+
+// PasswordHasher hashes passwords into self-describing strings, so hashes made
+// with older parameters still verify.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password string, hash string) (bool, error)
+}
+
+// BreachedPasswords reports passwords known from public breaches.
+type BreachedPasswords interface {
+	Contains(password string) bool
+}
@@ -2,15 +2,259 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"userapi/app/internal/core/domain"
 )
 
+// UserRepository stores users. Every method is scoped to the tenant id passed as
+// its first argument after the context. Its writes also store, in the same
+// transaction, the events domain.EventsFromContext raises for the user as
+// written.
 type UserRepository interface {
-	CreateUser(context.Context, domain.User) (domain.User, error)
-	RetrieveUser(context.Context, string) (domain.User, error)
-	RetrieveAllUsers(context.Context) ([]domain.User, error)
-	UpdateUser(context.Context, string, domain.User) (domain.User, error)
-	DeleteUser(context.Context, string) error
+	CreateUser(context.Context, string, domain.User) (domain.User, error)
+	RetrieveUser(context.Context, string, string) (domain.User, error)
+	RetrieveUserByEmail(context.Context, string, string) (domain.User, error)
+	RetrieveAllUsers(context.Context, string) ([]domain.User, error)
+	// RetrieveUsersByAttributes returns the users whose attributes contain the given ones.
+	RetrieveUsersByAttributes(context.Context, string, map[string]any) ([]domain.User, error)
+	// RetrieveUsersPage returns, by user id, at most limit users after the
+	// given user id, or from the first one when it is empty, whose attributes
+	// contain the given ones.
+	RetrieveUsersPage(ctx context.Context, tenantId string, attributes map[string]any, after string, limit int) ([]domain.User, error)
+	UpdateUser(context.Context, string, string, domain.User) (domain.User, error)
+	DeleteUser(context.Context, string, string) error
+	SetEmailVerified(context.Context, string, string, bool) (domain.User, error)
+	// TransitionUserStatus moves the user from transition.From to transition.To
+	// and records the transition. It returns domain.ErrInvalidTransition when the
+	// user is no longer in transition.From.
+	TransitionUserStatus(context.Context, string, string, domain.StatusTransition) (domain.User, error)
+	// UpdateUserWithTransition does the transition of TransitionUserStatus and
+	// the update of UpdateUser in one transaction. The events are raised once,
+	// from the user before the transition to the user after the update.
+	UpdateUserWithTransition(context.Context, string, string, domain.User, domain.StatusTransition) (domain.User, error)
+	// RetrieveStatusTransitions returns the status history of a user, newest first.
+	RetrieveStatusTransitions(context.Context, string, string) ([]domain.StatusTransition, error)
 	Close() error
 }
+
+type TenantRepository interface {
+	CreateTenant(context.Context, domain.Tenant) (domain.Tenant, error)
+	RetrieveTenant(context.Context, string) (domain.Tenant, error)
+	RetrieveTenantBySlug(context.Context, string) (domain.Tenant, error)
+	RetrieveAllTenants(context.Context) ([]domain.Tenant, error)
+	DisableTenant(context.Context, string) (domain.Tenant, error)
+}
+
+// AttributeSchemaRepository stores the attribute schema of each tenant.
+type AttributeSchemaRepository interface {
+	// RetrieveAttributeSchema returns ErrNotFound when the tenant has no schema.
+	RetrieveAttributeSchema(context.Context, string) (domain.AttributeSchema, error)
+	SaveAttributeSchema(context.Context, string, []byte) (domain.AttributeSchema, error)
+	// DeleteAttributeSchema returns ErrNotFound when the tenant has no schema.
+	DeleteAttributeSchema(context.Context, string) error
+}
+
+// VerificationRepository stores the pending phone verification codes. Like
+// UserRepository, every method is scoped to the tenant id passed after the context.
+type VerificationRepository interface {
+	SavePhoneVerification(context.Context, string, domain.PhoneVerification) (domain.PhoneVerification, error)
+	RetrievePhoneVerification(context.Context, string, string) (domain.PhoneVerification, error)
+	// CountPhoneVerificationAttempt atomically increments the attempts of the
+	// pending code and returns it.
+	CountPhoneVerificationAttempt(context.Context, string, string) (domain.PhoneVerification, error)
+	LockPhoneVerification(context.Context, string, string, time.Time) error
+	// ConfirmPhoneVerification marks the phone as verified and discards the code.
+	ConfirmPhoneVerification(context.Context, string, string) (domain.User, error)
+}
+
+// CredentialRepository stores the passwords of the users that can log in. Like
+// UserRepository, every method is scoped to the tenant id passed after the context.
+type CredentialRepository interface {
+	// SaveCredential sets the password hash and clears any failed attempts.
+	SaveCredential(context.Context, string, domain.Credential) (domain.Credential, error)
+	RetrieveCredential(context.Context, string, string) (domain.Credential, error)
+	// CountLoginFailure atomically increments the failed attempts and returns the credential.
+	CountLoginFailure(context.Context, string, string) (domain.Credential, error)
+	LockCredential(context.Context, string, string, time.Time) error
+	ResetLoginFailures(context.Context, string, string) error
+}
+
+// PrivacyRepository stores data subject requests. Like UserRepository, every
+// method is scoped to the tenant id passed after the context.
+type PrivacyRepository interface {
+	CreatePrivacyRequest(context.Context, string, domain.PrivacyRequest) (domain.PrivacyRequest, error)
+	CompletePrivacyRequest(context.Context, string, string, domain.PrivacyRequestStatus, string) (domain.PrivacyRequest, error)
+	RetrievePrivacyRequest(context.Context, string, string) (domain.PrivacyRequest, error)
+	RetrievePrivacyRequests(context.Context, string) ([]domain.PrivacyRequest, error)
+	RetrievePrivacyRequestsByUser(context.Context, string, string) ([]domain.PrivacyRequest, error)
+	RetrieveErasureCertificatesByUser(context.Context, string, string) ([]domain.ErasureCertificate, error)
+	// EraseUser anonymizes the user, issues the certificate and completes the
+	// request in a single transaction.
+	EraseUser(context.Context, string, string, string) (domain.ErasureCertificate, error)
+}
+
+// MFARepository stores the second factors of the users, their recovery codes and
+// the pending WebAuthn challenges. Like UserRepository, every method is scoped to
+// the tenant id passed after the context.
+type MFARepository interface {
+	CreateMFAFactor(context.Context, string, domain.MFAFactor) (domain.MFAFactor, error)
+	RetrieveMFAFactor(context.Context, string, string, string) (domain.MFAFactor, error)
+	RetrieveMFAFactorByCredentialID(context.Context, string, string, []byte) (domain.MFAFactor, error)
+	RetrieveMFAFactors(context.Context, string, string) ([]domain.MFAFactor, error)
+	// UseTOTPFactor atomically records the time step of an accepted code and
+	// activates the factor. It returns ErrInvalidCode when the step is not newer
+	// than the last one used, so a code cannot be replayed.
+	UseTOTPFactor(context.Context, string, string, string, int64) (domain.MFAFactor, error)
+	// UseWebAuthnFactor atomically records the signature counter of an
+	// assertion. It returns ErrMFAFailed when the counter did not increase.
+	UseWebAuthnFactor(context.Context, string, string, string, uint32) (domain.MFAFactor, error)
+	DeleteMFAFactor(context.Context, string, string, string) error
+	// ReplaceRecoveryCodes discards the recovery codes of the user and stores the given hashes.
+	ReplaceRecoveryCodes(context.Context, string, string, [][]byte) error
+	// UseRecoveryCode marks the code with the given hash as used. It returns
+	// ErrInvalidCode when no unused code matches.
+	UseRecoveryCode(context.Context, string, string, []byte) error
+	CreateMFAChallenge(context.Context, string, domain.MFAChallenge) (domain.MFAChallenge, error)
+	// ConsumeMFAChallenge deletes the challenge and returns it, so it can be used once.
+	ConsumeMFAChallenge(context.Context, string, string, string, string) (domain.MFAChallenge, error)
+}
+
+// SessionRepository stores the sessions issued to users. Like UserRepository,
+// every method is scoped to the tenant id passed after the context.
+type SessionRepository interface {
+	CreateSession(context.Context, string, domain.Session) (domain.Session, error)
+	RetrieveSession(context.Context, string, string) (domain.Session, error)
+	// RetrieveSessions returns the sessions of the user that are neither revoked
+	// nor expired, most recently seen first.
+	RetrieveSessions(context.Context, string, string) ([]domain.Session, error)
+	// TouchSession records that the session was seen from the IP address.
+	TouchSession(context.Context, string, string, string) error
+	// RotateRefreshToken atomically replaces the refresh token hash of an active
+	// session and extends it, provided the hash is still the given one. It
+	// returns ErrInvalidToken otherwise.
+	RotateRefreshToken(context.Context, string, string, []byte, []byte, time.Time) (domain.Session, error)
+	// RevokeSession returns ErrNotFound when the user has no such active session.
+	RevokeSession(context.Context, string, string, string) error
+	// RevokeSessions revokes every active session of the user and returns how many there were.
+	RevokeSessions(context.Context, string, string) (int, error)
+}
+
+// ScheduleRepository stores the scheduled status changes. Like UserRepository,
+// every method is scoped to the tenant id passed after the context.
+type ScheduleRepository interface {
+	CreateScheduledAction(context.Context, string, domain.ScheduledAction) (domain.ScheduledAction, error)
+	RetrieveScheduledActions(context.Context, string, string) ([]domain.ScheduledAction, error)
+	// CancelScheduledAction returns ErrNotFound when the user has no such pending action.
+	CancelScheduledAction(context.Context, string, string, string) error
+	// RetrieveDueScheduledActions returns at most limit pending actions due at the given time, oldest first.
+	RetrieveDueScheduledActions(context.Context, string, time.Time, int) ([]domain.ScheduledAction, error)
+	// CompleteScheduledAction records the outcome of a pending action. It returns
+	// ErrNotFound when the action is no longer pending, for instance because it
+	// was cancelled meanwhile.
+	CompleteScheduledAction(context.Context, string, string, domain.ScheduledActionStatus, string) error
+	// RetrieveExpiredSuspensions returns the ids of the suspended users whose
+	// suspension ended at or before the given time.
+	RetrieveExpiredSuspensions(context.Context, string, time.Time) ([]string, error)
+}
+
+// OutboxRepository keeps the events stored with the user changes, across
+// tenants, until they are published.
+type OutboxRepository interface {
+	// RetrieveDueEvents returns at most limit unpublished events due at the
+	// given time, in the order they were stored. The events of a user with an
+	// earlier event waiting for a retry are left out, dead events aside.
+	RetrieveDueEvents(context.Context, time.Time, int) ([]domain.Event, error)
+	MarkEventPublished(context.Context, int64) error
+	// MarkEventFailed records a failed publication with its error, and when
+	// to try again.
+	MarkEventFailed(context.Context, int64, string, time.Time) error
+	// MarkEventDead records a last failed publication with its error. The
+	// event is no longer published.
+	MarkEventDead(context.Context, int64, string) error
+	// DeleteFinishedEvents deletes the events published or dead before the
+	// given time, returning how many.
+	DeleteFinishedEvents(context.Context, time.Time) (int64, error)
+}
+
+// WebhookRepository stores the webhooks and their deliveries. Like
+// UserRepository, every method is scoped to the tenant id passed after the
+// context.
+type WebhookRepository interface {
+	CreateWebhook(context.Context, string, domain.Webhook) (domain.Webhook, error)
+	RetrieveWebhook(context.Context, string, string) (domain.Webhook, error)
+	RetrieveWebhooks(context.Context, string) ([]domain.Webhook, error)
+	// DeleteWebhook deletes the webhook with its deliveries.
+	DeleteWebhook(context.Context, string, string) error
+	// CreateWebhookDeliveries queues the event for the webhooks subscribed to
+	// its type. Queuing an event twice is a no-op.
+	CreateWebhookDeliveries(context.Context, string, domain.Event) error
+	// RetrieveWebhookDeliveries returns at most limit deliveries of the
+	// webhook, newest first.
+	RetrieveWebhookDeliveries(context.Context, string, string, int) ([]domain.WebhookDelivery, error)
+	// RetrieveWebhookDeliveriesByUser returns the deliveries of the events of
+	// a user, newest first.
+	RetrieveWebhookDeliveriesByUser(context.Context, string, string) ([]domain.WebhookDelivery, error)
+	// RetrieveDueWebhookDeliveries returns at most limit pending deliveries due
+	// at the given time, oldest first.
+	RetrieveDueWebhookDeliveries(context.Context, string, time.Time, int) ([]domain.WebhookDelivery, error)
+	// RecordWebhookAttempt appends the attempt to a pending delivery, moving it
+	// to the status and, when still pending, to its next attempt. It returns
+	// ErrNotFound when the delivery is no longer pending.
+	RecordWebhookAttempt(context.Context, string, string, domain.WebhookAttempt, domain.WebhookDeliveryStatus, time.Time) error
+	// RedeliverWebhookDelivery makes a delivery of the webhook pending and due
+	// now, whatever its status.
+	RedeliverWebhookDelivery(context.Context, string, string, string) (domain.WebhookDelivery, error)
+	// DeleteFinishedWebhookDeliveries deletes the succeeded and dead
+	// deliveries queued before the given time, returning how many.
+	DeleteFinishedWebhookDeliveries(context.Context, string, time.Time) (int64, error)
+}
+
+// GroupRepository stores the groups and their members. Like UserRepository,
+// every method is scoped to the tenant id passed after the context.
+type GroupRepository interface {
+	CreateGroup(context.Context, string, domain.Group) (domain.Group, error)
+	// RetrieveGroup returns the group with its direct members.
+	RetrieveGroup(context.Context, string, string) (domain.Group, error)
+	RetrieveAllGroups(context.Context, string) ([]domain.Group, error)
+	UpdateGroup(context.Context, string, string, domain.Group) (domain.Group, error)
+	DeleteGroup(context.Context, string, string) error
+	// AddGroupUser adds a user to a group; adding a member twice is a no-op.
+	AddGroupUser(context.Context, string, string, string) error
+	RemoveGroupUser(context.Context, string, string, string) error
+	// AddSubgroup nests the second group in the first. It returns
+	// ErrGroupCycle when the first group is already nested in the second, and
+	// checks this atomically with the insertion.
+	AddSubgroup(context.Context, string, string, string) error
+	RemoveSubgroup(context.Context, string, string, string) error
+	// RetrieveEffectiveGroupUsers returns the users who are members of the
+	// group directly or through nested groups.
+	RetrieveEffectiveGroupUsers(context.Context, string, string) ([]domain.User, error)
+	// RetrieveUserGroups returns every group the user belongs to, directly or
+	// through nested groups.
+	RetrieveUserGroups(context.Context, string, string) ([]domain.GroupMembership, error)
+}
+
+// ContactRepository stores the contact methods and postal addresses of users.
+// Like UserRepository, every method is scoped to the tenant id passed after the
+// context, and a contact method is looked up by user, kind and id.
+type ContactRepository interface {
+	// CreateContactMethod stores a contact method. When it is primary, the
+	// previous primary one of the same kind loses the flag.
+	CreateContactMethod(context.Context, string, domain.ContactMethod) (domain.ContactMethod, error)
+	// RetrieveContactMethods returns the contact methods of a kind, the primary one first.
+	RetrieveContactMethods(context.Context, string, string, domain.ContactKind) ([]domain.ContactMethod, error)
+	// UpdateContactMethod writes the label and the value of a contact method.
+	UpdateContactMethod(context.Context, string, domain.ContactMethod) (domain.ContactMethod, error)
+	// SetPrimaryContactMethod flags a contact method as the primary one of its kind.
+	SetPrimaryContactMethod(context.Context, string, string, domain.ContactKind, string) (domain.ContactMethod, error)
+	DeleteContactMethod(context.Context, string, string, domain.ContactKind, string) error
+	// CreatePostalAddress stores a postal address. When it is primary, the
+	// previous primary address loses the flag.
+	CreatePostalAddress(context.Context, string, domain.PostalAddress) (domain.PostalAddress, error)
+	// RetrievePostalAddresses returns the postal addresses of a user, the primary one first.
+	RetrievePostalAddresses(context.Context, string, string) ([]domain.PostalAddress, error)
+	UpdatePostalAddress(context.Context, string, domain.PostalAddress) (domain.PostalAddress, error)
+	SetPrimaryPostalAddress(context.Context, string, string, string) (domain.PostalAddress, error)
+	DeletePostalAddress(context.Context, string, string, string) error
+}
@@ -2,14 +2,167 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"userapi/app/internal/core/domain"
 )
 
+// UserService manages the users of the tenant carried by the context.
 type UserService interface {
 	AddUser(context.Context, domain.User) (domain.User, error)
 	GetUserById(context.Context, string) (domain.User, error)
+	GetUserByEmail(context.Context, string) (domain.User, error)
 	GetAllUsers(context.Context) ([]domain.User, error)
+	// GetUsersByAttributes returns the users whose attributes contain the given ones.
+	GetUsersByAttributes(context.Context, map[string]any) ([]domain.User, error)
+	// GetUsersPage returns, by user id, at most limit users after the given
+	// user id, or from the first one when it is empty, whose attributes
+	// contain the given ones.
+	GetUsersPage(ctx context.Context, attributes map[string]any, after string, limit int) ([]domain.User, error)
 	UpdateUserByID(context.Context, string, domain.User) (domain.User, error)
 	DeleteUserByID(context.Context, string) error
+	// ActivateUser moves a PENDING or LOCKED user to ACTIVE.
+	ActivateUser(context.Context, string, string) (domain.User, error)
+	// SuspendUser suspends an ACTIVE user with a reason, until the given time when it is not zero.
+	SuspendUser(context.Context, string, string, time.Time) (domain.User, error)
+	// ReactivateUser moves a SUSPENDED or DEACTIVATED user back to ACTIVE.
+	ReactivateUser(context.Context, string, string) (domain.User, error)
+	GetStatusTransitions(context.Context, string) ([]domain.StatusTransition, error)
+}
+
+type TenantService interface {
+	AddTenant(context.Context, domain.Tenant) (domain.Tenant, error)
+	GetTenantByID(context.Context, string) (domain.Tenant, error)
+	GetTenantBySlug(context.Context, string) (domain.Tenant, error)
+	GetAllTenants(context.Context) ([]domain.Tenant, error)
+	DisableTenantByID(context.Context, string) (domain.Tenant, error)
+	GetAttributeSchema(context.Context, string) (domain.AttributeSchema, error)
+	SetAttributeSchema(context.Context, string, []byte) (domain.AttributeSchema, error)
+	DeleteAttributeSchema(context.Context, string) error
+}
+
+// VerificationService proves that users control the contact details they gave.
+type VerificationService interface {
+	RequestEmailVerification(context.Context, string) error
+	ConfirmEmailVerification(context.Context, string) (domain.User, error)
+	RequestPhoneVerification(context.Context, string) error
+	ConfirmPhoneVerification(context.Context, string, string) (domain.User, error)
+}
+
+// CredentialService stores passwords and authenticates users with them.
+type CredentialService interface {
+	SetPassword(context.Context, string, string) error
+	// ChangePassword sets the password of a user given their current one,
+	// failing with ErrInvalidCredentials when it is wrong.
+	ChangePassword(context.Context, string, string, string) error
+	Login(context.Context, string, string) (domain.Session, error)
+	CompleteLogin(context.Context, string, domain.MFAVerification) (domain.Session, error)
+	RequestPasswordReset(context.Context, string) error
+	ResetPassword(context.Context, string, string) error
+}
+
+// MFAService manages the second factors of users and verifies them.
+type MFAService interface {
+	EnrollTOTP(context.Context, string, string) (domain.TOTPEnrollment, error)
+	ConfirmTOTP(context.Context, string, string, string) (domain.MFAFactor, error)
+	BeginWebAuthnRegistration(context.Context, string) (domain.WebAuthnOptions, error)
+	FinishWebAuthnRegistration(context.Context, string, domain.WebAuthnRegistration) (domain.MFAFactor, error)
+	BeginWebAuthnAssertion(context.Context, string) (domain.WebAuthnOptions, error)
+	VerifyMFA(context.Context, string, domain.MFAVerification) error
+	GenerateRecoveryCodes(context.Context, string) ([]string, error)
+	GetMFAFactors(context.Context, string) ([]domain.MFAFactor, error)
+	RevokeMFAFactor(context.Context, string, string) error
+}
+
+// SessionService issues, refreshes and revokes the sessions of users.
+type SessionService interface {
+	StartSession(context.Context, string) (domain.Session, error)
+	RefreshSession(context.Context, string) (domain.Session, error)
+	// CheckSession returns the session when it is still active, and records that it was seen.
+	CheckSession(context.Context, string) (domain.Session, error)
+	GetSessions(context.Context, string) ([]domain.Session, error)
+	RevokeSession(context.Context, string, string) error
+	RevokeAllSessions(context.Context, string) (int, error)
+}
+
+// PrivacyService handles data subject export and erasure requests.
+type PrivacyService interface {
+	ExportUserData(context.Context, string) (domain.UserDataExport, error)
+	EraseUser(context.Context, string) (domain.PrivacyRequest, error)
+	GetPrivacyRequest(context.Context, string) (domain.PrivacyRequest, error)
+	GetPrivacyRequests(context.Context) ([]domain.PrivacyRequest, error)
+}
+
+// ScheduleService schedules status changes of users and runs them when they are due.
+type ScheduleService interface {
+	ScheduleAction(context.Context, string, domain.ScheduledAction) (domain.ScheduledAction, error)
+	GetScheduledActions(context.Context, string) ([]domain.ScheduledAction, error)
+	CancelScheduledAction(context.Context, string, string) error
+}
+
+// WebhookService manages the webhooks of the tenant carried by the context.
+type WebhookService interface {
+	// CreateWebhook returns the webhook with its secret, generated when not
+	// given. The other methods leave the secret out.
+	CreateWebhook(context.Context, domain.Webhook) (domain.Webhook, error)
+	GetWebhook(context.Context, string) (domain.Webhook, error)
+	GetWebhooks(context.Context) ([]domain.Webhook, error)
+	DeleteWebhook(context.Context, string) error
+	// GetWebhookDeliveries returns the latest deliveries of the webhook with
+	// their attempts, newest first.
+	GetWebhookDeliveries(context.Context, string) ([]domain.WebhookDelivery, error)
+	// RedeliverWebhookDelivery queues a delivery of the webhook again, dead or
+	// not.
+	RedeliverWebhookDelivery(context.Context, string, string) (domain.WebhookDelivery, error)
+}
+
+// GroupService manages the groups of the tenant carried by the context.
+type GroupService interface {
+	CreateGroup(context.Context, domain.Group) (domain.Group, error)
+	GetGroup(context.Context, string) (domain.Group, error)
+	GetGroups(context.Context) ([]domain.Group, error)
+	UpdateGroup(context.Context, string, domain.Group) (domain.Group, error)
+	DeleteGroup(context.Context, string) error
+	AddGroupUser(context.Context, string, string) error
+	RemoveGroupUser(context.Context, string, string) error
+	// AddSubgroup nests the second group in the first, refusing with ErrGroupCycle
+	// when that would make a group contain itself.
+	AddSubgroup(context.Context, string, string) error
+	RemoveSubgroup(context.Context, string, string) error
+	// GetGroupMembers returns the users who are members of the group, directly or through nested groups.
+	GetGroupMembers(context.Context, string) ([]domain.User, error)
+	// GetUserGroups returns the groups the user belongs to, directly or through nested groups.
+	GetUserGroups(context.Context, string) ([]domain.GroupMembership, error)
+}
+
+// ContactService manages the email addresses, phone numbers and postal
+// addresses of the users of the tenant carried by the context. The primary
+// email and phone are kept equal to User.Email and User.Phone.
+type ContactService interface {
+	GetContactMethods(context.Context, string, domain.ContactKind) ([]domain.ContactMethod, error)
+	AddContactMethod(context.Context, string, domain.ContactMethod) (domain.ContactMethod, error)
+	// UpdateContactMethod changes the label or the value of a contact method, and
+	// makes it primary when Primary is set. Empty fields are kept.
+	UpdateContactMethod(context.Context, string, domain.ContactMethod) (domain.ContactMethod, error)
+	// DeleteContactMethod returns ErrPrimaryContact for the primary contact method.
+	DeleteContactMethod(context.Context, string, domain.ContactKind, string) error
+	GetPostalAddresses(context.Context, string) ([]domain.PostalAddress, error)
+	AddPostalAddress(context.Context, string, domain.PostalAddress) (domain.PostalAddress, error)
+	// UpdatePostalAddress changes the fields set in the address, and makes it
+	// primary when Primary is set.
+	UpdatePostalAddress(context.Context, string, domain.PostalAddress) (domain.PostalAddress, error)
+	// DeletePostalAddress removes an address; when it was the primary one, the
+	// oldest remaining address becomes primary.
+	DeletePostalAddress(context.Context, string, string) error
+}
+
+// AvatarService manages the profile pictures of the users of the tenant
+// carried by the context.
+type AvatarService interface {
+	// SetAvatar replaces the avatar of a user with an uploaded image.
+	SetAvatar(context.Context, string, []byte) (domain.Avatar, error)
+	// GetAvatar returns the smallest thumbnail at least as large as the size,
+	// or the full image when the size is 0 or larger than every thumbnail.
+	GetAvatar(context.Context, string, int) (domain.Blob, error)
+	DeleteAvatar(context.Context, string) error
 }
@@ -0,0 +1,7 @@
+package ports
+
+// TokenSigner issues and verifies signed, self-contained tokens.
+type TokenSigner interface {
+	Sign(claims map[string]any) (string, error)
+	Verify(token string) (map[string]any, error)
+}
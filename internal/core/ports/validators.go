@@ -4,3 +4,10 @@ type Validator interface {
 	Struct(s interface{}) error
 	Var(field interface{}, tag string) error
 }
+
+// SchemaValidator validates JSON documents against JSON Schemas.
+type SchemaValidator interface {
+	// CheckSchema returns an error when the schema is not one the validator can apply.
+	CheckSchema(schema []byte) error
+	Validate(schema []byte, document any) error
+}
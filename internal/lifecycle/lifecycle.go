@@ -0,0 +1,146 @@
+// Package lifecycle starts the components of the application in order, runs
+// them until a signal asks it to stop or one of them fails, then stops them in
+// the reverse order within a deadline.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Hook starts and stops a component. Either function can be nil.
+type Hook struct {
+	Name string
+	// OnStart returns once the component is started. Components serving
+	// until stopped, such as servers, serve in a goroutine and report a
+	// failure with Manager.Fail.
+	OnStart func(context.Context) error
+	// OnStop returns once the component is stopped, or when the context
+	// expires.
+	OnStop func(context.Context) error
+}
+
+// Manager runs the hooks. Hooks are started in the order they are appended
+// and stopped in the reverse order, so the ones appended first, such as the
+// database pool, are stopped last.
+type Manager struct {
+	// ShutdownTimeout bounds the stop of all the hooks.
+	ShutdownTimeout time.Duration
+	// Signals stop the application, SIGINT and SIGTERM when empty.
+	Signals []os.Signal
+
+	mu     sync.Mutex
+	hooks  []Hook
+	failed chan error
+}
+
+func NewManager(shutdownTimeout time.Duration) *Manager {
+	return &Manager{ShutdownTimeout: shutdownTimeout, failed: make(chan error, 1)}
+}
+
+func (m *Manager) Append(hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// Fail stops the application because of err. Only the first failure is kept.
+func (m *Manager) Fail(err error) {
+	select {
+	case m.failed <- err:
+	default:
+	}
+}
+
+// Run starts the hooks and blocks until ctx is done, a signal is received or
+// a component fails, then stops the hooks that were started. It returns the
+// failure, along with the errors of the hooks that could not stop. A second
+// signal during the shutdown kills the process.
+func (m *Manager) Run(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := append([]Hook{}, m.hooks...)
+	m.mu.Unlock()
+	signals := m.Signals
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ctx, stopSignals := signal.NotifyContext(ctx, signals...)
+	defer stopSignals()
+
+	var failure error
+	started := 0
+	for _, hook := range hooks {
+		if hook.OnStart == nil {
+			started++
+			continue
+		}
+		slog.Info("starting", "component", hook.Name)
+		if err := hook.OnStart(ctx); err != nil {
+			failure = fmt.Errorf("could not start %s: %w", hook.Name, err)
+			break
+		}
+		started++
+	}
+	if failure == nil {
+		select {
+		case <-ctx.Done():
+			slog.Info("shutting down", "cause", context.Cause(ctx))
+		case failure = <-m.failed:
+			slog.Error("shutting down after a failure", "error", failure)
+		}
+	}
+	// Restores the default handling, so that another signal kills the
+	// process if the shutdown hangs.
+	stopSignals()
+
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.ShutdownTimeout)
+	defer cancel()
+	errs := []error{failure}
+	for index := started - 1; index >= 0; index-- {
+		hook := hooks[index]
+		if hook.OnStop == nil {
+			continue
+		}
+		start := time.Now()
+		if err := hook.OnStop(stopCtx); err != nil {
+			errs = append(errs, fmt.Errorf("could not stop %s: %w", hook.Name, err))
+			continue
+		}
+		slog.Info("stopped", "component", hook.Name, "duration", time.Since(start))
+	}
+	return errors.Join(errs...)
+}
+
+// Background returns the hook of a worker running until its context is
+// canceled, such as a scheduler. Stopping it cancels the context and waits
+// for run to return.
+func Background(name string, run func(context.Context)) Hook {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	}
+}
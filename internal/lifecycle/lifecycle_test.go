@@ -0,0 +1,99 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) hook(name string, startErr error) Hook {
+	record := func(event string) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.events = append(r.events, event)
+	}
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			record("start " + name)
+			return startErr
+		},
+		OnStop: func(context.Context) error {
+			record("stop " + name)
+			return nil
+		},
+	}
+}
+
+func (r *recorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.events, ", ")
+}
+
+func TestManagerStopsInReverseOrder(t *testing.T) {
+	events := &recorder{}
+	manager := NewManager(time.Second)
+	manager.Append(events.hook("postgres", nil))
+	manager.Append(events.hook("http", nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- manager.Run(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if events.String() != "start postgres, start http, stop http, stop postgres" {
+		t.Fatalf("unexpected order %s", events)
+	}
+}
+
+func TestManagerStopsOnFailure(t *testing.T) {
+	events := &recorder{}
+	manager := NewManager(time.Second)
+	manager.Append(events.hook("postgres", nil))
+	failure := errors.New("listener closed")
+	manager.Append(Hook{Name: "http", OnStart: func(context.Context) error {
+		go manager.Fail(failure)
+		return nil
+	}})
+	if err := manager.Run(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("expected the failure, got %v", err)
+	}
+	if events.String() != "start postgres, stop postgres" {
+		t.Fatalf("unexpected order %s", events)
+	}
+}
+
+func TestManagerStopsStartedHooksWhenOneCannotStart(t *testing.T) {
+	events := &recorder{}
+	manager := NewManager(time.Second)
+	manager.Append(events.hook("postgres", nil))
+	manager.Append(events.hook("http", errors.New("address in use")))
+	manager.Append(events.hook("worker", nil))
+	if err := manager.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "could not start http") {
+		t.Fatalf("expected the start error, got %v", err)
+	}
+	if events.String() != "start postgres, start http, stop postgres" {
+		t.Fatalf("unexpected order %s", events)
+	}
+}
+
+func TestBackgroundStopsWithinTheDeadline(t *testing.T) {
+	manager := NewManager(20 * time.Millisecond)
+	manager.Append(Background("stuck", func(context.Context) { select {} }))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := manager.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to be reported, got %v", err)
+	}
+}
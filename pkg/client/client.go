@@ -0,0 +1,215 @@
+// Package client is a Go client of the user API, with retries, pagination
+// and typed errors. It only depends on the API, so it can be imported from
+// outside of the module.
+//
+//	users := client.New("https://users.example.com")
+//	users.Token = token
+//	for user, err := range users.Users(ctx, client.UserFilter{}) {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Client calls the user API at BaseURL. Its fields are set before the first
+// call.
+type Client struct {
+	BaseURL string
+	// Token is sent as a bearer token when set.
+	Token string
+	// TenantID is sent in the X-Tenant-ID header when set, otherwise the
+	// server takes the tenant from the token, the host or its default.
+	TenantID string
+	// HTTPClient sends the requests. The one of New propagates the trace
+	// context of the calls.
+	HTTPClient *http.Client
+	// MaxRetries is how many times a failed request is retried.
+	MaxRetries int
+	// MinBackoff is the wait before the first retry, doubling on every retry
+	// up to MaxBackoff. A Retry-After header given by the server wins.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// PageSize is the number of users fetched per request by the iterators.
+	PageSize int
+}
+
+// New returns a client of the API at baseURL, retrying three times.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{Transport: newTransport(), Timeout: 30 * time.Second},
+		MaxRetries: 3,
+		MinBackoff: 200 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+		PageSize:   100,
+	}
+}
+
+// newTransport traces the requests as client spans of the global tracer
+// provider, and sends their trace context in the traceparent header whatever
+// propagator is set globally.
+func newTransport() http.RoundTripper {
+	return otelhttp.NewTransport(nil,
+		otelhttp.WithPropagators(propagation.TraceContext{}),
+		otelhttp.WithSpanNameFormatter(func(_ string, request *http.Request) string { return request.Method }),
+	)
+}
+
+// response is a successful response, read in full.
+type response struct {
+	header http.Header
+	body   []byte
+	url    *url.URL
+}
+
+// decode unmarshals the JSON body into result.
+func (r response) decode(result any) error {
+	if err := json.Unmarshal(r.body, result); err != nil {
+		return fmt.Errorf("could not decode the response of %s: %w", r.url.Path, err)
+	}
+	return nil
+}
+
+// do sends a request to the target, a path of the API with its query.
+func (c *Client) do(ctx context.Context, method string, target string, body any) (response, error) {
+	requestURL, err := url.Parse(c.BaseURL + target)
+	if err != nil {
+		return response{}, err
+	}
+	return c.send(ctx, method, requestURL, body)
+}
+
+// send sends the request, retrying it when it may succeed later: after 429
+// and 503 responses, which the server sends before doing anything, and after
+// the other 5xx responses and connection errors when the method is
+// idempotent. Responses of 400 and above are returned as *Error.
+func (c *Client) send(ctx context.Context, method string, requestURL *url.URL, body any) (response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return response{}, err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		result, retryAfter, err := c.attempt(ctx, method, requestURL, payload)
+		if err == nil || attempt >= c.MaxRetries || !retryable(method, err) {
+			return result, err
+		}
+		wait := retryAfter
+		if wait <= 0 {
+			wait = c.backoff(attempt)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return response{}, errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends the request once, returning the wait the server asks for
+// along with its errors.
+func (c *Client) attempt(ctx context.Context, method string, requestURL *url.URL, payload []byte) (response, time.Duration, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, requestURL.String(), reader)
+	if err != nil {
+		return response{}, 0, err
+	}
+	request.Header.Set("Accept", "application/json, "+problemContentType)
+	if payload != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.TenantID != "" {
+		request.Header.Set("X-Tenant-ID", c.TenantID)
+	}
+	httpResponse, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return response{}, 0, err
+	}
+	defer httpResponse.Body.Close()
+	body, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return response{}, 0, err
+	}
+	if httpResponse.StatusCode >= http.StatusBadRequest {
+		return response{}, retryAfter(httpResponse.Header.Get("Retry-After")), newError(httpResponse, body)
+	}
+	return response{header: httpResponse.Header, body: body, url: requestURL}, 0, nil
+}
+
+// backoff returns the wait before the retry following attempt, with jitter
+// so that clients failing together do not retry together.
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.MaxBackoff
+	if shifted := c.MinBackoff << attempt; shifted > 0 && shifted < wait {
+		wait = shifted
+	}
+	if wait <= 0 {
+		return 0
+	}
+	return wait/2 + rand.N(wait/2+1)
+}
+
+func retryable(method string, err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests, apiErr.StatusCode == http.StatusServiceUnavailable:
+			return true
+		case apiErr.StatusCode >= http.StatusInternalServerError:
+			return idempotent(method)
+		}
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return idempotent(method)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as a date.
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
@@ -0,0 +1,256 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"userapi/app/internal/adapters/http"
+	"userapi/app/internal/adapters/service"
+	"userapi/app/internal/core/domain"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// fakeUserService is the mock user service, refusing to reactivate the
+// users which are not suspended or deactivated.
+type fakeUserService struct {
+	*service.MockUserServiceImpl
+}
+
+func (f fakeUserService) ReactivateUser(ctx context.Context, userID string, reason string) (domain.User, error) {
+	user, err := f.GetUserById(ctx, userID)
+	if err != nil {
+		return user, err
+	}
+	if !user.Status.CanTransitionTo(domain.ACTIVE) {
+		return domain.User{}, fmt.Errorf("user %s: %w", userID, domain.ErrInvalidTransition)
+	}
+	return f.MockUserServiceImpl.ReactivateUser(ctx, userID, reason)
+}
+
+// newTestServer serves the API over the fake user service, through wrap when
+// not nil.
+func newTestServer(t *testing.T, wrap func(nethttp.Handler) nethttp.Handler) *Client {
+	t.Helper()
+	server := http.NewServer(fakeUserService{service.NewMockUserServiceImpl()}, validator.New())
+	server.AdminToken = "admin-token"
+	var handler nethttp.Handler = server.Handler()
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+	testServer := httptest.NewServer(handler)
+	t.Cleanup(testServer.Close)
+	c := New(testServer.URL)
+	c.Token = "admin-token"
+	c.TenantID = uuid.NewString()
+	c.MinBackoff = time.Millisecond
+	return c
+}
+
+func TestClientUsers(t *testing.T) {
+	ctx := context.Background()
+	c := newTestServer(t, nil)
+
+	created, err := c.AddUser(ctx, User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Status: ACTIVE})
+	if err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	if created.UserID == "" || created.Status != ACTIVE {
+		t.Fatalf("AddUser returned %+v", created)
+	}
+	found, err := c.GetUserById(ctx, created.UserID)
+	if err != nil || found.Email != "ada@example.com" {
+		t.Fatalf("GetUserById returned %+v, %v", found, err)
+	}
+	updated, err := c.UpdateUserByID(ctx, created.UserID, User{LastName: "King"})
+	if err != nil || updated.LastName != "King" {
+		t.Fatalf("UpdateUserByID returned %+v, %v", updated, err)
+	}
+	suspended, err := c.SuspendUser(ctx, created.UserID, "chargeback", time.Time{})
+	if err != nil || suspended.Status != SUSPENDED {
+		t.Fatalf("SuspendUser returned %+v, %v", suspended, err)
+	}
+	if _, err := c.GetStatusTransitions(ctx, created.UserID); err != nil {
+		t.Fatalf("GetStatusTransitions: %v", err)
+	}
+	if err := c.DeleteUserByID(ctx, created.UserID); err != nil {
+		t.Fatalf("DeleteUserByID: %v", err)
+	}
+	if _, err := c.GetUserById(ctx, created.UserID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetUserById after the deletion returned %v, want ErrNotFound", err)
+	}
+}
+
+func TestClientPagination(t *testing.T) {
+	ctx := context.Background()
+	var requests atomic.Int32
+	c := newTestServer(t, func(next nethttp.Handler) nethttp.Handler {
+		return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			if r.Method == nethttp.MethodGet {
+				requests.Add(1)
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	c.PageSize = 2
+	for index := range 5 {
+		user := User{FirstName: "User", LastName: fmt.Sprint("Number", index), Email: fmt.Sprintf("user%d@example.com", index), Status: ACTIVE}
+		if _, err := c.AddUser(ctx, user); err != nil {
+			t.Fatalf("AddUser: %v", err)
+		}
+	}
+	users, err := c.GetAllUsers(ctx)
+	if err != nil {
+		t.Fatalf("GetAllUsers: %v", err)
+	}
+	if len(users) != 5 {
+		t.Fatalf("GetAllUsers returned %d users, want 5", len(users))
+	}
+	seen := map[string]bool{}
+	for _, user := range users {
+		seen[user.UserID] = true
+	}
+	if len(seen) != 5 {
+		t.Fatalf("GetAllUsers returned duplicates: %+v", users)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Fatalf("GetAllUsers sent %d requests, want 3 pages", got)
+	}
+
+	count := 0
+	for _, err := range c.Users(ctx, UserFilter{}) {
+		if err != nil {
+			t.Fatalf("Users: %v", err)
+		}
+		if count++; count == 3 {
+			break
+		}
+	}
+	if got := requests.Load(); got != 5 {
+		t.Fatalf("stopping after 3 users sent %d requests, want 5", got)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+	c := newTestServer(t, nil)
+
+	created, err := c.AddUser(ctx, User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Status: ACTIVE})
+	if err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	_, err = c.ReactivateUser(ctx, created.UserID, "")
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("ReactivateUser of an active user returned %v, want ErrInvalidTransition", err)
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("ReactivateUser returned %T, want *Error", err)
+	}
+	if apiErr.StatusCode != nethttp.StatusConflict || apiErr.Type != "about:blank" || apiErr.Instance != "/users/"+created.UserID+":reactivate" || apiErr.Detail == "" {
+		t.Fatalf("ReactivateUser returned %+v", apiErr)
+	}
+
+	token := c.Token
+	c.Token = ""
+	if _, err := c.GetAllUsers(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != nethttp.StatusUnauthorized {
+		t.Fatalf("GetAllUsers without a token returned %v, want a 401", err)
+	}
+	c.Token = token
+
+	c.TenantID = ""
+	if _, err := c.GetAllUsers(ctx); !errors.Is(err, ErrTenantRequired) {
+		t.Fatalf("GetAllUsers without a tenant returned %v, want ErrTenantRequired", err)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var failures []int
+	var requests int
+	c := newTestServer(t, func(next nethttp.Handler) nethttp.Handler {
+		return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			mu.Lock()
+			requests++
+			status := 0
+			if len(failures) > 0 {
+				status, failures = failures[0], failures[1:]
+			}
+			mu.Unlock()
+			switch status {
+			case 0:
+				next.ServeHTTP(w, r)
+			case nethttp.StatusTooManyRequests:
+				w.Header().Set("Retry-After", "0")
+				fallthrough
+			default:
+				nethttp.Error(w, nethttp.StatusText(status), status)
+			}
+		})
+	})
+	failWith := func(statuses ...int) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures, requests = statuses, 0
+	}
+	sent := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+
+	failWith(nethttp.StatusServiceUnavailable, nethttp.StatusTooManyRequests)
+	if _, err := c.AddUser(ctx, User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Status: ACTIVE}); err != nil {
+		t.Fatalf("AddUser after a 503 and a 429: %v", err)
+	}
+	if got := sent(); got != 3 {
+		t.Fatalf("AddUser sent %d requests, want 3", got)
+	}
+
+	failWith(nethttp.StatusInternalServerError)
+	if _, err := c.AddUser(ctx, User{FirstName: "Alan", LastName: "Turing", Email: "alan@example.com", Status: ACTIVE}); err == nil {
+		t.Fatal("AddUser retried a 500, which may have created the user")
+	}
+	if got := sent(); got != 1 {
+		t.Fatalf("AddUser sent %d requests after a 500, want 1", got)
+	}
+
+	failWith(slices.Repeat([]int{nethttp.StatusBadGateway}, 10)...)
+	_, err := c.GetAllUsers(ctx)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != nethttp.StatusBadGateway {
+		t.Fatalf("GetAllUsers returned %v, want the last 502", err)
+	}
+	if got := sent(); got != c.MaxRetries+1 {
+		t.Fatalf("GetAllUsers sent %d requests, want %d", got, c.MaxRetries+1)
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	c := newTestServer(t, func(next nethttp.Handler) nethttp.Handler {
+		return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			nethttp.Error(w, "unavailable", nethttp.StatusServiceUnavailable)
+		})
+	})
+	c.MinBackoff, c.MaxBackoff = time.Minute, time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetUserById(ctx, uuid.NewString())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetUserById returned %v, want the deadline of the context", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("GetUserById waited %s after the deadline", elapsed)
+	}
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const problemContentType = "application/problem+json"
+
+// The errors of the service, matched with errors.Is against the errors the
+// client returns. Their texts are the ones of the server.
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrInvalidTransition = errors.New("status transition is not allowed")
+	ErrAlreadyErased     = errors.New("user has already been erased")
+	ErrReasonRequired    = errors.New("a reason is required")
+	ErrInvalidAttributes = errors.New("attributes do not match the attribute schema")
+	ErrTenantRequired    = errors.New("tenant is required")
+	ErrTenantDisabled    = errors.New("tenant is disabled")
+)
+
+// detailErrors are the errors recognised in the detail of the responses,
+// which the server writes with the error text.
+var detailErrors = []error{ErrAlreadyErased, ErrReasonRequired, ErrInvalidAttributes, ErrTenantRequired, ErrTenantDisabled}
+
+// Error is a failed response of the API, decoded from its RFC 9457 problem
+// document, or from its plain text body.
+type Error struct {
+	StatusCode int
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Detail     string `json:"detail"`
+	Instance   string `json:"instance"`
+	// TraceID identifies the trace of the failed request on the server.
+	TraceID string `json:"traceId"`
+}
+
+func newError(response *http.Response, body []byte) *Error {
+	apiErr := &Error{}
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediaType != problemContentType || json.Unmarshal(body, apiErr) != nil {
+		apiErr.Detail = strings.TrimSpace(string(body))
+	}
+	apiErr.StatusCode = response.StatusCode
+	if apiErr.Title == "" {
+		apiErr.Title = http.StatusText(response.StatusCode)
+	}
+	return apiErr
+}
+
+func (e *Error) Error() string {
+	message := fmt.Sprintf("%d %s", e.StatusCode, e.Title)
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	if e.TraceID != "" {
+		message += " (trace " + e.TraceID + ")"
+	}
+	return message
+}
+
+// Is matches the errors of the service the response stands for.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrInvalidTransition:
+		return e.StatusCode == http.StatusConflict && !strings.Contains(e.Detail, ErrAlreadyErased.Error())
+	}
+	return slices.Contains(detailErrors, target) && strings.Contains(e.Detail, target.Error())
+}
@@ -0,0 +1,323 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// UserStatus is the status of a user, as named by the API.
+type UserStatus string
+
+const (
+	ACTIVE      UserStatus = "active"
+	DEACTIVATED UserStatus = "deactivated"
+	PENDING     UserStatus = "pending"
+	SUSPENDED   UserStatus = "suspended"
+	LOCKED      UserStatus = "locked"
+)
+
+// User is a user of the API. The zero fields of a user given to AddUser or
+// UpdateUserByID are left out of the request.
+type User struct {
+	UserID       string
+	FirstName    string
+	LastName     string
+	Email        string
+	Phone        string
+	Age          int
+	Status       UserStatus
+	StatusReason string
+	// SuspendedUntil is when a suspension is meant to end, zero when open ended.
+	SuspendedUntil time.Time
+	// EmailVerified and PhoneVerifiedAt are set by the server only.
+	EmailVerified   bool
+	PhoneVerifiedAt time.Time
+	Attributes      map[string]any
+}
+
+// StatusTransition is a change of the status of a user.
+type StatusTransition struct {
+	TransitionID   string
+	UserID         string
+	From           UserStatus
+	To             UserStatus
+	Reason         string
+	SuspendedUntil time.Time
+	CreatedAt      time.Time
+}
+
+// userBody is the user of the requests, and of the responses which use
+// the same names.
+type userBody struct {
+	UserID          string         `json:"userId,omitempty"`
+	FirstName       string         `json:"firstname,omitempty"`
+	LastName        string         `json:"lastname,omitempty"`
+	Email           string         `json:"email,omitempty"`
+	Phone           string         `json:"phone,omitempty"`
+	Age             int            `json:"age,omitempty"`
+	Status          string         `json:"status,omitempty"`
+	StatusReason    string         `json:"statusReason,omitempty"`
+	SuspendedUntil  *time.Time     `json:"suspendedUntil,omitempty"`
+	EmailVerified   bool           `json:"emailVerified,omitempty"`
+	PhoneVerifiedAt *time.Time     `json:"phoneVerifiedAt,omitempty"`
+	Attributes      map[string]any `json:"attributes,omitempty"`
+}
+
+func newUserBody(user User) userBody {
+	return userBody{
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Email:        user.Email,
+		Phone:        user.Phone,
+		Age:          user.Age,
+		Status:       string(user.Status),
+		StatusReason: user.StatusReason,
+		Attributes:   user.Attributes,
+	}
+}
+
+func (body userBody) user() User {
+	user := User{
+		UserID:        body.UserID,
+		FirstName:     body.FirstName,
+		LastName:      body.LastName,
+		Email:         body.Email,
+		Phone:         body.Phone,
+		Age:           body.Age,
+		Status:        UserStatus(body.Status),
+		StatusReason:  body.StatusReason,
+		EmailVerified: body.EmailVerified,
+		Attributes:    body.Attributes,
+	}
+	if body.SuspendedUntil != nil {
+		user.SuspendedUntil = *body.SuspendedUntil
+	}
+	if body.PhoneVerifiedAt != nil {
+		user.PhoneVerifiedAt = *body.PhoneVerifiedAt
+	}
+	return user
+}
+
+func userPath(userID string, suffix string) string {
+	return "/users/" + url.PathEscape(userID) + suffix
+}
+
+func (c *Client) user(ctx context.Context, method string, path string, body any) (User, error) {
+	response, err := c.do(ctx, method, path, body)
+	if err != nil {
+		return User{}, err
+	}
+	var user userBody
+	if err := response.decode(&user); err != nil {
+		return User{}, err
+	}
+	return user.user(), nil
+}
+
+// UserFilter selects the users listed by Users. The zero filter lists them
+// all.
+type UserFilter struct {
+	Email string
+	// Attributes are the custom attributes the users have, with these values.
+	Attributes map[string]any
+}
+
+func (filter UserFilter) query() (url.Values, error) {
+	query := url.Values{}
+	if filter.Email != "" {
+		query.Set("email", filter.Email)
+	}
+	for name, value := range filter.Attributes {
+		blob, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("attributes."+name, string(blob))
+	}
+	return query, nil
+}
+
+// nextLink finds the next page in a Link header.
+var nextLink = regexp.MustCompile(`<([^>]*)>\s*;\s*rel="?next"?`)
+
+// Users iterates over the users of the filter, fetching them PageSize at a
+// time. The iteration stops at the first error, which it yields.
+func (c *Client) Users(ctx context.Context, filter UserFilter) iter.Seq2[User, error] {
+	return func(yield func(User, error) bool) {
+		query, err := filter.query()
+		if err != nil {
+			yield(User{}, err)
+			return
+		}
+		if c.PageSize > 0 {
+			query.Set("limit", strconv.Itoa(c.PageSize))
+		}
+		target, err := url.Parse(c.BaseURL + "/users?" + query.Encode())
+		if err != nil {
+			yield(User{}, err)
+			return
+		}
+		for target != nil {
+			response, err := c.send(ctx, http.MethodGet, target, nil)
+			if err != nil {
+				yield(User{}, err)
+				return
+			}
+			var page []userBody
+			if err := response.decode(&page); err != nil {
+				yield(User{}, err)
+				return
+			}
+			for _, user := range page {
+				if !yield(user.user(), nil) {
+					return
+				}
+			}
+			if target, err = nextPage(response); err != nil {
+				yield(User{}, err)
+				return
+			}
+		}
+	}
+}
+
+// nextPage returns the page following the response, or nil on the last page.
+func nextPage(response response) (*url.URL, error) {
+	match := nextLink.FindStringSubmatch(response.header.Get("Link"))
+	if match == nil {
+		return nil, nil
+	}
+	next, err := response.url.Parse(match[1])
+	if err != nil {
+		return nil, fmt.Errorf("could not follow the next page %q: %w", match[1], err)
+	}
+	return next, nil
+}
+
+func (c *Client) collect(ctx context.Context, filter UserFilter) ([]User, error) {
+	var users []User
+	for user, err := range c.Users(ctx, filter) {
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (c *Client) AddUser(ctx context.Context, user User) (User, error) {
+	return c.user(ctx, http.MethodPost, "/users", newUserBody(user))
+}
+
+func (c *Client) GetUserById(ctx context.Context, userID string) (User, error) {
+	return c.user(ctx, http.MethodGet, userPath(userID, ""), nil)
+}
+
+func (c *Client) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	users, err := c.collect(ctx, UserFilter{Email: email})
+	if err != nil {
+		return User{}, err
+	}
+	if len(users) == 0 {
+		return User{}, fmt.Errorf("%w: no user has the email %s", ErrNotFound, email)
+	}
+	return users[0], nil
+}
+
+func (c *Client) GetAllUsers(ctx context.Context) ([]User, error) {
+	return c.collect(ctx, UserFilter{})
+}
+
+func (c *Client) GetUsersByAttributes(ctx context.Context, attributes map[string]any) ([]User, error) {
+	return c.collect(ctx, UserFilter{Attributes: attributes})
+}
+
+func (c *Client) GetUsersPage(ctx context.Context, attributes map[string]any, after string, limit int) ([]User, error) {
+	query, err := UserFilter{Attributes: attributes}.query()
+	if err != nil {
+		return nil, err
+	}
+	query.Set("limit", strconv.Itoa(limit))
+	if after != "" {
+		query.Set("cursor", after)
+	}
+	response, err := c.do(ctx, http.MethodGet, "/users?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var page []userBody
+	if err := response.decode(&page); err != nil {
+		return nil, err
+	}
+	users := make([]User, 0, len(page))
+	for _, user := range page {
+		users = append(users, user.user())
+	}
+	return users, nil
+}
+
+func (c *Client) UpdateUserByID(ctx context.Context, userID string, user User) (User, error) {
+	return c.user(ctx, http.MethodPatch, userPath(userID, ""), newUserBody(user))
+}
+
+func (c *Client) DeleteUserByID(ctx context.Context, userID string) error {
+	_, err := c.do(ctx, http.MethodDelete, userPath(userID, ""), nil)
+	return err
+}
+
+func (c *Client) ActivateUser(ctx context.Context, userID string, reason string) (User, error) {
+	return c.user(ctx, http.MethodPost, userPath(userID, ":activate"), map[string]string{"reason": reason})
+}
+
+func (c *Client) SuspendUser(ctx context.Context, userID string, reason string, until time.Time) (User, error) {
+	body := map[string]any{"reason": reason}
+	if !until.IsZero() {
+		body["until"] = until
+	}
+	return c.user(ctx, http.MethodPost, userPath(userID, ":suspend"), body)
+}
+
+func (c *Client) ReactivateUser(ctx context.Context, userID string, reason string) (User, error) {
+	return c.user(ctx, http.MethodPost, userPath(userID, ":reactivate"), map[string]string{"reason": reason})
+}
+
+func (c *Client) GetStatusTransitions(ctx context.Context, userID string) ([]StatusTransition, error) {
+	response, err := c.do(ctx, http.MethodGet, userPath(userID, "/status-history"), nil)
+	if err != nil {
+		return nil, err
+	}
+	var items []struct {
+		TransitionID   string     `json:"transitionId"`
+		From           string     `json:"from"`
+		To             string     `json:"to"`
+		Reason         string     `json:"reason"`
+		SuspendedUntil *time.Time `json:"suspendedUntil"`
+		CreatedAt      time.Time  `json:"createdAt"`
+	}
+	if err := response.decode(&items); err != nil {
+		return nil, err
+	}
+	transitions := make([]StatusTransition, 0, len(items))
+	for _, item := range items {
+		transition := StatusTransition{
+			TransitionID: item.TransitionID,
+			UserID:       userID,
+			From:         UserStatus(item.From),
+			To:           UserStatus(item.To),
+			Reason:       item.Reason,
+			CreatedAt:    item.CreatedAt,
+		}
+		if item.SuspendedUntil != nil {
+			transition.SuspendedUntil = *item.SuspendedUntil
+		}
+		transitions = append(transitions, transition)
+	}
+	return transitions, nil
+}